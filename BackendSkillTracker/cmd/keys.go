@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"skilltracker/internal/config"
+	jwtutil "skilltracker/internal/utils/jwt"
+)
+
+// loadKeySet builds the token keyset from the configured key files, or
+// derives a key from the auth secret when there are none.
+func loadKeySet(cfg config.Auth) (*jwtutil.KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		return jwtutil.NewKeySet(cfg.Issuer, cfg.Audience, jwtutil.DeriveKey([]byte(cfg.JWTSecret)))
+	}
+	var active *jwtutil.Key
+	var others []*jwtutil.Key
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kc.ID, err)
+		}
+		signing := kc.PrivateKeyFile != ""
+		if active == nil && signing && (cfg.ActiveKey == "" || cfg.ActiveKey == k.ID) {
+			active = k
+			continue
+		}
+		others = append(others, k)
+	}
+	if active == nil {
+		return nil, fmt.Errorf("active key %q has no private key file", cfg.ActiveKey)
+	}
+	return jwtutil.NewKeySet(cfg.Issuer, cfg.Audience, active, others...)
+}
+
+func loadKey(kc config.SigningKey) (*jwtutil.Key, error) {
+	if kc.PrivateKeyFile != "" {
+		data, err := os.ReadFile(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return jwtutil.ParsePrivateKeyPEM(kc.ID, data)
+	}
+	data, err := os.ReadFile(kc.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return jwtutil.ParsePublicKeyPEM(kc.ID, data)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"skilltracker/internal/config"
+	"skilltracker/internal/service"
+	"skilltracker/internal/utils/mail"
+
+	"github.com/rs/zerolog"
+)
+
+// newMailer returns the configured SMTP sender. In dev mode without one,
+// messages are written to the log; otherwise it is nil and nothing is sent.
+func newMailer(cfg *config.Config, logger zerolog.Logger) mail.Sender {
+	if cfg.Mail.Host != "" {
+		return mail.NewSMTP(mail.SMTPConfig{
+			Host:     cfg.Mail.Host,
+			Port:     cfg.Mail.Port,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+			From:     cfg.Mail.From,
+		})
+	}
+	if cfg.DevMode() {
+		return logMailer{logger: logger}
+	}
+	return nil
+}
+
+// logMailer stands in for a mail server during development.
+type logMailer struct {
+	logger zerolog.Logger
+}
+
+func (m logMailer) Send(_ context.Context, msg mail.Message) error {
+	m.logger.Info().Str("to", msg.To).Str("subject", msg.Subject).Str("body", msg.Body).Msg("mail not sent: no mail server in dev mode")
+	return nil
+}
+
+// passwordOptions builds the password policy and reset settings. Resets
+// need both a mailer and the URL of the reset page.
+func passwordOptions(cfg config.Auth, mailer mail.Sender) (service.PasswordPolicy, service.PasswordReset, error) {
+	p := cfg.PasswordPolicy
+	policy := service.PasswordPolicy{
+		MinLength:     p.MinLength,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+		History:       p.History,
+	}
+	if p.BlocklistFile != "" {
+		list, err := service.LoadPasswordBlocklist(p.BlocklistFile)
+		if err != nil {
+			return policy, service.PasswordReset{}, err
+		}
+		policy.Blocklist = list
+	}
+	reset := service.PasswordReset{URL: cfg.PasswordReset.URL, TTL: cfg.PasswordReset.TTL}
+	if reset.URL != "" {
+		reset.Mailer = mailer
+	}
+	return policy, reset, nil
+}
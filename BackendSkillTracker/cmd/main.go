@@ -6,7 +6,9 @@ import (
 	"os"
 	"skilltracker/internal/config"
 	"skilltracker/internal/handler"
+	"skilltracker/internal/repository"
 	"skilltracker/internal/service"
+	"skilltracker/internal/storage/memory"
 	"skilltracker/internal/storage/postgres"
 	"skilltracker/internal/transport"
 	"time"
@@ -33,6 +35,14 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
 
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid config")
+	}
+	keys, err := loadKeySet(cfg.Auth)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load token keys")
+	}
+
 	store, err := postgres.New(cfg.Database.DSN)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to init storage")
@@ -50,18 +60,61 @@ func main() {
 
 	logger := log.Logger.With().Str("app", "skilltracker").Logger()
 
-	srv := service.New(store, logger, []byte(cfg.Auth.JWTSecret))
+	var repo repository.Repository = store
+	if cfg.Auth.Lockout.Store == "memory" {
+		repo = memory.WithLoginThrottle(repo)
+	}
+	passwordPolicy, passwordReset, err := passwordOptions(cfg.Auth, newMailer(cfg, logger))
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load password blocklist")
+	}
+	lockout := cfg.Auth.Lockout
+	srv := service.New(repo, logger, keys, service.Options{
+		Lockout: service.LockoutPolicy{
+			MaxFailures:   lockout.MaxFailures,
+			IPMaxFailures: lockout.IPMaxFailures,
+			Duration:      lockout.Duration,
+			BaseDelay:     lockout.BaseDelay,
+			MaxDelay:      lockout.MaxDelay,
+		},
+		TwoFactor: service.TwoFactorPolicy{
+			Issuer:        cfg.Auth.TwoFactor.Issuer,
+			RequiredRoles: cfg.Auth.TwoFactor.RequiredRoles,
+		},
+		Providers:     authProviders(cfg.Auth, repo),
+		OIDC:          oidcProviders(cfg.Auth.OIDC),
+		Password:      passwordPolicy,
+		PasswordReset: passwordReset,
+		APITokens: service.APITokenPolicy{
+			DefaultDays: cfg.Auth.APITokens.DefaultDays,
+			MaxDays:     cfg.Auth.APITokens.MaxDays,
+		},
+		Impersonation: service.ImpersonationPolicy{TTL: cfg.Auth.Impersonation.TTL},
+		Audit:         service.AuditPolicy{Retention: time.Duration(cfg.Audit.RetentionDays) * 24 * time.Hour},
+	})
 
 	adminPassword := os.Getenv("ADMIN_PASSWORD")
 	if adminPassword == "" {
 		adminPassword = "admin123"
 	}
-	if err := srv.SeedAdmin(context.Background(), adminPassword); err != nil {
-		logger.Error().Err(err).Msg("failed to seed admin account")
+	for _, org := range cfg.Organizations {
+		password := org.AdminPassword
+		if password == "" {
+			password = adminPassword
+		}
+		if err := srv.SeedOrganization(context.Background(), org.Slug, org.Name, password); err != nil {
+			logger.Error().Err(err).Str("organization", org.Slug).Msg("failed to seed organization")
+		}
 	}
 
+	schedCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go srv.Recurring().RunScheduler(schedCtx, cfg.Scheduler.Interval)
+	go srv.SLA().RunSLAMonitor(schedCtx, cfg.Scheduler.Interval)
+	go srv.Audit().RunAuditRetention(schedCtx, time.Hour)
+
 	h := handler.NewHandler(srv)
-	httpSrv := transport.NewServer([]byte(cfg.Auth.JWTSecret), h, cfg)
+	httpSrv := transport.NewServer(keys, h, cfg)
 	logger.Info().Msg("Server Running")
 	if err := transport.Run(httpSrv); err != nil {
 		logger.Error().Err(err).Msg("server shutdown error")
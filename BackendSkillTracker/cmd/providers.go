@@ -0,0 +1,83 @@
+package main
+
+import (
+	"skilltracker/internal/config"
+	"skilltracker/internal/models"
+	"skilltracker/internal/repository"
+	"skilltracker/internal/service"
+	"skilltracker/internal/utils/oidc"
+)
+
+// authProviders builds the login providers in their configured order.
+func authProviders(cfg config.Auth, repo repository.Repository) []service.AuthProvider {
+	var providers []service.AuthProvider
+	for _, name := range cfg.Providers {
+		switch name {
+		case "local":
+			providers = append(providers, service.NewPasswordProvider(repo))
+		case "ldap":
+			providers = append(providers, service.NewLDAPProvider(ldapConfig(cfg.LDAP)))
+		}
+	}
+	return providers
+}
+
+func ldapConfig(l config.LDAP) service.LDAPConfig {
+	roles := make([]service.LDAPGroupRole, 0, len(l.GroupRoles))
+	for _, g := range l.GroupRoles {
+		roles = append(roles, service.LDAPGroupRole{Group: g.Group, Role: models.Role(g.Role)})
+	}
+	return service.LDAPConfig{
+		URL:                l.URL,
+		StartTLS:           l.StartTLS,
+		InsecureSkipVerify: l.InsecureSkipVerify,
+		Timeout:            l.Timeout,
+		BindDN:             l.BindDN,
+		BindPassword:       l.BindPassword,
+		BaseDN:             l.BaseDN,
+		UserFilter:         l.UserFilter,
+		UsernameAttribute:  l.UsernameAttribute,
+		NameAttribute:      l.NameAttribute,
+		GroupAttribute:     l.GroupAttribute,
+		GroupFilter:        l.GroupFilter,
+		GroupBaseDN:        l.GroupBaseDN,
+		GroupRoles:         roles,
+		DefaultRole:        models.Role(l.DefaultRole),
+		Organization:       l.Organization,
+	}
+}
+
+// oidcProviders builds the OpenID Connect providers. Their users belong to
+// the "default" organization unless one is configured.
+func oidcProviders(cfgs []config.OIDCProvider) []service.OIDCProvider {
+	providers := make([]service.OIDCProvider, 0, len(cfgs))
+	for _, p := range cfgs {
+		mapping := make([]service.OIDCRoleMapping, 0, len(p.RoleMapping))
+		for _, m := range p.RoleMapping {
+			mapping = append(mapping, service.OIDCRoleMapping{Value: m.Value, Role: models.Role(m.Role)})
+		}
+		org := p.Organization
+		if org == "" {
+			org = "default"
+		}
+		providers = append(providers, service.OIDCProvider{
+			Name:         p.Name,
+			Organization: org,
+			Client: oidc.New(oidc.Config{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				RedirectURL:  p.RedirectURL,
+				Scopes:       p.Scopes,
+			}),
+			UsernameClaim: p.UsernameClaim,
+			EmailClaim:    p.EmailClaim,
+			NameClaim:     p.NameClaim,
+			RolesClaim:    p.RolesClaim,
+			RoleMapping:   mapping,
+			DefaultRole:   models.Role(p.DefaultRole),
+			LinkBy:        p.LinkBy,
+		})
+	}
+	return providers
+}
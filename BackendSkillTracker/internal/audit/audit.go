@@ -0,0 +1,49 @@
+// Package audit carries who makes a request, and from where, through the
+// context, so the services can record it in the audit log without every
+// call passing it along.
+package audit
+
+import "context"
+
+type ctxKey int
+
+const (
+	requestKey ctxKey = iota
+	actorKey
+)
+
+// Request is where a request comes from.
+type Request struct {
+	ID        string
+	IP        string
+	UserAgent string
+}
+
+// Actor is the authenticated caller of a request.
+type Actor struct {
+	UserID   int
+	Username string
+	// ImpersonatorID is the manager acting as the user, or 0.
+	ImpersonatorID int
+}
+
+func WithRequest(ctx context.Context, r Request) context.Context {
+	return context.WithValue(ctx, requestKey, r)
+}
+
+// RequestFrom returns the request of the context, empty outside of one.
+func RequestFrom(ctx context.Context) Request {
+	r, _ := ctx.Value(requestKey).(Request)
+	return r
+}
+
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey, a)
+}
+
+// ActorFrom returns the caller of the context; ok is false for anonymous
+// requests and background jobs.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	a, ok := ctx.Value(actorKey).(Actor)
+	return a, ok && a.UserID > 0
+}
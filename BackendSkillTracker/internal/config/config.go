@@ -1,29 +1,205 @@
 package config
 
 import (
+    "errors"
+    "fmt"
+    "net"
+    "strings"
     "time"
     "github.com/spf13/viper"
 )
 
+// DefaultJWTSecret is the built-in auth secret. It is refused outside dev
+// mode, as anyone knowing it could sign tokens.
+const DefaultJWTSecret = "devsecret"
+
 type HTTP struct {
     Port        string        `mapstructure:"port"`
     ReadTimeout time.Duration `mapstructure:"read_timeout"`
     WriteTimeout time.Duration `mapstructure:"write_timeout"`
     IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+    // TrustedProxies are the CIDR ranges of the reverse proxies whose
+    // X-Forwarded-For is believed. Without them the client IP is the
+    // address of the connection and the header is ignored.
+    TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type Database struct {
     DSN string `mapstructure:"dsn"`
 }
 
+// SigningKey is a PEM key file of the token keyset. A key with only a
+// public key file verifies tokens but doesn't sign them.
+type SigningKey struct {
+    ID             string `mapstructure:"kid"`
+    PrivateKeyFile string `mapstructure:"private_key_file"`
+    PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
 type Auth struct {
+    // JWTSecret derives the Ed25519 signing key when no Keys are configured.
     JWTSecret string `mapstructure:"jwt_secret"`
+    Issuer    string `mapstructure:"issuer"`
+    Audience  string `mapstructure:"audience"`
+    // ActiveKey is the kid of the key that signs new tokens; the first key
+    // with a private key file by default. The other keys only verify, so a
+    // key can be rotated while tokens signed by the previous one are valid.
+    ActiveKey string       `mapstructure:"active_key"`
+    Keys      []SigningKey `mapstructure:"keys"`
+    Lockout   Lockout      `mapstructure:"lockout"`
+    TwoFactor TwoFactor    `mapstructure:"two_factor"`
+    // Providers check passwords at login in this order: "local" for users
+    // created in the app, "ldap" for the directory.
+    Providers []string `mapstructure:"providers"`
+    LDAP      LDAP     `mapstructure:"ldap"`
+    // OIDC are OpenID Connect providers users log in with through
+    // /oidc/{name}/authorize instead of a password.
+    OIDC []OIDCProvider `mapstructure:"oidc"`
+    PasswordPolicy PasswordPolicy `mapstructure:"password_policy"`
+    PasswordReset  PasswordReset  `mapstructure:"password_reset"`
+    APITokens      APITokens      `mapstructure:"api_tokens"`
+    Impersonation  Impersonation  `mapstructure:"impersonation"`
+}
+
+// Impersonation limits the access tokens managers get to act as another
+// user.
+type Impersonation struct {
+    TTL time.Duration `mapstructure:"ttl"`
+}
+
+// APITokens limits the lifetime of personal access tokens, in days.
+// DefaultDays applies when a token is created without one.
+type APITokens struct {
+    DefaultDays int `mapstructure:"default_days"`
+    MaxDays     int `mapstructure:"max_days"`
+}
+
+// PasswordPolicy applies to passwords set in the app. BlocklistFile lists
+// refused passwords, one per line; History is how many recent passwords
+// can't be reused.
+type PasswordPolicy struct {
+    MinLength     int    `mapstructure:"min_length"`
+    RequireUpper  bool   `mapstructure:"require_upper"`
+    RequireLower  bool   `mapstructure:"require_lower"`
+    RequireDigit  bool   `mapstructure:"require_digit"`
+    RequireSymbol bool   `mapstructure:"require_symbol"`
+    BlocklistFile string `mapstructure:"blocklist_file"`
+    History       int    `mapstructure:"history"`
+}
+
+// PasswordReset sends reset links by mail. URL is the frontend page that
+// takes the token; without it, or without mail, there are no resets.
+type PasswordReset struct {
+    URL string        `mapstructure:"url"`
+    TTL time.Duration `mapstructure:"ttl"`
+}
+
+// OIDCProvider is an OpenID Connect provider. Users are linked to existing
+// accounts by the LinkBy claims at their first login, or created.
+type OIDCProvider struct {
+    Name          string        `mapstructure:"name"`
+    Organization  string        `mapstructure:"organization"`
+    Issuer        string        `mapstructure:"issuer"`
+    ClientID      string        `mapstructure:"client_id"`
+    ClientSecret  string        `mapstructure:"client_secret"`
+    RedirectURL   string        `mapstructure:"redirect_url"`
+    Scopes        []string      `mapstructure:"scopes"`
+    UsernameClaim string        `mapstructure:"username_claim"`
+    EmailClaim    string        `mapstructure:"email_claim"`
+    NameClaim     string        `mapstructure:"name_claim"`
+    RolesClaim    string        `mapstructure:"roles_claim"`
+    RoleMapping   []RoleMapping `mapstructure:"role_mapping"`
+    DefaultRole   string        `mapstructure:"default_role"`
+    LinkBy        []string      `mapstructure:"link_by"`
+}
+
+type RoleMapping struct {
+    Value string `mapstructure:"value"`
+    Role  string `mapstructure:"role"`
+}
+
+// LDAP is a directory users log in with. They are created at their first
+// login, with the role of the first group in GroupRoles they are in.
+type LDAP struct {
+    URL                string        `mapstructure:"url"`
+    StartTLS           bool          `mapstructure:"start_tls"`
+    InsecureSkipVerify bool          `mapstructure:"insecure_skip_verify"`
+    Timeout            time.Duration `mapstructure:"timeout"`
+    BindDN             string        `mapstructure:"bind_dn"`
+    BindPassword       string        `mapstructure:"bind_password"`
+    BaseDN             string        `mapstructure:"base_dn"`
+    UserFilter         string        `mapstructure:"user_filter"`
+    UsernameAttribute  string        `mapstructure:"username_attribute"`
+    NameAttribute      string        `mapstructure:"name_attribute"`
+    GroupAttribute     string        `mapstructure:"group_attribute"`
+    GroupFilter        string        `mapstructure:"group_filter"`
+    GroupBaseDN        string        `mapstructure:"group_base_dn"`
+    GroupRoles         []GroupRole   `mapstructure:"group_roles"`
+    DefaultRole        string        `mapstructure:"default_role"`
+    Organization       string        `mapstructure:"organization"`
+}
+
+type GroupRole struct {
+    Group string `mapstructure:"group"`
+    Role  string `mapstructure:"role"`
+}
+
+// TwoFactor enforces TOTP for the listed roles; their users set it up at
+// their next login.
+type TwoFactor struct {
+    Issuer        string   `mapstructure:"issuer"`
+    RequiredRoles []string `mapstructure:"required_roles"`
+}
+
+// Lockout throttles failed logins. Store is "postgres", shared by all
+// replicas, or "memory" for a single node.
+type Lockout struct {
+    Store         string        `mapstructure:"store"`
+    MaxFailures   int           `mapstructure:"max_failures"`
+    IPMaxFailures int           `mapstructure:"ip_max_failures"`
+    Duration      time.Duration `mapstructure:"duration"`
+    BaseDelay     time.Duration `mapstructure:"base_delay"`
+    MaxDelay      time.Duration `mapstructure:"max_delay"`
+}
+
+type Scheduler struct {
+    Interval time.Duration `mapstructure:"interval"`
+}
+
+// Audit is the retention of the audit log. Entries older than
+// RetentionDays are deleted; 0 keeps them forever.
+type Audit struct {
+    RetentionDays int `mapstructure:"retention_days"`
+}
+
+// Organization is a tenant that is created at startup together with its
+// admin account. AdminPassword falls back to the ADMIN_PASSWORD variable.
+type Organization struct {
+    Slug          string `mapstructure:"slug"`
+    Name          string `mapstructure:"name"`
+    AdminPassword string `mapstructure:"admin_password"`
+}
+
+// Mail is the SMTP server outgoing mail is submitted to. Without a host no
+// mail is sent; in dev mode it is logged instead.
+type Mail struct {
+    Host     string `mapstructure:"host"`
+    Port     int    `mapstructure:"port"`
+    Username string `mapstructure:"username"`
+    Password string `mapstructure:"password"`
+    From     string `mapstructure:"from"`
 }
 
 type Config struct {
+    // Env is "dev" for local development; anything else is production.
+    Env        string  `mapstructure:"env"`
     HTTPServer HTTP    `mapstructure:"http"`
     Database   Database `mapstructure:"database"`
     Auth       Auth     `mapstructure:"auth"`
+    Scheduler  Scheduler `mapstructure:"scheduler"`
+    Mail       Mail      `mapstructure:"mail"`
+    Audit      Audit     `mapstructure:"audit"`
+    Organizations []Organization `mapstructure:"organizations"`
 }
 
 func Load() (*Config, error) {
@@ -35,7 +211,30 @@ func Load() (*Config, error) {
     v.SetDefault("http.read_timeout", "10s")
     v.SetDefault("http.write_timeout", "10s")
     v.SetDefault("http.idle_timeout", "60s")
-    v.SetDefault("auth.jwt_secret", "devsecret")
+    v.SetDefault("env", "production")
+    v.SetDefault("auth.jwt_secret", DefaultJWTSecret)
+    v.SetDefault("auth.issuer", "skilltracker")
+    v.SetDefault("auth.audience", "skilltracker-api")
+    v.SetDefault("auth.lockout.store", "postgres")
+    v.SetDefault("auth.lockout.max_failures", 5)
+    v.SetDefault("auth.lockout.ip_max_failures", 50)
+    v.SetDefault("auth.lockout.duration", "15m")
+    v.SetDefault("auth.lockout.base_delay", "1s")
+    v.SetDefault("auth.lockout.max_delay", "1m")
+    v.SetDefault("auth.two_factor.issuer", "SkillTracker")
+    v.SetDefault("auth.two_factor.required_roles", []string{"manager"})
+    v.SetDefault("auth.providers", []string{"local"})
+    v.SetDefault("auth.ldap.user_filter", "(uid=%s)")
+    v.SetDefault("auth.ldap.timeout", "5s")
+    v.SetDefault("auth.password_policy.min_length", 8)
+    v.SetDefault("auth.password_policy.history", 5)
+    v.SetDefault("auth.password_reset.ttl", "1h")
+    v.SetDefault("auth.api_tokens.default_days", 90)
+    v.SetDefault("auth.api_tokens.max_days", 365)
+    v.SetDefault("auth.impersonation.ttl", "15m")
+    v.SetDefault("mail.port", 587)
+    v.SetDefault("scheduler.interval", "1m")
+    v.SetDefault("audit.retention_days", 365)
 
     if err := v.ReadInConfig(); err != nil {
         // allow missing file; env-only configs
@@ -46,5 +245,100 @@ func Load() (*Config, error) {
     if err := v.Unmarshal(&cfg); err != nil {
         return nil, err
     }
+    if len(cfg.Organizations) == 0 {
+        cfg.Organizations = []Organization{{Slug: "default", Name: "Default"}}
+    }
     return &cfg, nil
 }
+
+func (c *Config) DevMode() bool { return c.Env == "dev" }
+
+// Validate rejects settings that are only acceptable in dev mode.
+func (c *Config) Validate() error {
+    if !c.DevMode() && (c.Auth.JWTSecret == "" || c.Auth.JWTSecret == DefaultJWTSecret) {
+        return errors.New("auth.jwt_secret must be changed from the default outside dev mode (env: dev)")
+    }
+    for _, p := range c.HTTPServer.TrustedProxies {
+        if _, _, err := net.ParseCIDR(p); err != nil {
+            return fmt.Errorf("http.trusted_proxies: %q is not a CIDR range", p)
+        }
+    }
+    if s := c.Auth.Lockout.Store; s != "postgres" && s != "memory" {
+        return errors.New(`auth.lockout.store must be "postgres" or "memory"`)
+    }
+    if len(c.Auth.Providers) == 0 {
+        return errors.New("auth.providers must not be empty")
+    }
+    for _, p := range c.Auth.Providers {
+        switch p {
+        case "local":
+        case "ldap":
+            if err := c.Auth.LDAP.validate(); err != nil {
+                return err
+            }
+        default:
+            return fmt.Errorf("auth.providers: unknown provider %q", p)
+        }
+    }
+    if n := c.Auth.PasswordPolicy.MinLength; n < 0 || n > 72 {
+        return errors.New("auth.password_policy.min_length must be between 0 and 72")
+    }
+    if t := c.Auth.APITokens; t.DefaultDays < 1 || t.MaxDays < t.DefaultDays {
+        return errors.New("auth.api_tokens: default_days must be at least 1 and at most max_days")
+    }
+    if ttl := c.Auth.Impersonation.TTL; ttl < time.Minute || ttl > time.Hour {
+        return errors.New("auth.impersonation.ttl must be between 1m and 1h")
+    }
+    if c.Audit.RetentionDays < 0 {
+        return errors.New("audit.retention_days must not be negative")
+    }
+    if c.Mail.Host != "" && c.Mail.From == "" {
+        return errors.New("mail.from is required with mail.host")
+    }
+    names := make(map[string]bool)
+    for _, p := range c.Auth.OIDC {
+        if err := p.validate(); err != nil {
+            return err
+        }
+        if names[p.Name] {
+            return fmt.Errorf("auth.oidc: duplicate provider %q", p.Name)
+        }
+        names[p.Name] = true
+    }
+    return nil
+}
+
+func (p OIDCProvider) validate() error {
+    if p.Name == "" || p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+        return errors.New("auth.oidc providers need a name, issuer, client_id and redirect_url")
+    }
+    for _, l := range p.LinkBy {
+        if l != "username" && l != "email" {
+            return fmt.Errorf("auth.oidc %s: link_by must list \"username\" or \"email\", not %q", p.Name, l)
+        }
+    }
+    for _, m := range p.RoleMapping {
+        if m.Value == "" || m.Role == "" {
+            return fmt.Errorf("auth.oidc %s: role_mapping needs a value and a role", p.Name)
+        }
+    }
+    return nil
+}
+
+func (l LDAP) validate() error {
+    if l.URL == "" || l.BaseDN == "" {
+        return errors.New("auth.ldap.url and auth.ldap.base_dn are required")
+    }
+    if !strings.Contains(l.UserFilter, "%s") {
+        return errors.New("auth.ldap.user_filter must contain %s for the username")
+    }
+    if l.GroupFilter != "" && !strings.Contains(l.GroupFilter, "%s") {
+        return errors.New("auth.ldap.group_filter must contain %s for the user DN")
+    }
+    for _, g := range l.GroupRoles {
+        if g.Group == "" || g.Role == "" {
+            return errors.New("auth.ldap.group_roles need a group and a role")
+        }
+    }
+    return nil
+}
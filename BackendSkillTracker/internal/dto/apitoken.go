@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+// APITokenPrefix starts every personal access token, which tells them
+// apart from JWT access tokens.
+const APITokenPrefix = "stp_"
+
+// APITokenRequest creates a personal access token. Scopes are "read" for
+// GET requests and "write" for the others.
+type APITokenRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=read write"`
+	// ExpiresInDays defaults to the configured lifetime.
+	ExpiresInDays int `json:"expires_in_days" validate:"omitempty,min=1"`
+}
+
+type APITokenResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Prefix is the start of the token, to recognize it.
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APITokenCreatedResponse carries the token itself. It is shown only
+// here; afterwards only its hash is known.
+type APITokenCreatedResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
+
+// APITokenIdentity is the caller a valid personal access token stands
+// for.
+type APITokenIdentity struct {
+	TokenID  int
+	OrgID    int
+	UserID   int
+	Username string
+	Role     string
+	Scopes   []string
+}
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditFilter selects audit log entries. Dates are YYYY-MM-DD, To is
+// inclusive.
+type AuditFilter struct {
+	// Action is a comma-separated list of actions.
+	Action string `query:"action"`
+	// ActorID matches entries the user made, also while impersonating.
+	ActorID    int    `query:"actor_id"`
+	TargetType string `query:"target_type"`
+	TargetID   int    `query:"target_id"`
+	RequestID  string `query:"request_id"`
+	From       string `query:"from"`
+	To         string `query:"to"`
+	Limit      int    `query:"limit"`
+	Offset     int    `query:"offset"`
+}
+
+type AuditLogResponse struct {
+	ID             int             `json:"id"`
+	Action         string          `json:"action"`
+	ActorID        *int            `json:"actor_id,omitempty"`
+	ActorName      string          `json:"actor_name,omitempty"`
+	ImpersonatorID *int            `json:"impersonator_id,omitempty"`
+	TargetType     string          `json:"target_type,omitempty"`
+	TargetID       *int            `json:"target_id,omitempty"`
+	IP             string          `json:"ip,omitempty"`
+	UserAgent      string          `json:"user_agent,omitempty"`
+	RequestID      string          `json:"request_id,omitempty"`
+	Before         json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After          json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type AuditLogPage struct {
+	Items []*AuditLogResponse `json:"items"`
+	Total int64               `json:"total"`
+}
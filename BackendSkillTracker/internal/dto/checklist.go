@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type ChecklistItemRequest struct {
+	Text string `json:"text" validate:"required,max=500"`
+}
+
+type ChecklistReorderRequest struct {
+	ItemIDs []int `json:"item_ids" validate:"required,min=1"`
+}
+
+type ChecklistItemResponse struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	Position  int        `json:"position"`
+	Text      string     `json:"text"`
+	Done      bool       `json:"done"`
+	DoneBy    *int       `json:"done_by,omitempty"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// ImpersonationRequest starts acting as another user. The reason goes to
+// the security log.
+type ImpersonationRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonationResponse carries an access token acting as User. There is
+// no refresh token; a new impersonation is needed once it expires.
+type ImpersonationResponse struct {
+	AccessToken string       `json:"access_token"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	User        UserResponse `json:"user"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+type LabelRequest struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color" validate:"omitempty,hexcolor,len=7"`
+}
+
+type LabelResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LabelUsageResponse struct {
+	LabelID    int    `json:"label_id"`
+	Name       string `json:"name"`
+	Color      string `json:"color"`
+	Total      int    `json:"total"`
+	Pending    int    `json:"pending"`
+	InProgress int    `json:"in_progress"`
+	Completed  int    `json:"completed"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type NotificationResponse struct {
+	ID        int        `json:"id"`
+	TaskID    *int       `json:"task_id,omitempty"`
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+// OIDCAuthorizeResponse is where to send the user to log in at the
+// identity provider.
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OIDCCallbackRequest carries the parameters the identity provider
+// redirected back with.
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type OrganizationResponse struct {
+	ID        int       `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
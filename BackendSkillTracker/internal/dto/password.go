@@ -0,0 +1,33 @@
+package dto
+
+// PasswordPolicyResponse describes what new passwords have to look like.
+type PasswordPolicyResponse struct {
+	MinLength     int  `json:"min_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	// History is how many recent passwords, the current one included,
+	// can't be reused.
+	History int `json:"history"`
+}
+
+// ForgotPasswordRequest asks for a reset link. Login is a username or an
+// email address.
+type ForgotPasswordRequest struct {
+	Login        string `json:"login" validate:"required"`
+	Organization string `json:"organization"`
+}
+
+// ResetPasswordRequest sets a new password with the token of a reset link.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// PasswordChangeLoginRequest replaces a password chosen by someone else
+// during login, with the challenge token from /login.
+type PasswordChangeLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Password       string `json:"password" validate:"required"`
+}
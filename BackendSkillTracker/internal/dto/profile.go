@@ -0,0 +1,45 @@
+package dto
+
+// ProfileResponse is the own profile of the current user.
+type ProfileResponse struct {
+	UserResponse
+	Timezone string    `json:"timezone,omitempty"`
+	Locale   string    `json:"locale,omitempty"`
+	Contacts []Contact `json:"contacts"`
+}
+
+type Contact struct {
+	Type  string `json:"type" validate:"required,oneof=phone telegram slack teams skype other"`
+	Value string `json:"value" validate:"required,max=100"`
+}
+
+// UpdateProfileRequest replaces the fields users edit themselves. Username,
+// email, role, team, position and department stay with managers: the email
+// isn't verified, yet password resets and identity providers trust it.
+type UpdateProfileRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// Timezone is an IANA name such as Europe/Moscow.
+	Timezone string `json:"timezone" validate:"omitempty,timezone,max=64"`
+	// Locale is a BCP 47 tag such as ru or en-US.
+	Locale   string    `json:"locale" validate:"omitempty,bcp47_language_tag,max=35"`
+	Contacts []Contact `json:"contacts" validate:"max=10,dive"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type AvatarResponse struct {
+	AvatarURL string `json:"avatar_url"`
+}
+
+// NotificationPreference switches one notification type on or off.
+type NotificationPreference struct {
+	Type    string `json:"type" validate:"required,oneof=assigned status_changed reassigned"`
+	Enabled bool   `json:"enabled"`
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"required,min=1,dive"`
+}
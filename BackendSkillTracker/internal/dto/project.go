@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// ProjectRequest creates or updates a project. Dates are YYYY-MM-DD;
+// MemberIDs replaces the member list.
+type ProjectRequest struct {
+	Name        string `json:"name" validate:"required,min=3,max=200"`
+	Description string `json:"description"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Status      string `json:"status" validate:"omitempty,oneof=active on_hold completed archived"`
+	MemberIDs   []int  `json:"member_ids"`
+}
+
+type ProjectResponse struct {
+	ID             int                   `json:"id"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	OwnerID        int                   `json:"owner_id"`
+	StartDate      *time.Time            `json:"start_date,omitempty"`
+	EndDate        *time.Time            `json:"end_date,omitempty"`
+	Status         string                `json:"status"`
+	Members        []UserSummaryResponse `json:"members"`
+	TaskCount      int                   `json:"task_count"`
+	CompletedTasks int                   `json:"completed_tasks"`
+	Progress       int                   `json:"progress"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+import "time"
+
+type RecurringTaskRequest struct {
+	EmployeeID    int    `json:"employee_id" validate:"required"`
+	Title         string `json:"title" validate:"required,min=3"`
+	Description   string `json:"description"`
+	DurationHours int    `json:"duration_hours" validate:"min=0"`
+	Frequency     string `json:"frequency" validate:"required,oneof=daily weekly monthly"`
+	Interval      int    `json:"interval" validate:"min=0"`
+	Trigger       string `json:"trigger" validate:"omitempty,oneof=schedule completion"`
+	StartsAt      string `json:"starts_at" validate:"required"`
+	Until         string `json:"until"`
+	Count         int    `json:"count" validate:"min=0"`
+	SkillIDs      []int  `json:"skill_ids"`
+}
+
+type RecurringTaskResponse struct {
+	ID                 int             `json:"id"`
+	CreatorID          int             `json:"creator_id"`
+	EmployeeID         int             `json:"employee_id"`
+	Title              string          `json:"title"`
+	Description        string          `json:"description"`
+	DurationHours      int             `json:"duration_hours"`
+	Frequency          string          `json:"frequency"`
+	Interval           int             `json:"interval"`
+	Trigger            string          `json:"trigger"`
+	StartsAt           time.Time       `json:"starts_at"`
+	Until              *time.Time      `json:"until,omitempty"`
+	Count              int             `json:"count"`
+	OccurrencesCreated int             `json:"occurrences_created"`
+	NextRunAt          *time.Time      `json:"next_run_at,omitempty"`
+	RequiredSkills     []SkillResponse `json:"required_skills"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// OccurrenceRequest overrides fields of a single upcoming occurrence.
+type OccurrenceRequest struct {
+	EmployeeID  int    `json:"employee_id"`
+	Title       string `json:"title" validate:"omitempty,min=3"`
+	Description string `json:"description"`
+	Deadline    string `json:"deadline"`
+}
+
+type OccurrenceResponse struct {
+	Occurrence  int       `json:"occurrence"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	Deadline    time.Time `json:"deadline"`
+	EmployeeID  int       `json:"employee_id"`
+	Title       string    `json:"title"`
+	Skipped     bool      `json:"skipped"`
+	Overridden  bool      `json:"overridden"`
+}
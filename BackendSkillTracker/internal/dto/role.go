@@ -0,0 +1,16 @@
+package dto
+
+type RoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=20"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+type RoleResponse struct {
+	ID          int      `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+	// Builtin roles are defined in code and can't be changed.
+	Builtin bool `json:"builtin"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// ClientInfo describes where a login or refresh comes from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type SessionResponse struct {
+	ID         int       `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session of the calling access token.
+	Current bool `json:"current"`
+}
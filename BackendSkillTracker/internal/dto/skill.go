@@ -11,6 +11,7 @@ type SkillResponse struct {
 	ID          int       `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	Level       int       `json:"level,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
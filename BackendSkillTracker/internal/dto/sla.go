@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type SLAPolicyRequest struct {
+	ResponseMinutes   int `json:"response_minutes" validate:"required,min=1"`
+	ResolutionMinutes int `json:"resolution_minutes" validate:"required,min=1"`
+}
+
+type SLAPolicyResponse struct {
+	Priority          string    `json:"priority"`
+	ResponseMinutes   int       `json:"response_minutes"`
+	ResolutionMinutes int       `json:"resolution_minutes"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// SLAStatusResponse is the SLA state of a task. Status is "on_track",
+// "breached" or, for completed tasks without breaches, "met".
+type SLAStatusResponse struct {
+	Status               string     `json:"status"`
+	ResponseDueAt        *time.Time `json:"response_due_at,omitempty"`
+	ResolutionDueAt      *time.Time `json:"resolution_due_at,omitempty"`
+	RespondedAt          *time.Time `json:"responded_at,omitempty"`
+	CompletedAt          *time.Time `json:"completed_at,omitempty"`
+	ResponseBreachedAt   *time.Time `json:"response_breached_at,omitempty"`
+	ResolutionBreachedAt *time.Time `json:"resolution_breached_at,omitempty"`
+}
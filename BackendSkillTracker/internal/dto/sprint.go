@@ -0,0 +1,51 @@
+package dto
+
+import "time"
+
+// SprintRequest creates or updates a sprint. Dates are YYYY-MM-DD, both inclusive.
+type SprintRequest struct {
+	Name      string `json:"name" validate:"required,min=3,max=200"`
+	Goal      string `json:"goal" validate:"max=1000"`
+	ProjectID *int   `json:"project_id"`
+	StartDate string `json:"start_date" validate:"required"`
+	EndDate   string `json:"end_date" validate:"required"`
+}
+
+type SprintResponse struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Goal      string     `json:"goal"`
+	ProjectID *int       `json:"project_id,omitempty"`
+	StartDate string     `json:"start_date"`
+	EndDate   string     `json:"end_date"`
+	Status    string     `json:"status"`
+	CreatorID int        `json:"creator_id"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CloseSprintRequest closes a sprint. Unfinished tasks move to NextSprintID,
+// or to the next sprint of the same project when it is not set.
+type CloseSprintRequest struct {
+	NextSprintID int `json:"next_sprint_id"`
+}
+
+type CloseSprintResponse struct {
+	CarriedOver  int  `json:"carried_over"`
+	NextSprintID *int `json:"next_sprint_id,omitempty"`
+}
+
+// BurndownPoint is the state of the sprint at the end of a day. Ideal is the
+// linear burndown from the total to zero over the sprint.
+type BurndownPoint struct {
+	Date      string  `json:"date"`
+	Total     int     `json:"total"`
+	Completed int     `json:"completed"`
+	Remaining int     `json:"remaining"`
+	Ideal     float64 `json:"ideal"`
+}
+
+type BurndownResponse struct {
+	SprintID int             `json:"sprint_id"`
+	Points   []BurndownPoint `json:"points"`
+}
@@ -2,27 +2,51 @@ package dto
 
 import "time"
 
+// TaskRequest creates or updates a task. EmployeeID is only used on creation;
+// use the reassign operation to change the lead later.
 type TaskRequest struct {
-	EmployeeID  int    `json:"employee_id" validate:"required"`
-	Title       string `json:"title" validate:"required,min=3"`
-	Description string `json:"description"`
-	Deadline    string `json:"deadline" validate:"required"`
-	Progress    int    `json:"progress" validate:"min=0,max=100"`
-	Status      string `json:"status" validate:"omitempty,oneof=pending in_progress completed"`
+	EmployeeID      int    `json:"employee_id" validate:"required"`
+	Title           string `json:"title" validate:"required,min=3"`
+	Description     string `json:"description"`
+	Deadline        string `json:"deadline" validate:"required"`
+	Progress        int    `json:"progress" validate:"min=0,max=100"`
+	EstimateMinutes int    `json:"estimate_minutes" validate:"min=0"`
+	Status          string `json:"status" validate:"omitempty,oneof=pending in_progress completed"`
+	Priority        string `json:"priority" validate:"omitempty,oneof=low medium high critical"`
+	// ProjectID moves the task to a project; 0 removes it from its project.
+	ProjectID *int `json:"project_id"`
+	// SprintID places the task into a sprint; 0 removes it from its sprint.
+	SprintID *int `json:"sprint_id"`
+	// AssigneeIDs and WatcherIDs are only used on creation. EmployeeID is
+	// the responsible lead; AssigneeIDs lists the co-assignees besides them.
+	AssigneeIDs []int `json:"assignee_ids"`
+	WatcherIDs  []int `json:"watcher_ids"`
 }
 
 type TaskResponse struct {
-	ID             int             `json:"id"`
-	EmployeeID     int             `json:"employee_id"`
-	CreatorID      int             `json:"creator_id"`
-	Title          string          `json:"title"`
-	Description    string          `json:"description"`
-	Deadline       time.Time       `json:"deadline"`
-	Status         string          `json:"status"`
-	Progress       int             `json:"progress"`
-	RequiredSkills []SkillResponse `json:"required_skills"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	ID              int                     `json:"id"`
+	EmployeeID      int                     `json:"employee_id"`
+	CreatorID       int                     `json:"creator_id"`
+	Title           string                  `json:"title"`
+	Description     string                  `json:"description"`
+	Deadline        time.Time               `json:"deadline"`
+	Status          string                  `json:"status"`
+	Progress        int                     `json:"progress"`
+	Priority        string                  `json:"priority"`
+	SLA             *SLAStatusResponse      `json:"sla,omitempty"`
+	EstimateMinutes int                     `json:"estimate_minutes"`
+	RequiredSkills  []SkillResponse         `json:"required_skills"`
+	RecurringTaskID *int                    `json:"recurring_task_id,omitempty"`
+	Occurrence      int                     `json:"occurrence,omitempty"`
+	TemplateID      *int                    `json:"template_id,omitempty"`
+	ProjectID       *int                    `json:"project_id,omitempty"`
+	SprintID        *int                    `json:"sprint_id,omitempty"`
+	Checklist       []ChecklistItemResponse `json:"checklist,omitempty"`
+	Labels          []LabelResponse         `json:"labels"`
+	Assignees       []UserSummaryResponse   `json:"assignees"`
+	Watchers        []UserSummaryResponse   `json:"watchers"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
 }
 
 type AttachmentResponse struct {
@@ -34,12 +58,16 @@ type AttachmentResponse struct {
 }
 
 type TaskHistoryResponse struct {
-	ID        int       `json:"id"`
-	TaskID    int       `json:"task_id"`
-	OldStatus string    `json:"old_status"`
-	NewStatus string    `json:"new_status"`
-	ChangedBy int       `json:"changed_by"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int       `json:"id"`
+	TaskID     int       `json:"task_id"`
+	Event      string    `json:"event"`
+	OldStatus  string    `json:"old_status"`
+	NewStatus  string    `json:"new_status"`
+	Note       string    `json:"note,omitempty"`
+	FromUserID *int      `json:"from_user_id,omitempty"`
+	ToUserID   *int      `json:"to_user_id,omitempty"`
+	ChangedBy  int       `json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type TaskFilter struct {
@@ -49,4 +77,39 @@ type TaskFilter struct {
 	Search     string `query:"search"`
 	FromDate   string `query:"from_date"`
 	ToDate     string `query:"to_date"`
+	ProjectID  int    `query:"project_id"`
+	SprintID   int    `query:"sprint_id"`
+	// Priority is a comma-separated list of priorities.
+	Priority string `query:"priority"`
+	// Tags matches tasks having any of the comma-separated label names,
+	// TagsAll those having all of them.
+	Tags    string `query:"tags"`
+	TagsAll string `query:"tags_all"`
+	// Sort is one of "priority" (most urgent first), "deadline" or
+	// "created_at" (default, newest first).
+	Sort string `query:"sort"`
+	// ParticipantID limits the result to tasks the user created, works on or
+	// watches. Set by the service for users without task.read.any.
+	ParticipantID int
+	// TeamIDs limits the result to tasks led by members of these teams or
+	// created by TeamViewerID. Set by the service for team managers; an
+	// empty, non-nil list leaves only the tasks of TeamViewerID.
+	TeamIDs      []int
+	TeamViewerID int
+	// AllTeams asks to skip the team scope of a manager.
+	AllTeams bool `query:"all_teams"`
+}
+
+// TaskAssigneesRequest replaces the co-assignees of a task. The lead is
+// dropped from AssigneeIDs if listed there.
+type TaskAssigneesRequest struct {
+	AssigneeIDs []int `json:"assignee_ids"`
+}
+
+// ReassignTaskRequest hands the task over to another lead.
+type ReassignTaskRequest struct {
+	EmployeeID int    `json:"employee_id" validate:"required"`
+	Note       string `json:"note" validate:"max=1000"`
+	// RequireSkills rejects an assignee missing any of the required skills.
+	RequireSkills bool `json:"require_skills"`
 }
@@ -0,0 +1,20 @@
+package dto
+
+type TeamRequest struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	ParentID  *int   `json:"parent_id"`
+	ManagerID *int   `json:"manager_id"`
+}
+
+type TeamResponse struct {
+	ID        int                   `json:"id"`
+	Name      string                `json:"name"`
+	ParentID  *int                  `json:"parent_id,omitempty"`
+	ManagerID *int                  `json:"manager_id,omitempty"`
+	Members   []UserSummaryResponse `json:"members,omitempty"`
+}
+
+// UserTeamRequest moves a user to a team; null or 0 removes them from it.
+type UserTeamRequest struct {
+	TeamID *int `json:"team_id"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+type TemplateSkillRequest struct {
+	SkillID int `json:"skill_id" validate:"required"`
+	Level   int `json:"level" validate:"min=0,max=5"`
+}
+
+type TaskTemplateRequest struct {
+	Title         string                 `json:"title" validate:"required,min=3"`
+	Description   string                 `json:"description"`
+	DurationHours int                    `json:"duration_hours" validate:"min=0"`
+	Shared        bool                   `json:"shared"`
+	Skills        []TemplateSkillRequest `json:"skills" validate:"dive"`
+	Checklist     []string               `json:"checklist" validate:"dive,required,max=500"`
+}
+
+type TemplateSkillResponse struct {
+	SkillID int    `json:"skill_id"`
+	Name    string `json:"name"`
+	Level   int    `json:"level"`
+}
+
+type TaskTemplateResponse struct {
+	ID            int                     `json:"id"`
+	CreatorID     int                     `json:"creator_id"`
+	Title         string                  `json:"title"`
+	Description   string                  `json:"description"`
+	DurationHours int                     `json:"duration_hours"`
+	Shared        bool                    `json:"shared"`
+	Skills        []TemplateSkillResponse `json:"skills"`
+	Checklist     []string                `json:"checklist"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
+
+// TaskFromTemplateRequest creates a task from a template. Empty fields
+// fall back to the template values; the deadline defaults to now plus the
+// template duration.
+type TaskFromTemplateRequest struct {
+	EmployeeID  int    `json:"employee_id" validate:"required"`
+	Title       string `json:"title" validate:"omitempty,min=3"`
+	Description string `json:"description"`
+	Deadline    string `json:"deadline"`
+}
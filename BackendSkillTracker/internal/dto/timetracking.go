@@ -0,0 +1,65 @@
+package dto
+
+import "time"
+
+type TimeEntryRequest struct {
+	StartedAt       string `json:"started_at" validate:"required"`
+	DurationMinutes int    `json:"duration_minutes" validate:"required,min=1,max=1440"`
+	Note            string `json:"note" validate:"max=500"`
+}
+
+type TimeEntryResponse struct {
+	ID              int        `json:"id"`
+	TaskID          int        `json:"task_id"`
+	UserID          int        `json:"user_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Running         bool       `json:"running"`
+	Note            string     `json:"note"`
+}
+
+type TimesheetDay struct {
+	Date    string `json:"date"`
+	Minutes int    `json:"minutes"`
+}
+
+type TimesheetResponse struct {
+	ID           int                 `json:"id,omitempty"`
+	UserID       int                 `json:"user_id"`
+	WeekStart    string              `json:"week_start"`
+	Status       string              `json:"status"`
+	TotalMinutes int                 `json:"total_minutes"`
+	Days         []TimesheetDay      `json:"days"`
+	Entries      []TimeEntryResponse `json:"entries"`
+	SubmittedAt  *time.Time          `json:"submitted_at,omitempty"`
+	ReviewedBy   *int                `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time          `json:"reviewed_at,omitempty"`
+	Comment      string              `json:"comment,omitempty"`
+}
+
+type TimesheetReviewRequest struct {
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type TaskTimeReport struct {
+	TaskID          int    `json:"task_id"`
+	Title           string `json:"title"`
+	EstimateMinutes int    `json:"estimate_minutes"`
+	ActualMinutes   int    `json:"actual_minutes"`
+	VarianceMinutes int    `json:"variance_minutes"`
+}
+
+type SkillTimeReport struct {
+	SkillID         int    `json:"skill_id"`
+	Name            string `json:"name"`
+	Tasks           int    `json:"tasks"`
+	EstimateMinutes int    `json:"estimate_minutes"`
+	ActualMinutes   int    `json:"actual_minutes"`
+	VarianceMinutes int    `json:"variance_minutes"`
+}
+
+type TimeReportResponse struct {
+	Tasks  []TaskTimeReport  `json:"tasks"`
+	Skills []SkillTimeReport `json:"skills"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+type TwoFactorStatusResponse struct {
+	Enabled bool `json:"enabled"`
+	// Required is set when the user's role enforces 2FA.
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollResponse is the secret of a new authenticator. OtpauthURI
+// is meant to be shown as a QR code.
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code or a recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+// TwoFactorLoginRequest completes a login with the challenge token from
+// /login and a TOTP or recovery code.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
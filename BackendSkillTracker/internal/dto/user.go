@@ -3,12 +3,27 @@ package dto
 type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	// Organization is the slug of the user's organization; empty means
+	// the default one.
+	Organization string `json:"organization"`
 }
 
+// LoginResponse carries the tokens of a completed login. When a second
+// factor is needed the tokens are empty and ChallengeToken has to be
+// presented to /login/2fa with a code instead.
 type LoginResponse struct {
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
+	AccessToken  string       `json:"access_token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
 	User         UserResponse `json:"user"`
+	// TwoFactor is "verify" when a TOTP or recovery code is due and
+	// "enroll" when the role requires 2FA the user hasn't set up yet.
+	TwoFactor      string `json:"two_factor,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+	// PasswordChange is set when the password was chosen by someone else
+	// and has to be replaced at /login/password before tokens are issued.
+	PasswordChange bool `json:"password_change,omitempty"`
+	// RecoveryCodes are returned once when 2FA was set up during login.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type RefreshRequest struct {
@@ -18,15 +33,23 @@ type RefreshRequest struct {
 type UserRequest struct {
 	Username string `json:"username" validate:"required,min=3"`
 	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role" validate:"required,oneof=manager employee"`
+	Role     string `json:"role" validate:"required,max=20"`
 	Name     string `json:"name" validate:"required"`
+	// Email receives password reset links.
+	Email      string `json:"email" validate:"omitempty,email,max=255"`
+	Position   string `json:"position" validate:"max=100"`
+	Department string `json:"department" validate:"max=100"`
 }
 
 type UpdateUserRequest struct {
 	Username string `json:"username" validate:"required,min=3"`
 	Password string `json:"password" validate:"omitempty,min=6"`
-	Role     string `json:"role" validate:"required,oneof=manager employee"`
+	Role     string `json:"role" validate:"required,max=20"`
 	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"omitempty,email,max=255"`
+	// Position and Department are kept when empty.
+	Position   string `json:"position" validate:"max=100"`
+	Department string `json:"department" validate:"max=100"`
 }
 
 type UserResponse struct {
@@ -34,4 +57,18 @@ type UserResponse struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
 	Name     string `json:"name"`
+	TeamID   *int   `json:"team_id,omitempty"`
+	Email    string `json:"email,omitempty"`
+	// AuthSource is "local" or the directory that manages the password.
+	AuthSource string `json:"auth_source,omitempty"`
+	Position   string `json:"position,omitempty"`
+	Department string `json:"department,omitempty"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+}
+
+// UserSummaryResponse is a short reference to a user inside other resources.
+type UserSummaryResponse struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
 }
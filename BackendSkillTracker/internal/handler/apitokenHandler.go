@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+func apiTokenErrorStatus(err error) int {
+	switch err.Error() {
+	case "invalid scope", "token lifetime exceeds the maximum":
+		return http.StatusBadRequest
+	case "forbidden":
+		return http.StatusForbidden
+	case "token not found", "user not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// GetAPITokens godoc
+// @Summary List my API tokens
+// @Description Personal access tokens of the current user, newest first. The tokens themselves are not shown again.
+// @Tags tokens
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.APITokenResponse
+// @Router /tokens [get]
+func (h *Handler) GetAPITokens(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	res, err := h.service.APIToken().GetAPITokens(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// CreateAPIToken godoc
+// @Summary Create an API token
+// @Description Issues a personal access token for scripts and integrations. The token is in this response only; send it as "Authorization: Bearer stp_...".
+// @Tags tokens
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.APITokenRequest true "Name, scopes and lifetime"
+// @Success 201 {object} dto.APITokenCreatedResponse
+// @Failure 400 {object} map[string]string
+// @Router /tokens [post]
+func (h *Handler) CreateAPIToken(c echo.Context) error {
+	var req dto.APITokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.APIToken().CreateAPIToken(c.Request().Context(), userID, &req)
+	if err != nil {
+		return c.JSON(apiTokenErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// RevokeAPIToken godoc
+// @Summary Revoke one of my API tokens
+// @Tags tokens
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tokens/{id} [delete]
+func (h *Handler) RevokeAPIToken(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.APIToken().RevokeAPIToken(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(apiTokenErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "revoked"})
+}
+
+// RevokeUserAPITokens godoc
+// @Summary Revoke all API tokens of a user
+// @Description For a leaver or a leaked token (user.manage)
+// @Tags tokens
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/tokens [delete]
+func (h *Handler) RevokeUserAPITokens(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.APIToken().RevokeUserAPITokens(c.Request().Context(), id, managerID, role, allTeams); err != nil {
+		return c.JSON(apiTokenErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "revoked"})
+}
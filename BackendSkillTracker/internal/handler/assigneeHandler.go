@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func assigneeErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "task not found", "user not found":
+		return http.StatusNotFound
+	case "task is already assigned to this user", "assignee lacks required skills":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// SetTaskAssignees godoc
+// @Summary Set task co-assignees
+// @Description Replaces the co-assignees working with the lead. Available to the task creator and lead
+// @Tags tasks
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param req body dto.TaskAssigneesRequest true "Co-assignees"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/assignees [put]
+func (h *Handler) SetTaskAssignees(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.TaskAssigneesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	userID := c.Get("user_id").(int)
+	if err := h.service.Task().SetTaskAssignees(c.Request().Context(), id, &req, userID); err != nil {
+		return c.JSON(assigneeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// ReassignTask godoc
+// @Summary Reassign a task
+// @Description Hands the task over to a new lead with an optional handoff note. Available to the task creator and managers
+// @Tags tasks
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param req body dto.ReassignTaskRequest true "Reassign request"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/reassign [post]
+func (h *Handler) ReassignTask(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.ReassignTaskRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Task().ReassignTask(c.Request().Context(), id, &req, userID, role, allTeams); err != nil {
+		return c.JSON(assigneeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "reassigned"})
+}
+
+// AddTaskWatcher godoc
+// @Summary Watch a task
+// @Description Anyone who can read a task can watch it; adding other users requires edit rights on it
+// @Tags tasks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param user_id path int true "Watcher user ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/watchers/{user_id} [post]
+func (h *Handler) AddTaskWatcher(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	watcherID, _ := strconv.Atoi(c.Param("user_id"))
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Task().AddTaskWatcher(c.Request().Context(), id, watcherID, userID, role, allTeams); err != nil {
+		return c.JSON(assigneeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "added"})
+}
+
+// RemoveTaskWatcher godoc
+// @Summary Stop watching a task
+// @Tags tasks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param user_id path int true "Watcher user ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/watchers/{user_id} [delete]
+func (h *Handler) RemoveTaskWatcher(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	watcherID, _ := strconv.Atoi(c.Param("user_id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Task().RemoveTaskWatcher(c.Request().Context(), id, watcherID, userID); err != nil {
+		return c.JSON(assigneeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "removed"})
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+func auditErrorStatus(err error) int {
+	switch err.Error() {
+	case "invalid date", "invalid page":
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetAuditLog godoc
+// @Summary Audit log
+// @Description Security-relevant and administrative actions of the organization, newest first
+// @Tags audit
+// @Security ApiKeyAuth
+// @Produce json
+// @Param action query string false "Comma-separated actions, e.g. login.failed,user.updated"
+// @Param actor_id query int false "Acting user, also while impersonating"
+// @Param target_type query string false "Target type, e.g. user or role"
+// @Param target_id query int false "Target ID"
+// @Param request_id query string false "Request ID (X-Request-Id)"
+// @Param from query string false "From date (YYYY-MM-DD)"
+// @Param to query string false "To date, inclusive (YYYY-MM-DD)"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param offset query int false "Offset"
+// @Success 200 {object} dto.AuditLogPage
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /audit-log [get]
+func (h *Handler) GetAuditLog(c echo.Context) error {
+	var filter dto.AuditFilter
+	if err := c.Bind(&filter); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid query params"})
+	}
+	res, err := h.service.Audit().GetAuditLog(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(auditErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ExportAuditLog godoc
+// @Summary Export the audit log
+// @Description All entries matching the filters of GET /audit-log, as CSV (default) or JSON
+// @Tags audit
+// @Security ApiKeyAuth
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "csv or json"
+// @Param action query string false "Comma-separated actions"
+// @Param actor_id query int false "Acting user, also while impersonating"
+// @Param target_type query string false "Target type"
+// @Param target_id query int false "Target ID"
+// @Param request_id query string false "Request ID"
+// @Param from query string false "From date (YYYY-MM-DD)"
+// @Param to query string false "To date, inclusive (YYYY-MM-DD)"
+// @Success 200 {array} dto.AuditLogResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /audit-log/export [get]
+func (h *Handler) ExportAuditLog(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format != "" && format != "csv" && format != "json" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid format"})
+	}
+	var filter dto.AuditFilter
+	if err := c.Bind(&filter); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid query params"})
+	}
+	res, err := h.service.Audit().ExportAuditLog(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(auditErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	name := "audit-log-" + time.Now().Format("2006-01-02")
+	if format == "json" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+name+`.json"`)
+		return c.JSON(http.StatusOK, res)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+name+`.csv"`)
+	c.Response().WriteHeader(http.StatusOK)
+	w := csv.NewWriter(c.Response())
+	w.Write([]string{"id", "created_at", "action", "actor_id", "actor_name", "impersonator_id",
+		"target_type", "target_id", "ip", "user_agent", "request_id", "before", "after"})
+	for _, e := range res {
+		w.Write([]string{
+			strconv.Itoa(e.ID),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			e.Action,
+			optionalID(e.ActorID),
+			e.ActorName,
+			optionalID(e.ImpersonatorID),
+			e.TargetType,
+			optionalID(e.TargetID),
+			e.IP,
+			e.UserAgent,
+			e.RequestID,
+			string(e.Before),
+			string(e.After),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func optionalID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
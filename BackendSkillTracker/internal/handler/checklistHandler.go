@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func checklistErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "task not found", "checklist item not found":
+		return http.StatusNotFound
+	case "invalid item order":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetChecklist godoc
+// @Summary Get task checklist
+// @Tags checklists
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {array} dto.ChecklistItemResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist [get]
+func (h *Handler) GetChecklist(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Checklist().GetChecklist(c.Request().Context(), taskID, userID, role, allTeams)
+	if err != nil {
+		return c.JSON(checklistErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// AddChecklistItem godoc
+// @Summary Add checklist item
+// @Description Append an item to the task checklist; task progress is recalculated
+// @Tags checklists
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param req body dto.ChecklistItemRequest true "Checklist item"
+// @Success 201 {object} dto.ChecklistItemResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist [post]
+func (h *Handler) AddChecklistItem(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	var req dto.ChecklistItemRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Checklist().AddChecklistItem(c.Request().Context(), taskID, userID, req.Text)
+	if err != nil {
+		return c.JSON(checklistErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// UpdateChecklistItem godoc
+// @Summary Rename checklist item
+// @Tags checklists
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param item_id path int true "Checklist item ID"
+// @Param req body dto.ChecklistItemRequest true "Checklist item"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist/{item_id} [put]
+func (h *Handler) UpdateChecklistItem(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	itemID, _ := strconv.Atoi(c.Param("item_id"))
+	var req dto.ChecklistItemRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	if err := h.service.Checklist().UpdateChecklistItem(c.Request().Context(), taskID, itemID, userID, req.Text); err != nil {
+		return c.JSON(checklistErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// CheckChecklistItem godoc
+// @Summary Check checklist item
+// @Tags checklists
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param item_id path int true "Checklist item ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist/{item_id}/check [post]
+func (h *Handler) CheckChecklistItem(c echo.Context) error {
+	return h.setChecklistItemDone(c, true)
+}
+
+// UncheckChecklistItem godoc
+// @Summary Uncheck checklist item
+// @Tags checklists
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param item_id path int true "Checklist item ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist/{item_id}/uncheck [post]
+func (h *Handler) UncheckChecklistItem(c echo.Context) error {
+	return h.setChecklistItemDone(c, false)
+}
+
+func (h *Handler) setChecklistItemDone(c echo.Context, done bool) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	itemID, _ := strconv.Atoi(c.Param("item_id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Checklist().SetChecklistItemDone(c.Request().Context(), taskID, itemID, userID, done); err != nil {
+		return c.JSON(checklistErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteChecklistItem godoc
+// @Summary Delete checklist item
+// @Tags checklists
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param item_id path int true "Checklist item ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist/{item_id} [delete]
+func (h *Handler) DeleteChecklistItem(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	itemID, _ := strconv.Atoi(c.Param("item_id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Checklist().DeleteChecklistItem(c.Request().Context(), taskID, itemID, userID); err != nil {
+		return c.JSON(checklistErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// ReorderChecklist godoc
+// @Summary Reorder checklist
+// @Description Set the order of checklist items; item_ids must list every item of the task once
+// @Tags checklists
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param req body dto.ChecklistReorderRequest true "New order"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /tasks/{id}/checklist/order [put]
+func (h *Handler) ReorderChecklist(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	var req dto.ChecklistReorderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	if err := h.service.Checklist().ReorderChecklist(c.Request().Context(), taskID, userID, req.ItemIDs); err != nil {
+		return c.JSON(checklistErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "reordered"})
+}
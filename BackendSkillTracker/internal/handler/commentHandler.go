@@ -15,8 +15,11 @@ import (
 // @Accept json
 // @Produce json
 // @Param req body dto.CommentRequest true "Comment request"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} dto.CommentResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /comments [post]
 func (h *Handler) CreateComment(c echo.Context) error {
 	var req dto.CommentRequest
@@ -27,8 +30,14 @@ func (h *Handler) CreateComment(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 	userID := c.Get("user_id").(int)
-	res, err := h.service.Comment().CreateComment(c.Request().Context(), req.TaskID, userID, req.Text)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Comment().CreateComment(c.Request().Context(), req.TaskID, userID, role, allTeams, req.Text)
 	if err != nil {
+		switch err.Error() {
+		case "forbidden", "task not found":
+			return c.JSON(taskAccessErrorStatus(err), map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
@@ -41,13 +50,19 @@ func (h *Handler) CreateComment(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Produce json
 // @Param task_id path int true "Task ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {array} dto.CommentResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /tasks/{task_id}/comments [get]
 func (h *Handler) GetCommentsByTaskID(c echo.Context) error {
 	taskID, _ := strconv.Atoi(c.Param("task_id"))
-	res, err := h.service.Comment().GetCommentsByTaskID(c.Request().Context(), taskID)
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Comment().GetCommentsByTaskID(c.Request().Context(), taskID, userID, role, allTeams)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(taskAccessErrorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
 }
@@ -86,7 +101,7 @@ func (h *Handler) UpdateComment(c echo.Context) error {
 
 // DeleteComment godoc
 // @Summary Delete comment
-// @Description Remove a comment from a task
+// @Description Remove a comment from a task. Authors delete their own comments; comment.delete.any allows any
 // @Tags comments
 // @Security ApiKeyAuth
 // @Produce json
@@ -98,7 +113,8 @@ func (h *Handler) UpdateComment(c echo.Context) error {
 func (h *Handler) DeleteComment(c echo.Context) error {
     id, _ := strconv.Atoi(c.Param("id"))
     userID := c.Get("user_id").(int)
-    if err := h.service.Comment().DeleteComment(c.Request().Context(), id, userID); err != nil {
+    role, _ := c.Get("role").(string)
+    if err := h.service.Comment().DeleteComment(c.Request().Context(), id, userID, role); err != nil {
         if err.Error() == "forbidden" { return c.JSON(http.StatusForbidden, map[string]string{"error":"forbidden"}) }
         return c.JSON(http.StatusNotFound, map[string]string{"error":"comment not found"})
     }
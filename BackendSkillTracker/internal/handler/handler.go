@@ -21,3 +21,23 @@ func NewHandler(s service.ServiceInterface) *Handler {
 func (h *Handler) validate(i interface{}) error {
 	return h.validator.Struct(i)
 }
+
+// Access exposes the permission policy to the route middleware.
+func (h *Handler) Access() service.AccessService {
+	return h.service.Access()
+}
+
+// Session exposes the token revocation check to the auth middleware.
+func (h *Handler) Session() service.SessionService {
+	return h.service.Session()
+}
+
+// APIToken exposes personal access token lookup to the auth middleware.
+func (h *Handler) APIToken() service.APITokenService {
+	return h.service.APIToken()
+}
+
+// Impersonation exposes the impersonation log to the route middleware.
+func (h *Handler) Impersonation() service.ImpersonationService {
+	return h.service.Impersonation()
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+func impersonationErrorStatus(err error) int {
+	switch err.Error() {
+	case "cannot impersonate yourself":
+		return http.StatusBadRequest
+	case "user cannot be impersonated", "forbidden":
+		return http.StatusForbidden
+	case "user not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// ImpersonateUser godoc
+// @Summary Act as another user
+// @Description Issues a short-lived access token acting as the user, to see the app as they do (user.impersonate). The token carries the manager in its act claim; every request made with it is logged, and sessions, 2FA, API tokens and logout are refused. Users who may impersonate can't be impersonated.
+// @Tags users
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param req body dto.ImpersonationRequest true "Reason"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} dto.ImpersonationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/impersonate [post]
+func (h *Handler) ImpersonateUser(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.ImpersonationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	actorID := c.Get("user_id").(int)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Impersonation().Impersonate(c.Request().Context(), actorID, id, allTeams, req.Reason)
+	if err != nil {
+		return c.JSON(impersonationErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func labelErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "task not found", "label not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateLabel godoc
+// @Summary Create a label
+// @Description Names are stored in lower case and must not contain commas
+// @Tags labels
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.LabelRequest true "Label request"
+// @Success 201 {object} dto.LabelResponse
+// @Failure 400 {object} map[string]string
+// @Router /labels [post]
+func (h *Handler) CreateLabel(c echo.Context) error {
+	var req dto.LabelRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	res, err := h.service.Label().CreateLabel(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GetLabels godoc
+// @Summary List labels
+// @Tags labels
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.LabelResponse
+// @Router /labels [get]
+func (h *Handler) GetLabels(c echo.Context) error {
+	res, err := h.service.Label().GetLabels(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateLabel godoc
+// @Summary Update a label
+// @Tags labels
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Label ID"
+// @Param req body dto.LabelRequest true "Label request"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /labels/{id} [put]
+func (h *Handler) UpdateLabel(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.LabelRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.service.Label().UpdateLabel(c.Request().Context(), id, &req); err != nil {
+		return c.JSON(labelErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteLabel godoc
+// @Summary Delete a label
+// @Description The label is removed from all tasks
+// @Tags labels
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Label ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /labels/{id} [delete]
+func (h *Handler) DeleteLabel(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.service.Label().DeleteLabel(c.Request().Context(), id); err != nil {
+		return c.JSON(labelErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// GetLabelUsage godoc
+// @Summary Label usage statistics
+// @Description Number of tasks per label and status, most used first
+// @Tags labels
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.LabelUsageResponse
+// @Router /labels/usage [get]
+func (h *Handler) GetLabelUsage(c echo.Context) error {
+	res, err := h.service.Label().GetLabelUsage(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// AddLabelToTask godoc
+// @Summary Add label to task
+// @Description Available to the task creator and assignee
+// @Tags labels
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/labels/{label_id} [post]
+func (h *Handler) AddLabelToTask(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	labelID, _ := strconv.Atoi(c.Param("label_id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Label().AddLabelToTask(c.Request().Context(), taskID, labelID, userID); err != nil {
+		return c.JSON(labelErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "label added"})
+}
+
+// RemoveLabelFromTask godoc
+// @Summary Remove label from task
+// @Description Available to the task creator and assignee
+// @Tags labels
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /tasks/{id}/labels/{label_id} [delete]
+func (h *Handler) RemoveLabelFromTask(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	labelID, _ := strconv.Atoi(c.Param("label_id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Label().RemoveLabelFromTask(c.Request().Context(), taskID, labelID, userID); err != nil {
+		return c.JSON(labelErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "label removed"})
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetNotifications godoc
+// @Summary List my notifications
+// @Description Newest first
+// @Tags notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {array} dto.NotificationResponse
+// @Router /notifications [get]
+func (h *Handler) GetNotifications(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	unread, _ := strconv.ParseBool(c.QueryParam("unread"))
+	res, err := h.service.Notification().GetNotifications(c.Request().Context(), userID, unread)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /notifications/{id}/read [post]
+func (h *Handler) MarkNotificationRead(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Notification().MarkNotificationRead(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all my notifications as read
+// @Tags notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /notifications/read-all [post]
+func (h *Handler) MarkAllNotificationsRead(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	if err := h.service.Notification().MarkAllNotificationsRead(c.Request().Context(), userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// GetNotificationPreferences godoc
+// @Summary My notification preferences
+// @Description Every notification type with whether I get it. Types are on until turned off
+// @Tags notifications
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.NotificationPreference
+// @Router /me/notification-preferences [get]
+func (h *Handler) GetNotificationPreferences(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Notification().GetNotificationPreferences(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary Change my notification preferences
+// @Description Turns the listed notification types on or off; types not listed keep their setting
+// @Tags notifications
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.NotificationPreferencesRequest true "Preferences"
+// @Success 200 {array} dto.NotificationPreference
+// @Failure 400 {object} map[string]string
+// @Router /me/notification-preferences [put]
+func (h *Handler) UpdateNotificationPreferences(c echo.Context) error {
+	var req dto.NotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Notification().UpdateNotificationPreferences(c.Request().Context(), userID, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+func oidcErrorStatus(err error) int {
+	switch err.Error() {
+	case "unknown identity provider", "organization not found":
+		return http.StatusNotFound
+	case "invalid oidc state", "no username in id token":
+		return http.StatusBadRequest
+	case "invalid authorization code":
+		return http.StatusUnauthorized
+	case "no role for this account", "account not found":
+		return http.StatusForbidden
+	case "username taken", "account cannot be linked":
+		return http.StatusConflict
+	case "identity provider unavailable":
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// StartOIDCLogin godoc
+// @Summary Start login with an OpenID Connect provider
+// @Description Returns the provider URL to send the browser to. The provider redirects back to the configured redirect URL with code and state, which go to /oidc/{provider}/callback.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} dto.OIDCAuthorizeResponse
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /oidc/{provider}/authorize [get]
+func (h *Handler) StartOIDCLogin(c echo.Context) error {
+	res, err := h.service.User().StartOIDCLogin(c.Request().Context(), c.Param("provider"))
+	if err != nil {
+		return c.JSON(oidcErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// CompleteOIDCLogin godoc
+// @Summary Complete login with an OpenID Connect provider
+// @Description Redeems the code the provider redirected back with. Users are linked by provider subject, then by username or verified email as configured, or created. Responds like /login, including 2FA challenges.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param req body dto.OIDCCallbackRequest true "Code and state"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /oidc/{provider}/callback [post]
+func (h *Handler) CompleteOIDCLogin(c echo.Context) error {
+	var req dto.OIDCCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	res, err := h.service.User().CompleteOIDCLogin(c.Request().Context(), c.Param("provider"), &req, clientInfo(c))
+	if err != nil {
+		return c.JSON(oidcErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetOrganization godoc
+// @Summary Get my organization
+// @Description The organization (tenant) of the current user
+// @Tags organizations
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} dto.OrganizationResponse
+// @Failure 404 {object} map[string]string
+// @Router /organization [get]
+func (h *Handler) GetOrganization(c echo.Context) error {
+	orgID := c.Get("org_id").(int)
+	res, err := h.service.Organization().GetOrganization(c.Request().Context(), orgID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+// passwordPolicyErrors are the ways a new password can fail the policy.
+var passwordPolicyErrors = map[string]bool{
+	"password is too short":              true,
+	"password is too long":               true,
+	"password needs an uppercase letter": true,
+	"password needs a lowercase letter":  true,
+	"password needs a digit":             true,
+	"password needs a symbol":            true,
+	"password is too common":             true,
+	"password was used recently":         true,
+}
+
+func passwordErrorStatus(err error) int {
+	if passwordPolicyErrors[err.Error()] {
+		return http.StatusBadRequest
+	}
+	switch err.Error() {
+	case "invalid reset token":
+		return http.StatusBadRequest
+	case "invalid challenge token":
+		return http.StatusUnauthorized
+	case "password reset unavailable":
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// GetPasswordPolicy godoc
+// @Summary Password policy
+// @Description What new passwords have to look like
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.PasswordPolicyResponse
+// @Router /password/policy [get]
+func (h *Handler) GetPasswordPolicy(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.User().GetPasswordPolicy())
+}
+
+// ChangeLoginPassword godoc
+// @Summary Replace the initial password during login
+// @Description For a login response with password_change: sets a new password with the challenge token and completes the login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param req body dto.PasswordChangeLoginRequest true "Challenge and new password"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /login/password [post]
+func (h *Handler) ChangeLoginPassword(c echo.Context) error {
+	var req dto.PasswordChangeLoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	res, err := h.service.User().ChangeLoginPassword(c.Request().Context(), &req, clientInfo(c))
+	if err != nil {
+		return c.JSON(passwordErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset link
+// @Description Mails a single-use reset link to the user with the username or email. The response is the same whether the account exists or not.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param req body dto.ForgotPasswordRequest true "Username or email"
+// @Success 202 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /password/forgot [post]
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	var req dto.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.service.User().RequestPasswordReset(c.Request().Context(), &req); err != nil {
+		return c.JSON(passwordErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "if the account exists, a reset link was sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password with the token of a reset link and ends all sessions of the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param req body dto.ResetPasswordRequest true "Token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /password/reset [post]
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var req dto.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.service.User().ResetPassword(c.Request().Context(), &req); err != nil {
+		return c.JSON(passwordErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "password reset"})
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// avatarDir is served under /uploads/avatars.
+	avatarDir     = "./uploads/avatars"
+	maxAvatarSize = 2 << 20
+)
+
+// avatarTypes are the accepted picture formats by sniffed content type.
+var avatarTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+func profileErrorStatus(err error) int {
+	if passwordPolicyErrors[err.Error()] {
+		return http.StatusBadRequest
+	}
+	switch err.Error() {
+	case "name is managed by the directory", "password is managed by the directory", "invalid current password":
+		return http.StatusBadRequest
+	case "user not found":
+		return http.StatusNotFound
+	case "account locked":
+		return http.StatusLocked
+	case "too many login attempts":
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// GetProfile godoc
+// @Summary My profile
+// @Tags profile
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} dto.ProfileResponse
+// @Router /me [get]
+func (h *Handler) GetProfile(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Profile().GetProfile(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(profileErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateProfile godoc
+// @Summary Edit my profile
+// @Description Replaces name, timezone, locale and contacts. Username, email, role, team, position and department are changed by managers
+// @Tags profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.UpdateProfileRequest true "Profile"
+// @Success 200 {object} dto.ProfileResponse
+// @Failure 400 {object} map[string]string
+// @Router /me [put]
+func (h *Handler) UpdateProfile(c echo.Context) error {
+	var req dto.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Profile().UpdateProfile(c.Request().Context(), userID, &req)
+	if err != nil {
+		return c.JSON(profileErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ChangePassword godoc
+// @Summary Change my password
+// @Description Checks the current password; wrong ones count as failed logins. All sessions end, this one included, and the response carries the tokens of a new session
+// @Tags profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 423 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /me/password [post]
+func (h *Handler) ChangePassword(c echo.Context) error {
+	var req dto.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Profile().ChangePassword(c.Request().Context(), userID, &req, clientInfo(c))
+	if err != nil {
+		return c.JSON(profileErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UploadAvatar godoc
+// @Summary Upload my avatar
+// @Description PNG, JPEG, GIF or WebP up to 2 MB; replaces the previous avatar
+// @Tags profile
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Picture"
+// @Success 200 {object} dto.AvatarResponse
+// @Failure 400 {object} map[string]string
+// @Router /me/avatar [post]
+func (h *Handler) UploadAvatar(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid file"})
+	}
+	if file.Size > maxAvatarSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "file is too large"})
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// The content decides the type, not the name or header of the upload.
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	ext, ok := avatarTypes[http.DetectContentType(head[:n])]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "unsupported image type"})
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(avatarDir, 0755); err != nil {
+		return err
+	}
+	userID := c.Get("user_id").(int)
+	name := strconv.Itoa(userID) + "_" + strconv.FormatInt(time.Now().UnixNano(), 10) + ext
+	dstPath := filepath.Join(avatarDir, name)
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, io.LimitReader(src, maxAvatarSize)); err != nil {
+		os.Remove(dstPath)
+		return err
+	}
+
+	previous, err := h.service.Profile().SetAvatar(c.Request().Context(), userID, name)
+	if err != nil {
+		os.Remove(dstPath)
+		return c.JSON(profileErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	removeAvatar(previous)
+	return c.JSON(http.StatusOK, dto.AvatarResponse{AvatarURL: "/uploads/avatars/" + name})
+}
+
+// DeleteAvatar godoc
+// @Summary Remove my avatar
+// @Tags profile
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /me/avatar [delete]
+func (h *Handler) DeleteAvatar(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	previous, err := h.service.Profile().SetAvatar(c.Request().Context(), userID, "")
+	if err != nil {
+		return c.JSON(profileErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	removeAvatar(previous)
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+func removeAvatar(name string) {
+	if name != "" {
+		os.Remove(filepath.Join(avatarDir, filepath.Base(name)))
+	}
+}
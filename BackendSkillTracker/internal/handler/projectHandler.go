@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func projectErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "project not found", "user not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateProject godoc
+// @Summary Create a project
+// @Description The current manager becomes the project owner
+// @Tags projects
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.ProjectRequest true "Project request"
+// @Success 201 {object} dto.ProjectResponse
+// @Failure 400 {object} map[string]string
+// @Router /projects [post]
+func (h *Handler) CreateProject(c echo.Context) error {
+	var req dto.ProjectRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Project().CreateProject(c.Request().Context(), &req, userID)
+	if err != nil {
+		return c.JSON(projectErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GetProjects godoc
+// @Summary List projects
+// @Description Managers see all projects, employees only projects they are members of
+// @Tags projects
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.ProjectResponse
+// @Router /projects [get]
+func (h *Handler) GetProjects(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	res, err := h.service.Project().GetProjects(c.Request().Context(), userID, role)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetProjectByID godoc
+// @Summary Get project by ID
+// @Description Includes progress rolled up from the project tasks
+// @Tags projects
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} dto.ProjectResponse
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id} [get]
+func (h *Handler) GetProjectByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	res, err := h.service.Project().GetProjectByID(c.Request().Context(), id, userID, role)
+	if err != nil {
+		return c.JSON(projectErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateProject godoc
+// @Summary Update project
+// @Description Only the owner can change a project; member_ids replaces the member list
+// @Tags projects
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param req body dto.ProjectRequest true "Project request"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id} [put]
+func (h *Handler) UpdateProject(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.ProjectRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	if err := h.service.Project().UpdateProject(c.Request().Context(), id, &req, userID); err != nil {
+		return c.JSON(projectErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteProject godoc
+// @Summary Delete project
+// @Description Only the owner can delete a project; its tasks are kept without a project
+// @Tags projects
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id} [delete]
+func (h *Handler) DeleteProject(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Project().DeleteProject(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(projectErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func recurringErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "recurring task not found", "skill not found":
+		return http.StatusNotFound
+	case "occurrence already created", "occurrence out of range":
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateRecurringTask godoc
+// @Summary Create a recurring task
+// @Description Create a recurrence rule from which tasks are materialized on schedule or on completion of the previous occurrence
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.RecurringTaskRequest true "Recurring task request"
+// @Success 201 {object} dto.RecurringTaskResponse
+// @Failure 400 {object} map[string]string
+// @Router /recurring-tasks [post]
+func (h *Handler) CreateRecurringTask(c echo.Context) error {
+	var req dto.RecurringTaskRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Recurring().CreateRecurringTask(c.Request().Context(), &req, userID)
+	if err != nil {
+		return c.JSON(recurringErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GetRecurringTasks godoc
+// @Summary List recurring tasks
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.RecurringTaskResponse
+// @Router /recurring-tasks [get]
+func (h *Handler) GetRecurringTasks(c echo.Context) error {
+	res, err := h.service.Recurring().GetRecurringTasks(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetRecurringTaskByID godoc
+// @Summary Get recurring task by ID
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Recurring task ID"
+// @Success 200 {object} dto.RecurringTaskResponse
+// @Failure 404 {object} map[string]string
+// @Router /recurring-tasks/{id} [get]
+func (h *Handler) GetRecurringTaskByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	res, err := h.service.Recurring().GetRecurringTaskByID(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "recurring task not found"})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateRecurringTask godoc
+// @Summary Update recurring task
+// @Description Change the rule or the blueprint; already created occurrences are not touched
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Recurring task ID"
+// @Param req body dto.RecurringTaskRequest true "Update request"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /recurring-tasks/{id} [put]
+func (h *Handler) UpdateRecurringTask(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.RecurringTaskRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	if err := h.service.Recurring().UpdateRecurringTask(c.Request().Context(), id, &req, userID); err != nil {
+		return c.JSON(recurringErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteRecurringTask godoc
+// @Summary Delete recurring task
+// @Description Stop the recurrence; already created occurrences are kept
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Recurring task ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /recurring-tasks/{id} [delete]
+func (h *Handler) DeleteRecurringTask(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Recurring().DeleteRecurringTask(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(recurringErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// GetOccurrences godoc
+// @Summary List upcoming occurrences
+// @Description Preview the next occurrences of a recurring task with their overrides applied
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Recurring task ID"
+// @Param limit query int false "Number of occurrences (default 10, max 100)"
+// @Success 200 {array} dto.OccurrenceResponse
+// @Failure 404 {object} map[string]string
+// @Router /recurring-tasks/{id}/occurrences [get]
+func (h *Handler) GetOccurrences(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	res, err := h.service.Recurring().GetOccurrences(c.Request().Context(), id, limit)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateOccurrence godoc
+// @Summary Edit a single occurrence
+// @Description Override fields of one upcoming occurrence before it is created
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Recurring task ID"
+// @Param n path int true "Occurrence number"
+// @Param req body dto.OccurrenceRequest true "Occurrence overrides"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /recurring-tasks/{id}/occurrences/{n} [put]
+func (h *Handler) UpdateOccurrence(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	n, _ := strconv.Atoi(c.Param("n"))
+	var req dto.OccurrenceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	if err := h.service.Recurring().UpdateOccurrence(c.Request().Context(), id, n, &req, userID); err != nil {
+		return c.JSON(recurringErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// SkipOccurrence godoc
+// @Summary Skip a single occurrence
+// @Tags recurring-tasks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Recurring task ID"
+// @Param n path int true "Occurrence number"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /recurring-tasks/{id}/occurrences/{n}/skip [post]
+func (h *Handler) SkipOccurrence(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	n, _ := strconv.Atoi(c.Param("n"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Recurring().SkipOccurrence(c.Request().Context(), id, n, userID); err != nil {
+		return c.JSON(recurringErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "skipped"})
+}
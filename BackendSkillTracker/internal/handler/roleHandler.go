@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func roleErrorStatus(err error) int {
+	switch err.Error() {
+	case "role not found":
+		return http.StatusNotFound
+	case "role already exists", "role is in use":
+		return http.StatusConflict
+	case "forbidden", "cannot change your own role":
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// GetPermissions godoc
+// @Summary List permissions
+// @Description All named permissions that can be bundled into roles
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} string
+// @Router /permissions [get]
+func (h *Handler) GetPermissions(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.Access().GetPermissions())
+}
+
+// GetRoles godoc
+// @Summary List roles
+// @Description Built-in roles followed by custom roles
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.RoleResponse
+// @Router /roles [get]
+func (h *Handler) GetRoles(c echo.Context) error {
+	res, err := h.service.Access().GetRoles(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// CreateRole godoc
+// @Summary Create a custom role
+// @Description Roles can only bundle permissions the caller holds
+// @Tags roles
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.RoleRequest true "Role request"
+// @Success 201 {object} dto.RoleResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles [post]
+func (h *Handler) CreateRole(c echo.Context) error {
+	var req dto.RoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	role, _ := c.Get("role").(string)
+	res, err := h.service.Access().CreateRole(c.Request().Context(), &req, role)
+	if err != nil {
+		return c.JSON(roleErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// UpdateRole godoc
+// @Summary Update a custom role
+// @Description Replaces the name, description and permissions. A role in use can't be renamed. Roles can only bundle permissions the caller holds, and nobody changes their own role
+// @Tags roles
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Param req body dto.RoleRequest true "Role request"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles/{id} [put]
+func (h *Handler) UpdateRole(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.RoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	role, _ := c.Get("role").(string)
+	if err := h.service.Access().UpdateRole(c.Request().Context(), id, &req, role); err != nil {
+		return c.JSON(roleErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteRole godoc
+// @Summary Delete a custom role
+// @Description Only roles no user has can be deleted
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles/{id} [delete]
+func (h *Handler) DeleteRole(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.service.Access().DeleteRole(c.Request().Context(), id); err != nil {
+		return c.JSON(roleErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
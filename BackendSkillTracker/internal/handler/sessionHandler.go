@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+func clientInfo(c echo.Context) dto.ClientInfo {
+	return dto.ClientInfo{UserAgent: c.Request().UserAgent(), IP: c.RealIP()}
+}
+
+func sessionErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "session not found", "user not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// GetMySessions godoc
+// @Summary List my sessions
+// @Description Active login sessions of the current user, most recently used first
+// @Tags sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.SessionResponse
+// @Router /sessions [get]
+func (h *Handler) GetMySessions(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	sessionID, _ := c.Get("session_id").(int)
+	res, err := h.service.Session().GetMySessions(c.Request().Context(), userID, sessionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// RevokeMySession godoc
+// @Summary Revoke one of my sessions
+// @Description Its refresh token stops working; issued access tokens expire on their own
+// @Tags sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /sessions/{id} [delete]
+func (h *Handler) RevokeMySession(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Session().RevokeMySession(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(sessionErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "revoked"})
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Signs the user out everywhere (user.manage)
+// @Tags sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/sessions [delete]
+func (h *Handler) RevokeUserSessions(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Session().RevokeUserSessions(c.Request().Context(), id, managerID, role, allTeams); err != nil {
+		return c.JSON(sessionErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "revoked"})
+}
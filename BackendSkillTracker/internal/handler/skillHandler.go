@@ -70,12 +70,20 @@ func (h *Handler) DeleteSkill(c echo.Context) error {
 // @Produce json
 // @Param id path int true "User ID"
 // @Param skill_id path int true "Skill ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /users/{id}/skills/{skill_id} [post]
 func (h *Handler) AssignSkillToUser(c echo.Context) error {
 	userID, _ := strconv.Atoi(c.Param("id"))
 	skillID, _ := strconv.Atoi(c.Param("skill_id"))
-	if err := h.service.Skill().AssignSkillToUser(c.Request().Context(), userID, skillID); err != nil {
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Skill().AssignSkillToUser(c.Request().Context(), userID, skillID, managerID, role, allTeams); err != nil {
+		if err.Error() == "forbidden" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
 		if err.Error() == "user not found" || err.Error() == "skill not found" {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
 		}
@@ -91,12 +99,23 @@ func (h *Handler) AssignSkillToUser(c echo.Context) error {
 // @Produce json
 // @Param id path int true "User ID"
 // @Param skill_id path int true "Skill ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /users/{id}/skills/{skill_id} [delete]
 func (h *Handler) RemoveSkillFromUser(c echo.Context) error {
 	userID, _ := strconv.Atoi(c.Param("id"))
 	skillID, _ := strconv.Atoi(c.Param("skill_id"))
-	if err := h.service.Skill().RemoveSkillFromUser(c.Request().Context(), userID, skillID); err != nil {
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Skill().RemoveSkillFromUser(c.Request().Context(), userID, skillID, managerID, role, allTeams); err != nil {
+		if err.Error() == "forbidden" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
+		if err.Error() == "user not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "skill removed"})
@@ -104,16 +123,28 @@ func (h *Handler) RemoveSkillFromUser(c echo.Context) error {
 
 // GetUserSkills godoc
 // @Summary Get user skills
+// @Description Users can view their own skills; other users require user.read
 // @Tags skills
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "User ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {array} dto.SkillResponse
+// @Failure 403 {object} map[string]string
 // @Router /users/{id}/skills [get]
 func (h *Handler) GetUserSkills(c echo.Context) error {
 	userID, _ := strconv.Atoi(c.Param("id"))
-	res, err := h.service.Skill().GetUserSkills(c.Request().Context(), userID)
+	viewerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Skill().GetUserSkills(c.Request().Context(), userID, viewerID, role, allTeams)
 	if err != nil {
+		if err.Error() == "forbidden" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
+		if err.Error() == "user not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
@@ -172,31 +203,42 @@ func (h *Handler) RemoveSkillFromTask(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "Task ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {array} dto.SkillResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /tasks/{id}/skills [get]
 func (h *Handler) GetTaskSkills(c echo.Context) error {
 	taskID, _ := strconv.Atoi(c.Param("id"))
-	res, err := h.service.Task().GetTaskSkills(c.Request().Context(), taskID)
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Task().GetTaskSkills(c.Request().Context(), taskID, userID, role, allTeams)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(taskAccessErrorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
 }
 
 // GetRecommendedEmployees godoc
 // @Summary Get recommended employees for task
-// @Description Returns employees sorted by skill match score
+// @Description Returns employees sorted by skill match score. Team managers get candidates from their teams unless all_teams=true
 // @Tags tasks
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "Task ID"
+// @Param all_teams query bool false "Search across all teams (team.search.all)"
 // @Success 200 {array} dto.RecommendedEmployeeResponse
+// @Failure 403 {object} map[string]string
 // @Router /tasks/{id}/recommended-employees [get]
 func (h *Handler) GetRecommendedEmployees(c echo.Context) error {
 	taskID, _ := strconv.Atoi(c.Param("id"))
-	res, err := h.service.Task().GetRecommendedEmployees(c.Request().Context(), taskID)
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Task().GetRecommendedEmployees(c.Request().Context(), taskID, userID, role, allTeams)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(taskAccessErrorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+// GetSLAPolicies godoc
+// @Summary List SLA policies
+// @Description Expected response and resolution time per task priority
+// @Tags sla
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.SLAPolicyResponse
+// @Router /sla-policies [get]
+func (h *Handler) GetSLAPolicies(c echo.Context) error {
+	res, err := h.service.SLA().GetSLAPolicies(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateSLAPolicy godoc
+// @Summary Set the SLA policy of a priority
+// @Description Applies to new tasks and priority changes; due times of existing tasks are kept
+// @Tags sla
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param priority path string true "Priority (low, medium, high, critical)"
+// @Param req body dto.SLAPolicyRequest true "SLA policy"
+// @Success 200 {object} dto.SLAPolicyResponse
+// @Failure 400 {object} map[string]string
+// @Router /sla-policies/{priority} [put]
+func (h *Handler) UpdateSLAPolicy(c echo.Context) error {
+	var req dto.SLAPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	res, err := h.service.SLA().UpdateSLAPolicy(c.Request().Context(), c.Param("priority"), &req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
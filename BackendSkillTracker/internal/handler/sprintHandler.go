@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func sprintErrorStatus(err error) int {
+	switch err.Error() {
+	case "sprint not found", "project not found":
+		return http.StatusNotFound
+	case "sprint is closed", "sprint already started":
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateSprint godoc
+// @Summary Create a sprint
+// @Description Create a sprint or milestone with inclusive start and end dates
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.SprintRequest true "Sprint request"
+// @Success 201 {object} dto.SprintResponse
+// @Failure 400 {object} map[string]string
+// @Router /sprints [post]
+func (h *Handler) CreateSprint(c echo.Context) error {
+	var req dto.SprintRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Sprint().CreateSprint(c.Request().Context(), &req, userID)
+	if err != nil {
+		return c.JSON(sprintErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GetSprints godoc
+// @Summary List sprints
+// @Description Without task.read.any only sprints of own projects and tasks are listed
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Produce json
+// @Param project_id query int false "Project ID filter"
+// @Success 200 {array} dto.SprintResponse
+// @Router /sprints [get]
+func (h *Handler) GetSprints(c echo.Context) error {
+	projectID, _ := strconv.Atoi(c.QueryParam("project_id"))
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	res, err := h.service.Sprint().GetSprints(c.Request().Context(), projectID, userID, role)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetSprintByID godoc
+// @Summary Get sprint by ID
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Sprint ID"
+// @Success 200 {object} dto.SprintResponse
+// @Failure 404 {object} map[string]string
+// @Router /sprints/{id} [get]
+func (h *Handler) GetSprintByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	res, err := h.service.Sprint().GetSprintByID(c.Request().Context(), id, userID, role)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateSprint godoc
+// @Summary Update sprint
+// @Description Closed sprints can't be changed
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Sprint ID"
+// @Param req body dto.SprintRequest true "Sprint request"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /sprints/{id} [put]
+func (h *Handler) UpdateSprint(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.SprintRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.service.Sprint().UpdateSprint(c.Request().Context(), id, &req); err != nil {
+		return c.JSON(sprintErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteSprint godoc
+// @Summary Delete sprint
+// @Description Tasks of the sprint are kept without a sprint
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Sprint ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /sprints/{id} [delete]
+func (h *Handler) DeleteSprint(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.service.Sprint().DeleteSprint(c.Request().Context(), id); err != nil {
+		return c.JSON(sprintErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// StartSprint godoc
+// @Summary Start a planned sprint
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Sprint ID"
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /sprints/{id}/start [post]
+func (h *Handler) StartSprint(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.service.Sprint().StartSprint(c.Request().Context(), id); err != nil {
+		return c.JSON(sprintErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "started"})
+}
+
+// CloseSprint godoc
+// @Summary Close a sprint
+// @Description Unfinished tasks move to next_sprint_id or to the next sprint of the same project
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Sprint ID"
+// @Param req body dto.CloseSprintRequest false "Carry-over target"
+// @Success 200 {object} dto.CloseSprintResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /sprints/{id}/close [post]
+func (h *Handler) CloseSprint(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.CloseSprintRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Sprint().CloseSprint(c.Request().Context(), id, &req, userID)
+	if err != nil {
+		return c.JSON(sprintErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetBurndown godoc
+// @Summary Sprint burndown and burnup
+// @Description Remaining and completed tasks at the end of each sprint day, computed from the task status history
+// @Tags sprints
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Sprint ID"
+// @Success 200 {object} dto.BurndownResponse
+// @Failure 404 {object} map[string]string
+// @Router /sprints/{id}/burndown [get]
+func (h *Handler) GetBurndown(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	res, err := h.service.Sprint().GetBurndown(c.Request().Context(), id, userID, role)
+	if err != nil {
+		return c.JSON(sprintErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -11,6 +11,18 @@ import (
     "time"
 )
 
+// taskAccessErrorStatus maps the errors of task read checks.
+func taskAccessErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "task not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // CreateTask godoc
 // @Summary Create a new task
 // @Description Assign a new task to an employee
@@ -19,8 +31,10 @@ import (
 // @Accept json
 // @Produce json
 // @Param req body dto.TaskRequest true "Task request"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} dto.TaskResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /tasks [post]
 func (h *Handler) CreateTask(c echo.Context) error {
 	var req dto.TaskRequest
@@ -31,8 +45,13 @@ func (h *Handler) CreateTask(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 	userID := c.Get("user_id").(int)
-	res, err := h.service.Task().CreateTask(c.Request().Context(), &req, userID)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Task().CreateTask(c.Request().Context(), &req, userID, role, allTeams)
 	if err != nil {
+		if err.Error() == "forbidden" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
@@ -45,28 +64,41 @@ func (h *Handler) CreateTask(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "Task ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} dto.TaskResponse
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /tasks/{id} [get]
 func (h *Handler) GetTaskByID(c echo.Context) error {
 	id, _ := strconv.Atoi(c.Param("id"))
-	res, err := h.service.Task().GetTaskByID(c.Request().Context(), id)
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Task().GetTaskByID(c.Request().Context(), id, userID, role, allTeams)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "task not found"})
+		return c.JSON(taskAccessErrorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
 }
 
 // GetMyTasks godoc
 // @Summary Get my tasks
-// @Description Retrieve tasks assigned to or created by the current user
+// @Description Retrieve tasks where the current user is the lead or a co-assignee, or watched tasks with watching=true
 // @Tags tasks
 // @Security ApiKeyAuth
 // @Produce json
+// @Param watching query bool false "Return watched tasks instead"
 // @Success 200 {array} dto.TaskResponse
 // @Router /tasks/my [get]
 func (h *Handler) GetMyTasks(c echo.Context) error {
 	userID := c.Get("user_id").(int)
+	if watching, _ := strconv.ParseBool(c.QueryParam("watching")); watching {
+		res, err := h.service.Task().GetWatchedTasks(c.Request().Context(), userID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusOK, res)
+	}
 	res, err := h.service.Task().GetTasksByEmployeeID(c.Request().Context(), userID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -83,6 +115,7 @@ func (h *Handler) GetMyTasks(c echo.Context) error {
 // @Produce json
 // @Param id path int true "Task ID"
 // @Param req body dto.TaskRequest true "Update request"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -97,10 +130,18 @@ func (h *Handler) UpdateTask(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 	userID := c.Get("user_id").(int)
-	if err := h.service.Task().UpdateTask(c.Request().Context(), id, &req, userID); err != nil {
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Task().UpdateTask(c.Request().Context(), id, &req, userID, role, allTeams); err != nil {
 		if err.Error() == "forbidden" {
 			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
 		}
+		switch err.Error() {
+		case "project not found", "sprint not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case "sprint is closed":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "task not found"})
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
@@ -113,6 +154,7 @@ func (h *Handler) UpdateTask(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "Task ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -120,7 +162,9 @@ func (h *Handler) UpdateTask(c echo.Context) error {
 func (h *Handler) DeleteTask(c echo.Context) error {
     id, _ := strconv.Atoi(c.Param("id"))
     userID := c.Get("user_id").(int)
-    if err := h.service.Task().DeleteTask(c.Request().Context(), id, userID); err != nil {
+    role, _ := c.Get("role").(string)
+    allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+    if err := h.service.Task().DeleteTask(c.Request().Context(), id, userID, role, allTeams); err != nil {
         if err.Error() == "forbidden" { return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"}) }
         return c.JSON(http.StatusNotFound, map[string]string{"error": "task not found"})
     }
@@ -189,20 +233,26 @@ func (h *Handler) UploadAttachment(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "Task ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {array} dto.TaskHistoryResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /tasks/{id}/history [get]
 func (h *Handler) GetTaskHistory(c echo.Context) error {
 	taskID, _ := strconv.Atoi(c.Param("id"))
-	res, err := h.service.Task().GetTaskHistory(c.Request().Context(), taskID)
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Task().GetTaskHistory(c.Request().Context(), taskID, userID, role, allTeams)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(taskAccessErrorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
 }
 
 // ListTasks godoc
 // @Summary List tasks with filters
-// @Description Retrieve a list of tasks with filtering options
+// @Description Retrieve a list of tasks with filtering options. Without task.read.any only tasks the user takes part in are returned
 // @Tags tasks
 // @Security ApiKeyAuth
 // @Produce json
@@ -212,7 +262,15 @@ func (h *Handler) GetTaskHistory(c echo.Context) error {
 // @Param search query string false "Search term"
 // @Param from_date query string false "From date (YYYY-MM-DD)"
 // @Param to_date query string false "To date (YYYY-MM-DD)"
+// @Param project_id query int false "Project ID filter"
+// @Param sprint_id query int false "Sprint ID filter"
+// @Param priority query string false "Comma-separated priorities (low, medium, high, critical)"
+// @Param tags query string false "Comma-separated label names, any of them"
+// @Param tags_all query string false "Comma-separated label names, all of them"
+// @Param sort query string false "Sort order: priority, deadline or created_at (default)"
+// @Param all_teams query bool false "Search across all teams (team.search.all)"
 // @Success 200 {array} dto.TaskResponse
+// @Failure 403 {object} map[string]string
 // @Router /tasks [get]
 func (h *Handler) ListTasks(c echo.Context) error {
 	var filter dto.TaskFilter
@@ -220,9 +278,11 @@ func (h *Handler) ListTasks(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid query params"})
 	}
 
-	res, err := h.service.Task().ListTasks(c.Request().Context(), filter)
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	res, err := h.service.Task().ListTasks(c.Request().Context(), filter, userID, role)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(taskAccessErrorStatus(err), map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, res)
 }
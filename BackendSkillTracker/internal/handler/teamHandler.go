@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func teamErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "team not found", "user not found":
+		return http.StatusNotFound
+	case "team has subteams":
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateTeam godoc
+// @Summary Create a team
+// @Description Teams form a hierarchy through parent_id; the manager manages the whole subtree
+// @Tags teams
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.TeamRequest true "Team request"
+// @Success 201 {object} dto.TeamResponse
+// @Failure 400 {object} map[string]string
+// @Router /teams [post]
+func (h *Handler) CreateTeam(c echo.Context) error {
+	var req dto.TeamRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	res, err := h.service.Team().CreateTeam(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(teamErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GetTeams godoc
+// @Summary List teams
+// @Tags teams
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.TeamResponse
+// @Router /teams [get]
+func (h *Handler) GetTeams(c echo.Context) error {
+	res, err := h.service.Team().GetTeams(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetTeamByID godoc
+// @Summary Get a team with its members
+// @Tags teams
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Team ID"
+// @Success 200 {object} dto.TeamResponse
+// @Failure 404 {object} map[string]string
+// @Router /teams/{id} [get]
+func (h *Handler) GetTeamByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	res, err := h.service.Team().GetTeamByID(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(teamErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateTeam godoc
+// @Summary Update a team
+// @Tags teams
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Team ID"
+// @Param req body dto.TeamRequest true "Team request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /teams/{id} [put]
+func (h *Handler) UpdateTeam(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.TeamRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.service.Team().UpdateTeam(c.Request().Context(), id, &req); err != nil {
+		return c.JSON(teamErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteTeam godoc
+// @Summary Delete a team
+// @Description Teams with subteams can't be deleted; members are left without a team
+// @Tags teams
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Team ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /teams/{id} [delete]
+func (h *Handler) DeleteTeam(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.service.Team().DeleteTeam(c.Request().Context(), id); err != nil {
+		return c.JSON(teamErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// SetUserTeam godoc
+// @Summary Move a user to a team
+// @Tags teams
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param req body dto.UserTeamRequest true "Team, null to remove"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/team [put]
+func (h *Handler) SetUserTeam(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.UserTeamRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Team().SetUserTeam(c.Request().Context(), id, &req, managerID, role, allTeams); err != nil {
+		return c.JSON(teamErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func templateErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "template not found", "skill not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateTemplate godoc
+// @Summary Create a task template
+// @Description Create a reusable template with default title, description, duration, required skills and checklist
+// @Tags templates
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.TaskTemplateRequest true "Template request"
+// @Success 201 {object} dto.TaskTemplateResponse
+// @Failure 400 {object} map[string]string
+// @Router /task-templates [post]
+func (h *Handler) CreateTemplate(c echo.Context) error {
+	var req dto.TaskTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Template().CreateTemplate(c.Request().Context(), &req, userID)
+	if err != nil {
+		return c.JSON(templateErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GetTemplates godoc
+// @Summary List task templates
+// @Description Own templates and templates shared by other managers
+// @Tags templates
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.TaskTemplateResponse
+// @Router /task-templates [get]
+func (h *Handler) GetTemplates(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Template().GetTemplates(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetTemplateByID godoc
+// @Summary Get task template by ID
+// @Tags templates
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} dto.TaskTemplateResponse
+// @Failure 404 {object} map[string]string
+// @Router /task-templates/{id} [get]
+func (h *Handler) GetTemplateByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Template().GetTemplateByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateTemplate godoc
+// @Summary Update task template
+// @Description Only the creator can change a template
+// @Tags templates
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param req body dto.TaskTemplateRequest true "Update request"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /task-templates/{id} [put]
+func (h *Handler) UpdateTemplate(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.TaskTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	if err := h.service.Template().UpdateTemplate(c.Request().Context(), id, &req, userID); err != nil {
+		return c.JSON(templateErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+}
+
+// DeleteTemplate godoc
+// @Summary Delete task template
+// @Description Only the creator can delete a template; tasks created from it are kept
+// @Tags templates
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /task-templates/{id} [delete]
+func (h *Handler) DeleteTemplate(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Template().DeleteTemplate(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(templateErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// CreateTaskFromTemplate godoc
+// @Summary Create a task from a template
+// @Description Create a task with the template defaults; title, description and deadline can be overridden
+// @Tags templates
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param req body dto.TaskFromTemplateRequest true "Overrides"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} dto.TaskResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /task-templates/{id}/tasks [post]
+func (h *Handler) CreateTaskFromTemplate(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.TaskFromTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Template().CreateTaskFromTemplate(c.Request().Context(), id, &req, userID, role, allTeams)
+	if err != nil {
+		return c.JSON(templateErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
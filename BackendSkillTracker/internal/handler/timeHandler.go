@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"skilltracker/internal/dto"
+)
+
+func timeErrorStatus(err error) int {
+	switch err.Error() {
+	case "forbidden":
+		return http.StatusForbidden
+	case "task not found", "time entry not found", "timesheet not found", "user not found", "no running timer":
+		return http.StatusNotFound
+	case "timer already running", "timesheet locked", "timesheet already submitted", "timesheet not submitted":
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// StartTimer godoc
+// @Summary Start a timer on a task
+// @Description Only one timer per user can run at a time
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 201 {object} dto.TimeEntryResponse
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /tasks/{id}/time/start [post]
+func (h *Handler) StartTimer(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Time().StartTimer(c.Request().Context(), taskID, userID)
+	if err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// StopTimer godoc
+// @Summary Stop my running timer
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} dto.TimeEntryResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /time-entries/stop [post]
+func (h *Handler) StopTimer(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Time().StopTimer(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// LogTime godoc
+// @Summary Log time on a task manually
+// @Tags time
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param req body dto.TimeEntryRequest true "Time entry"
+// @Success 201 {object} dto.TimeEntryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /tasks/{id}/time [post]
+func (h *Handler) LogTime(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	var req dto.TimeEntryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Time().LogTime(c.Request().Context(), taskID, userID, &req)
+	if err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GetTaskTimeEntries godoc
+// @Summary List time entries of a task
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {array} dto.TimeEntryResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/time [get]
+func (h *Handler) GetTaskTimeEntries(c echo.Context) error {
+	taskID, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Time().GetTaskTimeEntries(c.Request().Context(), taskID, userID, role, allTeams)
+	if err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// DeleteTimeEntry godoc
+// @Summary Delete my time entry
+// @Description Entries of a submitted or approved week cannot be deleted
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "Time entry ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /time-entries/{id} [delete]
+func (h *Handler) DeleteTimeEntry(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.Get("user_id").(int)
+	if err := h.service.Time().DeleteTimeEntry(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// GetMyTimesheet godoc
+// @Summary Get my weekly timesheet
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Param week query string false "Any date of the week (YYYY-MM-DD), defaults to the current week"
+// @Success 200 {object} dto.TimesheetResponse
+// @Router /timesheets/my [get]
+func (h *Handler) GetMyTimesheet(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	res, err := h.service.Time().GetTimesheet(c.Request().Context(), userID, c.QueryParam("week"), userID, "", false)
+	if err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetUserTimesheet godoc
+// @Summary Get a user's weekly timesheet
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Param week query string false "Any date of the week (YYYY-MM-DD), defaults to the current week"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} dto.TimesheetResponse
+// @Failure 403 {object} map[string]string
+// @Router /users/{id}/timesheets [get]
+func (h *Handler) GetUserTimesheet(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	res, err := h.service.Time().GetTimesheet(c.Request().Context(), id, c.QueryParam("week"), managerID, role, allTeams)
+	if err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// SubmitTimesheet godoc
+// @Summary Submit my weekly timesheet for approval
+// @Description Entries of the week are locked until the timesheet is rejected
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Param week query string false "Any date of the week (YYYY-MM-DD), defaults to the current week"
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /timesheets/submit [post]
+func (h *Handler) SubmitTimesheet(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	if err := h.service.Time().SubmitTimesheet(c.Request().Context(), userID, c.QueryParam("week")); err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "submitted"})
+}
+
+// ApproveTimesheet godoc
+// @Summary Approve a submitted timesheet
+// @Tags time
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Timesheet ID"
+// @Param req body dto.TimesheetReviewRequest false "Review comment"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /timesheets/{id}/approve [post]
+func (h *Handler) ApproveTimesheet(c echo.Context) error {
+	return h.reviewTimesheet(c, true)
+}
+
+// RejectTimesheet godoc
+// @Summary Reject a submitted timesheet
+// @Description The employee can correct entries and submit again
+// @Tags time
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Timesheet ID"
+// @Param req body dto.TimesheetReviewRequest false "Review comment"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /timesheets/{id}/reject [post]
+func (h *Handler) RejectTimesheet(c echo.Context) error {
+	return h.reviewTimesheet(c, false)
+}
+
+func (h *Handler) reviewTimesheet(c echo.Context, approve bool) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var req dto.TimesheetReviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.Time().ReviewTimesheet(c.Request().Context(), id, userID, role, allTeams, approve, req.Comment); err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	if approve {
+		return c.JSON(http.StatusOK, map[string]string{"message": "approved"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "rejected"})
+}
+
+// GetTimeReport godoc
+// @Summary Estimate vs actual time report
+// @Description Per task and per required skill, for time logged in the period
+// @Tags time
+// @Security ApiKeyAuth
+// @Produce json
+// @Param from query string false "From date (YYYY-MM-DD)"
+// @Param to query string false "To date, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} dto.TimeReportResponse
+// @Failure 400 {object} map[string]string
+// @Router /reports/time [get]
+func (h *Handler) GetTimeReport(c echo.Context) error {
+	res, err := h.service.Time().GetTimeReport(c.Request().Context(), c.QueryParam("from"), c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(timeErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"skilltracker/internal/dto"
+
+	"github.com/labstack/echo/v4"
+)
+
+func twoFactorErrorStatus(err error) int {
+	switch err.Error() {
+	case "invalid code", "2fa enrollment not started":
+		return http.StatusBadRequest
+	case "invalid challenge token":
+		return http.StatusUnauthorized
+	case "2fa is required for your role", "forbidden":
+		return http.StatusForbidden
+	case "user not found":
+		return http.StatusNotFound
+	case "2fa already enabled":
+		return http.StatusConflict
+	case "account locked":
+		return http.StatusLocked
+	case "too many login attempts":
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// VerifyLoginTwoFactor godoc
+// @Summary Complete login with a 2FA code
+// @Description Second login step: the challenge token from /login and a TOTP or recovery code. For a challenge of type "enroll" the code confirms the new authenticator and the response includes recovery codes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param req body dto.TwoFactorLoginRequest true "Challenge and code"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /login/2fa [post]
+func (h *Handler) VerifyLoginTwoFactor(c echo.Context) error {
+	var req dto.TwoFactorLoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	res, err := h.service.User().VerifyLoginTwoFactor(c.Request().Context(), &req, clientInfo(c))
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// EnrollLoginTwoFactor godoc
+// @Summary Set up 2FA during login
+// @Description For a challenge of type "enroll": returns a new TOTP secret to confirm at /login/2fa
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param req body dto.TwoFactorChallengeRequest true "Challenge"
+// @Success 200 {object} dto.TwoFactorEnrollResponse
+// @Failure 401 {object} map[string]string
+// @Router /login/2fa/enroll [post]
+func (h *Handler) EnrollLoginTwoFactor(c echo.Context) error {
+	var req dto.TwoFactorChallengeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	res, err := h.service.User().EnrollLoginTwoFactor(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary My 2FA status
+// @Tags 2fa
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} dto.TwoFactorStatusResponse
+// @Router /2fa [get]
+func (h *Handler) GetTwoFactorStatus(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	res, err := h.service.TwoFactor().GetTwoFactorStatus(c.Request().Context(), userID, role)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// EnrollTwoFactor godoc
+// @Summary Start 2FA setup
+// @Description Returns a new TOTP secret and its otpauth URI; 2FA is on once confirmed with a code
+// @Tags 2fa
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} dto.TwoFactorEnrollResponse
+// @Failure 409 {object} map[string]string
+// @Router /2fa/enroll [post]
+func (h *Handler) EnrollTwoFactor(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	res, err := h.service.TwoFactor().EnrollTwoFactor(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm 2FA setup
+// @Description Enables 2FA with a first code from the authenticator and returns recovery codes, shown only once
+// @Tags 2fa
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Router /2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactor(c echo.Context) error {
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.TwoFactor().ConfirmTwoFactor(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable 2FA
+// @Description Needs a current TOTP or recovery code; not allowed when the role requires 2FA
+// @Tags 2fa
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /2fa [delete]
+func (h *Handler) DisableTwoFactor(c echo.Context) error {
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	if err := h.service.TwoFactor().DisableTwoFactor(c.Request().Context(), userID, role, req.Code); err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "2fa disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary New recovery codes
+// @Description Replaces all recovery codes; needs a current TOTP or recovery code
+// @Tags 2fa
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param req body dto.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Router /2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c echo.Context) error {
+	var req dto.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid input"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	userID := c.Get("user_id").(int)
+	res, err := h.service.TwoFactor().RegenerateRecoveryCodes(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ResetTwoFactor godoc
+// @Summary Reset 2FA of a user
+// @Description Removes the authenticator and recovery codes of a user who lost them (user.manage)
+// @Tags 2fa
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/2fa [delete]
+func (h *Handler) ResetTwoFactor(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.TwoFactor().ResetTwoFactor(c.Request().Context(), id, managerID, role, allTeams); err != nil {
+		return c.JSON(twoFactorErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "2fa reset"})
+}
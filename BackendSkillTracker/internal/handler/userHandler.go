@@ -9,7 +9,7 @@ import (
 
 // RefreshToken godoc
 // @Summary Refresh access token
-// @Description Get new access and refresh tokens using a valid refresh token
+// @Description Get new access and refresh tokens using a valid refresh token. The presented token is used up; reusing it revokes the session
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -25,7 +25,7 @@ func (h *Handler) RefreshToken(c echo.Context) error {
 	if err := h.validate(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	res, err := h.service.User().RefreshToken(c.Request().Context(), &req)
+	res, err := h.service.User().RefreshToken(c.Request().Context(), &req, clientInfo(c))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
@@ -34,7 +34,7 @@ func (h *Handler) RefreshToken(c echo.Context) error {
 
 // Logout godoc
 // @Summary Logout user
-// @Description Invalidate the refresh token
+// @Description End the current session and invalidate its refresh token
 // @Tags auth
 // @Security ApiKeyAuth
 // @Produce json
@@ -43,7 +43,25 @@ func (h *Handler) RefreshToken(c echo.Context) error {
 // @Router /logout [post]
 func (h *Handler) Logout(c echo.Context) error {
 	userID := c.Get("user_id").(int)
-	if err := h.service.User().Logout(c.Request().Context(), userID); err != nil {
+	sessionID, _ := c.Get("session_id").(int)
+	if err := h.service.User().Logout(c.Request().Context(), userID, sessionID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "logged out"})
+}
+
+// LogoutAll godoc
+// @Summary Logout everywhere
+// @Description End all sessions of the current user and revoke their access tokens
+// @Tags auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /logout/all [post]
+func (h *Handler) LogoutAll(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	if err := h.service.User().LogoutAll(c.Request().Context(), userID); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "logged out"})
@@ -58,6 +76,9 @@ func (h *Handler) Logout(c echo.Context) error {
 // @Param req body dto.LoginRequest true "Login request"
 // @Success 200 {object} dto.LoginResponse
 // @Failure 401 {object} map[string]string
+// @Failure 423 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /login [post]
 func (h *Handler) Login(c echo.Context) error {
 	var req dto.LoginRequest
@@ -67,8 +88,16 @@ func (h *Handler) Login(c echo.Context) error {
 	if err := h.validate(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	res, err := h.service.User().Login(c.Request().Context(), &req)
+	res, err := h.service.User().Login(c.Request().Context(), &req, clientInfo(c))
 	if err != nil {
+		switch err.Error() {
+		case "account locked":
+			return c.JSON(http.StatusLocked, map[string]string{"error": err.Error()})
+		case "too many login attempts":
+			return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+		case "authentication unavailable":
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
 	}
 	return c.JSON(http.StatusOK, res)
@@ -85,6 +114,7 @@ func (h *Handler) Login(c echo.Context) error {
 // @Success 200 {object} dto.UserResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /users [post]
 func (h *Handler) CreateUser(c echo.Context) error {
 	var req dto.UserRequest
@@ -94,8 +124,12 @@ func (h *Handler) CreateUser(c echo.Context) error {
 	if err := h.validate(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	u, err := h.service.User().CreateUser(c.Request().Context(), &req)
+	role, _ := c.Get("role").(string)
+	u, err := h.service.User().CreateUser(c.Request().Context(), &req, role)
 	if err != nil {
+		if err.Error() == "forbidden" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, u)
@@ -103,16 +137,24 @@ func (h *Handler) CreateUser(c echo.Context) error {
 
 // GetUsers godoc
 // @Summary Get all users
-// @Description Retrieve a list of all users (Manager only)
+// @Description Retrieve a list of users (user.read). Team managers see their teams unless all_teams=true
 // @Tags users
 // @Security ApiKeyAuth
 // @Produce json
+// @Param all_teams query bool false "Search across all teams (team.search.all)"
 // @Success 200 {array} dto.UserResponse
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /users [get]
 func (h *Handler) GetUsers(c echo.Context) error {
-    users, err := h.service.User().GetUsers(c.Request().Context())
-    if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+    userID := c.Get("user_id").(int)
+    role, _ := c.Get("role").(string)
+    allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+    users, err := h.service.User().GetUsers(c.Request().Context(), userID, role, allTeams)
+    if err != nil {
+        if err.Error() == "forbidden" { return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"}) }
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
     return c.JSON(http.StatusOK, users)
 }
 
@@ -123,13 +165,21 @@ func (h *Handler) GetUsers(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "User ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} dto.UserResponse
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /users/{id} [get]
 func (h *Handler) GetUserByID(c echo.Context) error {
     id, _ := strconv.Atoi(c.Param("id"))
-    u, err := h.service.User().GetUserByID(c.Request().Context(), id)
-    if err != nil { return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"}) }
+    viewerID := c.Get("user_id").(int)
+    role, _ := c.Get("role").(string)
+    allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+    u, err := h.service.User().GetUserByID(c.Request().Context(), id, viewerID, role, allTeams)
+    if err != nil {
+        if err.Error() == "forbidden" { return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"}) }
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+    }
     return c.JSON(http.StatusOK, u)
 }
 
@@ -142,7 +192,9 @@ func (h *Handler) GetUserByID(c echo.Context) error {
 // @Produce json
 // @Param id path int true "User ID"
 // @Param req body dto.UserRequest true "Update request"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /users/{id} [put]
 func (h *Handler) UpdateUser(c echo.Context) error {
@@ -155,12 +207,24 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 	userReq := &dto.UserRequest{
-		Username: req.Username,
-		Password: req.Password,
-		Role:     req.Role,
-		Name:     req.Name,
+		Username:   req.Username,
+		Password:   req.Password,
+		Role:       req.Role,
+		Name:       req.Name,
+		Email:      req.Email,
+		Position:   req.Position,
+		Department: req.Department,
 	}
-	if err := h.service.User().UpdateUser(c.Request().Context(), id, userReq); err != nil {
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.User().UpdateUser(c.Request().Context(), id, userReq, managerID, role, allTeams); err != nil {
+		if err.Error() == "forbidden" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		}
+		if err.Error() == "role not found" || err.Error() == "password is managed by the directory" || passwordPolicyErrors[err.Error()] {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
@@ -173,13 +237,49 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "User ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
 // @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /users/{id} [delete]
 func (h *Handler) DeleteUser(c echo.Context) error {
     id, _ := strconv.Atoi(c.Param("id"))
-    if err := h.service.User().DeleteUser(c.Request().Context(), id); err != nil {
+    managerID := c.Get("user_id").(int)
+    role, _ := c.Get("role").(string)
+    allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+    if err := h.service.User().DeleteUser(c.Request().Context(), id, managerID, role, allTeams); err != nil {
+        if err.Error() == "forbidden" { return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"}) }
         return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
     }
     return c.JSON(http.StatusOK, map[string]string{"message": "deleted"})
 }
+
+// UnlockUser godoc
+// @Summary Unlock user
+// @Description Lift a login lockout of the user and clear their failed attempts (user.manage)
+// @Tags users
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Param all_teams query bool false "Act across all teams (team.search.all)"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/unlock [post]
+func (h *Handler) UnlockUser(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	managerID := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+	allTeams, _ := strconv.ParseBool(c.QueryParam("all_teams"))
+	if err := h.service.User().UnlockUser(c.Request().Context(), id, managerID, role, allTeams); err != nil {
+		return c.JSON(sessionErrorStatus(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "unlocked"})
+}
+
+// JWKS serves the public keys access tokens are signed with at
+// /.well-known/jwks.json, outside the API base path, so other services can
+// verify tokens without sharing a secret.
+func (h *Handler) JWKS(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.User().JWKS())
+}
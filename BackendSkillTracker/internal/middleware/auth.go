@@ -1,13 +1,30 @@
 package middleware
 
 import (
+    "context"
     "net/http"
     "strings"
     "github.com/labstack/echo/v4"
+    "skilltracker/internal/audit"
+    "skilltracker/internal/dto"
+    "skilltracker/internal/permission"
+    "skilltracker/internal/tenant"
     "skilltracker/internal/utils/jwt"
 )
 
-func AuthRequired(jwtSecret []byte) echo.MiddlewareFunc {
+// TokenVersionChecker tells whether access tokens of a user carrying a token
+// version are still valid.
+type TokenVersionChecker interface {
+    TokenVersionValid(ctx context.Context, userID int, version int) bool
+}
+
+// APITokenAuthenticator resolves personal access tokens.
+type APITokenAuthenticator interface {
+    AuthenticateAPIToken(ctx context.Context, token string, ip string) (*dto.APITokenIdentity, error)
+}
+
+// AuthRequired accepts JWT access tokens and personal access tokens.
+func AuthRequired(keys *jwt.KeySet, tv TokenVersionChecker, at APITokenAuthenticator) echo.MiddlewareFunc {
     return func(next echo.HandlerFunc) echo.HandlerFunc {
         return func(c echo.Context) error {
             authHeader := c.Request().Header.Get("Authorization")
@@ -15,11 +32,35 @@ func AuthRequired(jwtSecret []byte) echo.MiddlewareFunc {
                 return c.JSON(http.StatusUnauthorized, map[string]string{"error": "missing bearer"})
             }
             tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-            claims, err := jwt.ValidateToken(tokenStr, jwtSecret)
-            if err != nil {
+            if strings.HasPrefix(tokenStr, dto.APITokenPrefix) {
+                return apiTokenAuth(c, next, at, tokenStr)
+            }
+            claims, err := keys.ValidateToken(tokenStr)
+            if err != nil || claims.OrgID == 0 {
                 return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
             }
+            // The organization travels in the request context; the storage
+            // layer scopes every query to it.
+            ctx := tenant.WithOrg(c.Request().Context(), claims.OrgID)
+            // Logging out everywhere, a password or role change and deletion
+            // bump the user's version and so revoke earlier tokens.
+            if !tv.TokenVersionValid(ctx, claims.UserID, claims.Version) {
+                return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token revoked"})
+            }
+            actor := audit.Actor{UserID: claims.UserID, Username: claims.Username}
+            // An impersonation token also ends with the actor's tokens.
+            if claims.Act != nil {
+                if !tv.TokenVersionValid(ctx, claims.Act.UserID, claims.Act.Version) {
+                    return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token revoked"})
+                }
+                c.Set("actor_id", claims.Act.UserID)
+                c.Set("actor_username", claims.Act.Username)
+                actor.ImpersonatorID = claims.Act.UserID
+            }
+            c.SetRequest(c.Request().WithContext(audit.WithActor(ctx, actor)))
+            c.Set("org_id", claims.OrgID)
             c.Set("user_id", claims.UserID)
+            c.Set("session_id", claims.SessionID)
             c.Set("username", claims.Username)
             c.Set("role", claims.Role)
             return next(c)
@@ -27,16 +68,117 @@ func AuthRequired(jwtSecret []byte) echo.MiddlewareFunc {
     }
 }
 
-func RoleRequired(roles ...string) echo.MiddlewareFunc {
-    allowed := map[string]struct{}{}
-    for _, r := range roles { allowed[r] = struct{}{} }
+// scopeAllows tells whether the scopes of a personal access token cover
+// the request method: "read" allows safe methods, "write" the others.
+func scopeAllows(scopes []string, method string) bool {
+    need := "write"
+    switch method {
+    case http.MethodGet, http.MethodHead, http.MethodOptions:
+        need = "read"
+    }
+    for _, sc := range scopes {
+        if sc == need {
+            return true
+        }
+    }
+    return false
+}
+
+func apiTokenAuth(c echo.Context, next echo.HandlerFunc, at APITokenAuthenticator, tokenStr string) error {
+    id, err := at.AuthenticateAPIToken(c.Request().Context(), tokenStr, c.RealIP())
+    if err != nil {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+    }
+    if !scopeAllows(id.Scopes, c.Request().Method) {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "insufficient scope"})
+    }
+    ctx := tenant.WithOrg(c.Request().Context(), id.OrgID)
+    ctx = audit.WithActor(ctx, audit.Actor{UserID: id.UserID, Username: id.Username})
+    c.SetRequest(c.Request().WithContext(ctx))
+    c.Set("org_id", id.OrgID)
+    c.Set("user_id", id.UserID)
+    c.Set("username", id.Username)
+    c.Set("role", id.Role)
+    c.Set("api_token_id", id.TokenID)
+    return next(c)
+}
+
+// InteractiveOnly refuses personal access tokens and impersonation, for
+// routes that manage the credentials and sessions of the user.
+func InteractiveOnly() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            if _, ok := c.Get("api_token_id").(int); ok {
+                return c.JSON(http.StatusForbidden, map[string]string{"error": "not allowed with an api token"})
+            }
+            if _, ok := c.Get("actor_id").(int); ok {
+                return c.JSON(http.StatusForbidden, map[string]string{"error": "not allowed while impersonating"})
+            }
+            return next(c)
+        }
+    }
+}
+
+// ImpersonationRecorder logs requests made while impersonating.
+type ImpersonationRecorder interface {
+    RecordImpersonatedRequest(ctx context.Context, actorID int, userID int, method string, path string, status int)
+}
+
+// ImpersonationAudit records every request made with an impersonation
+// token, refused ones included. It runs after AuthRequired.
+func ImpersonationAudit(rec ImpersonationRecorder) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            actorID, ok := c.Get("actor_id").(int)
+            if !ok {
+                return next(c)
+            }
+            err := next(c)
+            status := c.Response().Status
+            if he, ok := err.(*echo.HTTPError); ok {
+                status = he.Code
+            }
+            userID, _ := c.Get("user_id").(int)
+            rec.RecordImpersonatedRequest(c.Request().Context(), actorID, userID, c.Request().Method, c.Request().URL.Path, status)
+            return err
+        }
+    }
+}
+
+// PermissionChecker resolves a role to its permissions.
+type PermissionChecker interface {
+    Can(ctx context.Context, role string, p permission.Permission) bool
+}
+
+// PermissionRequired allows the request only if the caller's role grants all
+// of the given permissions.
+func PermissionRequired(pc PermissionChecker, perms ...permission.Permission) echo.MiddlewareFunc {
     return func(next echo.HandlerFunc) echo.HandlerFunc {
         return func(c echo.Context) error {
             role, _ := c.Get("role").(string)
-            if _, ok := allowed[role]; !ok {
-                return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+            for _, p := range perms {
+                if !pc.Can(c.Request().Context(), role, p) {
+                    return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+                }
             }
             return next(c)
         }
     }
 }
+
+// RequestInfo puts the request ID, client IP and user agent into the
+// request context for the audit log. It runs after echo's RequestID.
+func RequestInfo() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            req := c.Request()
+            ctx := audit.WithRequest(req.Context(), audit.Request{
+                ID:        c.Response().Header().Get(echo.HeaderXRequestID),
+                IP:        c.RealIP(),
+                UserAgent: req.UserAgent(),
+            })
+            c.SetRequest(req.WithContext(ctx))
+            return next(c)
+        }
+    }
+}
@@ -7,7 +7,19 @@ import (
 )
 
 type Role string
+
+// AuthLocal is the AuthSource of users with a password hash.
+const AuthLocal = "local"
+
 type TaskStatus string
+type RecurrenceFrequency string
+type RecurrenceTrigger string
+type HistoryEvent string
+type TimesheetStatus string
+type TaskPriority string
+type ProjectStatus string
+type SprintStatus string
+type NotificationType string
 
 const (
 	RoleManager  Role = "manager"
@@ -16,15 +28,82 @@ const (
 	StatusPending    TaskStatus = "pending"
 	StatusInProgress TaskStatus = "in_progress"
 	StatusCompleted  TaskStatus = "completed"
+
+	FrequencyDaily   RecurrenceFrequency = "daily"
+	FrequencyWeekly  RecurrenceFrequency = "weekly"
+	FrequencyMonthly RecurrenceFrequency = "monthly"
+
+	// TriggerSchedule materializes occurrences when their start time comes,
+	// TriggerCompletion materializes the next one when the previous is completed.
+	TriggerSchedule   RecurrenceTrigger = "schedule"
+	TriggerCompletion RecurrenceTrigger = "completion"
+
+	HistoryStatus    HistoryEvent = "status"
+	HistoryChecklist HistoryEvent = "checklist"
+	HistorySprint    HistoryEvent = "sprint"
+	HistoryReassign  HistoryEvent = "reassign"
+
+	TimesheetOpen      TimesheetStatus = "open"
+	TimesheetSubmitted TimesheetStatus = "submitted"
+	TimesheetApproved  TimesheetStatus = "approved"
+	TimesheetRejected  TimesheetStatus = "rejected"
+
+	PriorityLow      TaskPriority = "low"
+	PriorityMedium   TaskPriority = "medium"
+	PriorityHigh     TaskPriority = "high"
+	PriorityCritical TaskPriority = "critical"
+
+	ProjectActive    ProjectStatus = "active"
+	ProjectOnHold    ProjectStatus = "on_hold"
+	ProjectCompleted ProjectStatus = "completed"
+	ProjectArchived  ProjectStatus = "archived"
+
+	SprintPlanned SprintStatus = "planned"
+	SprintActive  SprintStatus = "active"
+	SprintClosed  SprintStatus = "closed"
+
+	NotificationAssigned      NotificationType = "assigned"
+	NotificationStatusChanged NotificationType = "status_changed"
+	NotificationReassigned    NotificationType = "reassigned"
 )
 
+// Organization is a tenant. Every model with an OrgID belongs to exactly one
+// organization and is only visible within it; rows created before
+// organizations existed belong to the first one.
+type Organization struct {
+	ID        int       `gorm:"primaryKey"`
+	Slug      string    `gorm:"unique;not null;size:50"`
+	Name      string    `gorm:"not null;size:200"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
 type User struct {
-	ID           int            `gorm:"primaryKey"`
-	Username     string         `gorm:"unique;not null;size:50"`
-	PasswordHash string         `gorm:"not null"`
-	Role         Role           `gorm:"not null;type:varchar(20)"`
-	Name         string         `gorm:"not null;size:100"`
-	RefreshToken string         `gorm:"index"`
+	ID           int    `gorm:"primaryKey"`
+	OrgID        int    `gorm:"not null;default:1;uniqueIndex:idx_users_org_username"`
+	Username     string `gorm:"not null;size:50;uniqueIndex:idx_users_org_username"`
+	PasswordHash string `gorm:"not null"`
+	Role         Role   `gorm:"not null;type:varchar(20)"`
+	Name         string `gorm:"not null;size:100"`
+	TeamID       *int   `gorm:"index"`
+	Email        string `gorm:"size:255;index"`
+	// Position and Department are set by managers; the user edits the
+	// rest of the profile through /me.
+	Position   string `gorm:"size:100"`
+	Department string `gorm:"size:100"`
+	// Avatar is the file name of the uploaded picture in uploads/avatars.
+	Avatar   string        `gorm:"size:255"`
+	Timezone string        `gorm:"size:64"`
+	Locale   string        `gorm:"size:35"`
+	Contacts []UserContact `gorm:"serializer:json;type:jsonb"`
+	// AuthSource is the provider that checks the password: AuthLocal for a
+	// bcrypt hash, otherwise a directory the user was provisioned from.
+	AuthSource string `gorm:"not null;size:20;default:local"`
+	// MustChangePassword is set for passwords someone else chose, e.g. the
+	// manager who created the account; the next login has to replace it.
+	MustChangePassword bool `gorm:"not null;default:false"`
+	// TokenVersion is embedded in access tokens; bumping it revokes every
+	// access token issued before.
+	TokenVersion int            `gorm:"not null;default:0"`
 	CreatedAt    time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt    time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt    gorm.DeletedAt `gorm:"index"`
@@ -32,8 +111,170 @@ type User struct {
 	Skills []Skill `gorm:"many2many:user_skills;"`
 }
 
+// UserContact is a way to reach a user besides email, e.g. a phone number
+// or a messenger handle.
+type UserContact struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// LocalAuth reports whether the password is checked against PasswordHash.
+func (u *User) LocalAuth() bool { return u.AuthSource == "" || u.AuthSource == AuthLocal }
+
+// Session is one login of a user, e.g. on one device. Its refresh tokens
+// form a family: every refresh uses up the presented token and issues the
+// next one, and presenting a used token again revokes the whole session.
+type Session struct {
+	ID         int       `gorm:"primaryKey"`
+	OrgID      int       `gorm:"not null;default:1;index"`
+	UserID     int       `gorm:"not null;index"`
+	UserAgent  string    `gorm:"size:500"`
+	IP         string    `gorm:"size:64"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	LastUsedAt time.Time `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+
+	Tokens []RefreshToken `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+}
+
+// RefreshToken is one token of a session's family. Only the SHA-256 hash
+// of the token is stored.
+type RefreshToken struct {
+	ID        int    `gorm:"primaryKey"`
+	OrgID     int    `gorm:"not null;default:1;index"`
+	SessionID int    `gorm:"not null;index"`
+	TokenHash string `gorm:"unique;not null;size:64"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	Session Session `gorm:"foreignKey:SessionID"`
+}
+
+// TOTPCredential is the authenticator app of a user. It takes effect once
+// confirmed with a first code. LastStep is the time step of the last
+// accepted code, so a code can't be used twice.
+type TOTPCredential struct {
+	ID          int    `gorm:"primaryKey"`
+	OrgID       int    `gorm:"not null;default:1;index"`
+	UserID      int    `gorm:"not null;uniqueIndex"`
+	Secret      string `gorm:"not null;size:64"`
+	ConfirmedAt *time.Time
+	LastStep    int64     `gorm:"not null;default:0"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code when the
+// authenticator is lost. Only its hash is stored.
+type RecoveryCode struct {
+	ID        int    `gorm:"primaryKey"`
+	OrgID     int    `gorm:"not null;default:1;index"`
+	UserID    int    `gorm:"not null;index"`
+	CodeHash  string `gorm:"not null;size:64"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// APIToken is a personal access token for scripts and integrations. Only
+// the SHA-256 hash of the token is stored; Prefix identifies it in lists.
+// Scopes is a space-separated list.
+type APIToken struct {
+	ID         int       `gorm:"primaryKey"`
+	OrgID      int       `gorm:"not null;default:1;index"`
+	UserID     int       `gorm:"not null;index"`
+	Name       string    `gorm:"not null;size:100"`
+	Prefix     string    `gorm:"not null;size:16"`
+	TokenHash  string    `gorm:"unique;not null;size:64"`
+	Scopes     string    `gorm:"not null;size:100"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	LastUsedAt *time.Time
+	LastUsedIP string `gorm:"size:64"`
+	RevokedAt  *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// AuditLog is an append-only record of a security-relevant or
+// administrative action. Before and After are JSON snapshots of the
+// target; ActorName keeps the username of a failed login or a deleted
+// actor.
+type AuditLog struct {
+	ID             int    `gorm:"primaryKey"`
+	OrgID          int    `gorm:"not null;default:1;index"`
+	Action         string `gorm:"not null;size:64;index"`
+	ActorID        *int   `gorm:"index"`
+	ActorName      string `gorm:"size:100"`
+	ImpersonatorID *int
+	TargetType     string    `gorm:"size:32;index:idx_audit_logs_target"`
+	TargetID       *int      `gorm:"index:idx_audit_logs_target"`
+	IP             string    `gorm:"size:64"`
+	UserAgent      string    `gorm:"size:255"`
+	RequestID      string    `gorm:"size:64;index"`
+	Before         *string   `gorm:"type:jsonb"`
+	After          *string   `gorm:"type:jsonb"`
+	CreatedAt      time.Time `gorm:"autoCreateTime;index"`
+}
+
+// PasswordHistory is a previous password hash of a user, kept so that a
+// new password can't repeat a recent one.
+type PasswordHistory struct {
+	ID           int       `gorm:"primaryKey"`
+	OrgID        int       `gorm:"not null;default:1;index"`
+	UserID       int       `gorm:"not null;index"`
+	PasswordHash string    `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// PasswordResetToken is a mailed link to set a new password. Only the
+// SHA-256 hash of the token is stored, and it works once.
+type PasswordResetToken struct {
+	ID        int       `gorm:"primaryKey"`
+	OrgID     int       `gorm:"not null;default:1;index"`
+	UserID    int       `gorm:"not null;index"`
+	TokenHash string    `gorm:"unique;not null;size:64"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// OIDCState is an OpenID Connect login between the redirect to the
+// provider and the callback, keeping the PKCE verifier on the server. It
+// isn't tenant scoped: the callback only has the state.
+type OIDCState struct {
+	StateHash string    `gorm:"primaryKey;size:64"`
+	Provider  string    `gorm:"not null;size:50"`
+	Verifier  string    `gorm:"not null;size:128"`
+	Nonce     string    `gorm:"not null;size:64"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName keeps GORM from naming the table "o_id_c_states".
+func (OIDCState) TableName() string { return "oidc_states" }
+
+// ExternalIdentity links a user to their subject at an OpenID Connect
+// provider.
+type ExternalIdentity struct {
+	ID        int       `gorm:"primaryKey"`
+	OrgID     int       `gorm:"not null;default:1;uniqueIndex:idx_external_identity"`
+	UserID    int       `gorm:"not null;index"`
+	Provider  string    `gorm:"not null;size:50;uniqueIndex:idx_external_identity"`
+	Subject   string    `gorm:"not null;size:255;uniqueIndex:idx_external_identity"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// LoginThrottle counts recent failed logins for a username or a client IP.
+// Rows aren't tenant scoped: they are read before anyone is authenticated,
+// and the key of a username includes its organization.
+type LoginThrottle struct {
+	Key           string    `gorm:"primaryKey;size:200"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+}
+
 type Task struct {
 	ID          int            `gorm:"primaryKey"`
+	OrgID       int            `gorm:"not null;default:1;index"`
 	EmployeeID  int            `gorm:"not null;index"`
 	CreatorID   int            `gorm:"not null;index"`
 	Title       string         `gorm:"not null;size:200"`
@@ -45,26 +286,56 @@ type Task struct {
 	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt `gorm:"index"`
 
+	RecurringTaskID *int `gorm:"index"`
+	Occurrence      int  `gorm:"not null;default:0"`
+	TemplateID      *int `gorm:"index"`
+	EstimateMinutes int  `gorm:"not null;default:0"`
+	ProjectID       *int `gorm:"index"`
+	SprintID        *int `gorm:"index"`
+
+	// SLA tracking. Due times come from the SLA policy of the priority;
+	// breach timestamps are the due times that were missed.
+	Priority             TaskPriority `gorm:"not null;type:varchar(20);default:medium;index"`
+	ResponseDueAt        *time.Time
+	ResolutionDueAt      *time.Time
+	RespondedAt          *time.Time
+	CompletedAt          *time.Time
+	ResponseBreachedAt   *time.Time
+	ResolutionBreachedAt *time.Time
+
 	Employee       User                `gorm:"foreignKey:EmployeeID"`
 	Creator        User                `gorm:"foreignKey:CreatorID"`
 	Attachments    []FileAttachment    `gorm:"foreignKey:TaskID"`
 	History        []TaskStatusHistory `gorm:"foreignKey:TaskID"`
 	RequiredSkills []Skill             `gorm:"many2many:task_skills;"`
+	Checklist      []ChecklistItem     `gorm:"foreignKey:TaskID"`
+	Labels         []Label             `gorm:"many2many:task_labels;"`
+	// Assignees work on the task together with the lead (EmployeeID);
+	// watchers are notified about changes without being assigned.
+	Assignees []User `gorm:"many2many:task_assignees;"`
+	Watchers  []User `gorm:"many2many:task_watchers;"`
 }
 
 type TaskStatusHistory struct {
-	ID        int        `gorm:"primaryKey"`
-	TaskID    int        `gorm:"not null"`
-	OldStatus TaskStatus `gorm:"not null;type:varchar(20)"`
-	NewStatus TaskStatus `gorm:"not null;type:varchar(20)"`
-	ChangedBy int        `gorm:"not null"`
-	CreatedAt time.Time  `gorm:"autoCreateTime"`
+	ID        int          `gorm:"primaryKey"`
+	OrgID     int          `gorm:"not null;default:1;index"`
+	TaskID    int          `gorm:"not null"`
+	Event     HistoryEvent `gorm:"not null;type:varchar(20);default:status"`
+	OldStatus TaskStatus   `gorm:"not null;type:varchar(20)"`
+	NewStatus TaskStatus   `gorm:"not null;type:varchar(20)"`
+	Note      string       `gorm:"size:1000"`
+	// FromUserID and ToUserID are set for reassign events.
+	FromUserID *int
+	ToUserID   *int
+	ChangedBy  int       `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
 
 	User User `gorm:"foreignKey:ChangedBy"`
 }
 
 type FileAttachment struct {
 	ID         int       `gorm:"primaryKey"`
+	OrgID      int       `gorm:"not null;default:1;index"`
 	TaskID     int       `gorm:"not null"`
 	FileName   string    `gorm:"not null"`
 	FilePath   string    `gorm:"not null"`
@@ -74,6 +345,7 @@ type FileAttachment struct {
 
 type Comment struct {
 	ID        int            `gorm:"primaryKey"`
+	OrgID     int            `gorm:"not null;default:1;index"`
 	TaskID    int            `gorm:"not null;index"`
 	UserID    int            `gorm:"not null;index"`
 	Text      string         `gorm:"not null"`
@@ -86,9 +358,12 @@ type Comment struct {
 
 type Skill struct {
 	ID          int       `gorm:"primaryKey"`
-	Name        string    `gorm:"unique;not null;size:100"`
+	OrgID       int       `gorm:"not null;default:1;uniqueIndex:idx_skills_org_name"`
+	Name        string    `gorm:"not null;size:100;uniqueIndex:idx_skills_org_name"`
 	Description string    `gorm:"size:500"`
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	// Level is read from task_skills when skills are loaded for a task.
+	Level int `gorm:"->;-:migration"`
 
 	Users []User `gorm:"many2many:user_skills;"`
 	Tasks []Task `gorm:"many2many:task_skills;"`
@@ -102,4 +377,283 @@ type UserSkill struct {
 type TaskSkill struct {
 	TaskID  int `gorm:"primaryKey"`
 	SkillID int `gorm:"primaryKey"`
+	Level   int `gorm:"not null;default:0"`
+}
+
+type TaskAssignee struct {
+	TaskID int `gorm:"primaryKey"`
+	UserID int `gorm:"primaryKey"`
+}
+
+type TaskWatcher struct {
+	TaskID int `gorm:"primaryKey"`
+	UserID int `gorm:"primaryKey"`
+}
+
+// Team is a node of the department hierarchy. The manager of a team manages
+// its whole subtree.
+type Team struct {
+	ID        int       `gorm:"primaryKey"`
+	OrgID     int       `gorm:"not null;default:1;uniqueIndex:idx_teams_org_name"`
+	Name      string    `gorm:"not null;size:100;uniqueIndex:idx_teams_org_name"`
+	ParentID  *int      `gorm:"index"`
+	ManagerID *int      `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// RoleDefinition is a custom role: a named bundle of permissions. The
+// built-in manager and employee roles are defined in the permission package.
+type RoleDefinition struct {
+	ID          int              `gorm:"primaryKey"`
+	OrgID       int              `gorm:"not null;default:1;uniqueIndex:idx_role_definitions_org_name"`
+	Name        string           `gorm:"not null;size:20;uniqueIndex:idx_role_definitions_org_name"`
+	Description string           `gorm:"size:255"`
+	Permissions []RolePermission `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time        `gorm:"autoUpdateTime"`
+}
+
+type RolePermission struct {
+	RoleID     int    `gorm:"primaryKey"`
+	Permission string `gorm:"primaryKey;size:50"`
+}
+
+// Notification is an in-app message about a change the user takes part in.
+type Notification struct {
+	ID        int              `gorm:"primaryKey"`
+	OrgID     int              `gorm:"not null;default:1;index"`
+	UserID    int              `gorm:"not null;index"`
+	TaskID    *int             `gorm:"index"`
+	Type      NotificationType `gorm:"not null;type:varchar(30)"`
+	Message   string           `gorm:"not null;size:500"`
+	ReadAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+// NotificationPreference switches one notification type on or off for a
+// user. Types without a preference are on.
+type NotificationPreference struct {
+	ID      int              `gorm:"primaryKey"`
+	OrgID   int              `gorm:"not null;default:1;index"`
+	UserID  int              `gorm:"not null;uniqueIndex:idx_notification_prefs_user_type"`
+	Type    NotificationType `gorm:"not null;type:varchar(30);uniqueIndex:idx_notification_prefs_user_type"`
+	Enabled bool             `gorm:"not null"`
+}
+
+// Project groups tasks. Employees only see projects they are members of.
+type Project struct {
+	ID          int            `gorm:"primaryKey"`
+	OrgID       int            `gorm:"not null;default:1;index"`
+	Name        string         `gorm:"not null;size:200"`
+	Description string         `gorm:"not null;default:''"`
+	OwnerID     int            `gorm:"not null;index"`
+	StartDate   *time.Time     `gorm:"type:date"`
+	EndDate     *time.Time     `gorm:"type:date"`
+	Status      ProjectStatus  `gorm:"not null;type:varchar(20);default:active;index"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+
+	Owner   User   `gorm:"foreignKey:OwnerID"`
+	Members []User `gorm:"many2many:project_members;"`
+}
+
+type ProjectMember struct {
+	ProjectID int `gorm:"primaryKey"`
+	UserID    int `gorm:"primaryKey"`
+}
+
+// ProjectProgress is the rollup of the tasks of one project. Completed
+// tasks count as 100% progress.
+type ProjectProgress struct {
+	ProjectID   int
+	Tasks       int
+	Completed   int
+	ProgressSum int
+}
+
+// Sprint is a time-boxed iteration (or milestone) that tasks are placed into.
+// Unfinished tasks are carried over to the next sprint when it is closed.
+type Sprint struct {
+	ID        int          `gorm:"primaryKey"`
+	OrgID     int          `gorm:"not null;default:1;index"`
+	Name      string       `gorm:"not null;size:200"`
+	Goal      string       `gorm:"size:1000"`
+	ProjectID *int         `gorm:"index"`
+	StartDate time.Time    `gorm:"not null;type:date"`
+	EndDate   time.Time    `gorm:"not null;type:date"`
+	Status    SprintStatus `gorm:"not null;type:varchar(20);default:planned;index"`
+	CreatorID int          `gorm:"not null"`
+	ClosedAt  *time.Time
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// SprintTask records that a task belonged to a sprint when it was closed, so
+// carried-over tasks still count in the burndown of the closed sprint.
+type SprintTask struct {
+	SprintID int `gorm:"primaryKey"`
+	TaskID   int `gorm:"primaryKey"`
+}
+
+// Label is a free-form tag on tasks, e.g. "client-x" or "tech-debt".
+type Label struct {
+	ID        int       `gorm:"primaryKey"`
+	OrgID     int       `gorm:"not null;default:1;uniqueIndex:idx_labels_org_name"`
+	Name      string    `gorm:"not null;size:50;uniqueIndex:idx_labels_org_name"`
+	Color     string    `gorm:"not null;size:7;default:'#808080'"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+type TaskLabel struct {
+	TaskID  int `gorm:"primaryKey"`
+	LabelID int `gorm:"primaryKey"`
+}
+
+// LabelUsage is the number of tasks with a label in one status.
+type LabelUsage struct {
+	LabelID int
+	Status  TaskStatus
+	Count   int
+}
+
+// RecurringTask is a blueprint from which occurrences (regular tasks) are
+// materialized according to an RRULE-like rule.
+type RecurringTask struct {
+	ID                 int                 `gorm:"primaryKey"`
+	OrgID              int                 `gorm:"not null;default:1;index"`
+	CreatorID          int                 `gorm:"not null;index"`
+	EmployeeID         int                 `gorm:"not null;index"`
+	Title              string              `gorm:"not null;size:200"`
+	Description        string              `gorm:"not null"`
+	DurationHours      int                 `gorm:"not null;default:24"`
+	Frequency          RecurrenceFrequency `gorm:"not null;type:varchar(20)"`
+	Interval           int                 `gorm:"not null;default:1"`
+	Trigger            RecurrenceTrigger   `gorm:"not null;type:varchar(20);default:schedule"`
+	StartsAt           time.Time           `gorm:"not null"`
+	Until              *time.Time
+	Count              int            `gorm:"not null;default:0"`
+	OccurrencesCreated int            `gorm:"not null;default:0"`
+	NextRunAt          *time.Time     `gorm:"index"`
+	CreatedAt          time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
+
+	RequiredSkills []Skill                  `gorm:"many2many:recurring_task_skills;"`
+	Exceptions     []RecurringTaskException `gorm:"foreignKey:RecurringTaskID"`
+}
+
+type RecurringTaskSkill struct {
+	RecurringTaskID int `gorm:"primaryKey"`
+	SkillID         int `gorm:"primaryKey"`
+}
+
+// RecurringTaskException overrides or skips a single not yet materialized occurrence.
+type RecurringTaskException struct {
+	ID              int  `gorm:"primaryKey"`
+	OrgID           int  `gorm:"not null;default:1;index"`
+	RecurringTaskID int  `gorm:"not null;uniqueIndex:idx_recurring_occurrence"`
+	Occurrence      int  `gorm:"not null;uniqueIndex:idx_recurring_occurrence"`
+	Skip            bool `gorm:"not null;default:false"`
+	EmployeeID      *int
+	Title           string `gorm:"size:200"`
+	Description     string
+	Deadline        *time.Time
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+}
+
+// TaskTemplate holds reusable task defaults. Shared templates are visible
+// to every manager, the others only to their creator.
+type TaskTemplate struct {
+	ID            int            `gorm:"primaryKey"`
+	OrgID         int            `gorm:"not null;default:1;index"`
+	CreatorID     int            `gorm:"not null;index"`
+	Title         string         `gorm:"not null;size:200"`
+	Description   string         `gorm:"not null"`
+	DurationHours int            `gorm:"not null;default:24"`
+	Shared        bool           `gorm:"not null;default:false;index"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+
+	Skills    []TaskTemplateSkill         `gorm:"foreignKey:TemplateID"`
+	Checklist []TaskTemplateChecklistItem `gorm:"foreignKey:TemplateID"`
+}
+
+type TaskTemplateSkill struct {
+	TemplateID int `gorm:"primaryKey"`
+	SkillID    int `gorm:"primaryKey"`
+	Level      int `gorm:"not null;default:0"`
+
+	Skill Skill `gorm:"foreignKey:SkillID"`
+}
+
+type TaskTemplateChecklistItem struct {
+	ID         int    `gorm:"primaryKey"`
+	OrgID      int    `gorm:"not null;default:1;index"`
+	TemplateID int    `gorm:"not null;index"`
+	Position   int    `gorm:"not null"`
+	Text       string `gorm:"not null;size:500"`
+}
+
+// ChecklistItem is a step of a task. When a task has a checklist, its
+// Progress is derived from the share of completed items.
+type ChecklistItem struct {
+	ID        int    `gorm:"primaryKey"`
+	OrgID     int    `gorm:"not null;default:1;index"`
+	TaskID    int    `gorm:"not null;index"`
+	Position  int    `gorm:"not null"`
+	Text      string `gorm:"not null;size:500"`
+	Done      bool   `gorm:"not null;default:false"`
+	DoneBy    *int
+	DoneAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TimeEntry is time logged by a user against a task. A running timer is an
+// entry without EndedAt.
+type TimeEntry struct {
+	ID              int       `gorm:"primaryKey"`
+	OrgID           int       `gorm:"not null;default:1;index"`
+	TaskID          int       `gorm:"not null;index"`
+	// At most one running (not ended) entry per user.
+	UserID          int       `gorm:"not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL"`
+	StartedAt       time.Time `gorm:"not null;index"`
+	EndedAt         *time.Time
+	DurationMinutes int       `gorm:"not null;default:0"`
+	Note            string    `gorm:"size:500"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+
+	Task Task `gorm:"foreignKey:TaskID"`
+}
+
+// Timesheet is the approval state of one user's week (WeekStart is a Monday).
+// Entries of a submitted or approved week can't be changed.
+type Timesheet struct {
+	ID          int             `gorm:"primaryKey"`
+	OrgID       int             `gorm:"not null;default:1;index"`
+	UserID      int             `gorm:"not null;uniqueIndex:idx_timesheet_user_week"`
+	WeekStart   time.Time       `gorm:"not null;type:date;uniqueIndex:idx_timesheet_user_week"`
+	Status      TimesheetStatus `gorm:"not null;type:varchar(20);default:open"`
+	SubmittedAt *time.Time
+	ReviewedBy  *int
+	ReviewedAt  *time.Time
+	Comment     string    `gorm:"size:1000"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// SLAPolicy defines the expected response and resolution time for tasks of
+// one priority. Response means the task left the pending status.
+type SLAPolicy struct {
+	ID                int          `gorm:"primaryKey"`
+	OrgID             int          `gorm:"not null;default:1;uniqueIndex:idx_sla_policies_org_priority"`
+	Priority          TaskPriority `gorm:"not null;type:varchar(20);uniqueIndex:idx_sla_policies_org_priority"`
+	ResponseMinutes   int          `gorm:"not null"`
+	ResolutionMinutes int          `gorm:"not null"`
+	CreatedAt         time.Time    `gorm:"autoCreateTime"`
+	UpdatedAt         time.Time    `gorm:"autoUpdateTime"`
 }
@@ -0,0 +1,100 @@
+// Package permission defines the named permissions checked by the API and
+// the permission bundles of the built-in roles.
+package permission
+
+type Permission string
+
+const (
+	TaskCreate       Permission = "task.create"
+	TaskReadAny      Permission = "task.read.any"
+	TaskUpdateAny    Permission = "task.update.any"
+	TaskDeleteAny    Permission = "task.delete.any"
+	TaskAssign       Permission = "task.assign"
+	CommentDeleteAny Permission = "comment.delete.any"
+	UserRead         Permission = "user.read"
+	UserManage       Permission = "user.manage"
+	SkillManage      Permission = "skill.manage"
+	SkillAssign      Permission = "skill.assign"
+	ProjectReadAny   Permission = "project.read.any"
+	ProjectManage    Permission = "project.manage"
+	SprintManage     Permission = "sprint.manage"
+	LabelManage      Permission = "label.manage"
+	TemplateManage   Permission = "template.manage"
+	RecurringManage  Permission = "recurring.manage"
+	TimesheetReview  Permission = "timesheet.review"
+	ReportRead       Permission = "report.read"
+	SLAManage        Permission = "sla.manage"
+	RoleManage       Permission = "role.manage"
+	TeamManage       Permission = "team.manage"
+	// TeamSearchAll lets a team manager look beyond their subtree.
+	TeamSearchAll Permission = "team.search.all"
+	// UserImpersonate lets a manager act as another user to reproduce
+	// what they see.
+	UserImpersonate Permission = "user.impersonate"
+	// AuditRead shows and exports the audit log.
+	AuditRead Permission = "audit.read"
+)
+
+var all = []Permission{
+	TaskCreate, TaskReadAny, TaskUpdateAny, TaskDeleteAny, TaskAssign,
+	CommentDeleteAny,
+	UserRead, UserManage,
+	SkillManage, SkillAssign,
+	ProjectReadAny, ProjectManage, SprintManage, LabelManage,
+	TemplateManage, RecurringManage,
+	TimesheetReview, ReportRead,
+	SLAManage, RoleManage,
+	TeamManage, TeamSearchAll,
+	UserImpersonate, AuditRead,
+}
+
+// All returns every known permission.
+func All() []Permission {
+	out := make([]Permission, len(all))
+	copy(out, all)
+	return out
+}
+
+// Valid reports whether p is a known permission.
+func Valid(p Permission) bool {
+	for _, known := range all {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Set is a bundle of permissions granted by a role.
+type Set map[Permission]struct{}
+
+func NewSet(ps ...Permission) Set {
+	s := make(Set, len(ps))
+	for _, p := range ps {
+		s[p] = struct{}{}
+	}
+	return s
+}
+
+func (s Set) Has(p Permission) bool {
+	_, ok := s[p]
+	return ok
+}
+
+// Built-in roles. They can't be changed or deleted; everything else is a
+// custom role stored in the database.
+const (
+	RoleManager  = "manager"
+	RoleEmployee = "employee"
+)
+
+// Builtin returns the permissions of a built-in role.
+func Builtin(role string) (Set, bool) {
+	switch role {
+	case RoleManager:
+		return NewSet(all...), true
+	case RoleEmployee:
+		return NewSet(), true
+	}
+	return nil, false
+}
@@ -4,17 +4,20 @@ import (
     "context"
     "skilltracker/internal/models"
     "skilltracker/internal/dto"
+    "time"
 )
 
 type UserRepository interface {
     CreateUser(ctx context.Context, user *models.User) error
     GetUserByID(ctx context.Context, id int) (*models.User, error)
     GetUserByUsername(ctx context.Context, username string) (*models.User, error)
-    GetUserByRefreshToken(ctx context.Context, token string) (*models.User, error)
+    GetUserByEmail(ctx context.Context, email string) (*models.User, error)
     UpdateUser(ctx context.Context, user *models.User) error
     DeleteUser(ctx context.Context, id int) error
     GetUsers(ctx context.Context) ([]*models.User, error)
     GetEmployeesWithSkills(ctx context.Context) ([]*models.User, error)
+    GetTokenVersion(ctx context.Context, id int) (int, error)
+    BumpTokenVersion(ctx context.Context, id int) error
 }
 
 type TaskRepository interface {
@@ -22,13 +25,19 @@ type TaskRepository interface {
     GetTaskByID(ctx context.Context, id int) (*models.Task, error)
     GetTasksByEmployeeID(ctx context.Context, employeeID int) ([]models.Task, error)
     UpdateTask(ctx context.Context, task *models.Task) error
+    SetTaskProgress(ctx context.Context, taskID int, progress int) error
     DeleteTask(ctx context.Context, id int) error
     ListTasks(ctx context.Context, filter dto.TaskFilter) ([]models.Task, error)
     CreateHistory(ctx context.Context, h *models.TaskStatusHistory) error
     GetHistoryByTaskID(ctx context.Context, taskID int) ([]models.TaskStatusHistory, error)
-    AddSkillToTask(ctx context.Context, taskID int, skillID int) error
+    AddSkillToTask(ctx context.Context, taskID int, skillID int, level int) error
     RemoveSkillFromTask(ctx context.Context, taskID int, skillID int) error
     GetTaskSkills(ctx context.Context, taskID int) ([]models.Skill, error)
+    GetTasksByIDs(ctx context.Context, ids []int) ([]models.Task, error)
+    SetTaskAssignees(ctx context.Context, taskID int, userIDs []int) error
+    AddTaskWatcher(ctx context.Context, taskID int, userID int) error
+    RemoveTaskWatcher(ctx context.Context, taskID int, userID int) error
+    GetWatchedTasks(ctx context.Context, userID int) ([]models.Task, error)
 }
 
 type CommentRepository interface {
@@ -53,10 +62,253 @@ type SkillRepository interface {
     GetUserSkills(ctx context.Context, userID int) ([]models.Skill, error)
 }
 
+type RecurringTaskRepository interface {
+    CreateRecurringTask(ctx context.Context, r *models.RecurringTask) error
+    GetRecurringTaskByID(ctx context.Context, id int) (*models.RecurringTask, error)
+    GetRecurringTasks(ctx context.Context) ([]models.RecurringTask, error)
+    GetDueRecurringTasks(ctx context.Context, now time.Time) ([]models.RecurringTask, error)
+    UpdateRecurringTask(ctx context.Context, r *models.RecurringTask) error
+    // AdvanceRecurringTask stores OccurrencesCreated and NextRunAt of r
+    // unless the rule moved on from `from` occurrences meanwhile, e.g. in
+    // another instance. It reports whether r was stored.
+    AdvanceRecurringTask(ctx context.Context, r *models.RecurringTask, from int) (bool, error)
+    // CreateOccurrence advances r like AdvanceRecurringTask and creates the
+    // occurrence task t with its required skills in the same transaction.
+    // Nothing is stored when the rule moved on meanwhile.
+    CreateOccurrence(ctx context.Context, r *models.RecurringTask, from int, t *models.Task) (bool, error)
+    DeleteRecurringTask(ctx context.Context, id int) error
+    SetRecurringTaskSkills(ctx context.Context, id int, skillIDs []int) error
+    SaveException(ctx context.Context, ex *models.RecurringTaskException) error
+}
+
+type TaskTemplateRepository interface {
+    CreateTemplate(ctx context.Context, t *models.TaskTemplate) error
+    GetTemplateByID(ctx context.Context, id int) (*models.TaskTemplate, error)
+    GetTemplatesVisibleTo(ctx context.Context, userID int) ([]models.TaskTemplate, error)
+    UpdateTemplate(ctx context.Context, t *models.TaskTemplate) error
+    DeleteTemplate(ctx context.Context, id int) error
+}
+
+type ChecklistRepository interface {
+    CreateChecklistItem(ctx context.Context, item *models.ChecklistItem) error
+    GetChecklistItemByID(ctx context.Context, id int) (*models.ChecklistItem, error)
+    GetChecklistByTaskID(ctx context.Context, taskID int) ([]models.ChecklistItem, error)
+    UpdateChecklistItem(ctx context.Context, item *models.ChecklistItem) error
+    DeleteChecklistItem(ctx context.Context, id int) error
+    ReorderChecklist(ctx context.Context, taskID int, itemIDs []int) error
+}
+
+type TimeRepository interface {
+    CreateTimeEntry(ctx context.Context, e *models.TimeEntry) error
+    GetTimeEntryByID(ctx context.Context, id int) (*models.TimeEntry, error)
+    GetRunningTimeEntry(ctx context.Context, userID int) (*models.TimeEntry, error)
+    UpdateTimeEntry(ctx context.Context, e *models.TimeEntry) error
+    DeleteTimeEntry(ctx context.Context, id int) error
+    GetTimeEntriesByTaskID(ctx context.Context, taskID int) ([]models.TimeEntry, error)
+    GetTimeEntriesByUser(ctx context.Context, userID int, from, to time.Time) ([]models.TimeEntry, error)
+    GetLoggedMinutesByTask(ctx context.Context, from, to *time.Time) (map[int]int, error)
+    GetTimesheet(ctx context.Context, userID int, weekStart time.Time) (*models.Timesheet, error)
+    GetTimesheetByID(ctx context.Context, id int) (*models.Timesheet, error)
+    SaveTimesheet(ctx context.Context, ts *models.Timesheet) error
+}
+
+type SLARepository interface {
+    GetSLAPolicies(ctx context.Context) ([]models.SLAPolicy, error)
+    GetSLAPolicy(ctx context.Context, priority models.TaskPriority) (*models.SLAPolicy, error)
+    SaveSLAPolicy(ctx context.Context, p *models.SLAPolicy) error
+    GetTasksBreachingSLA(ctx context.Context, now time.Time) ([]models.Task, error)
+    // RecordSLABreaches stores the breach times set on t, each only if none
+    // is recorded yet. It reports whether any was stored.
+    RecordSLABreaches(ctx context.Context, t *models.Task) (bool, error)
+}
+
+type LabelRepository interface {
+    CreateLabel(ctx context.Context, l *models.Label) error
+    GetLabelByID(ctx context.Context, id int) (*models.Label, error)
+    GetLabels(ctx context.Context) ([]models.Label, error)
+    UpdateLabel(ctx context.Context, l *models.Label) error
+    DeleteLabel(ctx context.Context, id int) error
+    AddLabelToTask(ctx context.Context, taskID int, labelID int) error
+    RemoveLabelFromTask(ctx context.Context, taskID int, labelID int) error
+    GetLabelUsage(ctx context.Context) ([]models.LabelUsage, error)
+}
+
+type ProjectRepository interface {
+    CreateProject(ctx context.Context, p *models.Project) error
+    GetProjectByID(ctx context.Context, id int) (*models.Project, error)
+    GetProjects(ctx context.Context) ([]models.Project, error)
+    GetProjectsByMember(ctx context.Context, userID int) ([]models.Project, error)
+    UpdateProject(ctx context.Context, p *models.Project) error
+    DeleteProject(ctx context.Context, id int) error
+    SetProjectMembers(ctx context.Context, projectID int, userIDs []int) error
+    GetProjectProgress(ctx context.Context, projectIDs []int) ([]models.ProjectProgress, error)
+}
+
+type SprintRepository interface {
+    CreateSprint(ctx context.Context, sp *models.Sprint) error
+    GetSprintByID(ctx context.Context, id int) (*models.Sprint, error)
+    GetSprints(ctx context.Context, projectID int) ([]models.Sprint, error)
+    GetNextSprint(ctx context.Context, sp *models.Sprint) (*models.Sprint, error)
+    UpdateSprint(ctx context.Context, sp *models.Sprint) error
+    DeleteSprint(ctx context.Context, id int) error
+    // GetSprintTasks returns the tasks in the sprint, and for a closed
+    // sprint also those it had when it was closed.
+    GetSprintTasks(ctx context.Context, sprintID int) ([]models.Task, error)
+    GetStatusHistoryForTasks(ctx context.Context, taskIDs []int) ([]models.TaskStatusHistory, error)
+    // CloseSprint stores sp, records taskIDs as its members and moves the
+    // tasks of the moved history rows to nextSprintID, in one transaction.
+    CloseSprint(ctx context.Context, sp *models.Sprint, taskIDs []int, moved []models.TaskStatusHistory, nextSprintID *int) error
+}
+
+type NotificationRepository interface {
+    CreateNotifications(ctx context.Context, ns []models.Notification) error
+    GetNotifications(ctx context.Context, userID int, unreadOnly bool) ([]models.Notification, error)
+    MarkNotificationRead(ctx context.Context, id int, userID int) error
+    MarkAllNotificationsRead(ctx context.Context, userID int) error
+    GetNotificationPreferences(ctx context.Context, userID int) ([]models.NotificationPreference, error)
+    // SaveNotificationPreferences inserts or replaces the preferences of
+    // their user and type.
+    SaveNotificationPreferences(ctx context.Context, prefs []models.NotificationPreference) error
+    // GetMutedUsers returns those of userIDs that turned typ off.
+    GetMutedUsers(ctx context.Context, userIDs []int, typ models.NotificationType) ([]int, error)
+}
+
+type TeamRepository interface {
+    CreateTeam(ctx context.Context, t *models.Team) error
+    GetTeams(ctx context.Context) ([]models.Team, error)
+    GetTeamByID(ctx context.Context, id int) (*models.Team, error)
+    UpdateTeam(ctx context.Context, t *models.Team) error
+    DeleteTeam(ctx context.Context, id int) error
+    GetTeamMembers(ctx context.Context, teamID int) ([]models.User, error)
+    SetUserTeam(ctx context.Context, userID int, teamID *int) error
+}
+
+type RoleRepository interface {
+    CreateRole(ctx context.Context, r *models.RoleDefinition) error
+    GetRoles(ctx context.Context) ([]models.RoleDefinition, error)
+    GetRoleByID(ctx context.Context, id int) (*models.RoleDefinition, error)
+    GetRoleByName(ctx context.Context, name string) (*models.RoleDefinition, error)
+    UpdateRole(ctx context.Context, r *models.RoleDefinition) error
+    DeleteRole(ctx context.Context, id int) error
+    CountUsersWithRole(ctx context.Context, name string) (int64, error)
+}
+
+type SessionRepository interface {
+    CreateSession(ctx context.Context, sess *models.Session) error
+    GetSessionByID(ctx context.Context, id int) (*models.Session, error)
+    GetActiveSessions(ctx context.Context, userID int, now time.Time) ([]models.Session, error)
+    GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+    // RotateRefreshToken marks the used token, stores the next one and saves
+    // the session. It reports false if the token was already used.
+    RotateRefreshToken(ctx context.Context, usedID int, next *models.RefreshToken, sess *models.Session) (bool, error)
+    RevokeSession(ctx context.Context, id int, at time.Time) error
+    RevokeUserSessions(ctx context.Context, userID int, at time.Time) error
+}
+
+// OrganizationRepository manages tenants. Organizations themselves aren't
+// tenant scoped.
+type OrganizationRepository interface {
+    CreateOrganization(ctx context.Context, o *models.Organization) error
+    GetOrganizations(ctx context.Context) ([]models.Organization, error)
+    GetOrganizationByID(ctx context.Context, id int) (*models.Organization, error)
+    GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error)
+}
+
+// LoginThrottleRepository tracks failed logins. It is implemented by the
+// postgres storage, shared by all replicas, and in memory for single-node
+// setups.
+type LoginThrottleRepository interface {
+    GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error)
+    // RecordLoginFailure counts a failure and returns the updated row. The
+    // count restarts when the previous failure is older than window.
+    RecordLoginFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*models.LoginThrottle, error)
+    LockLogin(ctx context.Context, key string, until time.Time) error
+    ResetLoginThrottle(ctx context.Context, key string) error
+}
+
+type TwoFactorRepository interface {
+    GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error)
+    SaveTOTPCredential(ctx context.Context, c *models.TOTPCredential) error
+    // EnableTwoFactor confirms the credential and replaces the user's
+    // recovery codes in one transaction.
+    EnableTwoFactor(ctx context.Context, c *models.TOTPCredential, codes []models.RecoveryCode) error
+    // UseTOTPStep records step as used unless a later or the same step
+    // was used already.
+    UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+    DeleteTwoFactor(ctx context.Context, userID int) error
+    ReplaceRecoveryCodes(ctx context.Context, userID int, codes []models.RecoveryCode) error
+    UseRecoveryCode(ctx context.Context, userID int, codeHash string, at time.Time) (bool, error)
+    CountRecoveryCodes(ctx context.Context, userID int) (int64, error)
+}
+
+type OIDCRepository interface {
+    CreateOIDCState(ctx context.Context, st *models.OIDCState) error
+    // TakeOIDCState deletes and returns an unexpired state, so each one is
+    // used once.
+    TakeOIDCState(ctx context.Context, stateHash string, now time.Time) (*models.OIDCState, error)
+    GetExternalIdentity(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error)
+    CreateExternalIdentity(ctx context.Context, id *models.ExternalIdentity) error
+    // CountExternalIdentities counts the provider subjects linked to a user.
+    CountExternalIdentities(ctx context.Context, userID int) (int64, error)
+}
+
+type PasswordRepository interface {
+    // GetPasswordHistory returns the latest previous hashes, newest first.
+    GetPasswordHistory(ctx context.Context, userID int, limit int) ([]models.PasswordHistory, error)
+    // AddPasswordHistory records a replaced hash and drops all but the
+    // newest keep hashes of the user.
+    AddPasswordHistory(ctx context.Context, h *models.PasswordHistory, keep int) error
+    // CreatePasswordResetToken replaces the user's unused reset tokens.
+    CreatePasswordResetToken(ctx context.Context, t *models.PasswordResetToken) error
+    GetPasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+    // UsePasswordResetToken marks the token used unless it was already.
+    UsePasswordResetToken(ctx context.Context, id int, at time.Time) (bool, error)
+}
+
+type APITokenRepository interface {
+    CreateAPIToken(ctx context.Context, t *models.APIToken) error
+    GetAPITokens(ctx context.Context, userID int) ([]models.APIToken, error)
+    GetAPITokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+    // RevokeAPIToken revokes a token of the user; false if there is no
+    // such unrevoked token.
+    RevokeAPIToken(ctx context.Context, userID int, id int, at time.Time) (bool, error)
+    RevokeUserAPITokens(ctx context.Context, userID int, at time.Time) error
+    TouchAPIToken(ctx context.Context, id int, at time.Time, ip string) error
+}
+
+// AuditRepository appends to the audit log. Entries are never changed;
+// only the retention purge deletes them.
+type AuditRepository interface {
+    CreateAuditLog(ctx context.Context, l *models.AuditLog) error
+    // GetAuditLogs returns a page of the matching entries, newest first,
+    // and the number of all matching entries.
+    GetAuditLogs(ctx context.Context, filter dto.AuditFilter) ([]models.AuditLog, int64, error)
+    DeleteAuditLogsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
 type Repository interface {
 	User() UserRepository
 	Task() TaskRepository
 	Comment() CommentRepository
 	File() FileRepository
 	Skill() SkillRepository
+	RecurringTask() RecurringTaskRepository
+	Template() TaskTemplateRepository
+	Checklist() ChecklistRepository
+	Time() TimeRepository
+	SLA() SLARepository
+	Label() LabelRepository
+	Project() ProjectRepository
+	Sprint() SprintRepository
+	Notification() NotificationRepository
+	Role() RoleRepository
+	Team() TeamRepository
+	Organization() OrganizationRepository
+	Session() SessionRepository
+	LoginThrottle() LoginThrottleRepository
+	TwoFactor() TwoFactorRepository
+	OIDC() OIDCRepository
+	Password() PasswordRepository
+	APIToken() APITokenRepository
+	Audit() AuditRepository
 }
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+)
+
+// ACCESS CONTROL
+
+func (s *services) Access() AccessService { return s }
+
+// rolePermissions resolves a role to its permissions. Built-in roles are
+// defined in code, custom roles are loaded from the repository.
+func (s *services) rolePermissions(ctx context.Context, role string) (permission.Set, error) {
+	if set, ok := permission.Builtin(role); ok {
+		return set, nil
+	}
+	r, err := s.repo.Role().GetRoleByName(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	return roleDefinitionPermissions(r), nil
+}
+
+// Can is the single policy check used by both the route middleware and the
+// services. Unknown roles are granted nothing.
+func (s *services) Can(ctx context.Context, role string, p permission.Permission) bool {
+	set, err := s.rolePermissions(ctx, role)
+	if err != nil {
+		return false
+	}
+	return set.Has(p)
+}
+
+// checkRoleGrantable fails unless callerRole holds every permission of
+// role, so nobody hands out or takes over more rights than they have.
+func (s *services) checkRoleGrantable(ctx context.Context, callerRole, role string) error {
+	target, err := s.rolePermissions(ctx, role)
+	if err != nil {
+		return errors.New("role not found")
+	}
+	return s.checkPermissionsGrantable(ctx, callerRole, target)
+}
+
+// checkPermissionsGrantable fails unless callerRole holds every permission
+// of perms.
+func (s *services) checkPermissionsGrantable(ctx context.Context, callerRole string, perms permission.Set) error {
+	own, err := s.rolePermissions(ctx, callerRole)
+	if err != nil {
+		return errors.New("forbidden")
+	}
+	for p := range perms {
+		if !own.Has(p) {
+			return errors.New("forbidden")
+		}
+	}
+	return nil
+}
+
+// roleDefinitionPermissions returns the permissions bundled in r.
+func roleDefinitionPermissions(r *models.RoleDefinition) permission.Set {
+	set := make(permission.Set, len(r.Permissions))
+	for _, p := range r.Permissions {
+		set[permission.Permission(p.Permission)] = struct{}{}
+	}
+	return set
+}
+
+func (s *services) GetPermissions() []string {
+	all := permission.All()
+	out := make([]string, 0, len(all))
+	for _, p := range all {
+		out = append(out, string(p))
+	}
+	return out
+}
+
+func roleToDTO(r *models.RoleDefinition) *dto.RoleResponse {
+	perms := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		perms = append(perms, p.Permission)
+	}
+	sort.Strings(perms)
+	return &dto.RoleResponse{ID: r.ID, Name: r.Name, Description: r.Description, Permissions: perms}
+}
+
+func (s *services) GetRoles(ctx context.Context) ([]*dto.RoleResponse, error) {
+	roles, err := s.repo.Role().GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.RoleResponse, 0, len(roles)+2)
+	for _, name := range []string{permission.RoleManager, permission.RoleEmployee} {
+		set, _ := permission.Builtin(name)
+		perms := make([]string, 0, len(set))
+		for p := range set {
+			perms = append(perms, string(p))
+		}
+		sort.Strings(perms)
+		out = append(out, &dto.RoleResponse{Name: name, Permissions: perms, Builtin: true})
+	}
+	for i := range roles {
+		out = append(out, roleToDTO(&roles[i]))
+	}
+	return out, nil
+}
+
+// applyRoleRequest validates the request and copies it into r.
+func applyRoleRequest(r *models.RoleDefinition, req *dto.RoleRequest) error {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if _, builtin := permission.Builtin(name); builtin {
+		return errors.New("role already exists")
+	}
+	seen := map[string]struct{}{}
+	perms := make([]models.RolePermission, 0, len(req.Permissions))
+	for _, p := range req.Permissions {
+		if !permission.Valid(permission.Permission(p)) {
+			return errors.New("unknown permission")
+		}
+		if _, dup := seen[p]; dup {
+			continue
+		}
+		seen[p] = struct{}{}
+		perms = append(perms, models.RolePermission{RoleID: r.ID, Permission: p})
+	}
+	r.Name = name
+	r.Description = req.Description
+	r.Permissions = perms
+	return nil
+}
+
+// CreateRole adds a custom role. The caller can only bundle permissions
+// they hold themselves.
+func (s *services) CreateRole(ctx context.Context, req *dto.RoleRequest, callerRole string) (*dto.RoleResponse, error) {
+	r := &models.RoleDefinition{}
+	if err := applyRoleRequest(r, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPermissionsGrantable(ctx, callerRole, roleDefinitionPermissions(r)); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.Role().GetRoleByName(ctx, r.Name); err == nil {
+		return nil, errors.New("role already exists")
+	}
+	if err := s.repo.Role().CreateRole(ctx, r); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, auditEntry{Action: "role.created", TargetType: "role", TargetID: r.ID, After: roleToDTO(r)})
+	return roleToDTO(r), nil
+}
+
+// UpdateRole changes a custom role. Renaming a role that is in use is not
+// allowed since users reference roles by name. Like CreateRole, the role
+// before and after the change can't hold permissions the caller lacks, and
+// nobody edits their own role.
+func (s *services) UpdateRole(ctx context.Context, id int, req *dto.RoleRequest, callerRole string) error {
+	r, err := s.repo.Role().GetRoleByID(ctx, id)
+	if err != nil {
+		return errors.New("role not found")
+	}
+	if r.Name == callerRole {
+		return errors.New("cannot change your own role")
+	}
+	if err := s.checkPermissionsGrantable(ctx, callerRole, roleDefinitionPermissions(r)); err != nil {
+		return err
+	}
+	oldName := r.Name
+	before := roleToDTO(r)
+	if err := applyRoleRequest(r, req); err != nil {
+		return err
+	}
+	if err := s.checkPermissionsGrantable(ctx, callerRole, roleDefinitionPermissions(r)); err != nil {
+		return err
+	}
+	if r.Name != oldName {
+		if _, err := s.repo.Role().GetRoleByName(ctx, r.Name); err == nil {
+			return errors.New("role already exists")
+		}
+		n, err := s.repo.Role().CountUsersWithRole(ctx, oldName)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return errors.New("role is in use")
+		}
+	}
+	if err := s.repo.Role().UpdateRole(ctx, r); err != nil {
+		return err
+	}
+	s.audit(ctx, auditEntry{Action: "role.updated", TargetType: "role", TargetID: r.ID, Before: before, After: roleToDTO(r)})
+	return nil
+}
+
+func (s *services) DeleteRole(ctx context.Context, id int) error {
+	r, err := s.repo.Role().GetRoleByID(ctx, id)
+	if err != nil {
+		return errors.New("role not found")
+	}
+	n, err := s.repo.Role().CountUsersWithRole(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("role is in use")
+	}
+	if err := s.repo.Role().DeleteRole(ctx, id); err != nil {
+		return err
+	}
+	s.audit(ctx, auditEntry{Action: "role.deleted", TargetType: "role", TargetID: id, Before: roleToDTO(r)})
+	return nil
+}
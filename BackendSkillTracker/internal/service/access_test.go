@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func supportRole() *models.RoleDefinition {
+	return &models.RoleDefinition{
+		ID:   1,
+		Name: "support",
+		Permissions: []models.RolePermission{
+			{RoleID: 1, Permission: string(permission.TaskReadAny)},
+			{RoleID: 1, Permission: string(permission.CommentDeleteAny)},
+		},
+	}
+}
+
+func TestAccessService_Can(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockRoleRepo := new(MockRoleRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	mockRepo.On("Role").Return(mockRoleRepo)
+	mockRoleRepo.On("GetRoleByName", ctx, "support").Return(supportRole(), nil)
+	mockRoleRepo.On("GetRoleByName", ctx, "ghost").Return(nil, errors.New("record not found"))
+
+	assert.True(t, s.Access().Can(ctx, "manager", permission.UserManage))
+	assert.False(t, s.Access().Can(ctx, "employee", permission.TaskReadAny))
+	assert.True(t, s.Access().Can(ctx, "support", permission.TaskReadAny))
+	assert.False(t, s.Access().Can(ctx, "support", permission.UserManage))
+	assert.False(t, s.Access().Can(ctx, "ghost", permission.TaskReadAny))
+}
+
+func TestTaskService_ReadAccess(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("outsider employee is forbidden", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		_, err := s.Task().GetTaskByID(ctx, 1, 99, "employee", false)
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+
+		_, err = s.Comment().GetCommentsByTaskID(ctx, 1, 99, "employee", false)
+		assert.Equal(t, "forbidden", err.Error())
+
+		_, err = s.Task().GetTaskHistory(ctx, 1, 99, "employee", false)
+		assert.Equal(t, "forbidden", err.Error())
+
+		_, err = s.Checklist().GetChecklist(ctx, 1, 99, "employee", false)
+		assert.Equal(t, "forbidden", err.Error())
+
+		_, err = s.Time().GetTaskTimeEntries(ctx, 1, 99, "employee", false)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+
+	t.Run("watcher can read", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		res, err := s.Task().GetTaskByID(ctx, 1, 40, "employee", false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, res.ID)
+	})
+
+	t.Run("task.read.any is limited to the team scope", func(t *testing.T) {
+		// The task's lead (20) is in Sales, which 200 manages and 100 doesn't.
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockRoleRepo := new(MockRoleRepo)
+		mockTeamRepo := new(MockTeamRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Role").Return(mockRoleRepo)
+		mockRepo.On("Team").Return(mockTeamRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+		mockRoleRepo.On("GetRoleByName", ctx, "support").Return(supportRole(), nil)
+		mockTeamRepo.On("GetTeams", ctx).Return(orgTeams(), nil)
+		mockUserRepo.On("GetUserByID", ctx, 20).Return(&models.User{ID: 20, TeamID: intPtr(3)}, nil)
+
+		_, err := s.Task().GetTaskByID(ctx, 1, 200, "support", false)
+		assert.NoError(t, err)
+		_, err = s.Task().GetTaskByID(ctx, 1, 100, "support", false)
+		assert.EqualError(t, err, "forbidden")
+		_, err = s.Task().GetTaskByID(ctx, 1, 100, "support", true)
+		assert.EqualError(t, err, "forbidden")
+	})
+
+	t.Run("team.search.all reads other teams' tasks only when asked", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockTeamRepo := new(MockTeamRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Team").Return(mockTeamRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+		mockTeamRepo.On("GetTeams", ctx).Return(orgTeams(), nil)
+		mockUserRepo.On("GetUserByID", ctx, 20).Return(&models.User{ID: 20, TeamID: intPtr(3)}, nil)
+
+		_, err := s.Task().GetTaskByID(ctx, 1, 100, "manager", false)
+		assert.EqualError(t, err, "forbidden")
+		assert.EqualError(t, s.Task().UpdateTask(ctx, 1, &dto.TaskRequest{Status: "completed"}, 100, "manager", false), "forbidden")
+		assert.EqualError(t, s.Task().DeleteTask(ctx, 1, 100, "manager", false), "forbidden")
+		_, err = s.Task().GetTaskByID(ctx, 1, 100, "manager", true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("list is scoped without task.read.any", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockTeamRepo := new(MockTeamRepo)
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Team").Return(mockTeamRepo)
+		mockTeamRepo.On("GetTeams", ctx).Return([]models.Team{}, nil)
+		mockTaskRepo.On("ListTasks", ctx, mock.MatchedBy(func(f dto.TaskFilter) bool {
+			return f.ParticipantID == 7
+		})).Return([]models.Task{}, nil).Once()
+		mockTaskRepo.On("ListTasks", ctx, mock.MatchedBy(func(f dto.TaskFilter) bool {
+			return f.ParticipantID == 0
+		})).Return([]models.Task{}, nil).Once()
+
+		_, err := s.Task().ListTasks(ctx, dto.TaskFilter{}, 7, "employee")
+		assert.NoError(t, err)
+		_, err = s.Task().ListTasks(ctx, dto.TaskFilter{}, 8, "manager")
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("other user's skills need user.read", func(t *testing.T) {
+		s := New(new(MockRepo), logger, testKeys, Options{})
+
+		_, err := s.Skill().GetUserSkills(ctx, 5, 6, "employee", false)
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+}
+
+func TestCommentService_DeleteComment_Moderator(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockCommentRepo := new(MockCommentRepo)
+	mockRoleRepo := new(MockRoleRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	mockRepo.On("Comment").Return(mockCommentRepo)
+	mockRepo.On("Role").Return(mockRoleRepo)
+	mockCommentRepo.On("GetCommentByID", ctx, 1).Return(&models.Comment{ID: 1, UserID: 2}, nil)
+	mockCommentRepo.On("DeleteComment", ctx, 1).Return(nil)
+	mockRoleRepo.On("GetRoleByName", ctx, "support").Return(supportRole(), nil)
+
+	assert.NoError(t, s.Comment().DeleteComment(ctx, 1, 3, "support"))
+}
+
+func TestAccessService_Roles(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("create custom role", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRoleRepo := new(MockRoleRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Role").Return(mockRoleRepo)
+		mockRoleRepo.On("GetRoleByName", ctx, "support").Return(nil, errors.New("record not found"))
+		mockRoleRepo.On("CreateRole", ctx, mock.MatchedBy(func(r *models.RoleDefinition) bool {
+			return r.Name == "support" && len(r.Permissions) == 1
+		})).Return(nil)
+
+		res, err := s.Access().CreateRole(ctx, &dto.RoleRequest{
+			Name:        "Support",
+			Permissions: []string{"task.read.any", "task.read.any"},
+		}, "manager")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"task.read.any"}, res.Permissions)
+	})
+
+	t.Run("built-in name and unknown permission", func(t *testing.T) {
+		s := New(new(MockRepo), logger, testKeys, Options{})
+
+		_, err := s.Access().CreateRole(ctx, &dto.RoleRequest{Name: "manager"}, "manager")
+		assert.Equal(t, "role already exists", err.Error())
+
+		_, err = s.Access().CreateRole(ctx, &dto.RoleRequest{Name: "qa", Permissions: []string{"task.fly"}}, "manager")
+		assert.Equal(t, "unknown permission", err.Error())
+	})
+
+	t.Run("roles can't bundle permissions the caller lacks", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRoleRepo := new(MockRoleRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+		admin := &models.RoleDefinition{ID: 2, Name: "role-admin", Permissions: []models.RolePermission{
+			{RoleID: 2, Permission: string(permission.RoleManage)},
+			{RoleID: 2, Permission: string(permission.TaskReadAny)},
+			{RoleID: 2, Permission: string(permission.CommentDeleteAny)},
+		}}
+		mockRepo.On("Role").Return(mockRoleRepo)
+		mockRoleRepo.On("GetRoleByName", ctx, "role-admin").Return(admin, nil)
+		mockRoleRepo.On("GetRoleByID", ctx, 1).Return(supportRole(), nil)
+
+		_, err := s.Access().CreateRole(ctx, &dto.RoleRequest{Name: "auditor", Permissions: []string{"audit.read"}}, "role-admin")
+		assert.EqualError(t, err, "forbidden")
+
+		err = s.Access().UpdateRole(ctx, 1, &dto.RoleRequest{Name: "support", Permissions: []string{"task.read.any", "user.impersonate"}}, "role-admin")
+		assert.EqualError(t, err, "forbidden")
+		mockRoleRepo.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+		mockRoleRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("nobody edits their own role", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRoleRepo := new(MockRoleRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+		mockRepo.On("Role").Return(mockRoleRepo)
+		mockRoleRepo.On("GetRoleByID", ctx, 1).Return(supportRole(), nil)
+
+		err := s.Access().UpdateRole(ctx, 1, &dto.RoleRequest{Name: "support", Permissions: []string{"task.read.any"}}, "support")
+
+		assert.EqualError(t, err, "cannot change your own role")
+		mockRoleRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("role in use cannot be deleted", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRoleRepo := new(MockRoleRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Role").Return(mockRoleRepo)
+		mockRoleRepo.On("GetRoleByID", ctx, 1).Return(supportRole(), nil)
+		mockRoleRepo.On("CountUsersWithRole", ctx, "support").Return(int64(2), nil)
+
+		err := s.Access().DeleteRole(ctx, 1)
+
+		assert.Error(t, err)
+		assert.Equal(t, "role is in use", err.Error())
+		mockRoleRepo.AssertNotCalled(t, "DeleteRole", ctx, 1)
+	})
+}
+
+func TestUserService_RoleEscalation(t *testing.T) {
+	ctx := context.Background()
+	hr := &models.RoleDefinition{ID: 2, Name: "hr", Permissions: []models.RolePermission{
+		{RoleID: 2, Permission: string(permission.UserManage)},
+	}}
+	hrID, team := 9, 2
+	setup := func() (*MockUserRepo, ServiceInterface) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockRoleRepo := new(MockRoleRepo)
+		mockTeamRepo := new(MockTeamRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Role").Return(mockRoleRepo)
+		mockRepo.On("Team").Return(mockTeamRepo)
+		mockRoleRepo.On("GetRoleByName", ctx, "hr").Return(hr, nil)
+		mockTeamRepo.On("GetTeams", ctx).Return([]models.Team{{ID: 2, ManagerID: &hrID}}, nil)
+		return mockUserRepo, New(mockRepo, zerolog.Nop(), testKeys, Options{})
+	}
+
+	t.Run("user.manage alone cannot promote to manager", func(t *testing.T) {
+		users, s := setup()
+		users.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, Username: "bob", Role: models.RoleEmployee, TeamID: &team}, nil)
+
+		err := s.User().UpdateUser(ctx, 5, &dto.UserRequest{Role: "manager"}, hrID, "hr", false)
+		assert.EqualError(t, err, "forbidden")
+
+		_, err = s.User().CreateUser(ctx, &dto.UserRequest{Username: "eve", Password: "password123", Role: "manager", Name: "Eve"}, "hr")
+		assert.EqualError(t, err, "forbidden")
+		users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		users.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("nor take over a manager account", func(t *testing.T) {
+		users, s := setup()
+		users.On("GetUserByID", ctx, 1).Return(&models.User{ID: 1, Username: "boss", Role: models.RoleManager, TeamID: &team}, nil)
+
+		err := s.User().UpdateUser(ctx, 1, &dto.UserRequest{Password: "n3w-password"}, hrID, "hr", false)
+
+		assert.EqualError(t, err, "forbidden")
+		users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("nor edit a user whose role can't be checked", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		users := new(MockUserRepo)
+		roles := new(MockRoleRepo)
+		teams := new(MockTeamRepo)
+		mockRepo.On("User").Return(users)
+		mockRepo.On("Role").Return(roles)
+		mockRepo.On("Team").Return(teams)
+		roles.On("GetRoleByName", ctx, "hr").Return(hr, nil)
+		roles.On("GetRoleByName", ctx, "auditor").Return(nil, errors.New("connection reset"))
+		teams.On("GetTeams", ctx).Return([]models.Team{{ID: 2, ManagerID: &hrID}}, nil)
+		users.On("GetUserByID", ctx, 6).Return(&models.User{ID: 6, Username: "ann", Role: "auditor", TeamID: &team}, nil)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		err := s.User().UpdateUser(ctx, 6, &dto.UserRequest{Password: "n3w-password"}, hrID, "hr", false)
+
+		assert.EqualError(t, err, "role not found")
+		users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+}
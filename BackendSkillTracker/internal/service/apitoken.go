@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// apiTokenTouchInterval limits last-use tracking to one write per
+	// token and interval, not one per request.
+	apiTokenTouchInterval = time.Minute
+	apiTokenPrefixLength  = len(dto.APITokenPrefix) + 6
+)
+
+// APITokenPolicy limits the lifetime of personal access tokens.
+type APITokenPolicy struct {
+	// DefaultDays applies when a request names no lifetime; MaxDays caps
+	// the requested one.
+	DefaultDays int
+	MaxDays     int
+}
+
+func (p APITokenPolicy) days(requested int) (int, error) {
+	if requested == 0 {
+		requested = p.DefaultDays
+	}
+	if requested == 0 {
+		requested = 90
+	}
+	if p.MaxDays > 0 && requested > p.MaxDays {
+		return 0, errors.New("token lifetime exceeds the maximum")
+	}
+	return requested, nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	set := make(map[string]struct{}, len(scopes))
+	for _, sc := range scopes {
+		if sc != "read" && sc != "write" {
+			return nil, errors.New("invalid scope")
+		}
+		set[sc] = struct{}{}
+	}
+	out := make([]string, 0, len(set))
+	for sc := range set {
+		out = append(out, sc)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func apiTokenToDTO(t *models.APIToken) dto.APITokenResponse {
+	return dto.APITokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     strings.Fields(t.Scopes),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		LastUsedIP: t.LastUsedIP,
+		RevokedAt:  t.RevokedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func (s *services) APIToken() APITokenService { return s }
+
+// CreateAPIToken issues a personal access token. The token is returned
+// once; only its hash is stored.
+func (s *services) CreateAPIToken(ctx context.Context, userID int, req *dto.APITokenRequest) (*dto.APITokenCreatedResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.opts.APITokens.days(req.ExpiresInDays)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	token := dto.APITokenPrefix + secret
+	t := &models.APIToken{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    token[:apiTokenPrefixLength],
+		TokenHash: hashToken(token),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: time.Now().AddDate(0, 0, days),
+	}
+	if err := s.repo.APIToken().CreateAPIToken(ctx, t); err != nil {
+		return nil, err
+	}
+	s.securityEvent(zerolog.InfoLevel, "api_token_created").Int("user_id", userID).Int("token_id", t.ID).
+		Str("scopes", t.Scopes).Msg("api token created")
+	s.audit(ctx, auditEntry{Action: "api_token.created", TargetType: "api_token", TargetID: t.ID, After: apiTokenToDTO(t)})
+	return &dto.APITokenCreatedResponse{APITokenResponse: apiTokenToDTO(t), Token: token}, nil
+}
+
+func (s *services) GetAPITokens(ctx context.Context, userID int) ([]*dto.APITokenResponse, error) {
+	ts, err := s.repo.APIToken().GetAPITokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.APITokenResponse, 0, len(ts))
+	for i := range ts {
+		t := apiTokenToDTO(&ts[i])
+		out = append(out, &t)
+	}
+	return out, nil
+}
+
+func (s *services) RevokeAPIToken(ctx context.Context, userID int, id int) error {
+	revoked, err := s.repo.APIToken().RevokeAPIToken(ctx, userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("token not found")
+	}
+	s.securityEvent(zerolog.InfoLevel, "api_token_revoked").Int("user_id", userID).Int("token_id", id).Msg("api token revoked")
+	s.audit(ctx, auditEntry{Action: "api_token.revoked", TargetType: "api_token", TargetID: id})
+	return nil
+}
+
+// RevokeUserAPITokens revokes every token of a user, for managers.
+func (s *services) RevokeUserAPITokens(ctx context.Context, userID int, managerID int, role string, allTeams bool) error {
+	if _, err := s.getUserInScope(ctx, userID, managerID, role, allTeams); err != nil {
+		return err
+	}
+	if err := s.repo.APIToken().RevokeUserAPITokens(ctx, userID, time.Now()); err != nil {
+		return err
+	}
+	s.securityEvent(zerolog.InfoLevel, "api_tokens_revoked").Int("user_id", userID).Msg("all api tokens of the user revoked")
+	s.audit(ctx, auditEntry{Action: "user.api_tokens_revoked", TargetType: "user", TargetID: userID})
+	return nil
+}
+
+// AuthenticateAPIToken resolves a personal access token to its user, with
+// the user's current role. Use is recorded at most once per minute.
+func (s *services) AuthenticateAPIToken(ctx context.Context, token string, ip string) (*dto.APITokenIdentity, error) {
+	// The token identifies the user and with it the organization.
+	t, err := s.repo.APIToken().GetAPITokenByHash(tenant.System(ctx), hashToken(token))
+	now := time.Now()
+	if err != nil || t.RevokedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, errors.New("invalid token")
+	}
+	ctx = tenant.WithOrg(ctx, t.OrgID)
+	u, err := s.repo.User().GetUserByID(ctx, t.UserID)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= apiTokenTouchInterval || t.LastUsedIP != ip {
+		if err := s.repo.APIToken().TouchAPIToken(ctx, t.ID, now, ip); err != nil {
+			s.logger.Error().Err(err).Int("token_id", t.ID).Msg("failed to record api token use")
+		}
+	}
+	return &dto.APITokenIdentity{
+		TokenID:  t.ID,
+		OrgID:    t.OrgID,
+		UserID:   u.ID,
+		Username: u.Username,
+		Role:     string(u.Role),
+		Scopes:   strings.Fields(t.Scopes),
+	}, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPITokenFixture() (*MockAPITokenRepo, *MockUserRepo, ServiceInterface) {
+	tokens := new(MockAPITokenRepo)
+	users := new(MockUserRepo)
+	mockRepo := new(MockRepo)
+	mockRepo.On("APIToken").Return(tokens)
+	mockRepo.On("User").Return(users)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{APITokens: APITokenPolicy{DefaultDays: 30, MaxDays: 90}})
+	return tokens, users, s
+}
+
+// storedToken returns a live read token of user 7 for the given secret.
+func storedToken(tokens *MockAPITokenRepo, token string) *models.APIToken {
+	t := &models.APIToken{ID: 3, OrgID: 1, UserID: 7, TokenHash: hashToken(token), Scopes: "read", ExpiresAt: time.Now().Add(time.Hour)}
+	tokens.On("GetAPITokenByHash", mock.Anything, hashToken(token)).Return(t, nil)
+	return t
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("token is returned once and stored hashed", func(t *testing.T) {
+		tokens, _, s := newAPITokenFixture()
+		var stored *models.APIToken
+		tokens.On("CreateAPIToken", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.APIToken)
+			stored.ID = 1
+		}).Return(nil)
+		res, err := s.APIToken().CreateAPIToken(ctx, 7, &dto.APITokenRequest{Name: "ci", Scopes: []string{"write", "read", "read"}})
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(res.Token, dto.APITokenPrefix))
+		assert.Equal(t, []string{"read", "write"}, res.Scopes)
+		assert.True(t, strings.HasPrefix(res.Token, res.Prefix))
+		require.NotNil(t, stored)
+		assert.Equal(t, 7, stored.UserID)
+		assert.Equal(t, hashToken(res.Token), stored.TokenHash)
+		assert.NotContains(t, stored.TokenHash, res.Token)
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), res.ExpiresAt, time.Minute)
+	})
+
+	t.Run("lifetime is capped", func(t *testing.T) {
+		tokens, _, s := newAPITokenFixture()
+		_, err := s.APIToken().CreateAPIToken(ctx, 7, &dto.APITokenRequest{Name: "ci", Scopes: []string{"read"}, ExpiresInDays: 91})
+		assert.EqualError(t, err, "token lifetime exceeds the maximum")
+		tokens.AssertNotCalled(t, "CreateAPIToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown scope is refused", func(t *testing.T) {
+		tokens, _, s := newAPITokenFixture()
+		_, err := s.APIToken().CreateAPIToken(ctx, 7, &dto.APITokenRequest{Name: "ci", Scopes: []string{"admin"}})
+		assert.EqualError(t, err, "invalid scope")
+		tokens.AssertNotCalled(t, "CreateAPIToken", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetAPITokens(t *testing.T) {
+	ctx := context.Background()
+	tokens, _, s := newAPITokenFixture()
+	tokens.On("GetAPITokens", ctx, 7).Return([]models.APIToken{{ID: 1, UserID: 7, Name: "ci", Scopes: "read write"}}, nil)
+
+	list, err := s.APIToken().GetAPITokens(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "ci", list[0].Name)
+	assert.Equal(t, []string{"read", "write"}, list[0].Scopes)
+}
+
+func TestAuthenticateAPIToken(t *testing.T) {
+	ctx := context.Background()
+	token := dto.APITokenPrefix + "secret"
+
+	t.Run("valid token stands for the user with the current role", func(t *testing.T) {
+		tokens, users, s := newAPITokenFixture()
+		storedToken(tokens, token)
+		users.On("GetUserByID", inOrg(1), 7).Return(&models.User{ID: 7, Username: "alice", Role: "manager"}, nil)
+		tokens.On("TouchAPIToken", inOrg(1), 3, mock.Anything, "10.0.0.1").Return(nil).Once()
+
+		id, err := s.APIToken().AuthenticateAPIToken(ctx, token, "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, &dto.APITokenIdentity{TokenID: 3, OrgID: 1, UserID: 7, Username: "alice", Role: "manager", Scopes: []string{"read"}}, id)
+		tokens.AssertExpectations(t)
+	})
+
+	t.Run("use within a minute is written again only from a new address", func(t *testing.T) {
+		tokens, users, s := newAPITokenFixture()
+		stored := storedToken(tokens, token)
+		used := time.Now().Add(-10 * time.Second)
+		stored.LastUsedAt, stored.LastUsedIP = &used, "10.0.0.1"
+		users.On("GetUserByID", mock.Anything, 7).Return(&models.User{ID: 7}, nil)
+		tokens.On("TouchAPIToken", mock.Anything, 3, mock.Anything, "10.0.0.2").Return(nil).Once()
+
+		_, err := s.APIToken().AuthenticateAPIToken(ctx, token, "10.0.0.1")
+		require.NoError(t, err)
+		_, err = s.APIToken().AuthenticateAPIToken(ctx, token, "10.0.0.2")
+		require.NoError(t, err)
+		tokens.AssertNumberOfCalls(t, "TouchAPIToken", 1)
+	})
+
+	t.Run("revoked, expired and unknown tokens are refused", func(t *testing.T) {
+		tokens, users, s := newAPITokenFixture()
+		revoked := dto.APITokenPrefix + "revoked"
+		at := time.Now().Add(-time.Minute)
+		storedToken(tokens, revoked).RevokedAt = &at
+		expired := dto.APITokenPrefix + "expired"
+		storedToken(tokens, expired).ExpiresAt = time.Now().Add(-time.Second)
+		unknown := dto.APITokenPrefix + "unknown"
+		tokens.On("GetAPITokenByHash", mock.Anything, hashToken(unknown)).Return(nil, errors.New("record not found"))
+
+		for _, token := range []string{revoked, expired, unknown} {
+			_, err := s.APIToken().AuthenticateAPIToken(ctx, token, "10.0.0.1")
+			assert.EqualError(t, err, "invalid token")
+		}
+		users.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+		tokens.AssertNotCalled(t, "TouchAPIToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("token of a deleted user is refused", func(t *testing.T) {
+		tokens, users, s := newAPITokenFixture()
+		storedToken(tokens, token)
+		users.On("GetUserByID", mock.Anything, 7).Return(nil, errors.New("record not found"))
+
+		_, err := s.APIToken().AuthenticateAPIToken(ctx, token, "10.0.0.1")
+		assert.EqualError(t, err, "invalid token")
+	})
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	ctx := context.Background()
+	tokens, users, s := newAPITokenFixture()
+	tokens.On("RevokeAPIToken", ctx, 8, 1, mock.Anything).Return(false, nil)
+	tokens.On("RevokeAPIToken", ctx, 7, 1, mock.Anything).Return(true, nil).Once()
+	tokens.On("RevokeAPIToken", ctx, 7, 1, mock.Anything).Return(false, nil)
+
+	assert.EqualError(t, s.APIToken().RevokeAPIToken(ctx, 8, 1), "token not found")
+	require.NoError(t, s.APIToken().RevokeAPIToken(ctx, 7, 1))
+	assert.EqualError(t, s.APIToken().RevokeAPIToken(ctx, 7, 1), "token not found")
+
+	users.On("GetUserByID", mock.Anything, 8).Return(&models.User{ID: 8}, nil)
+	tokens.On("RevokeUserAPITokens", ctx, 8, mock.Anything).Return(nil)
+	require.NoError(t, s.APIToken().RevokeUserAPITokens(ctx, 8, 1, "manager", true))
+	tokens.AssertExpectations(t)
+}
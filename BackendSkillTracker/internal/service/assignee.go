@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+)
+
+// ASSIGNEES AND WATCHERS
+
+func usersToSummary(users []models.User) []dto.UserSummaryResponse {
+	out := make([]dto.UserSummaryResponse, 0, len(users))
+	for _, u := range users {
+		out = append(out, dto.UserSummaryResponse{ID: u.ID, Username: u.Username, Name: u.Name})
+	}
+	return out
+}
+
+// isTaskAssignee reports whether the user is the lead or a co-assignee.
+func isTaskAssignee(t *models.Task, userID int) bool {
+	if t.EmployeeID == userID {
+		return true
+	}
+	for _, u := range t.Assignees {
+		if u.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// canEditTask reports whether the user may change the task: its creator and
+// every assignee can, watchers can't.
+func canEditTask(t *models.Task, userID int) bool {
+	return t.CreatorID == userID || isTaskAssignee(t, userID)
+}
+
+func isTaskWatcher(t *models.Task, userID int) bool {
+	for _, u := range t.Watchers {
+		if u.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// getReadableTask loads the task if the user takes part in it, or the role
+// may read any task of the user's team scope.
+func (s *services) getReadableTask(ctx context.Context, taskID int, userID int, role string, allTeams bool) (*models.Task, error) {
+	t, err := s.repo.Task().GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, errors.New("task not found")
+	}
+	if canEditTask(t, userID) || isTaskWatcher(t, userID) {
+		return t, nil
+	}
+	if s.Can(ctx, role, permission.TaskReadAny) && s.checkTaskInScope(ctx, t, userID, role, allTeams) == nil {
+		return t, nil
+	}
+	return nil, errors.New("forbidden")
+}
+
+// checkTaskInScope applies the team scope of ListTasks to a single task:
+// the manager's own tasks and those led by users of their teams, or any
+// task when allTeams is asked for.
+func (s *services) checkTaskInScope(ctx context.Context, t *models.Task, userID int, role string, allTeams bool) error {
+	scope, err := s.teamScope(ctx, userID, role, allTeams)
+	if err != nil {
+		return err
+	}
+	if scope == nil || t.CreatorID == userID {
+		return nil
+	}
+	lead, err := s.repo.User().GetUserByID(ctx, t.EmployeeID)
+	if err != nil || !inTeams(lead.TeamID, scope) {
+		return errors.New("forbidden")
+	}
+	return nil
+}
+
+// taskParticipants returns everyone interested in task changes.
+func taskParticipants(t *models.Task) []int {
+	ids := []int{t.CreatorID, t.EmployeeID}
+	for _, u := range t.Assignees {
+		ids = append(ids, u.ID)
+	}
+	for _, u := range t.Watchers {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+// coAssignees removes the lead and duplicates from the assignee list.
+func coAssignees(leadID int, ids []int) []int {
+	out := make([]int, 0, len(ids))
+	seen := map[int]struct{}{leadID: {}}
+	for _, id := range ids {
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+// loadUsers fetches the given users, failing if any of them doesn't exist.
+func (s *services) loadUsers(ctx context.Context, ids []int) ([]models.User, error) {
+	users := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		u, err := s.repo.User().GetUserByID(ctx, id)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+// notify creates a notification for every user except the actor and those
+// who turned the type off. Failures are logged and never fail the
+// operation that caused them.
+func (s *services) notify(ctx context.Context, userIDs []int, actorID int, taskID int, typ models.NotificationType, msg string) {
+	recipients := make([]int, 0, len(userIDs))
+	seen := map[int]struct{}{actorID: {}, 0: {}}
+	for _, id := range userIDs {
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		recipients = append(recipients, id)
+	}
+	if len(recipients) == 0 {
+		return
+	}
+	muted, err := s.repo.Notification().GetMutedUsers(ctx, recipients, typ)
+	if err != nil {
+		s.logger.Error().Err(err).Int("task_id", taskID).Msg("failed to load notification preferences")
+	}
+	skip := make(map[int]struct{}, len(muted))
+	for _, id := range muted {
+		skip[id] = struct{}{}
+	}
+	ns := make([]models.Notification, 0, len(recipients))
+	for _, id := range recipients {
+		if _, off := skip[id]; off {
+			continue
+		}
+		tid := taskID
+		ns = append(ns, models.Notification{UserID: id, TaskID: &tid, Type: typ, Message: msg})
+	}
+	if len(ns) == 0 {
+		return
+	}
+	if err := s.repo.Notification().CreateNotifications(ctx, ns); err != nil {
+		s.logger.Error().Err(err).Int("task_id", taskID).Msg("failed to create notifications")
+	}
+}
+
+// addTaskPeople stores the co-assignees and watchers of a newly created task.
+func (s *services) addTaskPeople(ctx context.Context, t *models.Task, assignees, watchers []models.User) error {
+	if len(assignees) > 0 {
+		ids := make([]int, 0, len(assignees))
+		for _, u := range assignees {
+			ids = append(ids, u.ID)
+		}
+		if err := s.repo.Task().SetTaskAssignees(ctx, t.ID, ids); err != nil {
+			return err
+		}
+	}
+	for _, u := range watchers {
+		if err := s.repo.Task().AddTaskWatcher(ctx, t.ID, u.ID); err != nil {
+			return err
+		}
+	}
+	t.Assignees, t.Watchers = assignees, watchers
+	return nil
+}
+
+// SetTaskAssignees replaces the co-assignees of a task. Only the creator or
+// the lead can do it; newly assigned users are notified.
+func (s *services) SetTaskAssignees(ctx context.Context, taskID int, req *dto.TaskAssigneesRequest, userID int) error {
+	t, err := s.repo.Task().GetTaskByID(ctx, taskID)
+	if err != nil {
+		return errors.New("task not found")
+	}
+	if t.CreatorID != userID && t.EmployeeID != userID {
+		return errors.New("forbidden")
+	}
+	assignees := coAssignees(t.EmployeeID, req.AssigneeIDs)
+	if _, err := s.loadUsers(ctx, assignees); err != nil {
+		return err
+	}
+	added := make([]int, 0, len(assignees))
+	for _, id := range assignees {
+		if !isTaskAssignee(t, id) {
+			added = append(added, id)
+		}
+	}
+	if err := s.repo.Task().SetTaskAssignees(ctx, taskID, assignees); err != nil {
+		return err
+	}
+	s.notify(ctx, added, userID, taskID, models.NotificationAssigned, fmt.Sprintf("You were assigned to task %q", t.Title))
+	return nil
+}
+
+// AddTaskWatcher lets users watch a task they can read; adding other users
+// requires edit rights on the task.
+func (s *services) AddTaskWatcher(ctx context.Context, taskID int, watcherID int, userID int, role string, allTeams bool) error {
+	t, err := s.getReadableTask(ctx, taskID, userID, role, allTeams)
+	if err != nil {
+		return err
+	}
+	if watcherID != userID && !canEditTask(t, userID) {
+		return errors.New("forbidden")
+	}
+	if _, err := s.loadUsers(ctx, []int{watcherID}); err != nil {
+		return err
+	}
+	return s.repo.Task().AddTaskWatcher(ctx, taskID, watcherID)
+}
+
+func (s *services) RemoveTaskWatcher(ctx context.Context, taskID int, watcherID int, userID int) error {
+	t, err := s.repo.Task().GetTaskByID(ctx, taskID)
+	if err != nil {
+		return errors.New("task not found")
+	}
+	if watcherID != userID && !canEditTask(t, userID) {
+		return errors.New("forbidden")
+	}
+	return s.repo.Task().RemoveTaskWatcher(ctx, taskID, watcherID)
+}
+
+func (s *services) GetWatchedTasks(ctx context.Context, userID int) ([]*dto.TaskResponse, error) {
+	ts, err := s.repo.Task().GetWatchedTasks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.TaskResponse, 0, len(ts))
+	for i := range ts {
+		out = append(out, taskToDTO(&ts[i]))
+	}
+	return out, nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func sharedTask() *models.Task {
+	return &models.Task{
+		ID:         1,
+		Title:      "Pair work",
+		CreatorID:  10,
+		EmployeeID: 20,
+		Status:     models.StatusPending,
+		Assignees:  []models.User{{ID: 30}},
+		Watchers:   []models.User{{ID: 40}},
+	}
+}
+
+func TestTaskService_CoAssigneePermissions(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("co-assignee can update, watchers are notified", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockNotificationRepo := new(MockNotificationRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Notification").Return(mockNotificationRepo)
+		mockNotificationRepo.On("GetMutedUsers", ctx, mock.Anything, mock.Anything).Return([]int{}, nil)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+		mockTaskRepo.On("UpdateTask", ctx, mock.Anything).Return(nil)
+		mockTaskRepo.On("CreateHistory", ctx, mock.Anything).Return(nil)
+		mockNotificationRepo.On("CreateNotifications", ctx, mock.MatchedBy(func(ns []models.Notification) bool {
+			users := map[int]bool{}
+			for _, n := range ns {
+				users[n.UserID] = n.Type == models.NotificationStatusChanged
+			}
+			// The actor (30) is skipped; creator, lead and watcher are notified.
+			return len(ns) == 3 && users[10] && users[20] && users[40]
+		})).Return(nil)
+
+		err := s.Task().UpdateTask(ctx, 1, &dto.TaskRequest{Status: "in_progress"}, 30, "employee", false)
+
+		assert.NoError(t, err)
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("co-assignee cannot delete", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		err := s.Task().DeleteTask(ctx, 1, 30, "employee", false)
+
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+		mockTaskRepo.AssertNotCalled(t, "DeleteTask", ctx, 1)
+	})
+
+	t.Run("watcher cannot update", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		err := s.Task().UpdateTask(ctx, 1, &dto.TaskRequest{Status: "completed"}, 40, "employee", false)
+
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+}
+
+func TestTaskService_SetTaskAssignees(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("lead dropped, only new people notified", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockNotificationRepo := new(MockNotificationRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Notification").Return(mockNotificationRepo)
+		mockNotificationRepo.On("GetMutedUsers", ctx, mock.Anything, mock.Anything).Return([]int{}, nil)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+		mockUserRepo.On("GetUserByID", ctx, 30).Return(&models.User{ID: 30}, nil)
+		mockUserRepo.On("GetUserByID", ctx, 50).Return(&models.User{ID: 50}, nil)
+		mockTaskRepo.On("SetTaskAssignees", ctx, 1, []int{30, 50}).Return(nil)
+		mockNotificationRepo.On("CreateNotifications", ctx, mock.MatchedBy(func(ns []models.Notification) bool {
+			return len(ns) == 1 && ns[0].UserID == 50 && ns[0].Type == models.NotificationAssigned
+		})).Return(nil)
+
+		err := s.Task().SetTaskAssignees(ctx, 1, &dto.TaskAssigneesRequest{AssigneeIDs: []int{20, 30, 50, 50}}, 10)
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("co-assignee cannot change assignees", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		err := s.Task().SetTaskAssignees(ctx, 1, &dto.TaskAssigneesRequest{}, 30)
+
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+}
+
+func TestTaskService_AddTaskWatcher(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("readers can watch themselves", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+		mockUserRepo.On("GetUserByID", ctx, 60).Return(&models.User{ID: 60}, nil)
+		mockTaskRepo.On("AddTaskWatcher", ctx, 1, 60).Return(nil)
+
+		assert.NoError(t, s.Task().AddTaskWatcher(ctx, 1, 60, 60, "manager", true))
+	})
+
+	t.Run("outsider cannot watch a task they can't read", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		err := s.Task().AddTaskWatcher(ctx, 1, 60, 60, "employee", false)
+
+		assert.EqualError(t, err, "forbidden")
+		mockTaskRepo.AssertNotCalled(t, "AddTaskWatcher", ctx, 1, 60)
+	})
+
+	t.Run("watcher cannot add others", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		err := s.Task().AddTaskWatcher(ctx, 1, 70, 40, "employee", false)
+
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+}
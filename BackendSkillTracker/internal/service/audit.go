@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"skilltracker/internal/audit"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"time"
+)
+
+const (
+	auditPageSize    = 50
+	auditMaxPageSize = 500
+	// auditExportLimit caps an export; narrower filters get the rest.
+	auditExportLimit = 100000
+)
+
+// AuditPolicy says how long audit entries are kept. Zero keeps them
+// forever.
+type AuditPolicy struct {
+	Retention time.Duration
+}
+
+// auditEntry is an action to record. The actor, IP, user agent and request
+// ID come from the context.
+type auditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   int
+	// Before and After are snapshots of the target, usually its response
+	// DTO; never anything holding secrets.
+	Before interface{}
+	After  interface{}
+	// Actor is the user logging in, before the context has one.
+	Actor *models.User
+	// ActorName records the username of a failed login.
+	ActorName string
+}
+
+func auditSnapshot(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	out := string(b)
+	return &out
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// audit appends an entry to the audit log of the organization in ctx.
+// Failing to write it doesn't fail the action; the error is logged.
+func (s *services) audit(ctx context.Context, e auditEntry) {
+	if _, ok := tenant.OrgID(ctx); !ok {
+		return
+	}
+	r := audit.RequestFrom(ctx)
+	l := &models.AuditLog{
+		Action:     e.Action,
+		ActorName:  e.ActorName,
+		TargetType: e.TargetType,
+		IP:         truncate(r.IP, 64),
+		UserAgent:  truncate(r.UserAgent, 255),
+		RequestID:  truncate(r.ID, 64),
+		Before:     auditSnapshot(e.Before),
+		After:      auditSnapshot(e.After),
+	}
+	if e.TargetID != 0 {
+		l.TargetID = &e.TargetID
+	}
+	if a, ok := audit.ActorFrom(ctx); ok {
+		l.ActorID = &a.UserID
+		l.ActorName = a.Username
+		if a.ImpersonatorID != 0 {
+			l.ImpersonatorID = &a.ImpersonatorID
+		}
+	} else if e.Actor != nil {
+		l.ActorID = &e.Actor.ID
+		l.ActorName = e.Actor.Username
+	}
+	if err := s.repo.Audit().CreateAuditLog(ctx, l); err != nil {
+		s.logger.Error().Err(err).Str("action", e.Action).Msg("failed to write audit log")
+	}
+}
+
+func auditLogToDTO(l *models.AuditLog) *dto.AuditLogResponse {
+	out := &dto.AuditLogResponse{
+		ID:             l.ID,
+		Action:         l.Action,
+		ActorID:        l.ActorID,
+		ActorName:      l.ActorName,
+		ImpersonatorID: l.ImpersonatorID,
+		TargetType:     l.TargetType,
+		TargetID:       l.TargetID,
+		IP:             l.IP,
+		UserAgent:      l.UserAgent,
+		RequestID:      l.RequestID,
+		CreatedAt:      l.CreatedAt,
+	}
+	if l.Before != nil {
+		out.Before = json.RawMessage(*l.Before)
+	}
+	if l.After != nil {
+		out.After = json.RawMessage(*l.After)
+	}
+	return out
+}
+
+func checkAuditFilter(filter *dto.AuditFilter) error {
+	for _, d := range []string{filter.From, filter.To} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, d); err != nil {
+			return errors.New("invalid date")
+		}
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return errors.New("invalid page")
+	}
+	return nil
+}
+
+func (s *services) Audit() AuditService { return s }
+
+// GetAuditLog returns a page of the audit log, newest first.
+func (s *services) GetAuditLog(ctx context.Context, filter dto.AuditFilter) (*dto.AuditLogPage, error) {
+	if err := checkAuditFilter(&filter); err != nil {
+		return nil, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = auditPageSize
+	}
+	if filter.Limit > auditMaxPageSize {
+		filter.Limit = auditMaxPageSize
+	}
+	ls, total, err := s.repo.Audit().GetAuditLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := &dto.AuditLogPage{Items: make([]*dto.AuditLogResponse, 0, len(ls)), Total: total}
+	for i := range ls {
+		out.Items = append(out.Items, auditLogToDTO(&ls[i]))
+	}
+	return out, nil
+}
+
+// ExportAuditLog returns all matching entries, newest first, up to the
+// export limit. Limit and offset of the filter are ignored.
+func (s *services) ExportAuditLog(ctx context.Context, filter dto.AuditFilter) ([]*dto.AuditLogResponse, error) {
+	filter.Limit, filter.Offset = 0, 0
+	if err := checkAuditFilter(&filter); err != nil {
+		return nil, err
+	}
+	filter.Limit = auditExportLimit
+	ls, _, err := s.repo.Audit().GetAuditLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.AuditLogResponse, 0, len(ls))
+	for i := range ls {
+		out = append(out, auditLogToDTO(&ls[i]))
+	}
+	return out, nil
+}
+
+// PurgeAuditLog deletes the entries of every organization that are older
+// than the retention.
+func (s *services) PurgeAuditLog(ctx context.Context, now time.Time) (int64, error) {
+	if s.opts.Audit.Retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.Audit().DeleteAuditLogsBefore(tenant.System(ctx), now.Add(-s.opts.Audit.Retention))
+}
+
+// RunAuditRetention calls PurgeAuditLog each interval until ctx is
+// cancelled.
+func (s *services) RunAuditRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := s.PurgeAuditLog(ctx, time.Now())
+		if err != nil {
+			s.logger.Error().Err(err).Msg("audit log purge failed")
+		} else if n > 0 {
+			s.logger.Info().Int64("deleted", n).Msg("purged expired audit log entries")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// userSnapshot is what the audit log keeps of a user.
+func userSnapshot(u *models.User) dto.UserResponse { return userToDTO(u) }
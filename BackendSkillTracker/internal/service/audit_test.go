@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/audit"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditFixture(opts Options) (*MockUserRepo, *MockAuditRepo, ServiceInterface) {
+	users := new(MockUserRepo)
+	logs := acceptingAuditRepo()
+	mockRepo := new(MockRepo)
+	mockRepo.On("User").Return(users)
+	mockRepo.On("Audit").Return(logs)
+	return users, logs, New(mockRepo, zerolog.Nop(), testKeys, opts)
+}
+
+func TestAudit_RecordsRequestActorAndSnapshots(t *testing.T) {
+	users, logs, s := newAuditFixture(Options{})
+	ctx := tenant.WithOrg(context.Background(), 2)
+	ctx = audit.WithRequest(ctx, audit.Request{ID: "req-1", IP: "10.0.0.1", UserAgent: "curl/8"})
+	ctx = audit.WithActor(ctx, audit.Actor{UserID: 1, Username: "boss", ImpersonatorID: 9})
+	users.On("GetUserByID", ctx, 5).
+		Return(&models.User{ID: 5, OrgID: 2, Username: "alice", Role: "employee", AuthSource: models.AuthLocal}, nil)
+	users.On("UpdateUser", ctx, mock.Anything).Return(nil)
+	users.On("BumpTokenVersion", ctx, 5).Return(nil)
+
+	require.NoError(t, s.User().UpdateUser(ctx, 5, &dto.UserRequest{Role: "manager"}, 1, "manager", true))
+
+	require.Len(t, logs.created(), 1)
+	l := logs.created()[0]
+	assert.Equal(t, "user.updated", l.Action)
+	assert.Equal(t, 1, *l.ActorID)
+	assert.Equal(t, "boss", l.ActorName)
+	assert.Equal(t, 9, *l.ImpersonatorID)
+	assert.Equal(t, "user", l.TargetType)
+	assert.Equal(t, 5, *l.TargetID)
+	assert.Equal(t, "10.0.0.1", l.IP)
+	assert.Equal(t, "curl/8", l.UserAgent)
+	assert.Equal(t, "req-1", l.RequestID)
+	assert.Contains(t, *l.Before, `"role":"employee"`)
+	assert.Contains(t, *l.After, `"role":"manager"`)
+	assert.NotContains(t, *l.After, "password_changed")
+}
+
+func TestAudit_FailedLoginKeepsUsername(t *testing.T) {
+	users := new(MockUserRepo)
+	logs := acceptingAuditRepo()
+	mockRepo := new(MockRepo)
+	mockRepo.On("Organization").Return(defaultOrgRepo())
+	mockRepo.On("User").Return(users)
+	mockRepo.On("Audit").Return(logs)
+	users.On("GetUserByUsername", mock.Anything, "ghost").Return(nil, assert.AnError)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+	_, err := s.User().Login(context.Background(), &dto.LoginRequest{Username: "ghost", Password: "x"}, dto.ClientInfo{})
+	assert.EqualError(t, err, "invalid credentials")
+
+	require.Len(t, logs.created(), 1)
+	l := logs.created()[0]
+	assert.Equal(t, "login.failed", l.Action)
+	assert.Nil(t, l.ActorID)
+	assert.Equal(t, "ghost", l.ActorName)
+}
+
+func TestAudit_NothingOutsideAnOrganization(t *testing.T) {
+	_, logs, s := newAuditFixture(Options{})
+	s.(*services).audit(context.Background(), auditEntry{Action: "user.created"})
+	logs.AssertNotCalled(t, "CreateAuditLog", mock.Anything, mock.Anything)
+}
+
+func TestGetAuditLog(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 2)
+
+	t.Run("pages are bounded", func(t *testing.T) {
+		_, logs, s := newAuditFixture(Options{})
+		logs.On("GetAuditLogs", ctx, mock.Anything).Return([]models.AuditLog{}, int64(0), nil)
+		_, err := s.Audit().GetAuditLog(ctx, dto.AuditFilter{})
+		require.NoError(t, err)
+		_, err = s.Audit().GetAuditLog(ctx, dto.AuditFilter{Limit: 10000})
+		require.NoError(t, err)
+
+		logs.AssertCalled(t, "GetAuditLogs", ctx, mock.MatchedBy(func(f dto.AuditFilter) bool { return f.Limit == auditPageSize }))
+		logs.AssertCalled(t, "GetAuditLogs", ctx, mock.MatchedBy(func(f dto.AuditFilter) bool { return f.Limit == auditMaxPageSize }))
+	})
+
+	t.Run("snapshots are returned as JSON", func(t *testing.T) {
+		_, logs, s := newAuditFixture(Options{})
+		s.(*services).audit(ctx, auditEntry{Action: "role.created", After: dto.RoleResponse{Name: "lead"}})
+		logs.On("GetAuditLogs", ctx, mock.Anything).Return(logs.created(), int64(1), nil)
+		page, err := s.Audit().GetAuditLog(ctx, dto.AuditFilter{})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.EqualValues(t, 1, page.Total)
+		assert.Contains(t, string(page.Items[0].After), `"name":"lead"`)
+		assert.Nil(t, page.Items[0].Before)
+	})
+
+	t.Run("dates are checked", func(t *testing.T) {
+		_, _, s := newAuditFixture(Options{})
+		_, err := s.Audit().GetAuditLog(ctx, dto.AuditFilter{From: "yesterday"})
+		assert.EqualError(t, err, "invalid date")
+		_, err = s.Audit().ExportAuditLog(ctx, dto.AuditFilter{To: "2026-13-01"})
+		assert.EqualError(t, err, "invalid date")
+	})
+
+	t.Run("export ignores the page", func(t *testing.T) {
+		_, logs, s := newAuditFixture(Options{})
+		logs.On("GetAuditLogs", ctx, mock.Anything).Return([]models.AuditLog{}, int64(0), nil)
+		_, err := s.Audit().ExportAuditLog(ctx, dto.AuditFilter{Limit: 5, Offset: 10})
+		require.NoError(t, err)
+		logs.AssertCalled(t, "GetAuditLogs", ctx, mock.MatchedBy(func(f dto.AuditFilter) bool {
+			return f.Limit == auditExportLimit && f.Offset == 0
+		}))
+	})
+}
+
+func TestPurgeAuditLog(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	_, logs, s := newAuditFixture(Options{Audit: AuditPolicy{Retention: 30 * 24 * time.Hour}})
+	logs.On("DeleteAuditLogsBefore", mock.Anything, now.AddDate(0, 0, -30)).Return(int64(3), nil)
+	n, err := s.Audit().PurgeAuditLog(context.Background(), now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+	logs.AssertExpectations(t)
+
+	_, logs, s = newAuditFixture(Options{})
+	_, err = s.Audit().PurgeAuditLog(context.Background(), now)
+	require.NoError(t, err)
+	logs.AssertNotCalled(t, "DeleteAuditLogsBefore", mock.Anything, mock.Anything)
+}
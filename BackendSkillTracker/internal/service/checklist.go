@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"time"
+)
+
+// CHECKLISTS
+
+func (s *services) Checklist() ChecklistService { return s }
+
+func checklistItemToDTO(item *models.ChecklistItem) *dto.ChecklistItemResponse {
+	return &dto.ChecklistItemResponse{
+		ID:        item.ID,
+		TaskID:    item.TaskID,
+		Position:  item.Position,
+		Text:      item.Text,
+		Done:      item.Done,
+		DoneBy:    item.DoneBy,
+		DoneAt:    item.DoneAt,
+		CreatedAt: item.CreatedAt,
+	}
+}
+
+func checklistToDTO(items []models.ChecklistItem) []dto.ChecklistItemResponse {
+	out := make([]dto.ChecklistItemResponse, 0, len(items))
+	for i := range items {
+		out = append(out, *checklistItemToDTO(&items[i]))
+	}
+	return out
+}
+
+// checklistProgress returns the share of completed items in percent.
+func checklistProgress(items []models.ChecklistItem) int {
+	if len(items) == 0 {
+		return 0
+	}
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return done * 100 / len(items)
+}
+
+// nextChecklistPosition returns the position after the last item, so new
+// items go to the end even after deletions and reorders.
+func nextChecklistPosition(items []models.ChecklistItem) int {
+	next := 0
+	for _, item := range items {
+		if item.Position >= next {
+			next = item.Position + 1
+		}
+	}
+	return next
+}
+
+// getTaskAsParticipant loads the task and checks that userID is its creator
+// or one of its assignees.
+func (s *services) getTaskAsParticipant(ctx context.Context, taskID int, userID int) (*models.Task, error) {
+	t, err := s.repo.Task().GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, errors.New("task not found")
+	}
+	if !canEditTask(t, userID) {
+		return nil, errors.New("forbidden")
+	}
+	return t, nil
+}
+
+func (s *services) getChecklistItem(ctx context.Context, taskID int, itemID int) (*models.ChecklistItem, error) {
+	item, err := s.repo.Checklist().GetChecklistItemByID(ctx, itemID)
+	if err != nil || item.TaskID != taskID {
+		return nil, errors.New("checklist item not found")
+	}
+	return item, nil
+}
+
+// afterChecklistChange records the change in the task history and
+// recalculates the task progress from the current checklist. Removing the
+// last item resets the progress, which is then set by hand again.
+func (s *services) afterChecklistChange(ctx context.Context, t *models.Task, userID int, note string) error {
+	h := &models.TaskStatusHistory{
+		TaskID:    t.ID,
+		Event:     models.HistoryChecklist,
+		OldStatus: t.Status,
+		NewStatus: t.Status,
+		Note:      note,
+		ChangedBy: userID,
+	}
+	if err := s.repo.Task().CreateHistory(ctx, h); err != nil {
+		s.logger.Error().Err(err).Msg("failed to record checklist history")
+	}
+
+	items, err := s.repo.Checklist().GetChecklistByTaskID(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	progress := checklistProgress(items)
+	if progress == t.Progress {
+		return nil
+	}
+	if err := s.repo.Task().SetTaskProgress(ctx, t.ID, progress); err != nil {
+		return err
+	}
+	t.Progress = progress
+	t.Checklist = items
+	return nil
+}
+
+func (s *services) GetChecklist(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.ChecklistItemResponse, error) {
+	if _, err := s.getReadableTask(ctx, taskID, userID, role, allTeams); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.Checklist().GetChecklistByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.ChecklistItemResponse, 0, len(items))
+	for i := range items {
+		out = append(out, checklistItemToDTO(&items[i]))
+	}
+	return out, nil
+}
+
+func (s *services) AddChecklistItem(ctx context.Context, taskID int, userID int, text string) (*dto.ChecklistItemResponse, error) {
+	t, err := s.getTaskAsParticipant(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	item := &models.ChecklistItem{TaskID: taskID, Position: nextChecklistPosition(t.Checklist), Text: text}
+	if err := s.repo.Checklist().CreateChecklistItem(ctx, item); err != nil {
+		return nil, err
+	}
+	if err := s.afterChecklistChange(ctx, t, userID, "added: "+text); err != nil {
+		return nil, err
+	}
+	return checklistItemToDTO(item), nil
+}
+
+func (s *services) UpdateChecklistItem(ctx context.Context, taskID int, itemID int, userID int, text string) error {
+	t, err := s.getTaskAsParticipant(ctx, taskID, userID)
+	if err != nil {
+		return err
+	}
+	item, err := s.getChecklistItem(ctx, taskID, itemID)
+	if err != nil {
+		return err
+	}
+	old := item.Text
+	item.Text = text
+	if err := s.repo.Checklist().UpdateChecklistItem(ctx, item); err != nil {
+		return err
+	}
+	return s.afterChecklistChange(ctx, t, userID, "renamed: "+old+" -> "+text)
+}
+
+func (s *services) SetChecklistItemDone(ctx context.Context, taskID int, itemID int, userID int, done bool) error {
+	t, err := s.getTaskAsParticipant(ctx, taskID, userID)
+	if err != nil {
+		return err
+	}
+	item, err := s.getChecklistItem(ctx, taskID, itemID)
+	if err != nil {
+		return err
+	}
+	if item.Done == done {
+		return nil
+	}
+
+	item.Done = done
+	note := "unchecked: " + item.Text
+	if done {
+		now := time.Now()
+		item.DoneBy = &userID
+		item.DoneAt = &now
+		note = "checked: " + item.Text
+	} else {
+		item.DoneBy = nil
+		item.DoneAt = nil
+	}
+	if err := s.repo.Checklist().UpdateChecklistItem(ctx, item); err != nil {
+		return err
+	}
+	return s.afterChecklistChange(ctx, t, userID, note)
+}
+
+func (s *services) DeleteChecklistItem(ctx context.Context, taskID int, itemID int, userID int) error {
+	t, err := s.getTaskAsParticipant(ctx, taskID, userID)
+	if err != nil {
+		return err
+	}
+	item, err := s.getChecklistItem(ctx, taskID, itemID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Checklist().DeleteChecklistItem(ctx, itemID); err != nil {
+		return err
+	}
+	return s.afterChecklistChange(ctx, t, userID, "removed: "+item.Text)
+}
+
+// ReorderChecklist expects itemIDs to list every item of the task exactly once.
+func (s *services) ReorderChecklist(ctx context.Context, taskID int, userID int, itemIDs []int) error {
+	t, err := s.getTaskAsParticipant(ctx, taskID, userID)
+	if err != nil {
+		return err
+	}
+	if len(itemIDs) != len(t.Checklist) {
+		return errors.New("invalid item order")
+	}
+	existing := make(map[int]bool, len(t.Checklist))
+	for _, item := range t.Checklist {
+		existing[item.ID] = true
+	}
+	for _, id := range itemIDs {
+		if !existing[id] {
+			return errors.New("invalid item order")
+		}
+		delete(existing, id)
+	}
+	if err := s.repo.Checklist().ReorderChecklist(ctx, taskID, itemIDs); err != nil {
+		return err
+	}
+	return s.afterChecklistChange(ctx, t, userID, "reordered")
+}
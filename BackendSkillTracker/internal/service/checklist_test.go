@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChecklistService_SetChecklistItemDone(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("success - progress derived from checklist", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockChecklistRepo := new(MockChecklistRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		task := &models.Task{ID: 1, CreatorID: 2, EmployeeID: 3, Status: models.StatusInProgress}
+		item := &models.ChecklistItem{ID: 10, TaskID: 1, Text: "Collect data"}
+		after := []models.ChecklistItem{
+			{ID: 10, TaskID: 1, Done: true},
+			{ID: 11, TaskID: 1},
+			{ID: 12, TaskID: 1},
+			{ID: 13, TaskID: 1, Done: true},
+		}
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Checklist").Return(mockChecklistRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+		mockChecklistRepo.On("GetChecklistItemByID", ctx, 10).Return(item, nil)
+		mockChecklistRepo.On("UpdateChecklistItem", ctx, mock.MatchedBy(func(i *models.ChecklistItem) bool {
+			return i.Done && *i.DoneBy == 3 && i.DoneAt != nil
+		})).Return(nil)
+		mockTaskRepo.On("CreateHistory", ctx, mock.MatchedBy(func(h *models.TaskStatusHistory) bool {
+			return h.Event == models.HistoryChecklist && h.Note == "checked: Collect data"
+		})).Return(nil)
+		mockChecklistRepo.On("GetChecklistByTaskID", ctx, 1).Return(after, nil)
+		mockTaskRepo.On("SetTaskProgress", ctx, 1, 50).Return(nil)
+
+		err := s.Checklist().SetChecklistItemDone(ctx, 1, 10, 3, true)
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+		mockChecklistRepo.AssertExpectations(t)
+	})
+
+	t.Run("item of another task", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockChecklistRepo := new(MockChecklistRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Checklist").Return(mockChecklistRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(&models.Task{ID: 1, CreatorID: 2}, nil)
+		mockChecklistRepo.On("GetChecklistItemByID", ctx, 10).Return(&models.ChecklistItem{ID: 10, TaskID: 7}, nil)
+
+		err := s.Checklist().SetChecklistItemDone(ctx, 1, 10, 2, true)
+
+		assert.Error(t, err)
+		assert.Equal(t, "checklist item not found", err.Error())
+	})
+}
+
+func TestChecklistService_ReorderChecklist(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+	ctx := context.Background()
+
+	task := &models.Task{ID: 1, CreatorID: 2, Checklist: []models.ChecklistItem{{ID: 10}, {ID: 11}}}
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+
+	err := s.Checklist().ReorderChecklist(ctx, 1, 2, []int{11, 11})
+
+	assert.Error(t, err)
+	assert.Equal(t, "invalid item order", err.Error())
+}
+
+func TestChecklistService_DeleteLastItemResetsProgress(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockChecklistRepo := new(MockChecklistRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+	task := &models.Task{ID: 1, CreatorID: 2, EmployeeID: 3, Status: models.StatusInProgress, Progress: 100}
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("Checklist").Return(mockChecklistRepo)
+	mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+	mockChecklistRepo.On("GetChecklistItemByID", ctx, 10).Return(&models.ChecklistItem{ID: 10, TaskID: 1, Text: "Only step", Done: true}, nil)
+	mockChecklistRepo.On("DeleteChecklistItem", ctx, 10).Return(nil)
+	mockTaskRepo.On("CreateHistory", ctx, mock.Anything).Return(nil)
+	mockChecklistRepo.On("GetChecklistByTaskID", ctx, 1).Return([]models.ChecklistItem{}, nil)
+	mockTaskRepo.On("SetTaskProgress", ctx, 1, 0).Return(nil)
+
+	err := s.Checklist().DeleteChecklistItem(ctx, 1, 10, 3)
+
+	assert.NoError(t, err)
+	mockTaskRepo.AssertExpectations(t)
+}
+
+func TestChecklistService_AddChecklistItemGoesLast(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockChecklistRepo := new(MockChecklistRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+	// Item 11 at position 1 was deleted; a new item must not reuse position 2.
+	task := &models.Task{ID: 1, CreatorID: 2, Checklist: []models.ChecklistItem{
+		{ID: 10, Position: 0}, {ID: 12, Position: 2},
+	}}
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("Checklist").Return(mockChecklistRepo)
+	mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+	mockChecklistRepo.On("CreateChecklistItem", ctx, mock.MatchedBy(func(i *models.ChecklistItem) bool {
+		return i.Position == 3
+	})).Return(nil)
+	mockTaskRepo.On("CreateHistory", ctx, mock.Anything).Return(nil)
+	mockChecklistRepo.On("GetChecklistByTaskID", ctx, 1).Return([]models.ChecklistItem{
+		{ID: 10, Position: 0}, {ID: 12, Position: 2}, {ID: 13, Position: 3},
+	}, nil)
+
+	_, err := s.Checklist().AddChecklistItem(ctx, 1, 2, "Review")
+
+	assert.NoError(t, err)
+	mockChecklistRepo.AssertExpectations(t)
+	mockTaskRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+}
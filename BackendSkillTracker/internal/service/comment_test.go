@@ -14,16 +14,19 @@ func TestCommentService_CreateComment(t *testing.T) {
 	mockRepo := new(MockRepo)
 	mockCommentRepo := new(MockCommentRepo)
 	logger := zerolog.Nop()
-	s := New(mockRepo, logger, []byte("secret"))
+	s := New(mockRepo, logger, testKeys, Options{})
 	ctx := context.Background()
 
 	t.Run("success", func(t *testing.T) {
+		mockTaskRepo := new(MockTaskRepo)
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(&models.Task{ID: 1, CreatorID: 5, EmployeeID: 2}, nil)
 		mockRepo.On("Comment").Return(mockCommentRepo)
 		mockCommentRepo.On("CreateComment", ctx, mock.MatchedBy(func(c *models.Comment) bool {
 			return c.Text == "test comment" && c.TaskID == 1
 		})).Return(nil)
 
-		res, err := s.Comment().CreateComment(ctx, 1, 2, "test comment")
+		res, err := s.Comment().CreateComment(ctx, 1, 2, "employee", false, "test comment")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, res)
@@ -36,7 +39,7 @@ func TestCommentService_DeleteComment(t *testing.T) {
 	mockRepo := new(MockRepo)
 	mockCommentRepo := new(MockCommentRepo)
 	logger := zerolog.Nop()
-	s := New(mockRepo, logger, []byte("secret"))
+	s := New(mockRepo, logger, testKeys, Options{})
 	ctx := context.Background()
 
 	t.Run("success", func(t *testing.T) {
@@ -45,7 +48,7 @@ func TestCommentService_DeleteComment(t *testing.T) {
 		mockCommentRepo.On("GetCommentByID", ctx, 1).Return(comment, nil)
 		mockCommentRepo.On("DeleteComment", ctx, 1).Return(nil)
 
-		err := s.Comment().DeleteComment(ctx, 1, 2)
+		err := s.Comment().DeleteComment(ctx, 1, 2, "employee")
 		assert.NoError(t, err)
 	})
 
@@ -54,7 +57,7 @@ func TestCommentService_DeleteComment(t *testing.T) {
 		mockRepo.On("Comment").Return(mockCommentRepo)
 		mockCommentRepo.On("GetCommentByID", ctx, 1).Return(comment, nil)
 
-		err := s.Comment().DeleteComment(ctx, 1, 3)
+		err := s.Comment().DeleteComment(ctx, 1, 3, "employee")
 		assert.Error(t, err)
 		assert.Equal(t, "forbidden", err.Error())
 	})
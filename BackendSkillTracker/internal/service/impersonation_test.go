@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+	"skilltracker/internal/tenant"
+	jwtutil "skilltracker/internal/utils/jwt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newImpersonationFixture knows manager 1 and 6 and employee 5 of org 2.
+func newImpersonationFixture() ServiceInterface {
+	users := new(MockUserRepo)
+	mockRepo := new(MockRepo)
+	mockRepo.On("User").Return(users)
+	mockRepo.On("Audit").Return(acceptingAuditRepo())
+	users.On("GetUserByID", mock.Anything, 1).
+		Return(&models.User{ID: 1, OrgID: 2, Username: "boss", Role: "manager", TokenVersion: 4}, nil)
+	users.On("GetUserByID", mock.Anything, 5).
+		Return(&models.User{ID: 5, OrgID: 2, Username: "alice", Role: "employee", TokenVersion: 3}, nil)
+	users.On("GetUserByID", mock.Anything, 6).
+		Return(&models.User{ID: 6, OrgID: 2, Username: "carol", Role: "manager"}, nil)
+	users.On("GetUserByID", mock.Anything, mock.Anything).Return(nil, errors.New("record not found"))
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{Impersonation: ImpersonationPolicy{TTL: 10 * time.Minute}})
+	return s
+}
+
+func TestImpersonate(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 2)
+
+	t.Run("token acts as the user and names the manager", func(t *testing.T) {
+		s := newImpersonationFixture()
+		res, err := s.Impersonation().Impersonate(ctx, 1, 5, true, "ticket 42")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", res.User.Username)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), res.ExpiresAt, 5*time.Second)
+
+		claims, err := testKeys.ValidateToken(res.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, 5, claims.UserID)
+		assert.Equal(t, 2, claims.OrgID)
+		assert.Equal(t, 3, claims.Version)
+		assert.Equal(t, "employee", claims.Role)
+		assert.Equal(t, 0, claims.SessionID)
+		assert.Equal(t, &jwtutil.Actor{UserID: 1, Username: "boss", Version: 4}, claims.Act)
+	})
+
+	t.Run("users who can impersonate can't be impersonated", func(t *testing.T) {
+		s := newImpersonationFixture()
+		_, err := s.Impersonation().Impersonate(ctx, 1, 6, true, "ticket 42")
+		assert.EqualError(t, err, "user cannot be impersonated")
+	})
+
+	t.Run("nor users with permissions the manager lacks", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		users := new(MockUserRepo)
+		roles := new(MockRoleRepo)
+		teams := new(MockTeamRepo)
+		mockRepo.On("User").Return(users)
+		mockRepo.On("Role").Return(roles)
+		mockRepo.On("Team").Return(teams)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+		supportID, team := 7, 3
+		roles.On("GetRoleByName", ctx, "support").Return(&models.RoleDefinition{ID: 4, Name: "support", Permissions: []models.RolePermission{
+			{RoleID: 4, Permission: string(permission.UserImpersonate)},
+			{RoleID: 4, Permission: string(permission.UserRead)},
+		}}, nil)
+		roles.On("GetRoleByName", ctx, "lead").Return(&models.RoleDefinition{ID: 5, Name: "lead", Permissions: []models.RolePermission{
+			{RoleID: 5, Permission: string(permission.TaskCreate)},
+		}}, nil)
+		teams.On("GetTeams", ctx).Return([]models.Team{{ID: team, ManagerID: &supportID}}, nil)
+		users.On("GetUserByID", ctx, supportID).Return(&models.User{ID: supportID, OrgID: 2, Username: "sam", Role: "support"}, nil)
+		users.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, OrgID: 2, Username: "alice", Role: "lead", TeamID: &team}, nil)
+
+		_, err := s.Impersonation().Impersonate(ctx, supportID, 5, false, "ticket 42")
+
+		assert.EqualError(t, err, "user cannot be impersonated")
+	})
+
+	t.Run("not oneself or unknown users", func(t *testing.T) {
+		s := newImpersonationFixture()
+		_, err := s.Impersonation().Impersonate(ctx, 1, 1, true, "ticket 42")
+		assert.EqualError(t, err, "cannot impersonate yourself")
+		_, err = s.Impersonation().Impersonate(ctx, 1, 99, true, "ticket 42")
+		assert.EqualError(t, err, "user not found")
+	})
+}
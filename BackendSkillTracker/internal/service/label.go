@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"sort"
+	"strings"
+)
+
+// LABELS
+
+const defaultLabelColor = "#808080"
+
+func (s *services) Label() LabelService { return s }
+
+func labelToDTO(l *models.Label) *dto.LabelResponse {
+	return &dto.LabelResponse{ID: l.ID, Name: l.Name, Color: l.Color, CreatedAt: l.CreatedAt}
+}
+
+func labelsToDTO(labels []models.Label) []dto.LabelResponse {
+	out := make([]dto.LabelResponse, 0, len(labels))
+	for i := range labels {
+		out = append(out, *labelToDTO(&labels[i]))
+	}
+	return out
+}
+
+// applyLabelRequest normalizes the name to lower case; commas are reserved
+// as the separator of tag filters.
+func applyLabelRequest(l *models.Label, req *dto.LabelRequest) error {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if name == "" || strings.Contains(name, ",") {
+		return errors.New("invalid label name")
+	}
+	l.Name = name
+	l.Color = strings.ToLower(req.Color)
+	if l.Color == "" {
+		l.Color = defaultLabelColor
+	}
+	return nil
+}
+
+func (s *services) CreateLabel(ctx context.Context, req *dto.LabelRequest) (*dto.LabelResponse, error) {
+	l := &models.Label{}
+	if err := applyLabelRequest(l, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Label().CreateLabel(ctx, l); err != nil {
+		return nil, err
+	}
+	return labelToDTO(l), nil
+}
+
+func (s *services) GetLabels(ctx context.Context) ([]*dto.LabelResponse, error) {
+	ls, err := s.repo.Label().GetLabels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.LabelResponse, 0, len(ls))
+	for i := range ls {
+		out = append(out, labelToDTO(&ls[i]))
+	}
+	return out, nil
+}
+
+func (s *services) UpdateLabel(ctx context.Context, id int, req *dto.LabelRequest) error {
+	l, err := s.repo.Label().GetLabelByID(ctx, id)
+	if err != nil {
+		return errors.New("label not found")
+	}
+	if err := applyLabelRequest(l, req); err != nil {
+		return err
+	}
+	return s.repo.Label().UpdateLabel(ctx, l)
+}
+
+func (s *services) DeleteLabel(ctx context.Context, id int) error {
+	if _, err := s.repo.Label().GetLabelByID(ctx, id); err != nil {
+		return errors.New("label not found")
+	}
+	return s.repo.Label().DeleteLabel(ctx, id)
+}
+
+func (s *services) AddLabelToTask(ctx context.Context, taskID int, labelID int, userID int) error {
+	if _, err := s.getTaskAsParticipant(ctx, taskID, userID); err != nil {
+		return err
+	}
+	if _, err := s.repo.Label().GetLabelByID(ctx, labelID); err != nil {
+		return errors.New("label not found")
+	}
+	return s.repo.Label().AddLabelToTask(ctx, taskID, labelID)
+}
+
+func (s *services) RemoveLabelFromTask(ctx context.Context, taskID int, labelID int, userID int) error {
+	if _, err := s.getTaskAsParticipant(ctx, taskID, userID); err != nil {
+		return err
+	}
+	return s.repo.Label().RemoveLabelFromTask(ctx, taskID, labelID)
+}
+
+// GetLabelUsage returns task counts per label, most used first. Unused
+// labels are included with zero counts.
+func (s *services) GetLabelUsage(ctx context.Context) ([]*dto.LabelUsageResponse, error) {
+	ls, err := s.repo.Label().GetLabels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.repo.Label().GetLabelUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*dto.LabelUsageResponse, len(ls))
+	out := make([]*dto.LabelUsageResponse, 0, len(ls))
+	for _, l := range ls {
+		r := &dto.LabelUsageResponse{LabelID: l.ID, Name: l.Name, Color: l.Color}
+		byID[l.ID] = r
+		out = append(out, r)
+	}
+	for _, u := range usage {
+		r, ok := byID[u.LabelID]
+		if !ok {
+			continue
+		}
+		r.Total += u.Count
+		switch u.Status {
+		case models.StatusPending:
+			r.Pending += u.Count
+		case models.StatusInProgress:
+			r.InProgress += u.Count
+		case models.StatusCompleted:
+			r.Completed += u.Count
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Total > out[j].Total })
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLabelService_CreateLabel(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("success - name normalized, default colour", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockLabelRepo := new(MockLabelRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Label").Return(mockLabelRepo)
+		mockLabelRepo.On("CreateLabel", ctx, mock.MatchedBy(func(l *models.Label) bool {
+			return l.Name == "tech-debt" && l.Color == "#808080"
+		})).Return(nil)
+
+		res, err := s.Label().CreateLabel(ctx, &dto.LabelRequest{Name: " Tech-Debt "})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "tech-debt", res.Name)
+	})
+
+	t.Run("comma in name", func(t *testing.T) {
+		s := New(new(MockRepo), logger, testKeys, Options{})
+
+		_, err := s.Label().CreateLabel(ctx, &dto.LabelRequest{Name: "q4,q1"})
+
+		assert.Error(t, err)
+		assert.Equal(t, "invalid label name", err.Error())
+	})
+}
+
+func TestLabelService_GetLabelUsage(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockLabelRepo := new(MockLabelRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	mockRepo.On("Label").Return(mockLabelRepo)
+	mockLabelRepo.On("GetLabels", ctx).Return([]models.Label{
+		{ID: 1, Name: "client-x", Color: "#ff0000"},
+		{ID: 2, Name: "q4", Color: "#00ff00"},
+	}, nil)
+	mockLabelRepo.On("GetLabelUsage", ctx).Return([]models.LabelUsage{
+		{LabelID: 2, Status: models.StatusPending, Count: 3},
+		{LabelID: 2, Status: models.StatusCompleted, Count: 1},
+	}, nil)
+
+	res, err := s.Label().GetLabelUsage(ctx)
+
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, dto.LabelUsageResponse{LabelID: 2, Name: "q4", Color: "#00ff00", Total: 4, Pending: 3, Completed: 1}, *res[0])
+	assert.Equal(t, 0, res[1].Total)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"skilltracker/internal/models"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPGroupRole maps members of a directory group to a role. Group is the
+// DN of the group or its CN.
+type LDAPGroupRole struct {
+	Group string
+	Role  models.Role
+}
+
+// LDAPConfig configures password checks against a directory such as
+// OpenLDAP or Active Directory: the user entry is looked up with the
+// service account, then the password is checked by binding as that entry.
+type LDAPConfig struct {
+	// URL is ldap://host[:port] or ldaps://host[:port].
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+	// BindDN and BindPassword are the service account for searches; empty
+	// for anonymous searches.
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds the user entry; %s is the escaped username, e.g.
+	// "(uid=%s)" or "(sAMAccountName=%s)".
+	UserFilter        string
+	UsernameAttribute string
+	NameAttribute     string
+	// GroupAttribute lists the groups of a user entry, like memberOf.
+	GroupAttribute string
+	// GroupFilter, if set, also searches groups under GroupBaseDN; %s is
+	// the escaped user DN, e.g. "(member=%s)".
+	GroupFilter string
+	GroupBaseDN string
+	// GroupRoles are tried in order; the first group the user is in gives
+	// the role. Users in none get DefaultRole, or can't log in without it.
+	GroupRoles  []LDAPGroupRole
+	DefaultRole models.Role
+	// Organization is the slug of the organization whose users are in the
+	// directory; empty for all.
+	Organization string
+}
+
+// ldapConn is what LDAPProvider needs from a directory connection.
+type ldapConn interface {
+	Bind(dn, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// LDAPProvider is an AuthProvider backed by a directory. Users are
+// provisioned at their first login.
+type LDAPProvider struct {
+	cfg  LDAPConfig
+	dial func() (ldapConn, error)
+}
+
+func NewLDAPProvider(cfg LDAPConfig) *LDAPProvider {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(uid=%s)"
+	}
+	if cfg.UsernameAttribute == "" {
+		cfg.UsernameAttribute = "uid"
+	}
+	if cfg.NameAttribute == "" {
+		cfg.NameAttribute = "cn"
+	}
+	if cfg.GroupAttribute == "" {
+		cfg.GroupAttribute = "memberOf"
+	}
+	if cfg.GroupBaseDN == "" {
+		cfg.GroupBaseDN = cfg.BaseDN
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	p := &LDAPProvider{cfg: cfg}
+	p.dial = p.dialDirectory
+	return p
+}
+
+func (p *LDAPProvider) dialDirectory() (ldapConn, error) {
+	u, err := url.Parse(p.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid url: %w", err)
+	}
+	// StartTLS doesn't know the host, so the certificate is checked
+	// against the one of the URL.
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: p.cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(p.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: p.cfg.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(p.cfg.Timeout)
+	if p.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (p *LDAPProvider) Name() string { return "ldap" }
+
+func (p *LDAPProvider) Authenticate(ctx context.Context, org, username, password string) (*Identity, error) {
+	if p.cfg.Organization != "" && p.cfg.Organization != org {
+		return nil, nil
+	}
+	if username == "" || password == "" {
+		return nil, nil
+	}
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if p.cfg.BindDN != "" {
+		if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("service account bind: %w", err)
+		}
+	}
+
+	res, err := conn.Search(p.searchRequest(p.cfg.BaseDN,
+		fmt.Sprintf(p.cfg.UserFilter, ldap.EscapeFilter(username)), 2,
+		p.cfg.UsernameAttribute, p.cfg.NameAttribute, p.cfg.GroupAttribute))
+	if err != nil {
+		return nil, err
+	}
+	entries := res.Entries
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	if len(entries) > 1 {
+		return nil, fmt.Errorf("username %q matches %d entries", username, len(entries))
+	}
+	entry := entries[0]
+	groups := entry.GetEqualFoldAttributeValues(p.cfg.GroupAttribute)
+	if p.cfg.GroupFilter != "" {
+		found, err := conn.Search(p.searchRequest(p.cfg.GroupBaseDN,
+			fmt.Sprintf(p.cfg.GroupFilter, ldap.EscapeFilter(entry.DN)), 0, "cn"))
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range found.Entries {
+			groups = append(groups, g.DN)
+		}
+	}
+
+	// Binding as the entry is the password check.
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	role := p.role(groups)
+	if role == "" {
+		return nil, fmt.Errorf("%w: no group of %s maps to a role", ErrInvalidCredentials, entry.DN)
+	}
+	name := entry.GetEqualFoldAttributeValue(p.cfg.UsernameAttribute)
+	if name == "" {
+		name = username
+	}
+	return &Identity{Username: normalizeUsername(name), Name: entry.GetEqualFoldAttributeValue(p.cfg.NameAttribute), Role: role}, nil
+}
+
+// searchRequest searches the subtree of baseDN; sizeLimit 0 is the
+// server's limit. Aliases are never dereferenced.
+func (p *LDAPProvider) searchRequest(baseDN, filter string, sizeLimit int, attrs ...string) *ldap.SearchRequest {
+	return ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		sizeLimit, int(p.cfg.Timeout/time.Second), false, filter, attrs, nil)
+}
+
+func (p *LDAPProvider) role(groups []string) models.Role {
+	for _, m := range p.cfg.GroupRoles {
+		for _, g := range groups {
+			if strings.EqualFold(g, m.Group) || strings.EqualFold(groupCN(g), m.Group) {
+				return m.Role
+			}
+		}
+	}
+	return p.cfg.DefaultRole
+}
+
+// groupCN returns the CN of a group DN like "CN=Managers,OU=Groups,...".
+func groupCN(dn string) string {
+	rdn, _, _ := strings.Cut(dn, ",")
+	attr, value, ok := strings.Cut(rdn, "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(attr), "cn") {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
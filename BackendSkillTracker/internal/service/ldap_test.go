@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/models"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDirectory answers searches by filter and binds by DN and password.
+type fakeDirectory struct {
+	results   map[string][]*ldap.Entry
+	passwords map[string]string
+	binds     []string
+	filters   []string
+}
+
+func (d *fakeDirectory) Bind(dn, password string) error {
+	d.binds = append(d.binds, dn)
+	if want, ok := d.passwords[dn]; ok && want == password {
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+}
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.filters = append(d.filters, req.Filter)
+	return &ldap.SearchResult{Entries: d.results[req.Filter]}, nil
+}
+
+func (d *fakeDirectory) Close() error { return nil }
+
+const aliceDN = "uid=alice,ou=people,dc=example,dc=com"
+
+func testDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		results: map[string][]*ldap.Entry{
+			"(uid=alice)": {ldap.NewEntry(aliceDN, map[string][]string{
+				"uid":      {"Alice"},
+				"cn":       {"Alice Liddell"},
+				"memberOf": {"cn=developers,ou=groups,dc=example,dc=com", "CN=Team Leads,OU=Groups,DC=example,DC=com"},
+			})},
+		},
+		passwords: map[string]string{
+			"cn=reader,dc=example,dc=com": "reader-secret",
+			aliceDN:                       "wonderland",
+		},
+	}
+}
+
+func ldapProvider(cfg LDAPConfig, dir *fakeDirectory) *LDAPProvider {
+	cfg.BaseDN = "dc=example,dc=com"
+	cfg.BindDN, cfg.BindPassword = "cn=reader,dc=example,dc=com", "reader-secret"
+	p := NewLDAPProvider(cfg)
+	p.dial = func() (ldapConn, error) { return dir, nil }
+	return p
+}
+
+func TestLDAPProvider(t *testing.T) {
+	ctx := context.Background()
+	roles := []LDAPGroupRole{{Group: "Team Leads", Role: models.RoleManager}, {Group: "cn=developers,ou=groups,dc=example,dc=com", Role: models.RoleEmployee}}
+
+	t.Run("bind as the user entry and map groups", func(t *testing.T) {
+		dir := testDirectory()
+		p := ldapProvider(LDAPConfig{GroupRoles: roles}, dir)
+
+		ident, err := p.Authenticate(ctx, "default", "alice", "wonderland")
+
+		require.NoError(t, err)
+		assert.Equal(t, &Identity{Username: "alice", Name: "Alice Liddell", Role: models.RoleManager}, ident)
+		assert.Equal(t, []string{"cn=reader,dc=example,dc=com", aliceDN}, dir.binds)
+	})
+
+	t.Run("first matching mapping wins", func(t *testing.T) {
+		p := ldapProvider(LDAPConfig{GroupRoles: []LDAPGroupRole{roles[1], roles[0]}}, testDirectory())
+
+		ident, err := p.Authenticate(ctx, "default", "alice", "wonderland")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleEmployee, ident.Role)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		p := ldapProvider(LDAPConfig{GroupRoles: roles}, testDirectory())
+
+		_, err := p.Authenticate(ctx, "default", "alice", "looking-glass")
+
+		assert.Equal(t, ErrInvalidCredentials, err)
+	})
+
+	t.Run("unknown user is left to other providers", func(t *testing.T) {
+		p := ldapProvider(LDAPConfig{GroupRoles: roles}, testDirectory())
+
+		ident, err := p.Authenticate(ctx, "default", "bob", "x")
+
+		assert.NoError(t, err)
+		assert.Nil(t, ident)
+	})
+
+	t.Run("username is escaped in the filter", func(t *testing.T) {
+		dir := testDirectory()
+		p := ldapProvider(LDAPConfig{GroupRoles: roles}, dir)
+
+		ident, err := p.Authenticate(ctx, "default", "*)(uid=alice", "wonderland")
+
+		assert.NoError(t, err)
+		assert.Nil(t, ident)
+		assert.Equal(t, []string{`(uid=\2a\29\28uid=alice)`}, dir.filters)
+	})
+
+	t.Run("empty password never binds", func(t *testing.T) {
+		dir := testDirectory()
+		p := ldapProvider(LDAPConfig{GroupRoles: roles}, dir)
+
+		ident, err := p.Authenticate(ctx, "default", "alice", "")
+
+		assert.NoError(t, err)
+		assert.Nil(t, ident)
+		assert.Empty(t, dir.binds)
+	})
+
+	t.Run("users outside mapped groups", func(t *testing.T) {
+		p := ldapProvider(LDAPConfig{GroupRoles: []LDAPGroupRole{{Group: "admins", Role: models.RoleManager}}}, testDirectory())
+
+		_, err := p.Authenticate(ctx, "default", "alice", "wonderland")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		p = ldapProvider(LDAPConfig{DefaultRole: models.RoleEmployee}, testDirectory())
+		ident, err := p.Authenticate(ctx, "default", "alice", "wonderland")
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleEmployee, ident.Role)
+	})
+
+	t.Run("group search", func(t *testing.T) {
+		dir := testDirectory()
+		dir.results[`(member=uid=alice,ou=people,dc=example,dc=com)`] = []*ldap.Entry{ldap.NewEntry("cn=admins,ou=groups,dc=example,dc=com", nil)}
+		p := ldapProvider(LDAPConfig{GroupFilter: "(member=%s)", GroupRoles: []LDAPGroupRole{{Group: "admins", Role: models.RoleManager}}}, dir)
+
+		ident, err := p.Authenticate(ctx, "default", "alice", "wonderland")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleManager, ident.Role)
+	})
+
+	t.Run("other organizations", func(t *testing.T) {
+		p := ldapProvider(LDAPConfig{Organization: "acme"}, testDirectory())
+		p.dial = func() (ldapConn, error) { return nil, errors.New("not dialed") }
+
+		ident, err := p.Authenticate(ctx, "default", "alice", "wonderland")
+
+		assert.NoError(t, err)
+		assert.Nil(t, ident)
+	})
+
+	t.Run("directory down", func(t *testing.T) {
+		p := ldapProvider(LDAPConfig{}, testDirectory())
+		p.dial = func() (ldapConn, error) { return nil, errors.New("connection refused") }
+
+		_, err := p.Authenticate(ctx, "default", "alice", "wonderland")
+
+		assert.EqualError(t, err, "connection refused")
+	})
+}
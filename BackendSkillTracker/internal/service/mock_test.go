@@ -2,13 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"skilltracker/internal/models"
 	"skilltracker/internal/repository"
 	"skilltracker/internal/dto"
+	jwtutil "skilltracker/internal/utils/jwt"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
 
+// testKeys signs and verifies the access tokens of service tests.
+var testKeys, _ = jwtutil.NewKeySet("skilltracker", "skilltracker-api", jwtutil.DeriveKey([]byte("secret")))
+
 type MockRepo struct {
 	mock.Mock
 }
@@ -33,6 +39,82 @@ func (m *MockRepo) Skill() repository.SkillRepository {
 	return m.Called().Get(0).(repository.SkillRepository)
 }
 
+func (m *MockRepo) RecurringTask() repository.RecurringTaskRepository {
+	return m.Called().Get(0).(repository.RecurringTaskRepository)
+}
+
+func (m *MockRepo) Template() repository.TaskTemplateRepository {
+	return m.Called().Get(0).(repository.TaskTemplateRepository)
+}
+
+func (m *MockRepo) Checklist() repository.ChecklistRepository {
+	return m.Called().Get(0).(repository.ChecklistRepository)
+}
+
+func (m *MockRepo) Time() repository.TimeRepository {
+	return m.Called().Get(0).(repository.TimeRepository)
+}
+
+func (m *MockRepo) SLA() repository.SLARepository {
+	return m.Called().Get(0).(repository.SLARepository)
+}
+
+func (m *MockRepo) Label() repository.LabelRepository {
+	return m.Called().Get(0).(repository.LabelRepository)
+}
+
+func (m *MockRepo) Project() repository.ProjectRepository {
+	return m.Called().Get(0).(repository.ProjectRepository)
+}
+
+func (m *MockRepo) Sprint() repository.SprintRepository {
+	return m.Called().Get(0).(repository.SprintRepository)
+}
+
+func (m *MockRepo) Notification() repository.NotificationRepository {
+	return m.Called().Get(0).(repository.NotificationRepository)
+}
+
+func (m *MockRepo) Role() repository.RoleRepository {
+	return m.Called().Get(0).(repository.RoleRepository)
+}
+
+func (m *MockRepo) Team() repository.TeamRepository {
+	return m.Called().Get(0).(repository.TeamRepository)
+}
+
+func (m *MockRepo) Organization() repository.OrganizationRepository {
+	return m.Called().Get(0).(repository.OrganizationRepository)
+}
+
+func (m *MockRepo) Session() repository.SessionRepository {
+	return m.Called().Get(0).(repository.SessionRepository)
+}
+
+func (m *MockRepo) LoginThrottle() repository.LoginThrottleRepository {
+	return m.Called().Get(0).(repository.LoginThrottleRepository)
+}
+
+func (m *MockRepo) TwoFactor() repository.TwoFactorRepository {
+	return m.Called().Get(0).(repository.TwoFactorRepository)
+}
+
+func (m *MockRepo) OIDC() repository.OIDCRepository {
+	return m.Called().Get(0).(repository.OIDCRepository)
+}
+
+func (m *MockRepo) Password() repository.PasswordRepository {
+	return m.Called().Get(0).(repository.PasswordRepository)
+}
+
+func (m *MockRepo) APIToken() repository.APITokenRepository {
+	return m.Called().Get(0).(repository.APITokenRepository)
+}
+
+func (m *MockRepo) Audit() repository.AuditRepository {
+	return m.Called().Get(0).(repository.AuditRepository)
+}
+
 type MockUserRepo struct {
 	mock.Mock
 }
@@ -57,6 +139,14 @@ func (m *MockUserRepo) GetUserByUsername(ctx context.Context, username string) (
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockUserRepo) UpdateUser(ctx context.Context, u *models.User) error {
 	return m.Called(ctx, u).Error(0)
 }
@@ -70,14 +160,6 @@ func (m *MockUserRepo) GetUsers(ctx context.Context) ([]*models.User, error) {
 	return args.Get(0).([]*models.User), args.Error(1)
 }
 
-func (m *MockUserRepo) GetUserByRefreshToken(ctx context.Context, token string) (*models.User, error) {
-	args := m.Called(ctx, token)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.User), args.Error(1)
-}
-
 func (m *MockUserRepo) GetEmployeesWithSkills(ctx context.Context) ([]*models.User, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -86,6 +168,16 @@ func (m *MockUserRepo) GetEmployeesWithSkills(ctx context.Context) ([]*models.Us
 	return args.Get(0).([]*models.User), args.Error(1)
 }
 
+func (m *MockUserRepo) GetTokenVersion(ctx context.Context, id int) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepo) BumpTokenVersion(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 type MockTaskRepo struct {
 	mock.Mock
 }
@@ -129,8 +221,8 @@ func (m *MockTaskRepo) ListTasks(ctx context.Context, filter dto.TaskFilter) ([]
 	return args.Get(0).([]models.Task), args.Error(1)
 }
 
-func (m *MockTaskRepo) AddSkillToTask(ctx context.Context, taskID int, skillID int) error {
-	return m.Called(ctx, taskID, skillID).Error(0)
+func (m *MockTaskRepo) AddSkillToTask(ctx context.Context, taskID int, skillID int, level int) error {
+	return m.Called(ctx, taskID, skillID, level).Error(0)
 }
 
 func (m *MockTaskRepo) RemoveSkillFromTask(ctx context.Context, taskID int, skillID int) error {
@@ -145,6 +237,32 @@ func (m *MockTaskRepo) GetTaskSkills(ctx context.Context, taskID int) ([]models.
 	return args.Get(0).([]models.Skill), args.Error(1)
 }
 
+func (m *MockTaskRepo) GetTasksByIDs(ctx context.Context, ids []int) ([]models.Task, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepo) SetTaskAssignees(ctx context.Context, taskID int, userIDs []int) error {
+	return m.Called(ctx, taskID, userIDs).Error(0)
+}
+
+func (m *MockTaskRepo) AddTaskWatcher(ctx context.Context, taskID int, userID int) error {
+	return m.Called(ctx, taskID, userID).Error(0)
+}
+
+func (m *MockTaskRepo) RemoveTaskWatcher(ctx context.Context, taskID int, userID int) error {
+	return m.Called(ctx, taskID, userID).Error(0)
+}
+
+func (m *MockTaskRepo) SetTaskProgress(ctx context.Context, taskID int, progress int) error {
+	return m.Called(ctx, taskID, progress).Error(0)
+}
+
+func (m *MockTaskRepo) GetWatchedTasks(ctx context.Context, userID int) ([]models.Task, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
 type MockSkillRepo struct {
 	mock.Mock
 }
@@ -225,3 +343,724 @@ type MockFileRepo struct {
 func (m *MockFileRepo) CreateAttachment(ctx context.Context, f *models.FileAttachment) error {
 	return m.Called(ctx, f).Error(0)
 }
+
+type MockRecurringTaskRepo struct {
+	mock.Mock
+}
+
+func (m *MockRecurringTaskRepo) CreateRecurringTask(ctx context.Context, r *models.RecurringTask) error {
+	return m.Called(ctx, r).Error(0)
+}
+
+func (m *MockRecurringTaskRepo) GetRecurringTaskByID(ctx context.Context, id int) (*models.RecurringTask, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecurringTask), args.Error(1)
+}
+
+func (m *MockRecurringTaskRepo) GetRecurringTasks(ctx context.Context) ([]models.RecurringTask, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.RecurringTask), args.Error(1)
+}
+
+func (m *MockRecurringTaskRepo) GetDueRecurringTasks(ctx context.Context, now time.Time) ([]models.RecurringTask, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]models.RecurringTask), args.Error(1)
+}
+
+func (m *MockRecurringTaskRepo) UpdateRecurringTask(ctx context.Context, r *models.RecurringTask) error {
+	return m.Called(ctx, r).Error(0)
+}
+
+func (m *MockRecurringTaskRepo) AdvanceRecurringTask(ctx context.Context, r *models.RecurringTask, from int) (bool, error) {
+	args := m.Called(ctx, r, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRecurringTaskRepo) CreateOccurrence(ctx context.Context, r *models.RecurringTask, from int, t *models.Task) (bool, error) {
+	args := m.Called(ctx, r, from, t)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRecurringTaskRepo) DeleteRecurringTask(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockRecurringTaskRepo) SetRecurringTaskSkills(ctx context.Context, id int, skillIDs []int) error {
+	return m.Called(ctx, id, skillIDs).Error(0)
+}
+
+func (m *MockRecurringTaskRepo) SaveException(ctx context.Context, ex *models.RecurringTaskException) error {
+	return m.Called(ctx, ex).Error(0)
+}
+
+type MockTemplateRepo struct {
+	mock.Mock
+}
+
+func (m *MockTemplateRepo) CreateTemplate(ctx context.Context, t *models.TaskTemplate) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+func (m *MockTemplateRepo) GetTemplateByID(ctx context.Context, id int) (*models.TaskTemplate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepo) GetTemplatesVisibleTo(ctx context.Context, userID int) ([]models.TaskTemplate, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepo) UpdateTemplate(ctx context.Context, t *models.TaskTemplate) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+func (m *MockTemplateRepo) DeleteTemplate(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+type MockChecklistRepo struct {
+	mock.Mock
+}
+
+func (m *MockChecklistRepo) CreateChecklistItem(ctx context.Context, item *models.ChecklistItem) error {
+	return m.Called(ctx, item).Error(0)
+}
+
+func (m *MockChecklistRepo) GetChecklistItemByID(ctx context.Context, id int) (*models.ChecklistItem, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChecklistItem), args.Error(1)
+}
+
+func (m *MockChecklistRepo) GetChecklistByTaskID(ctx context.Context, taskID int) ([]models.ChecklistItem, error) {
+	args := m.Called(ctx, taskID)
+	return args.Get(0).([]models.ChecklistItem), args.Error(1)
+}
+
+func (m *MockChecklistRepo) UpdateChecklistItem(ctx context.Context, item *models.ChecklistItem) error {
+	return m.Called(ctx, item).Error(0)
+}
+
+func (m *MockChecklistRepo) DeleteChecklistItem(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockChecklistRepo) ReorderChecklist(ctx context.Context, taskID int, itemIDs []int) error {
+	return m.Called(ctx, taskID, itemIDs).Error(0)
+}
+
+type MockTimeRepo struct {
+	mock.Mock
+}
+
+func (m *MockTimeRepo) CreateTimeEntry(ctx context.Context, e *models.TimeEntry) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockTimeRepo) GetTimeEntryByID(ctx context.Context, id int) (*models.TimeEntry, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeEntry), args.Error(1)
+}
+
+func (m *MockTimeRepo) GetRunningTimeEntry(ctx context.Context, userID int) (*models.TimeEntry, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeEntry), args.Error(1)
+}
+
+func (m *MockTimeRepo) UpdateTimeEntry(ctx context.Context, e *models.TimeEntry) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockTimeRepo) DeleteTimeEntry(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockTimeRepo) GetTimeEntriesByTaskID(ctx context.Context, taskID int) ([]models.TimeEntry, error) {
+	args := m.Called(ctx, taskID)
+	return args.Get(0).([]models.TimeEntry), args.Error(1)
+}
+
+func (m *MockTimeRepo) GetTimeEntriesByUser(ctx context.Context, userID int, from, to time.Time) ([]models.TimeEntry, error) {
+	args := m.Called(ctx, userID, from, to)
+	return args.Get(0).([]models.TimeEntry), args.Error(1)
+}
+
+func (m *MockTimeRepo) GetLoggedMinutesByTask(ctx context.Context, from, to *time.Time) (map[int]int, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).(map[int]int), args.Error(1)
+}
+
+func (m *MockTimeRepo) GetTimesheet(ctx context.Context, userID int, weekStart time.Time) (*models.Timesheet, error) {
+	args := m.Called(ctx, userID, weekStart)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Timesheet), args.Error(1)
+}
+
+func (m *MockTimeRepo) GetTimesheetByID(ctx context.Context, id int) (*models.Timesheet, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Timesheet), args.Error(1)
+}
+
+func (m *MockTimeRepo) SaveTimesheet(ctx context.Context, ts *models.Timesheet) error {
+	return m.Called(ctx, ts).Error(0)
+}
+
+type MockSLARepo struct {
+	mock.Mock
+}
+
+func (m *MockSLARepo) GetSLAPolicies(ctx context.Context) ([]models.SLAPolicy, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.SLAPolicy), args.Error(1)
+}
+
+func (m *MockSLARepo) GetSLAPolicy(ctx context.Context, priority models.TaskPriority) (*models.SLAPolicy, error) {
+	args := m.Called(ctx, priority)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SLAPolicy), args.Error(1)
+}
+
+func (m *MockSLARepo) SaveSLAPolicy(ctx context.Context, p *models.SLAPolicy) error {
+	return m.Called(ctx, p).Error(0)
+}
+
+func (m *MockSLARepo) GetTasksBreachingSLA(ctx context.Context, now time.Time) ([]models.Task, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockSLARepo) RecordSLABreaches(ctx context.Context, t *models.Task) (bool, error) {
+	args := m.Called(ctx, t)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockLabelRepo struct {
+	mock.Mock
+}
+
+func (m *MockLabelRepo) CreateLabel(ctx context.Context, l *models.Label) error {
+	return m.Called(ctx, l).Error(0)
+}
+
+func (m *MockLabelRepo) GetLabelByID(ctx context.Context, id int) (*models.Label, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Label), args.Error(1)
+}
+
+func (m *MockLabelRepo) GetLabels(ctx context.Context) ([]models.Label, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Label), args.Error(1)
+}
+
+func (m *MockLabelRepo) UpdateLabel(ctx context.Context, l *models.Label) error {
+	return m.Called(ctx, l).Error(0)
+}
+
+func (m *MockLabelRepo) DeleteLabel(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockLabelRepo) AddLabelToTask(ctx context.Context, taskID int, labelID int) error {
+	return m.Called(ctx, taskID, labelID).Error(0)
+}
+
+func (m *MockLabelRepo) RemoveLabelFromTask(ctx context.Context, taskID int, labelID int) error {
+	return m.Called(ctx, taskID, labelID).Error(0)
+}
+
+func (m *MockLabelRepo) GetLabelUsage(ctx context.Context) ([]models.LabelUsage, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.LabelUsage), args.Error(1)
+}
+
+type MockProjectRepo struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepo) CreateProject(ctx context.Context, p *models.Project) error {
+	return m.Called(ctx, p).Error(0)
+}
+
+func (m *MockProjectRepo) GetProjectByID(ctx context.Context, id int) (*models.Project, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) GetProjects(ctx context.Context) ([]models.Project, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) GetProjectsByMember(ctx context.Context, userID int) ([]models.Project, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) UpdateProject(ctx context.Context, p *models.Project) error {
+	return m.Called(ctx, p).Error(0)
+}
+
+func (m *MockProjectRepo) DeleteProject(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockProjectRepo) SetProjectMembers(ctx context.Context, projectID int, userIDs []int) error {
+	return m.Called(ctx, projectID, userIDs).Error(0)
+}
+
+func (m *MockProjectRepo) GetProjectProgress(ctx context.Context, projectIDs []int) ([]models.ProjectProgress, error) {
+	args := m.Called(ctx, projectIDs)
+	return args.Get(0).([]models.ProjectProgress), args.Error(1)
+}
+
+type MockSprintRepo struct {
+	mock.Mock
+}
+
+func (m *MockSprintRepo) CreateSprint(ctx context.Context, sp *models.Sprint) error {
+	return m.Called(ctx, sp).Error(0)
+}
+
+func (m *MockSprintRepo) GetSprintByID(ctx context.Context, id int) (*models.Sprint, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Sprint), args.Error(1)
+}
+
+func (m *MockSprintRepo) GetSprints(ctx context.Context, projectID int) ([]models.Sprint, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]models.Sprint), args.Error(1)
+}
+
+func (m *MockSprintRepo) GetNextSprint(ctx context.Context, sp *models.Sprint) (*models.Sprint, error) {
+	args := m.Called(ctx, sp)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Sprint), args.Error(1)
+}
+
+func (m *MockSprintRepo) UpdateSprint(ctx context.Context, sp *models.Sprint) error {
+	return m.Called(ctx, sp).Error(0)
+}
+
+func (m *MockSprintRepo) DeleteSprint(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockSprintRepo) GetSprintTasks(ctx context.Context, sprintID int) ([]models.Task, error) {
+	args := m.Called(ctx, sprintID)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockSprintRepo) GetStatusHistoryForTasks(ctx context.Context, taskIDs []int) ([]models.TaskStatusHistory, error) {
+	args := m.Called(ctx, taskIDs)
+	return args.Get(0).([]models.TaskStatusHistory), args.Error(1)
+}
+
+func (m *MockSprintRepo) CloseSprint(ctx context.Context, sp *models.Sprint, taskIDs []int, moved []models.TaskStatusHistory, nextSprintID *int) error {
+	return m.Called(ctx, sp, taskIDs, moved, nextSprintID).Error(0)
+}
+
+type MockNotificationRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepo) CreateNotifications(ctx context.Context, ns []models.Notification) error {
+	return m.Called(ctx, ns).Error(0)
+}
+
+func (m *MockNotificationRepo) GetNotifications(ctx context.Context, userID int, unreadOnly bool) ([]models.Notification, error) {
+	args := m.Called(ctx, userID, unreadOnly)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepo) MarkNotificationRead(ctx context.Context, id int, userID int) error {
+	return m.Called(ctx, id, userID).Error(0)
+}
+
+func (m *MockNotificationRepo) MarkAllNotificationsRead(ctx context.Context, userID int) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockNotificationRepo) GetNotificationPreferences(ctx context.Context, userID int) ([]models.NotificationPreference, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.NotificationPreference), args.Error(1)
+}
+
+func (m *MockNotificationRepo) SaveNotificationPreferences(ctx context.Context, prefs []models.NotificationPreference) error {
+	return m.Called(ctx, prefs).Error(0)
+}
+
+func (m *MockNotificationRepo) GetMutedUsers(ctx context.Context, userIDs []int, typ models.NotificationType) ([]int, error) {
+	args := m.Called(ctx, userIDs, typ)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+type MockRoleRepo struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepo) CreateRole(ctx context.Context, r *models.RoleDefinition) error {
+	return m.Called(ctx, r).Error(0)
+}
+
+func (m *MockRoleRepo) GetRoles(ctx context.Context) ([]models.RoleDefinition, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.RoleDefinition), args.Error(1)
+}
+
+func (m *MockRoleRepo) GetRoleByID(ctx context.Context, id int) (*models.RoleDefinition, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RoleDefinition), args.Error(1)
+}
+
+func (m *MockRoleRepo) GetRoleByName(ctx context.Context, name string) (*models.RoleDefinition, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RoleDefinition), args.Error(1)
+}
+
+func (m *MockRoleRepo) UpdateRole(ctx context.Context, r *models.RoleDefinition) error {
+	return m.Called(ctx, r).Error(0)
+}
+
+func (m *MockRoleRepo) DeleteRole(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockRoleRepo) CountUsersWithRole(ctx context.Context, name string) (int64, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockTeamRepo struct {
+	mock.Mock
+}
+
+func (m *MockTeamRepo) CreateTeam(ctx context.Context, t *models.Team) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+func (m *MockTeamRepo) GetTeams(ctx context.Context) ([]models.Team, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepo) GetTeamByID(ctx context.Context, id int) (*models.Team, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepo) UpdateTeam(ctx context.Context, t *models.Team) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+func (m *MockTeamRepo) DeleteTeam(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockTeamRepo) GetTeamMembers(ctx context.Context, teamID int) ([]models.User, error) {
+	args := m.Called(ctx, teamID)
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockTeamRepo) SetUserTeam(ctx context.Context, userID int, teamID *int) error {
+	return m.Called(ctx, userID, teamID).Error(0)
+}
+
+type MockOrganizationRepo struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationRepo) CreateOrganization(ctx context.Context, o *models.Organization) error {
+	return m.Called(ctx, o).Error(0)
+}
+
+func (m *MockOrganizationRepo) GetOrganizations(ctx context.Context) ([]models.Organization, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) GetOrganizationByID(ctx context.Context, id int) (*models.Organization, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepo) GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+type MockSessionRepo struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepo) CreateSession(ctx context.Context, sess *models.Session) error {
+	return m.Called(ctx, sess).Error(0)
+}
+
+func (m *MockSessionRepo) GetSessionByID(ctx context.Context, id int) (*models.Session, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) GetActiveSessions(ctx context.Context, userID int, now time.Time) ([]models.Session, error) {
+	args := m.Called(ctx, userID, now)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockSessionRepo) RotateRefreshToken(ctx context.Context, usedID int, next *models.RefreshToken, sess *models.Session) (bool, error) {
+	args := m.Called(ctx, usedID, next, sess)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepo) RevokeSession(ctx context.Context, id int, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockSessionRepo) RevokeUserSessions(ctx context.Context, userID int, at time.Time) error {
+	return m.Called(ctx, userID, at).Error(0)
+}
+
+type MockTwoFactorRepo struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepo) GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TOTPCredential), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) SaveTOTPCredential(ctx context.Context, c *models.TOTPCredential) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) EnableTwoFactor(ctx context.Context, c *models.TOTPCredential, codes []models.RecoveryCode) error {
+	args := m.Called(ctx, c, codes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) DeleteTwoFactor(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int, codes []models.RecoveryCode) error {
+	args := m.Called(ctx, userID, codes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string, at time.Time) (bool, error) {
+	args := m.Called(ctx, userID, codeHash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) CountRecoveryCodes(ctx context.Context, userID int) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// noTwoFactorRepo has no user with 2FA set up.
+func noTwoFactorRepo() *MockTwoFactorRepo {
+	r := new(MockTwoFactorRepo)
+	r.On("GetTOTPCredential", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+	return r
+}
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) CreateAuditLog(ctx context.Context, l *models.AuditLog) error {
+	return m.Called(ctx, l).Error(0)
+}
+
+func (m *MockAuditRepo) GetAuditLogs(ctx context.Context, filter dto.AuditFilter) ([]models.AuditLog, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]models.AuditLog), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAuditRepo) DeleteAuditLogsBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// acceptingAuditRepo stores every audit entry.
+func acceptingAuditRepo() *MockAuditRepo {
+	r := new(MockAuditRepo)
+	r.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return r
+}
+
+// created returns the audit entries stored so far.
+func (m *MockAuditRepo) created() []models.AuditLog {
+	var out []models.AuditLog
+	for _, c := range m.Calls {
+		if c.Method == "CreateAuditLog" {
+			out = append(out, *c.Arguments.Get(1).(*models.AuditLog))
+		}
+	}
+	return out
+}
+
+type MockAPITokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPITokenRepo) CreateAPIToken(ctx context.Context, t *models.APIToken) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+func (m *MockAPITokenRepo) GetAPITokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenRepo) GetAPITokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenRepo) RevokeAPIToken(ctx context.Context, userID int, id int, at time.Time) (bool, error) {
+	args := m.Called(ctx, userID, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPITokenRepo) RevokeUserAPITokens(ctx context.Context, userID int, at time.Time) error {
+	return m.Called(ctx, userID, at).Error(0)
+}
+
+func (m *MockAPITokenRepo) TouchAPIToken(ctx context.Context, id int, at time.Time, ip string) error {
+	return m.Called(ctx, id, at, ip).Error(0)
+}
+
+type MockOIDCRepo struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepo) CreateOIDCState(ctx context.Context, st *models.OIDCState) error {
+	return m.Called(ctx, st).Error(0)
+}
+
+func (m *MockOIDCRepo) TakeOIDCState(ctx context.Context, stateHash string, now time.Time) (*models.OIDCState, error) {
+	args := m.Called(ctx, stateHash, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OIDCState), args.Error(1)
+}
+
+func (m *MockOIDCRepo) GetExternalIdentity(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ExternalIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepo) CreateExternalIdentity(ctx context.Context, id *models.ExternalIdentity) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockOIDCRepo) CountExternalIdentities(ctx context.Context, userID int) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockPasswordRepo struct {
+	mock.Mock
+}
+
+func (m *MockPasswordRepo) GetPasswordHistory(ctx context.Context, userID int, limit int) ([]models.PasswordHistory, error) {
+	args := m.Called(ctx, userID, limit)
+	return args.Get(0).([]models.PasswordHistory), args.Error(1)
+}
+
+func (m *MockPasswordRepo) AddPasswordHistory(ctx context.Context, h *models.PasswordHistory, keep int) error {
+	return m.Called(ctx, h, keep).Error(0)
+}
+
+func (m *MockPasswordRepo) CreatePasswordResetToken(ctx context.Context, t *models.PasswordResetToken) error {
+	return m.Called(ctx, t).Error(0)
+}
+
+func (m *MockPasswordRepo) GetPasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordRepo) UsePasswordResetToken(ctx context.Context, id int, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
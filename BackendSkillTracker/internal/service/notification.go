@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+)
+
+// notificationTypes are the notifications users can turn off.
+var notificationTypes = []models.NotificationType{
+	models.NotificationAssigned,
+	models.NotificationStatusChanged,
+	models.NotificationReassigned,
+}
+
+// NOTIFICATIONS
+
+func (s *services) Notification() NotificationService { return s }
+
+func (s *services) GetNotifications(ctx context.Context, userID int, unreadOnly bool) ([]*dto.NotificationResponse, error) {
+	ns, err := s.repo.Notification().GetNotifications(ctx, userID, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.NotificationResponse, 0, len(ns))
+	for _, n := range ns {
+		out = append(out, &dto.NotificationResponse{
+			ID:        n.ID,
+			TaskID:    n.TaskID,
+			Type:      string(n.Type),
+			Message:   n.Message,
+			ReadAt:    n.ReadAt,
+			CreatedAt: n.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+func (s *services) MarkNotificationRead(ctx context.Context, id int, userID int) error {
+	if err := s.repo.Notification().MarkNotificationRead(ctx, id, userID); err != nil {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+func (s *services) MarkAllNotificationsRead(ctx context.Context, userID int) error {
+	return s.repo.Notification().MarkAllNotificationsRead(ctx, userID)
+}
+
+// GetNotificationPreferences lists every notification type with whether
+// the user gets it.
+func (s *services) GetNotificationPreferences(ctx context.Context, userID int) ([]dto.NotificationPreference, error) {
+	prefs, err := s.repo.Notification().GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[models.NotificationType]bool, len(prefs))
+	for _, p := range prefs {
+		enabled[p.Type] = p.Enabled
+	}
+	out := make([]dto.NotificationPreference, 0, len(notificationTypes))
+	for _, typ := range notificationTypes {
+		on, ok := enabled[typ]
+		out = append(out, dto.NotificationPreference{Type: string(typ), Enabled: on || !ok})
+	}
+	return out, nil
+}
+
+// UpdateNotificationPreferences changes the listed types; the others stay
+// as they are.
+func (s *services) UpdateNotificationPreferences(ctx context.Context, userID int, req *dto.NotificationPreferencesRequest) ([]dto.NotificationPreference, error) {
+	prefs := make([]models.NotificationPreference, 0, len(req.Preferences))
+	for _, p := range req.Preferences {
+		prefs = append(prefs, models.NotificationPreference{UserID: userID, Type: models.NotificationType(p.Type), Enabled: p.Enabled})
+	}
+	if err := s.repo.Notification().SaveNotificationPreferences(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return s.GetNotificationPreferences(ctx, userID)
+}
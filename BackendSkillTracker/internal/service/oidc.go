@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"skilltracker/internal/utils/oidc"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// oidcStateTTL is how long the user may take to log in at the provider.
+const oidcStateTTL = 10 * time.Minute
+
+// AuthOIDC is the AuthSource of users created at their first OIDC login.
+const AuthOIDC = "oidc"
+
+// OIDCRoleMapping gives users with Value in their roles claim a role.
+type OIDCRoleMapping struct {
+	Value string
+	Role  models.Role
+}
+
+// OIDCProvider is an OpenID Connect identity provider users log in with
+// instead of a password.
+type OIDCProvider struct {
+	Name string
+	// Organization is the slug of the organization its users belong to.
+	Organization string
+	Client       *oidc.Client
+	// Claims for the username, email and display name; by default
+	// preferred_username, email and name.
+	UsernameClaim string
+	EmailClaim    string
+	NameClaim     string
+	// RolesClaim lists groups or roles of the user, e.g. "groups". The
+	// first RoleMapping value the user has gives the role at every login.
+	// Without a match the user gets DefaultRole, or can't log in.
+	RolesClaim  string
+	RoleMapping []OIDCRoleMapping
+	DefaultRole models.Role
+	// LinkBy lists "username" and "email": how the first login through the
+	// provider finds an existing user. Emails count only when verified.
+	// Users found by neither are created.
+	LinkBy []string
+}
+
+func (p *OIDCProvider) claim(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+func (p *OIDCProvider) links(by string) bool {
+	for _, l := range p.LinkBy {
+		if l == by {
+			return true
+		}
+	}
+	return false
+}
+
+// identity maps ID token claims to a user. Without a RolesClaim the role is
+// left empty, and so are roles no mapping gives.
+func (p *OIDCProvider) identity(claims oidc.Claims) (ident *Identity, email string) {
+	ident = &Identity{
+		Username: claims.String(p.claim(p.UsernameClaim, "preferred_username")),
+		Name:     claims.String(p.claim(p.NameClaim, "name")),
+	}
+	if claims.Bool("email_verified") {
+		email = claims.String(p.claim(p.EmailClaim, "email"))
+	}
+	if p.RolesClaim != "" {
+		ident.Role = p.DefaultRole
+		values := claims.Strings(p.RolesClaim)
+	mapping:
+		for _, m := range p.RoleMapping {
+			for _, v := range values {
+				if strings.EqualFold(v, m.Value) {
+					ident.Role = m.Role
+					break mapping
+				}
+			}
+		}
+	}
+	return ident, email
+}
+
+func (s *services) oidcProvider(name string) (*OIDCProvider, error) {
+	for i := range s.opts.OIDC {
+		if s.opts.OIDC[i].Name == name {
+			return &s.opts.OIDC[i], nil
+		}
+	}
+	return nil, errors.New("unknown identity provider")
+}
+
+// StartOIDCLogin returns where to send the user to log in at the provider.
+// The PKCE verifier stays on the server until the callback.
+func (s *services) StartOIDCLogin(ctx context.Context, provider string) (*dto.OIDCAuthorizeResponse, error) {
+	p, err := s.oidcProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = oidc.RandomString(); err != nil {
+			return nil, err
+		}
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+	authURL, err := p.Client.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		s.logger.Error().Err(err).Str("provider", provider).Msg("oidc discovery failed")
+		return nil, errors.New("identity provider unavailable")
+	}
+	st := &models.OIDCState{
+		StateHash: hashToken(state),
+		Provider:  provider,
+		Verifier:  verifier,
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(oidcStateTTL),
+	}
+	if err := s.repo.OIDC().CreateOIDCState(ctx, st); err != nil {
+		return nil, err
+	}
+	return &dto.OIDCAuthorizeResponse{AuthorizationURL: authURL, State: state}, nil
+}
+
+// CompleteOIDCLogin redeems the code the provider redirected back with and
+// logs the user of its ID token in. Each state works once.
+func (s *services) CompleteOIDCLogin(ctx context.Context, provider string, req *dto.OIDCCallbackRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	p, err := s.oidcProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.repo.OIDC().TakeOIDCState(ctx, hashToken(req.State), time.Now())
+	if err != nil || st.Provider != provider {
+		return nil, errors.New("invalid oidc state")
+	}
+	raw, err := p.Client.Exchange(ctx, req.Code, st.Verifier)
+	if err == nil {
+		var claims oidc.Claims
+		if claims, err = p.Client.VerifyIDToken(ctx, raw, st.Nonce); err == nil {
+			return s.oidcLogin(ctx, p, claims, client)
+		}
+	}
+	if errors.Is(err, oidc.ErrUnavailable) {
+		s.logger.Error().Err(err).Str("provider", provider).Msg("oidc provider failed")
+		return nil, errors.New("identity provider unavailable")
+	}
+	s.securityEvent(zerolog.WarnLevel, "oidc_login_failed").Err(err).Str("provider", provider).
+		Str("ip", client.IP).Msg("oidc login refused")
+	return nil, errors.New("invalid authorization code")
+}
+
+func (s *services) oidcLogin(ctx context.Context, p *OIDCProvider, claims oidc.Claims, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	org, err := s.repo.Organization().GetOrganizationBySlug(ctx, p.Organization)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+	ctx = tenant.WithOrg(ctx, org.ID)
+	u, err := s.oidcUser(ctx, p, claims)
+	if err != nil {
+		return nil, err
+	}
+	if res, err := s.secondFactor(ctx, u); err != nil || res != nil {
+		return res, err
+	}
+	return s.startSession(ctx, u, client)
+}
+
+// oidcUser finds the user of a subject: linked before, linked now by
+// username or email, or created.
+func (s *services) oidcUser(ctx context.Context, p *OIDCProvider, claims oidc.Claims) (*models.User, error) {
+	subject := claims.String("sub")
+	ident, email := p.identity(claims)
+	if p.RolesClaim != "" && ident.Role == "" {
+		s.securityEvent(zerolog.WarnLevel, "oidc_login_failed").Str("provider", p.Name).Str("subject", subject).
+			Msg("no role for the claims")
+		return nil, errors.New("no role for this account")
+	}
+
+	if link, err := s.repo.OIDC().GetExternalIdentity(ctx, p.Name, subject); err == nil {
+		u, err := s.repo.User().GetUserByID(ctx, link.UserID)
+		if err != nil {
+			return nil, errors.New("account not found")
+		}
+		return s.syncOIDCUser(ctx, u, ident)
+	}
+
+	u, err := s.linkOIDCUser(ctx, p, ident.Username, email)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		if u, err = s.createOIDCUser(ctx, p, ident, email); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.OIDC().CreateExternalIdentity(ctx, &models.ExternalIdentity{UserID: u.ID, Provider: p.Name, Subject: subject}); err != nil {
+		return nil, err
+	}
+	s.securityEvent(zerolog.InfoLevel, "oidc_linked").Int("user_id", u.ID).Str("provider", p.Name).
+		Str("subject", subject).Msg("identity linked")
+	s.audit(ctx, auditEntry{Action: "user.identity_linked", TargetType: "user", TargetID: u.ID, Actor: u,
+		After: map[string]string{"provider": p.Name, "subject": subject}})
+	return s.syncOIDCUser(ctx, u, ident)
+}
+
+// syncOIDCUser keeps the stored role when the provider gives none.
+func (s *services) syncOIDCUser(ctx context.Context, u *models.User, ident *Identity) (*models.User, error) {
+	if ident.Role == "" {
+		ident.Role = u.Role
+	}
+	return s.syncUser(ctx, u, ident)
+}
+
+// linkOIDCUser returns the existing user a first login belongs to, if any.
+func (s *services) linkOIDCUser(ctx context.Context, p *OIDCProvider, username, email string) (*models.User, error) {
+	var u *models.User
+	if p.links("username") && username != "" {
+		u, _ = s.repo.User().GetUserByUsername(ctx, username)
+	}
+	if u == nil && p.links("email") && email != "" {
+		u, _ = s.repo.User().GetUserByEmail(ctx, email)
+	}
+	if u == nil {
+		return nil, nil
+	}
+	if err := s.checkOIDCLinkable(ctx, u); err != nil {
+		s.securityEvent(zerolog.WarnLevel, "oidc_login_failed").Str("provider", p.Name).Int("user_id", u.ID).
+			Str("reason", err.Error()).Msg("existing user not linked")
+		return nil, errors.New("account cannot be linked")
+	}
+	return u, nil
+}
+
+// checkOIDCLinkable fails for users a first login must not take over: those
+// already linked to an identity, and those whose role grants any permission,
+// like the seeded admin. Linking skips their password and second factor, and
+// the provider's role would replace theirs.
+func (s *services) checkOIDCLinkable(ctx context.Context, u *models.User) error {
+	n, err := s.repo.OIDC().CountExternalIdentities(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("already linked")
+	}
+	perms, err := s.rolePermissions(ctx, string(u.Role))
+	if err != nil || len(perms) > 0 {
+		return errors.New("privileged role")
+	}
+	return nil
+}
+
+func (s *services) createOIDCUser(ctx context.Context, p *OIDCProvider, ident *Identity, email string) (*models.User, error) {
+	if ident.Username == "" {
+		return nil, errors.New("no username in id token")
+	}
+	if _, err := s.repo.User().GetUserByUsername(ctx, ident.Username); err == nil {
+		s.securityEvent(zerolog.WarnLevel, "oidc_login_failed").Str("provider", p.Name).Str("username", ident.Username).
+			Msg("username taken by an unlinked user")
+		return nil, errors.New("username taken")
+	}
+	u := &models.User{
+		Username:   ident.Username,
+		Name:       ident.Name,
+		Email:      email,
+		Role:       ident.Role,
+		AuthSource: AuthOIDC,
+	}
+	if u.Role == "" {
+		u.Role = p.DefaultRole
+	}
+	if u.Role == "" {
+		return nil, errors.New("no role for this account")
+	}
+	if u.Name == "" {
+		u.Name = ident.Username
+	}
+	if err := s.repo.User().CreateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	s.securityEvent(zerolog.InfoLevel, "user_provisioned").Int("user_id", u.ID).Str("username", u.Username).
+		Str("source", p.Name).Str("role", string(u.Role)).Msg("user provisioned")
+	s.audit(ctx, auditEntry{Action: "user.provisioned", TargetType: "user", TargetID: u.ID, Actor: u, After: userSnapshot(u)})
+	return u, nil
+}
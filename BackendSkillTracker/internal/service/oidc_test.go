@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/utils/oidc"
+	"skilltracker/internal/utils/oidc/oidctest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type oidcFixture struct {
+	idp   *oidctest.IdP
+	links *MockOIDCRepo
+	users *MockUserRepo
+	s     ServiceInterface
+}
+
+// newOIDCFixture sets up the "corp" provider with the given subjects
+// already linked.
+func newOIDCFixture(t *testing.T, configure func(*OIDCProvider), linked ...models.ExternalIdentity) *oidcFixture {
+	idp := oidctest.New("skilltracker", "s3cret")
+	t.Cleanup(idp.Close)
+	p := OIDCProvider{
+		Name:         "corp",
+		Organization: DefaultOrganization,
+		Client: oidc.New(oidc.Config{
+			Issuer:       idp.Issuer(),
+			ClientID:     idp.ClientID,
+			ClientSecret: idp.ClientSecret,
+			RedirectURL:  "https://app.example.com/oidc/callback",
+		}),
+		DefaultRole: models.RoleEmployee,
+		LinkBy:      []string{"email"},
+	}
+	if configure != nil {
+		configure(&p)
+	}
+	f := &oidcFixture{idp: idp, links: new(MockOIDCRepo), users: new(MockUserRepo)}
+	// Each started login can be completed once.
+	f.links.On("CreateOIDCState", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		st := *args.Get(1).(*models.OIDCState)
+		f.links.On("TakeOIDCState", mock.Anything, st.StateHash, mock.Anything).Return(&st, nil).Once()
+	}).Return(nil)
+	counts := make(map[int]int64)
+	for i := range linked {
+		f.links.On("GetExternalIdentity", mock.Anything, linked[i].Provider, linked[i].Subject).Return(&linked[i], nil)
+		counts[linked[i].UserID]++
+	}
+	for userID, n := range counts {
+		f.links.On("CountExternalIdentities", mock.Anything, userID).Return(n, nil)
+	}
+	f.links.On("GetExternalIdentity", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("record not found"))
+	f.links.On("CountExternalIdentities", mock.Anything, mock.Anything).Return(int64(0), nil)
+	f.links.On("CreateExternalIdentity", mock.Anything, mock.Anything).Return(nil)
+	mockRepo := new(MockRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	mockRepo.On("Organization").Return(defaultOrgRepo())
+	mockRepo.On("Audit").Return(acceptingAuditRepo())
+	mockRepo.On("User").Return(f.users)
+	mockRepo.On("Session").Return(mockSessionRepo)
+	mockRepo.On("TwoFactor").Return(noTwoFactorRepo())
+	mockRepo.On("OIDC").Return(f.links)
+	mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(nil)
+	f.s = New(mockRepo, zerolog.Nop(), testKeys, Options{OIDC: []OIDCProvider{p}})
+	return f
+}
+
+// login runs the whole flow for a user with claims at the provider.
+func (f *oidcFixture) login(claims map[string]interface{}) (*dto.LoginResponse, error) {
+	ctx := context.Background()
+	start, err := f.s.User().StartOIDCLogin(ctx, "corp")
+	if err != nil {
+		return nil, err
+	}
+	code, state, err := f.idp.Authorize(start.AuthorizationURL, claims)
+	if err != nil {
+		return nil, err
+	}
+	return f.s.User().CompleteOIDCLogin(ctx, "corp", &dto.OIDCCallbackRequest{Code: code, State: state}, dto.ClientInfo{})
+}
+
+func TestOIDCLogin(t *testing.T) {
+	t.Run("first login provisions the user", func(t *testing.T) {
+		f := newOIDCFixture(t, nil)
+		f.users.On("GetUserByEmail", inOrg(1), "alice@example.com").Return(nil, errors.New("not found"))
+		f.users.On("GetUserByUsername", inOrg(1), "alice").Return(nil, errors.New("not found"))
+		f.users.On("CreateUser", inOrg(1), mock.MatchedBy(func(u *models.User) bool {
+			return u.Username == "alice" && u.Email == "alice@example.com" && u.Role == models.RoleEmployee &&
+				u.AuthSource == AuthOIDC && u.PasswordHash == ""
+		})).Run(func(args mock.Arguments) { args.Get(1).(*models.User).ID = 7 }).Return(nil)
+
+		res, err := f.login(map[string]interface{}{
+			"sub": "s-1", "preferred_username": "alice", "email": "alice@example.com", "email_verified": true,
+		})
+
+		require.NoError(t, err)
+		claims, err := testKeys.ValidateToken(res.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, 7, claims.UserID)
+		f.links.AssertCalled(t, "CreateExternalIdentity", mock.Anything, &models.ExternalIdentity{UserID: 7, Provider: "corp", Subject: "s-1"})
+	})
+
+	t.Run("a verified email links an existing user", func(t *testing.T) {
+		f := newOIDCFixture(t, nil)
+		f.users.On("GetUserByEmail", inOrg(1), "alice@example.com").
+			Return(&models.User{ID: 3, OrgID: 1, Username: "asmith", Name: "Alice", Role: models.RoleEmployee, AuthSource: models.AuthLocal}, nil)
+
+		res, err := f.login(map[string]interface{}{
+			"sub": "s-1", "preferred_username": "alice", "email": "alice@example.com", "email_verified": true,
+		})
+
+		require.NoError(t, err)
+		claims, _ := testKeys.ValidateToken(res.AccessToken)
+		assert.Equal(t, 3, claims.UserID)
+		assert.Equal(t, "employee", claims.Role, "without a roles claim the stored role stays")
+		f.users.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("privileged local accounts aren't taken over", func(t *testing.T) {
+		f := newOIDCFixture(t, func(p *OIDCProvider) {
+			p.LinkBy = []string{"username"}
+			p.RolesClaim = "groups"
+			p.RoleMapping = []OIDCRoleMapping{{Value: "admins", Role: models.RoleManager}}
+		})
+		f.users.On("GetUserByUsername", inOrg(1), "admin").
+			Return(&models.User{ID: 1, OrgID: 1, Username: "admin", Role: models.RoleManager, AuthSource: models.AuthLocal}, nil)
+
+		_, err := f.login(map[string]interface{}{"sub": "s-9", "preferred_username": "admin", "groups": []string{"admins"}})
+
+		assert.EqualError(t, err, "account cannot be linked")
+		f.links.AssertNotCalled(t, "CreateExternalIdentity", mock.Anything, mock.Anything)
+		f.users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("accounts linked to another subject aren't taken over", func(t *testing.T) {
+		f := newOIDCFixture(t, nil, models.ExternalIdentity{UserID: 3, Provider: "corp", Subject: "s-1"})
+		f.users.On("GetUserByEmail", inOrg(1), "alice@example.com").
+			Return(&models.User{ID: 3, OrgID: 1, Username: "alice", Role: models.RoleEmployee, AuthSource: AuthOIDC}, nil)
+
+		_, err := f.login(map[string]interface{}{
+			"sub": "s-2", "preferred_username": "alice2", "email": "alice@example.com", "email_verified": true,
+		})
+
+		assert.EqualError(t, err, "account cannot be linked")
+		f.links.AssertNotCalled(t, "CreateExternalIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("an unverified email doesn't link", func(t *testing.T) {
+		f := newOIDCFixture(t, nil)
+		f.users.On("GetUserByUsername", inOrg(1), "alice").
+			Return(&models.User{ID: 3, OrgID: 1, Username: "alice", Email: "alice@example.com"}, nil)
+
+		_, err := f.login(map[string]interface{}{
+			"sub": "s-1", "preferred_username": "alice", "email": "alice@example.com",
+		})
+
+		assert.EqualError(t, err, "username taken")
+		f.users.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+		f.links.AssertNotCalled(t, "CreateExternalIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("linked subjects log in without claims matching", func(t *testing.T) {
+		f := newOIDCFixture(t, nil, models.ExternalIdentity{UserID: 3, Provider: "corp", Subject: "s-1"})
+		f.users.On("GetUserByID", inOrg(1), 3).
+			Return(&models.User{ID: 3, OrgID: 1, Username: "asmith", Name: "Alice", Role: models.RoleEmployee}, nil)
+
+		res, err := f.login(map[string]interface{}{"sub": "s-1", "preferred_username": "renamed"})
+
+		require.NoError(t, err)
+		claims, _ := testKeys.ValidateToken(res.AccessToken)
+		assert.Equal(t, 3, claims.UserID)
+	})
+
+	t.Run("roles claim maps the role", func(t *testing.T) {
+		f := newOIDCFixture(t, func(p *OIDCProvider) {
+			p.RolesClaim = "groups"
+			p.RoleMapping = []OIDCRoleMapping{{Value: "skilltracker-managers", Role: models.RoleManager}}
+			p.DefaultRole = ""
+		}, models.ExternalIdentity{UserID: 3, Provider: "corp", Subject: "s-1"})
+		f.users.On("GetUserByID", inOrg(1), 3).
+			Return(&models.User{ID: 3, OrgID: 1, Username: "alice", Name: "Alice", Role: models.RoleEmployee}, nil)
+		f.users.On("UpdateUser", inOrg(1), mock.MatchedBy(func(u *models.User) bool { return u.Role == models.RoleManager })).Return(nil)
+		f.users.On("BumpTokenVersion", inOrg(1), 3).Return(nil)
+
+		res, err := f.login(map[string]interface{}{"sub": "s-1", "groups": []string{"staff", "skilltracker-managers"}})
+		require.NoError(t, err)
+		claims, _ := testKeys.ValidateToken(res.AccessToken)
+		assert.Equal(t, "manager", claims.Role)
+
+		_, err = f.login(map[string]interface{}{"sub": "s-1", "groups": []string{"staff"}})
+		assert.EqualError(t, err, "no role for this account")
+	})
+
+	t.Run("states work once", func(t *testing.T) {
+		f := newOIDCFixture(t, nil, models.ExternalIdentity{UserID: 3, Provider: "corp", Subject: "s-1"})
+		f.users.On("GetUserByID", inOrg(1), 3).Return(&models.User{ID: 3, OrgID: 1, Username: "alice", Role: models.RoleEmployee}, nil)
+		ctx := context.Background()
+		start, err := f.s.User().StartOIDCLogin(ctx, "corp")
+		require.NoError(t, err)
+		code, state, err := f.idp.Authorize(start.AuthorizationURL, map[string]interface{}{"sub": "s-1"})
+		require.NoError(t, err)
+		req := &dto.OIDCCallbackRequest{Code: code, State: state}
+
+		_, err = f.s.User().CompleteOIDCLogin(ctx, "corp", req, dto.ClientInfo{})
+		require.NoError(t, err)
+		f.links.On("TakeOIDCState", mock.Anything, hashToken(state), mock.Anything).Return(nil, errors.New("record not found"))
+		_, err = f.s.User().CompleteOIDCLogin(ctx, "corp", req, dto.ClientInfo{})
+		assert.EqualError(t, err, "invalid oidc state")
+	})
+
+	t.Run("a code from another login is refused", func(t *testing.T) {
+		f := newOIDCFixture(t, nil)
+		ctx := context.Background()
+		first, _ := f.s.User().StartOIDCLogin(ctx, "corp")
+		second, _ := f.s.User().StartOIDCLogin(ctx, "corp")
+		code, _, err := f.idp.Authorize(first.AuthorizationURL, map[string]interface{}{"sub": "s-1"})
+		require.NoError(t, err)
+
+		_, err = f.s.User().CompleteOIDCLogin(ctx, "corp", &dto.OIDCCallbackRequest{Code: code, State: second.State}, dto.ClientInfo{})
+
+		assert.EqualError(t, err, "invalid authorization code", "the PKCE verifier belongs to the other login")
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		f := newOIDCFixture(t, nil)
+
+		_, err := f.s.User().StartOIDCLogin(context.Background(), "other")
+
+		assert.EqualError(t, err, "unknown identity provider")
+	})
+}
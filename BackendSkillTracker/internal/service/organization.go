@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultOrganization is the slug used by logins that don't name an
+// organization.
+const DefaultOrganization = "default"
+
+// ORGANIZATIONS
+
+func (s *services) Organization() OrganizationService { return s }
+
+func (s *services) GetOrganization(ctx context.Context, orgID int) (*dto.OrganizationResponse, error) {
+	o, err := s.repo.Organization().GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+	return &dto.OrganizationResponse{ID: o.ID, Slug: o.Slug, Name: o.Name, CreatedAt: o.CreatedAt}, nil
+}
+
+// SeedOrganization creates the organization and its admin account if they
+// don't exist.
+func (s *services) SeedOrganization(ctx context.Context, slug, name, adminPassword string) error {
+	o, err := s.repo.Organization().GetOrganizationBySlug(ctx, slug)
+	if err != nil {
+		o = &models.Organization{Slug: slug, Name: name}
+		if err := s.repo.Organization().CreateOrganization(ctx, o); err != nil {
+			return err
+		}
+		s.logger.Info().Str("organization", slug).Msg("Organization created")
+	}
+	return s.seedAdmin(tenant.WithOrg(ctx, o.ID), adminPassword)
+}
+
+// seedAdmin creates the admin account of the context's organization if it
+// doesn't exist.
+func (s *services) seedAdmin(ctx context.Context, adminPassword string) error {
+	if _, err := s.repo.User().GetUserByUsername(ctx, "admin"); err == nil {
+		return nil // already exists
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	// The password comes from configuration, so it is replaced at the
+	// first login.
+	u := &models.User{
+		Username:           "admin",
+		PasswordHash:       string(hash),
+		Role:               models.RoleManager,
+		Name:               "Administrator",
+		MustChangePassword: true,
+	}
+	if err := s.repo.User().CreateUser(ctx, u); err != nil {
+		return err
+	}
+	orgID, _ := tenant.OrgID(ctx)
+	s.logger.Info().Str("username", "admin").Int("org_id", orgID).Msg("Admin account created")
+	return nil
+}
+
+// forEachOrganization runs fn once per organization with a context scoped
+// to it. Background jobs use it as they don't act for a request.
+func (s *services) forEachOrganization(ctx context.Context, fn func(ctx context.Context) error) error {
+	orgs, err := s.repo.Organization().GetOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, o := range orgs {
+		if err := fn(tenant.WithOrg(ctx, o.ID)); err != nil {
+			s.logger.Error().Err(err).Int("org_id", o.ID).Msg("organization job failed")
+		}
+	}
+	return nil
+}
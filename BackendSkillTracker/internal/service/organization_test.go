@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// inOrg matches a context scoped to the organization.
+func inOrg(orgID int) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		id, ok := tenant.OrgID(ctx)
+		return ok && id == orgID
+	})
+}
+
+func defaultOrgRepo() *MockOrganizationRepo {
+	r := new(MockOrganizationRepo)
+	r.On("GetOrganizationBySlug", mock.Anything, DefaultOrganization).
+		Return(&models.Organization{ID: 1, Slug: DefaultOrganization}, nil)
+	return r
+}
+
+func TestLogin_Organization(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	t.Run("user is looked up in the named organization only", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("Organization").Return(mockOrgRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockOrgRepo.On("GetOrganizationBySlug", ctx, "acme").Return(&models.Organization{ID: 2, Slug: "acme"}, nil)
+		mockUserRepo.On("GetUserByUsername", inOrg(2), "alice").
+			Return(&models.User{ID: 5, OrgID: 2, Username: "alice", PasswordHash: string(hash), Role: models.RoleEmployee}, nil)
+		mockSessionRepo := new(MockSessionRepo)
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockRepo.On("TwoFactor").Return(noTwoFactorRepo())
+		mockSessionRepo.On("CreateSession", inOrg(2), mock.Anything).Return(nil)
+
+		res, err := s.User().Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123", Organization: "acme"}, dto.ClientInfo{})
+
+		assert.NoError(t, err)
+		claims, err := testKeys.ValidateToken(res.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, claims.OrgID)
+		assert.Equal(t, 5, claims.UserID)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown organization", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("Organization").Return(mockOrgRepo)
+		mockOrgRepo.On("GetOrganizationBySlug", ctx, "nope").Return(nil, errors.New("not found"))
+
+		_, err := s.User().Login(ctx, &dto.LoginRequest{Username: "alice", Password: "password123", Organization: "nope"}, dto.ClientInfo{})
+
+		assert.EqualError(t, err, "invalid credentials")
+		mockRepo.AssertNotCalled(t, "User")
+	})
+}
+
+func TestSeedOrganization(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates organization and its admin", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("Organization").Return(mockOrgRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockOrgRepo.On("GetOrganizationBySlug", ctx, "acme").Return(nil, errors.New("not found"))
+		mockOrgRepo.On("CreateOrganization", ctx, mock.MatchedBy(func(o *models.Organization) bool {
+			return o.Slug == "acme" && o.Name == "Acme"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Organization).ID = 4
+		}).Return(nil)
+		mockUserRepo.On("GetUserByUsername", inOrg(4), "admin").Return(nil, errors.New("not found"))
+		mockUserRepo.On("CreateUser", inOrg(4), mock.MatchedBy(func(u *models.User) bool {
+			return u.Username == "admin" && u.Role == models.RoleManager
+		})).Return(nil)
+
+		assert.NoError(t, s.SeedOrganization(ctx, "acme", "Acme", "admin123"))
+		mockOrgRepo.AssertExpectations(t)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("existing admin is kept", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("Organization").Return(mockOrgRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockOrgRepo.On("GetOrganizationBySlug", ctx, "acme").Return(&models.Organization{ID: 4, Slug: "acme"}, nil)
+		mockUserRepo.On("GetUserByUsername", inOrg(4), "admin").Return(&models.User{ID: 1, OrgID: 4}, nil)
+
+		assert.NoError(t, s.SeedOrganization(ctx, "acme", "Acme", "admin123"))
+		mockOrgRepo.AssertNotCalled(t, "CreateOrganization", mock.Anything, mock.Anything)
+		mockUserRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestForEachOrganization(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockOrgRepo := new(MockOrganizationRepo)
+	s := &services{repo: mockRepo, logger: zerolog.Nop()}
+
+	mockRepo.On("Organization").Return(mockOrgRepo)
+	mockOrgRepo.On("GetOrganizations", mock.Anything).Return([]models.Organization{{ID: 1}, {ID: 2}}, nil)
+
+	var seen []int
+	err := s.forEachOrganization(context.Background(), func(ctx context.Context) error {
+		id, _ := tenant.OrgID(ctx)
+		seen = append(seen, id)
+		return errors.New("failure of one organization doesn't stop the others")
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, seen)
+}
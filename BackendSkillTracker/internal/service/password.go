@@ -0,0 +1,354 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"skilltracker/internal/utils/mail"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	purposePasswordChange = "password_change"
+	// maxPasswordBytes is as much as bcrypt hashes.
+	maxPasswordBytes        = 72
+	defaultPasswordResetTTL = time.Hour
+)
+
+// PasswordPolicy is what new passwords have to look like. The zero value
+// accepts any password.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Blocklist holds refused passwords, lowercased, e.g. from a list of
+	// breached passwords.
+	Blocklist map[string]struct{}
+	// History is how many recent passwords, the current one included,
+	// can't be reused.
+	History int
+}
+
+func (p PasswordPolicy) check(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return errors.New("password is too short")
+	}
+	if len(password) > maxPasswordBytes {
+		return errors.New("password is too long")
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireUpper && !upper:
+		return errors.New("password needs an uppercase letter")
+	case p.RequireLower && !lower:
+		return errors.New("password needs a lowercase letter")
+	case p.RequireDigit && !digit:
+		return errors.New("password needs a digit")
+	case p.RequireSymbol && !symbol:
+		return errors.New("password needs a symbol")
+	}
+	if _, blocked := p.Blocklist[strings.ToLower(password)]; blocked {
+		return errors.New("password is too common")
+	}
+	return nil
+}
+
+// LoadPasswordBlocklist reads refused passwords, one per line. Empty lines
+// and lines starting with # are skipped.
+func LoadPasswordBlocklist(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	list := make(map[string]struct{})
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list[strings.ToLower(line)] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return list, nil
+}
+
+// PasswordReset configures reset links sent by mail. Without a Mailer
+// users can't reset their password themselves.
+type PasswordReset struct {
+	// URL is the page of the frontend that takes the token as ?token=.
+	URL    string
+	TTL    time.Duration
+	Mailer mail.Sender
+}
+
+func (p PasswordReset) ttl() time.Duration {
+	if p.TTL <= 0 {
+		return defaultPasswordResetTTL
+	}
+	return p.TTL
+}
+
+func (p PasswordReset) link(token string) string {
+	sep := "?"
+	if strings.Contains(p.URL, "?") {
+		sep = "&"
+	}
+	return p.URL + sep + url.Values{"token": {token}}.Encode()
+}
+
+// setPassword replaces the password of u after checking it against the
+// policy and, for existing users, their recent passwords. The caller
+// saves u.
+func (s *services) setPassword(ctx context.Context, u *models.User, password string) error {
+	if err := s.opts.Password.check(password); err != nil {
+		return err
+	}
+	if u.ID != 0 {
+		reused, err := s.passwordReused(ctx, u, password)
+		if err != nil {
+			return err
+		}
+		if reused {
+			return errors.New("password was used recently")
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	if keep := s.opts.Password.History - 1; u.ID != 0 && u.PasswordHash != "" && keep > 0 {
+		h := &models.PasswordHistory{UserID: u.ID, PasswordHash: u.PasswordHash}
+		if err := s.repo.Password().AddPasswordHistory(ctx, h, keep); err != nil {
+			return err
+		}
+	}
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+func (s *services) passwordReused(ctx context.Context, u *models.User, password string) (bool, error) {
+	n := s.opts.Password.History
+	if n <= 0 {
+		return false, nil
+	}
+	hashes := []string{u.PasswordHash}
+	if n > 1 {
+		history, err := s.repo.Password().GetPasswordHistory(ctx, u.ID, n-1)
+		if err != nil {
+			return false, err
+		}
+		for _, h := range history {
+			hashes = append(hashes, h.PasswordHash)
+		}
+	}
+	for _, h := range hashes {
+		if h != "" && bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// passwordChange returns the challenge of a login that has to replace the
+// password first, or nil.
+func (s *services) passwordChange(u *models.User) (*dto.LoginResponse, error) {
+	if !u.MustChangePassword || !u.LocalAuth() {
+		return nil, nil
+	}
+	token, err := s.keys.GenerateChallengeToken(u.ID, u.OrgID, purposePasswordChange, challengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResponse{PasswordChange: true, ChallengeToken: token}, nil
+}
+
+func (s *services) GetPasswordPolicy() *dto.PasswordPolicyResponse {
+	p := s.opts.Password
+	return &dto.PasswordPolicyResponse{
+		MinLength:     p.MinLength,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+		History:       p.History,
+	}
+}
+
+// ChangeLoginPassword sets the new password of a login that returned a
+// password change challenge, and completes the login.
+func (s *services) ChangeLoginPassword(ctx context.Context, req *dto.PasswordChangeLoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	ctx, u, _, err := s.challengeUser(ctx, req.ChallengeToken, purposePasswordChange)
+	if err != nil {
+		return nil, err
+	}
+	if !u.MustChangePassword {
+		return nil, errors.New("invalid challenge token")
+	}
+	if err := s.setPassword(ctx, u, req.Password); err != nil {
+		return nil, err
+	}
+	u.MustChangePassword = false
+	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	s.securityEvent(zerolog.InfoLevel, "password_changed").Int("user_id", u.ID).Msg("initial password replaced")
+	s.audit(ctx, auditEntry{Action: "password.changed", TargetType: "user", TargetID: u.ID, Actor: u})
+	return s.startSession(ctx, u, client)
+}
+
+// ChangePassword replaces the password of the current user after checking
+// the current one. Wrong guesses count as failed logins of the user. All
+// sessions end, the one of the request included; the caller continues with
+// the returned tokens.
+func (s *services) ChangePassword(ctx context.Context, userID int, req *dto.ChangePasswordRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	u, err := s.repo.User().GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !u.LocalAuth() {
+		return nil, errors.New("password is managed by the directory")
+	}
+	now := time.Now()
+	throttled := s.opts.Lockout.enabled()
+	userKey, ipKey := loginKeys(u.OrgID, u.Username, client.IP)
+	if throttled {
+		if err := s.checkLoginThrottle(ctx, userKey, ipKey, now); err != nil {
+			return nil, err
+		}
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.CurrentPassword)) != nil {
+		if throttled {
+			s.recordLoginFailure(ctx, userKey, ipKey, u.Username, client.IP, now)
+		}
+		return nil, errors.New("invalid current password")
+	}
+	if err := s.setPassword(ctx, u, req.NewPassword); err != nil {
+		return nil, err
+	}
+	u.MustChangePassword = false
+	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	s.securityEvent(zerolog.InfoLevel, "password_changed").Int("user_id", u.ID).Msg("password changed by the user")
+	s.audit(ctx, auditEntry{Action: "password.changed", TargetType: "user", TargetID: u.ID})
+	if err := s.endAllSessions(ctx, u.ID); err != nil {
+		return nil, err
+	}
+	u.TokenVersion++
+	return s.startSession(ctx, u, client)
+}
+
+// RequestPasswordReset mails a reset link to a local user with an email
+// address. Unknown users are not reported, so accounts can't be probed.
+func (s *services) RequestPasswordReset(ctx context.Context, req *dto.ForgotPasswordRequest) error {
+	if s.opts.PasswordReset.Mailer == nil {
+		return errors.New("password reset unavailable")
+	}
+	slug := req.Organization
+	if slug == "" {
+		slug = DefaultOrganization
+	}
+	org, err := s.repo.Organization().GetOrganizationBySlug(ctx, slug)
+	if err != nil {
+		return nil
+	}
+	ctx = tenant.WithOrg(ctx, org.ID)
+	u, err := s.repo.User().GetUserByUsername(ctx, normalizeUsername(req.Login))
+	if err != nil && strings.Contains(req.Login, "@") {
+		u, err = s.repo.User().GetUserByEmail(ctx, req.Login)
+	}
+	if err != nil || !u.LocalAuth() || u.Email == "" {
+		s.securityEvent(zerolog.InfoLevel, "password_reset_refused").Str("login", req.Login).Msg("no local user with an email")
+		return nil
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	ttl := s.opts.PasswordReset.ttl()
+	t := &models.PasswordResetToken{UserID: u.ID, TokenHash: hashToken(token), ExpiresAt: time.Now().Add(ttl)}
+	if err := s.repo.Password().CreatePasswordResetToken(ctx, t); err != nil {
+		return err
+	}
+	msg := mail.Message{
+		To:      u.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello %s,\n\nTo set a new password for %s, open this link within %s:\n\n%s\n\n"+
+			"If you didn't ask for this, ignore this message; your password stays unchanged.\n",
+			u.Name, u.Username, ttl, s.opts.PasswordReset.link(token)),
+	}
+	// The outcome isn't returned either, or it would tell the user exists.
+	if err := s.opts.PasswordReset.Mailer.Send(ctx, msg); err != nil {
+		s.logger.Error().Err(err).Int("user_id", u.ID).Msg("failed to send password reset mail")
+		return nil
+	}
+	s.securityEvent(zerolog.InfoLevel, "password_reset_requested").Int("user_id", u.ID).Msg("password reset link sent")
+	s.audit(ctx, auditEntry{Action: "password.reset_requested", TargetType: "user", TargetID: u.ID})
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token. Each token works
+// once. All sessions of the user end and a lockout of the username is
+// lifted.
+func (s *services) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error {
+	// The token identifies the user and with it the organization.
+	t, err := s.repo.Password().GetPasswordResetToken(tenant.System(ctx), hashToken(req.Token))
+	now := time.Now()
+	if err != nil || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return errors.New("invalid reset token")
+	}
+	ctx = tenant.WithOrg(ctx, t.OrgID)
+	u, err := s.repo.User().GetUserByID(ctx, t.UserID)
+	if err != nil || !u.LocalAuth() {
+		return errors.New("invalid reset token")
+	}
+	if err := s.setPassword(ctx, u, req.Password); err != nil {
+		return err
+	}
+	used, err := s.repo.Password().UsePasswordResetToken(ctx, t.ID, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errors.New("invalid reset token")
+	}
+	u.MustChangePassword = false
+	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
+		return err
+	}
+	s.securityEvent(zerolog.InfoLevel, "password_reset").Int("user_id", u.ID).Msg("password reset with a mailed link")
+	s.audit(ctx, auditEntry{Action: "password.reset", TargetType: "user", TargetID: u.ID, Actor: u})
+	s.loginSucceeded(ctx, u)
+	return s.endAllSessions(ctx, u.ID)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"skilltracker/internal/utils/mail"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeMailer struct {
+	sent []mail.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type passwordFixture struct {
+	users    *MockUserRepo
+	sessions *MockSessionRepo
+	pw       *MockPasswordRepo
+	mailer   *fakeMailer
+	s        ServiceInterface
+}
+
+func newPasswordFixture(policy PasswordPolicy) *passwordFixture {
+	f := &passwordFixture{users: new(MockUserRepo), sessions: new(MockSessionRepo), pw: new(MockPasswordRepo), mailer: &fakeMailer{}}
+	mockRepo := new(MockRepo)
+	mockRepo.On("Organization").Return(defaultOrgRepo())
+	mockRepo.On("Audit").Return(acceptingAuditRepo())
+	mockRepo.On("User").Return(f.users)
+	mockRepo.On("Session").Return(f.sessions)
+	mockRepo.On("TwoFactor").Return(noTwoFactorRepo())
+	mockRepo.On("Password").Return(f.pw)
+	f.sessions.On("CreateSession", mock.Anything, mock.Anything).Return(nil)
+	// Each reset token issued can be looked up by its hash.
+	f.pw.On("CreatePasswordResetToken", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		t := *args.Get(1).(*models.PasswordResetToken)
+		t.ID, t.OrgID = 1, 1
+		f.pw.On("GetPasswordResetToken", mock.Anything, t.TokenHash).Return(&t, nil)
+	}).Return(nil).Maybe()
+	f.s = New(mockRepo, zerolog.Nop(), testKeys, Options{
+		Password:      policy,
+		PasswordReset: PasswordReset{URL: "https://app.example.com/reset", Mailer: f.mailer},
+	})
+	return f
+}
+
+func hashed(password string) string {
+	h, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return string(h)
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	p := PasswordPolicy{
+		MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true,
+		Blocklist: map[string]struct{}{"password1!": {}},
+	}
+	for password, want := range map[string]string{
+		"Sh0rt!":                 "password is too short",
+		"lowercase1!":            "password needs an uppercase letter",
+		"UPPERCASE1!":            "password needs a lowercase letter",
+		"NoDigitsHere!":          "password needs a digit",
+		"NoSymbols123":           "password needs a symbol",
+		"Password1!":             "password is too common",
+		"Пароль-надёжный1":       "",
+		string(make([]byte, 73)): "password is too long",
+	} {
+		err := p.check(password)
+		if want == "" {
+			assert.NoError(t, err, password)
+		} else {
+			assert.EqualError(t, err, want, password)
+		}
+	}
+	assert.NoError(t, PasswordPolicy{}.check("x"), "the zero policy accepts anything")
+}
+
+func TestLoadPasswordBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# top passwords\n123456\n\nQwerty\n"), 0o600))
+
+	list, err := LoadPasswordBlocklist(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"123456": {}, "qwerty": {}}, list)
+}
+
+func TestCreateUser_PasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	f := newPasswordFixture(PasswordPolicy{MinLength: 10})
+
+	_, err := f.s.User().CreateUser(ctx, &dto.UserRequest{Username: "bob", Password: "short", Role: "employee", Name: "Bob"}, "manager")
+	assert.EqualError(t, err, "password is too short")
+
+	f.users.On("CreateUser", ctx, mock.MatchedBy(func(u *models.User) bool {
+		return u.MustChangePassword && bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("long enough pw")) == nil
+	})).Return(nil)
+	_, err = f.s.User().CreateUser(ctx, &dto.UserRequest{Username: "bob", Password: "long enough pw", Role: "employee", Name: "Bob"}, "manager")
+	require.NoError(t, err)
+	f.users.AssertExpectations(t)
+}
+
+func TestUpdateUser_PasswordHistory(t *testing.T) {
+	ctx := context.Background()
+	f := newPasswordFixture(PasswordPolicy{History: 3})
+	u := &models.User{ID: 7, Username: "bob", Role: models.RoleEmployee, PasswordHash: hashed("current-pw")}
+	f.users.On("GetUserByID", ctx, 7).Return(u, nil)
+	f.users.On("UpdateUser", ctx, mock.Anything).Return(nil)
+	f.users.On("BumpTokenVersion", ctx, 7).Return(nil)
+	f.sessions.On("RevokeUserSessions", ctx, 7, mock.Anything).Return(nil)
+	// History comes newest first; the fourth lookup sees the replaced one.
+	f.pw.On("GetPasswordHistory", ctx, 7, 2).Return([]models.PasswordHistory{
+		{UserID: 7, PasswordHash: hashed("previous-pw")},
+		{UserID: 7, PasswordHash: hashed("oldest-pw")},
+	}, nil).Times(3)
+	f.pw.On("GetPasswordHistory", ctx, 7, 2).Return([]models.PasswordHistory{
+		{UserID: 7, PasswordHash: hashed("current-pw")},
+		{UserID: 7, PasswordHash: hashed("previous-pw")},
+	}, nil)
+	f.pw.On("AddPasswordHistory", ctx, mock.Anything, 2).Return(nil)
+	update := func(password string) error {
+		return f.s.User().UpdateUser(ctx, 7, &dto.UserRequest{Password: password}, 1, "manager", true)
+	}
+
+	assert.EqualError(t, update("current-pw"), "password was used recently")
+	assert.EqualError(t, update("previous-pw"), "password was used recently")
+
+	require.NoError(t, update("brand-new-pw"))
+	assert.True(t, u.MustChangePassword, "a password set by a manager is temporary")
+	f.pw.AssertCalled(t, "AddPasswordHistory", ctx, mock.MatchedBy(func(h *models.PasswordHistory) bool {
+		return h.UserID == 7 && bcrypt.CompareHashAndPassword([]byte(h.PasswordHash), []byte("current-pw")) == nil
+	}), 2)
+	assert.NoError(t, update("oldest-pw"), "it dropped out of the history")
+}
+
+func TestUpdateUser_PasswordEndsSessions(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 1)
+	f := newPasswordFixture(PasswordPolicy{})
+	u := &models.User{ID: 7, OrgID: 1, Username: "bob", Role: models.RoleEmployee, PasswordHash: hashed("current-pw")}
+	rt := &models.RefreshToken{ID: 1, OrgID: 1, SessionID: 3,
+		Session: models.Session{ID: 3, OrgID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Hour)}}
+	f.users.On("GetUserByID", ctx, 7).Return(u, nil)
+	f.users.On("UpdateUser", ctx, u).Return(nil)
+	f.users.On("BumpTokenVersion", ctx, 7).Return(nil)
+	f.sessions.On("RevokeUserSessions", ctx, 7, mock.Anything).Run(func(args mock.Arguments) {
+		at := args.Get(2).(time.Time)
+		rt.Session.RevokedAt = &at
+	}).Return(nil)
+	f.sessions.On("GetRefreshToken", mock.Anything, hashToken("bob-refresh")).Return(rt, nil)
+
+	require.NoError(t, f.s.User().UpdateUser(ctx, 7, &dto.UserRequest{Password: "brand-new-pw"}, 1, "manager", true))
+
+	_, err := f.s.User().RefreshToken(context.Background(), &dto.RefreshRequest{RefreshToken: "bob-refresh"}, dto.ClientInfo{})
+	assert.EqualError(t, err, "invalid refresh token")
+	f.sessions.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogin_MustChangePassword(t *testing.T) {
+	ctx := context.Background()
+	f := newPasswordFixture(PasswordPolicy{MinLength: 8})
+	u := &models.User{ID: 7, OrgID: 1, Username: "bob", Role: models.RoleEmployee, PasswordHash: hashed("temp-pass"), MustChangePassword: true}
+	f.users.On("GetUserByUsername", inOrg(1), "bob").Return(u, nil)
+	f.users.On("GetUserByID", inOrg(1), 7).Return(u, nil)
+	f.users.On("UpdateUser", inOrg(1), u).Return(nil)
+
+	res, err := f.s.User().Login(ctx, &dto.LoginRequest{Username: "bob", Password: "temp-pass"}, dto.ClientInfo{})
+	require.NoError(t, err)
+	assert.True(t, res.PasswordChange)
+	assert.Empty(t, res.AccessToken, "no tokens until the password is replaced")
+
+	change := func(password string) (*dto.LoginResponse, error) {
+		return f.s.User().ChangeLoginPassword(ctx, &dto.PasswordChangeLoginRequest{ChallengeToken: res.ChallengeToken, Password: password}, dto.ClientInfo{})
+	}
+	_, err = change("short")
+	assert.EqualError(t, err, "password is too short")
+
+	done, err := change("my-own-password")
+	require.NoError(t, err)
+	assert.NotEmpty(t, done.AccessToken)
+	assert.False(t, u.MustChangePassword)
+
+	_, err = change("yet-another-password")
+	assert.EqualError(t, err, "invalid challenge token", "the challenge is spent")
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	alice := func() *models.User {
+		return &models.User{ID: 7, OrgID: 1, Username: "alice", Name: "Alice", Email: "alice@example.com", Role: models.RoleEmployee, PasswordHash: hashed("forgotten")}
+	}
+	// requestLink asks for a reset and returns the token of the mailed link.
+	requestLink := func(t *testing.T, f *passwordFixture, login string) string {
+		require.NoError(t, f.s.User().RequestPasswordReset(ctx, &dto.ForgotPasswordRequest{Login: login}))
+		require.Len(t, f.mailer.sent, 1)
+		assert.Equal(t, "alice@example.com", f.mailer.sent[0].To)
+		link := regexp.MustCompile(`https://\S+`).FindString(f.mailer.sent[0].Body)
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		return u.Query().Get("token")
+	}
+
+	t.Run("reset with the mailed link", func(t *testing.T) {
+		f := newPasswordFixture(PasswordPolicy{MinLength: 8})
+		u := alice()
+		u.MustChangePassword = true
+		f.users.On("GetUserByUsername", inOrg(1), "alice").Return(u, nil)
+		f.users.On("GetUserByID", inOrg(1), 7).Return(u, nil)
+		f.users.On("UpdateUser", inOrg(1), u).Return(nil)
+		f.users.On("BumpTokenVersion", inOrg(1), 7).Return(nil)
+		f.sessions.On("RevokeUserSessions", inOrg(1), 7, mock.Anything).Return(nil)
+		f.pw.On("UsePasswordResetToken", inOrg(1), 1, mock.Anything).Return(true, nil).Once()
+		f.pw.On("UsePasswordResetToken", inOrg(1), 1, mock.Anything).Return(false, nil)
+		token := requestLink(t, f, "alice")
+
+		reset := func(password string) error {
+			return f.s.User().ResetPassword(ctx, &dto.ResetPasswordRequest{Token: token, Password: password})
+		}
+		assert.EqualError(t, reset("short"), "password is too short")
+		require.NoError(t, reset("remembered-now"))
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("remembered-now")))
+		assert.False(t, u.MustChangePassword)
+		f.sessions.AssertCalled(t, "RevokeUserSessions", inOrg(1), 7, mock.Anything)
+
+		assert.EqualError(t, reset("another-one"), "invalid reset token", "links work once")
+	})
+
+	t.Run("by email", func(t *testing.T) {
+		f := newPasswordFixture(PasswordPolicy{})
+		f.users.On("GetUserByUsername", inOrg(1), "alice@example.com").Return(nil, errors.New("not found"))
+		f.users.On("GetUserByEmail", inOrg(1), "alice@example.com").Return(alice(), nil)
+
+		assert.NotEmpty(t, requestLink(t, f, "alice@example.com"))
+	})
+
+	t.Run("expired links are refused", func(t *testing.T) {
+		f := newPasswordFixture(PasswordPolicy{})
+		f.pw.On("GetPasswordResetToken", mock.Anything, hashToken("tok")).
+			Return(&models.PasswordResetToken{ID: 1, OrgID: 1, UserID: 7, TokenHash: hashToken("tok"), ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+
+		err := f.s.User().ResetPassword(ctx, &dto.ResetPasswordRequest{Token: "tok", Password: "whatever"})
+
+		assert.EqualError(t, err, "invalid reset token")
+	})
+
+	t.Run("unknown and directory users get no mail and no error", func(t *testing.T) {
+		f := newPasswordFixture(PasswordPolicy{})
+		f.users.On("GetUserByUsername", inOrg(1), "nobody").Return(nil, errors.New("not found"))
+		directory := alice()
+		directory.AuthSource = "ldap"
+		f.users.On("GetUserByUsername", inOrg(1), "alice").Return(directory, nil)
+
+		assert.NoError(t, f.s.User().RequestPasswordReset(ctx, &dto.ForgotPasswordRequest{Login: "nobody"}))
+		assert.NoError(t, f.s.User().RequestPasswordReset(ctx, &dto.ForgotPasswordRequest{Login: "alice"}))
+		assert.Empty(t, f.mailer.sent)
+		f.pw.AssertNotCalled(t, "CreatePasswordResetToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unavailable without a mailer", func(t *testing.T) {
+		s := New(new(MockRepo), zerolog.Nop(), testKeys, Options{})
+
+		err := s.User().RequestPasswordReset(ctx, &dto.ForgotPasswordRequest{Login: "alice"})
+
+		assert.EqualError(t, err, "password reset unavailable")
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+)
+
+// avatarURLPrefix is where the files in uploads/avatars are served.
+const avatarURLPrefix = "/uploads/avatars/"
+
+func avatarURL(name string) string {
+	if name == "" {
+		return ""
+	}
+	return avatarURLPrefix + name
+}
+
+func profileToDTO(u *models.User) *dto.ProfileResponse {
+	contacts := make([]dto.Contact, 0, len(u.Contacts))
+	for _, c := range u.Contacts {
+		contacts = append(contacts, dto.Contact{Type: c.Type, Value: c.Value})
+	}
+	return &dto.ProfileResponse{
+		UserResponse: userToDTO(u),
+		Timezone:     u.Timezone,
+		Locale:       u.Locale,
+		Contacts:     contacts,
+	}
+}
+
+func (s *services) Profile() ProfileService { return s }
+
+func (s *services) GetProfile(ctx context.Context, userID int) (*dto.ProfileResponse, error) {
+	u, err := s.repo.User().GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return profileToDTO(u), nil
+}
+
+// UpdateProfile replaces the fields users edit themselves. The name of a
+// directory user is synced at login, so it can't be changed here.
+func (s *services) UpdateProfile(ctx context.Context, userID int, req *dto.UpdateProfileRequest) (*dto.ProfileResponse, error) {
+	u, err := s.repo.User().GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !u.LocalAuth() && req.Name != u.Name {
+		return nil, errors.New("name is managed by the directory")
+	}
+	before := profileToDTO(u)
+	u.Name = req.Name
+	u.Timezone = req.Timezone
+	u.Locale = req.Locale
+	u.Contacts = make([]models.UserContact, 0, len(req.Contacts))
+	for _, c := range req.Contacts {
+		u.Contacts = append(u.Contacts, models.UserContact{Type: c.Type, Value: c.Value})
+	}
+	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	after := profileToDTO(u)
+	s.audit(ctx, auditEntry{Action: "profile.updated", TargetType: "user", TargetID: u.ID, Before: before, After: after})
+	return after, nil
+}
+
+// SetAvatar records the uploaded avatar file of the user, or removes the
+// avatar when fileName is empty. It returns the file it replaces, which
+// the caller deletes.
+func (s *services) SetAvatar(ctx context.Context, userID int, fileName string) (string, error) {
+	u, err := s.repo.User().GetUserByID(ctx, userID)
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+	previous := u.Avatar
+	u.Avatar = fileName
+	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
+		return "", err
+	}
+	return previous, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 1)
+	req := &dto.UpdateProfileRequest{
+		Name:     "Alice Smith",
+		Timezone: "Europe/Moscow",
+		Locale:   "ru",
+		Contacts: []dto.Contact{{Type: "telegram", Value: "@alice"}},
+	}
+
+	t.Run("own fields are replaced and audited", func(t *testing.T) {
+		users, logs, s := newAuditFixture(Options{})
+		u := &models.User{ID: 5, OrgID: 1, Username: "alice", Name: "Alice", Email: "alice@example.com", Role: models.RoleEmployee,
+			Position: "Developer", AuthSource: models.AuthLocal, Contacts: []models.UserContact{{Type: "phone", Value: "+7 900"}}}
+		users.On("GetUserByID", ctx, 5).Return(u, nil)
+		users.On("UpdateUser", ctx, u).Return(nil)
+
+		res, err := s.Profile().UpdateProfile(ctx, 5, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Alice Smith", res.Name)
+		assert.Equal(t, "Europe/Moscow", res.Timezone)
+		assert.Equal(t, []dto.Contact{{Type: "telegram", Value: "@alice"}}, res.Contacts)
+		assert.Equal(t, "Developer", res.Position, "position stays with managers")
+		assert.Equal(t, "alice@example.com", res.Email, "so does the email")
+		require.Len(t, logs.created(), 1)
+		assert.Equal(t, "profile.updated", logs.created()[0].Action)
+		assert.Contains(t, *logs.created()[0].Before, `"+7 900"`)
+	})
+
+	t.Run("directory users keep the directory name", func(t *testing.T) {
+		users, _, s := newAuditFixture(Options{})
+		users.On("GetUserByID", ctx, 5).
+			Return(&models.User{ID: 5, OrgID: 1, Username: "alice", Name: "Alice", AuthSource: "ldap"}, nil)
+
+		_, err := s.Profile().UpdateProfile(ctx, 5, req)
+
+		assert.EqualError(t, err, "name is managed by the directory")
+		users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSetAvatar_ReturnsReplacedFile(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 1)
+	users, _, s := newAuditFixture(Options{})
+	u := &models.User{ID: 5, OrgID: 1, Avatar: "5_1.png"}
+	users.On("GetUserByID", ctx, 5).Return(u, nil)
+	users.On("UpdateUser", ctx, u).Return(nil)
+
+	previous, err := s.Profile().SetAvatar(ctx, 5, "5_2.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "5_1.png", previous)
+
+	res, err := s.Profile().GetProfile(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "/uploads/avatars/5_2.jpg", res.AvatarURL)
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 1)
+	change := func(s ServiceInterface, current, next string) (*dto.LoginResponse, error) {
+		return s.Profile().ChangePassword(ctx, 5, &dto.ChangePasswordRequest{CurrentPassword: current, NewPassword: next}, dto.ClientInfo{IP: "10.0.0.1"})
+	}
+
+	t.Run("sessions end and a new one starts", func(t *testing.T) {
+		f := newPasswordFixture(PasswordPolicy{MinLength: 8, History: 2})
+		u := &models.User{ID: 5, OrgID: 1, Username: "alice", Role: models.RoleEmployee, PasswordHash: hashed("old-password"), MustChangePassword: true}
+		f.users.On("GetUserByID", ctx, 5).Return(u, nil)
+		f.users.On("UpdateUser", ctx, u).Return(nil)
+		f.users.On("BumpTokenVersion", ctx, 5).Return(nil)
+		f.sessions.On("RevokeUserSessions", ctx, 5, mock.Anything).Return(nil)
+		f.pw.On("GetPasswordHistory", ctx, 5, 1).Return([]models.PasswordHistory{}, nil)
+		f.pw.On("AddPasswordHistory", ctx, &models.PasswordHistory{UserID: 5, PasswordHash: u.PasswordHash}, 1).Return(nil)
+
+		_, err := change(f.s, "old-password", "short")
+		assert.EqualError(t, err, "password is too short")
+		_, err = change(f.s, "old-password", "old-password")
+		assert.EqualError(t, err, "password was used recently")
+
+		res, err := change(f.s, "old-password", "new-password")
+		require.NoError(t, err)
+		assert.NotEmpty(t, res.AccessToken)
+		assert.NotEmpty(t, res.RefreshToken)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("new-password")))
+		assert.False(t, u.MustChangePassword)
+		assert.Equal(t, 1, u.TokenVersion, "the new tokens carry the bumped version")
+		f.sessions.AssertCalled(t, "RevokeUserSessions", ctx, 5, mock.Anything)
+		f.pw.AssertExpectations(t)
+	})
+
+	t.Run("wrong current passwords count as failed logins", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 2, Duration: 15 * time.Minute})
+
+		for i := 0; i < 2; i++ {
+			_, err := change(s, "guess", "new-password")
+			assert.EqualError(t, err, "invalid current password")
+		}
+		_, err := change(s, "password123", "new-password")
+		assert.EqualError(t, err, "account locked")
+	})
+
+	t.Run("directory users change it in the directory", func(t *testing.T) {
+		f := newPasswordFixture(PasswordPolicy{})
+		f.users.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, OrgID: 1, Username: "alice", AuthSource: "ldap"}, nil)
+
+		_, err := change(f.s, "anything", "new-password")
+		assert.EqualError(t, err, "password is managed by the directory")
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("types are on until turned off", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		notifications := new(MockNotificationRepo)
+		mockRepo.On("Notification").Return(notifications)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+		notifications.On("SaveNotificationPreferences", ctx, []models.NotificationPreference{
+			{UserID: 5, Type: models.NotificationReassigned, Enabled: false},
+		}).Return(nil)
+		notifications.On("GetNotificationPreferences", ctx, 5).Return([]models.NotificationPreference{
+			{UserID: 5, Type: models.NotificationReassigned, Enabled: false},
+		}, nil)
+
+		res, err := s.Notification().UpdateNotificationPreferences(ctx, 5, &dto.NotificationPreferencesRequest{
+			Preferences: []dto.NotificationPreference{{Type: "reassigned", Enabled: false}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []dto.NotificationPreference{
+			{Type: "assigned", Enabled: true},
+			{Type: "status_changed", Enabled: true},
+			{Type: "reassigned", Enabled: false},
+		}, res)
+	})
+
+	t.Run("muted users get no notification", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		notifications := new(MockNotificationRepo)
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Notification").Return(notifications)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+		mockTaskRepo.On("UpdateTask", ctx, mock.Anything).Return(nil)
+		mockTaskRepo.On("CreateHistory", ctx, mock.Anything).Return(nil)
+		notifications.On("GetMutedUsers", ctx, mock.Anything, models.NotificationStatusChanged).Return([]int{20}, nil)
+		notifications.On("CreateNotifications", ctx, mock.MatchedBy(func(ns []models.Notification) bool {
+			for _, n := range ns {
+				if n.UserID == 20 {
+					return false
+				}
+			}
+			return len(ns) == 2
+		})).Return(nil)
+
+		err := s.Task().UpdateTask(ctx, 1, &dto.TaskRequest{Status: "in_progress"}, 30, "employee", false)
+
+		assert.NoError(t, err)
+		notifications.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+	"time"
+)
+
+// PROJECTS
+
+func (s *services) Project() ProjectService { return s }
+
+func projectToDTO(p *models.Project, prog *models.ProjectProgress) *dto.ProjectResponse {
+	res := &dto.ProjectResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		OwnerID:     p.OwnerID,
+		StartDate:   p.StartDate,
+		EndDate:     p.EndDate,
+		Status:      string(p.Status),
+		Members:     usersToSummary(p.Members),
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+	if prog != nil && prog.Tasks > 0 {
+		res.TaskCount = prog.Tasks
+		res.CompletedTasks = prog.Completed
+		res.Progress = prog.ProgressSum / prog.Tasks
+	}
+	return res
+}
+
+func parseOptionalDate(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	d, err := time.Parse(dateLayout, v)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func isProjectMember(p *models.Project, userID int) bool {
+	if p.OwnerID == userID {
+		return true
+	}
+	for _, u := range p.Members {
+		if u.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *services) applyProjectRequest(ctx context.Context, p *models.Project, req *dto.ProjectRequest) error {
+	start, err := parseOptionalDate(req.StartDate)
+	if err != nil {
+		return errors.New("invalid start_date format")
+	}
+	end, err := parseOptionalDate(req.EndDate)
+	if err != nil {
+		return errors.New("invalid end_date format")
+	}
+	if start != nil && end != nil && end.Before(*start) {
+		return errors.New("end date before start date")
+	}
+
+	members := make([]models.User, 0, len(req.MemberIDs))
+	seen := make(map[int]struct{}, len(req.MemberIDs))
+	for _, id := range req.MemberIDs {
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		u, err := s.repo.User().GetUserByID(ctx, id)
+		if err != nil {
+			return errors.New("user not found")
+		}
+		members = append(members, *u)
+	}
+
+	p.Name = req.Name
+	p.Description = req.Description
+	p.StartDate = start
+	p.EndDate = end
+	p.Status = models.ProjectStatus(req.Status)
+	if p.Status == "" {
+		p.Status = models.ProjectActive
+	}
+	p.Members = members
+	return nil
+}
+
+func memberIDs(p *models.Project) []int {
+	ids := make([]int, 0, len(p.Members))
+	for _, u := range p.Members {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+// projectProgress returns the task rollup of the projects keyed by project ID.
+func (s *services) projectProgress(ctx context.Context, ps []models.Project) (map[int]*models.ProjectProgress, error) {
+	ids := make([]int, 0, len(ps))
+	for _, p := range ps {
+		ids = append(ids, p.ID)
+	}
+	rows, err := s.repo.Project().GetProjectProgress(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int]*models.ProjectProgress, len(rows))
+	for i := range rows {
+		out[rows[i].ProjectID] = &rows[i]
+	}
+	return out, nil
+}
+
+func (s *services) CreateProject(ctx context.Context, req *dto.ProjectRequest, ownerID int) (*dto.ProjectResponse, error) {
+	p := &models.Project{OwnerID: ownerID}
+	if err := s.applyProjectRequest(ctx, p, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Project().CreateProject(ctx, p); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Project().SetProjectMembers(ctx, p.ID, memberIDs(p)); err != nil {
+		return nil, err
+	}
+	return projectToDTO(p, nil), nil
+}
+
+// GetProjects returns all projects to managers and member projects to employees.
+func (s *services) GetProjects(ctx context.Context, userID int, role string) ([]*dto.ProjectResponse, error) {
+	var (
+		ps  []models.Project
+		err error
+	)
+	if s.Can(ctx, role, permission.ProjectReadAny) {
+		ps, err = s.repo.Project().GetProjects(ctx)
+	} else {
+		ps, err = s.repo.Project().GetProjectsByMember(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.projectProgress(ctx, ps)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.ProjectResponse, 0, len(ps))
+	for i := range ps {
+		out = append(out, projectToDTO(&ps[i], progress[ps[i].ID]))
+	}
+	return out, nil
+}
+
+func (s *services) getVisibleProject(ctx context.Context, id int, userID int, role string) (*models.Project, error) {
+	p, err := s.repo.Project().GetProjectByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("project not found")
+	}
+	if !s.Can(ctx, role, permission.ProjectReadAny) && !isProjectMember(p, userID) {
+		return nil, errors.New("project not found")
+	}
+	return p, nil
+}
+
+func (s *services) GetProjectByID(ctx context.Context, id int, userID int, role string) (*dto.ProjectResponse, error) {
+	p, err := s.getVisibleProject(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.projectProgress(ctx, []models.Project{*p})
+	if err != nil {
+		return nil, err
+	}
+	return projectToDTO(p, progress[p.ID]), nil
+}
+
+func (s *services) UpdateProject(ctx context.Context, id int, req *dto.ProjectRequest, userID int) error {
+	p, err := s.repo.Project().GetProjectByID(ctx, id)
+	if err != nil {
+		return errors.New("project not found")
+	}
+	if p.OwnerID != userID {
+		return errors.New("forbidden")
+	}
+	if err := s.applyProjectRequest(ctx, p, req); err != nil {
+		return err
+	}
+	if err := s.repo.Project().UpdateProject(ctx, p); err != nil {
+		return err
+	}
+	return s.repo.Project().SetProjectMembers(ctx, p.ID, memberIDs(p))
+}
+
+func (s *services) DeleteProject(ctx context.Context, id int, userID int) error {
+	p, err := s.repo.Project().GetProjectByID(ctx, id)
+	if err != nil {
+		return errors.New("project not found")
+	}
+	if p.OwnerID != userID {
+		return errors.New("forbidden")
+	}
+	return s.repo.Project().DeleteProject(ctx, id)
+}
+
+// resolveProjectID checks the project referenced by a task request;
+// 0 means no project.
+func (s *services) resolveProjectID(ctx context.Context, id int) (*int, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	if _, err := s.repo.Project().GetProjectByID(ctx, id); err != nil {
+		return nil, errors.New("project not found")
+	}
+	return &id, nil
+}
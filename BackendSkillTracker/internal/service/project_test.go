@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectService_GetProjects(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("employee sees member projects with progress", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockProjectRepo := new(MockProjectRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Project").Return(mockProjectRepo)
+		mockProjectRepo.On("GetProjectsByMember", ctx, 3).Return([]models.Project{
+			{ID: 1, Name: "Migration", OwnerID: 2, Members: []models.User{{ID: 3}}},
+		}, nil)
+		mockProjectRepo.On("GetProjectProgress", ctx, []int{1}).Return([]models.ProjectProgress{
+			{ProjectID: 1, Tasks: 4, Completed: 1, ProgressSum: 190},
+		}, nil)
+
+		res, err := s.Project().GetProjects(ctx, 3, "employee")
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, 4, res[0].TaskCount)
+		assert.Equal(t, 47, res[0].Progress)
+		mockProjectRepo.AssertNotCalled(t, "GetProjects", ctx)
+	})
+}
+
+func TestProjectService_GetProjectByID(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockProjectRepo := new(MockProjectRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	mockRepo.On("Project").Return(mockProjectRepo)
+	mockProjectRepo.On("GetProjectByID", ctx, 1).
+		Return(&models.Project{ID: 1, OwnerID: 2, Members: []models.User{{ID: 3}}}, nil)
+
+	_, err := s.Project().GetProjectByID(ctx, 1, 4, "employee")
+
+	assert.Error(t, err)
+	assert.Equal(t, "project not found", err.Error())
+}
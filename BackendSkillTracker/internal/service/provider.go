@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/models"
+	"skilltracker/internal/repository"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials is returned by an AuthProvider that knows the user
+// but not the password. Login then fails without asking other providers.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// AuthProvider checks passwords at login. Providers are asked in the
+// configured order; one that doesn't know the user returns a nil Identity
+// and the next one is asked.
+type AuthProvider interface {
+	// Name is the AuthSource of the users the provider provisions.
+	Name() string
+	// Authenticate checks the password of a user of the organization with
+	// slug org. The context is scoped to that organization.
+	Authenticate(ctx context.Context, org, username, password string) (*Identity, error)
+}
+
+// Identity is a user as an AuthProvider knows them.
+type Identity struct {
+	Username string
+	Name     string
+	// Role comes from the directory and replaces the stored role at each
+	// login.
+	Role models.Role
+	// User is set by providers checking stored users, which need no
+	// provisioning.
+	User *models.User
+}
+
+// passwordProvider checks the bcrypt hash of local users.
+type passwordProvider struct {
+	repo repository.Repository
+}
+
+// NewPasswordProvider returns the provider of users created with
+// CreateUser. It is the only one unless Options.Providers says otherwise.
+func NewPasswordProvider(repo repository.Repository) AuthProvider {
+	return passwordProvider{repo: repo}
+}
+
+func (p passwordProvider) Name() string { return models.AuthLocal }
+
+func (p passwordProvider) Authenticate(ctx context.Context, _, username, password string) (*Identity, error) {
+	u, err := p.repo.User().GetUserByUsername(ctx, username)
+	if err != nil || !u.LocalAuth() {
+		return nil, nil
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &Identity{Username: u.Username, Name: u.Name, Role: u.Role, User: u}, nil
+}
+
+// authenticate returns the user the password belongs to, or nil. It fails
+// only if a provider that might have known the user is unavailable.
+func (s *services) authenticate(ctx context.Context, org, username, password string) (*models.User, error) {
+	var unavailable error
+	for _, p := range s.opts.Providers {
+		ident, err := p.Authenticate(ctx, org, username, password)
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			if err != ErrInvalidCredentials {
+				s.logger.Info().Err(err).Str("provider", p.Name()).Str("username", username).Msg("login refused")
+			}
+			return nil, nil
+		case err != nil:
+			s.logger.Error().Err(err).Str("provider", p.Name()).Msg("auth provider failed")
+			unavailable = err
+			continue
+		case ident == nil:
+			continue
+		}
+		if ident.User != nil {
+			return ident.User, nil
+		}
+		return s.provisionUser(ctx, p.Name(), ident)
+	}
+	if unavailable != nil {
+		return nil, errors.New("authentication unavailable")
+	}
+	return nil, nil
+}
+
+// provisionUser creates the user of a directory identity at their first
+// login and keeps name and role in sync afterwards. A username taken by a
+// user of another source is refused, so a directory can't take over local
+// accounts.
+func (s *services) provisionUser(ctx context.Context, source string, ident *Identity) (*models.User, error) {
+	u, err := s.repo.User().GetUserByUsername(ctx, ident.Username)
+	if err != nil {
+		u = &models.User{Username: ident.Username, Name: ident.Name, Role: ident.Role, AuthSource: source}
+		if u.Name == "" {
+			u.Name = ident.Username
+		}
+		if err := s.repo.User().CreateUser(ctx, u); err != nil {
+			return nil, err
+		}
+		s.securityEvent(zerolog.InfoLevel, "user_provisioned").Int("user_id", u.ID).Str("username", u.Username).
+			Str("source", source).Str("role", string(u.Role)).Msg("user provisioned")
+		s.audit(ctx, auditEntry{Action: "user.provisioned", TargetType: "user", TargetID: u.ID, Actor: u, After: userSnapshot(u)})
+		return u, nil
+	}
+	if u.AuthSource != source {
+		s.securityEvent(zerolog.WarnLevel, "auth_source_mismatch").Str("username", u.Username).
+			Str("source", source).Str("user_source", u.AuthSource).Msg("directory login for a user of another source")
+		return nil, nil
+	}
+	return s.syncUser(ctx, u, ident)
+}
+
+// syncUser applies the name and role an identity provider has for a user.
+// A new role revokes the access tokens carrying the old one and is audited
+// like a role change made by a manager.
+func (s *services) syncUser(ctx context.Context, u *models.User, ident *Identity) (*models.User, error) {
+	roleChanged := ident.Role != u.Role
+	if !roleChanged && (ident.Name == "" || ident.Name == u.Name) {
+		return u, nil
+	}
+	before := userSnapshot(u)
+	u.Role = ident.Role
+	if ident.Name != "" {
+		u.Name = ident.Name
+	}
+	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	if roleChanged {
+		if err := s.revokeAccessTokens(ctx, u.ID); err != nil {
+			return nil, err
+		}
+		u.TokenVersion++
+		s.securityEvent(zerolog.InfoLevel, "role_synced").Int("user_id", u.ID).Str("role", string(u.Role)).
+			Msg("role changed by identity provider")
+		s.audit(ctx, auditEntry{Action: "user.role_changed", TargetType: "user", TargetID: u.ID, Actor: u,
+			Before: before, After: userSnapshot(u)})
+	}
+	return u, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeProvider struct {
+	ident *Identity
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Name() string { return "ldap" }
+
+func (p *fakeProvider) Authenticate(context.Context, string, string, string) (*Identity, error) {
+	p.calls++
+	return p.ident, p.err
+}
+
+// providerService logs in with providers in the default organization; nil
+// stands for the local provider.
+func providerService(users *MockUserRepo, providers ...AuthProvider) ServiceInterface {
+	return providerServiceWithAudit(users, acceptingAuditRepo(), providers...)
+}
+
+func providerServiceWithAudit(users *MockUserRepo, logs *MockAuditRepo, providers ...AuthProvider) ServiceInterface {
+	mockRepo := new(MockRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	mockRepo.On("Organization").Return(defaultOrgRepo())
+	mockRepo.On("Audit").Return(logs)
+	mockRepo.On("User").Return(users)
+	mockRepo.On("Session").Return(mockSessionRepo)
+	mockRepo.On("TwoFactor").Return(noTwoFactorRepo())
+	mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(nil)
+	opts := Options{Providers: providers}
+	for i, p := range providers {
+		if p == nil {
+			providers[i] = NewPasswordProvider(mockRepo)
+		}
+	}
+	return New(mockRepo, zerolog.Nop(), testKeys, opts)
+}
+
+func loginAs(s ServiceInterface, username, password string) (*dto.LoginResponse, error) {
+	return s.User().Login(context.Background(), &dto.LoginRequest{Username: username, Password: password}, dto.ClientInfo{})
+}
+
+func TestLogin_Providers(t *testing.T) {
+	bob := &Identity{Username: "bob", Name: "Bob", Role: models.RoleManager}
+
+	t.Run("first login provisions the user", func(t *testing.T) {
+		users := new(MockUserRepo)
+		users.On("GetUserByUsername", inOrg(1), "bob").Return(nil, errors.New("not found"))
+		users.On("CreateUser", inOrg(1), mock.MatchedBy(func(u *models.User) bool {
+			return u.Username == "bob" && u.Name == "Bob" && u.Role == models.RoleManager && u.AuthSource == "ldap" && u.PasswordHash == ""
+		})).Run(func(args mock.Arguments) { args.Get(1).(*models.User).ID = 7 }).Return(nil)
+		s := providerService(users, &fakeProvider{ident: bob})
+
+		res, err := loginAs(s, "bob", "secret")
+
+		require.NoError(t, err)
+		claims, err := testKeys.ValidateToken(res.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, 7, claims.UserID)
+		assert.Equal(t, "manager", claims.Role)
+	})
+
+	t.Run("later logins sync the role", func(t *testing.T) {
+		users := new(MockUserRepo)
+		users.On("GetUserByUsername", inOrg(1), "bob").
+			Return(&models.User{ID: 7, OrgID: 1, Username: "bob", Name: "Bob", Role: models.RoleEmployee, AuthSource: "ldap", TokenVersion: 2}, nil)
+		users.On("UpdateUser", inOrg(1), mock.MatchedBy(func(u *models.User) bool { return u.Role == models.RoleManager })).Return(nil)
+		users.On("BumpTokenVersion", inOrg(1), 7).Return(nil)
+		logs := acceptingAuditRepo()
+		s := providerServiceWithAudit(users, logs, &fakeProvider{ident: bob})
+
+		res, err := loginAs(s, "bob", "secret")
+
+		require.NoError(t, err)
+		claims, _ := testKeys.ValidateToken(res.AccessToken)
+		assert.Equal(t, "manager", claims.Role)
+		assert.Equal(t, 3, claims.Version, "older tokens carry the old role")
+		users.AssertExpectations(t)
+		require.NotEmpty(t, logs.created())
+		changed := logs.created()[0]
+		assert.Equal(t, "user.role_changed", changed.Action)
+		assert.Equal(t, 7, *changed.TargetID)
+		assert.Contains(t, *changed.Before, `"role":"employee"`)
+		assert.Contains(t, *changed.After, `"role":"manager"`)
+	})
+
+	t.Run("local accounts aren't taken over", func(t *testing.T) {
+		users := new(MockUserRepo)
+		users.On("GetUserByUsername", inOrg(1), "bob").
+			Return(&models.User{ID: 7, OrgID: 1, Username: "bob", Role: models.RoleEmployee, AuthSource: models.AuthLocal}, nil)
+		s := providerService(users, &fakeProvider{ident: bob})
+
+		_, err := loginAs(s, "bob", "secret")
+
+		assert.EqualError(t, err, "invalid credentials")
+		users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a wrong local password isn't tried elsewhere", func(t *testing.T) {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		users := new(MockUserRepo)
+		users.On("GetUserByUsername", inOrg(1), "bob").
+			Return(&models.User{ID: 7, OrgID: 1, Username: "bob", PasswordHash: string(hash), Role: models.RoleEmployee}, nil)
+		directory := &fakeProvider{ident: bob}
+		s := providerService(users, nil, directory)
+
+		_, err := loginAs(s, "bob", "secret")
+
+		assert.EqualError(t, err, "invalid credentials")
+		assert.Zero(t, directory.calls)
+	})
+
+	t.Run("directory users skip the local provider", func(t *testing.T) {
+		users := new(MockUserRepo)
+		users.On("GetUserByUsername", inOrg(1), "bob").
+			Return(&models.User{ID: 7, OrgID: 1, Username: "bob", Name: "Bob", Role: models.RoleManager, AuthSource: "ldap"}, nil)
+		s := providerService(users, nil, &fakeProvider{ident: bob})
+
+		_, err := loginAs(s, "bob", "secret")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("provider down", func(t *testing.T) {
+		s := providerService(new(MockUserRepo), &fakeProvider{err: errors.New("connection refused")})
+
+		_, err := loginAs(s, "bob", "secret")
+
+		assert.EqualError(t, err, "authentication unavailable")
+	})
+}
+
+func TestUpdateUser_DirectoryPassword(t *testing.T) {
+	mockRepo := new(MockRepo)
+	users := new(MockUserRepo)
+	mockRepo.On("User").Return(users)
+	users.On("GetUserByID", mock.Anything, 7).Return(&models.User{ID: 7, Username: "bob", Role: models.RoleEmployee, AuthSource: "ldap"}, nil)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+	err := s.User().UpdateUser(context.Background(), 7, &dto.UserRequest{Password: "n3w-password"}, 1, "manager", true)
+
+	assert.EqualError(t, err, "password is managed by the directory")
+	users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+}
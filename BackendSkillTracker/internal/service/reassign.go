@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+)
+
+// REASSIGNMENT
+
+// missingSkills returns the names of required skills the user doesn't have.
+func missingSkills(required []models.Skill, has []models.Skill) []string {
+	owned := make(map[int]struct{}, len(has))
+	for _, sk := range has {
+		owned[sk.ID] = struct{}{}
+	}
+	missing := []string{}
+	for _, sk := range required {
+		if _, ok := owned[sk.ID]; !ok {
+			missing = append(missing, sk.Name)
+		}
+	}
+	return missing
+}
+
+// ReassignTask hands the task over to a new lead. Only the creator or a role
+// with task.assign can do it. The handoff is recorded in history and both the previous
+// and the new lead are notified.
+func (s *services) ReassignTask(ctx context.Context, taskID int, req *dto.ReassignTaskRequest, userID int, role string, allTeams bool) error {
+	t, err := s.repo.Task().GetTaskByID(ctx, taskID)
+	if err != nil {
+		return errors.New("task not found")
+	}
+	if t.CreatorID != userID && !s.Can(ctx, role, permission.TaskAssign) {
+		return errors.New("forbidden")
+	}
+	if req.EmployeeID == t.EmployeeID {
+		return errors.New("task is already assigned to this user")
+	}
+	if _, err := s.getUserInScope(ctx, req.EmployeeID, userID, role, allTeams); err != nil {
+		return err
+	}
+	if req.RequireSkills && len(t.RequiredSkills) > 0 {
+		has, err := s.repo.Skill().GetUserSkills(ctx, req.EmployeeID)
+		if err != nil {
+			return err
+		}
+		if len(missingSkills(t.RequiredSkills, has)) > 0 {
+			return errors.New("assignee lacks required skills")
+		}
+	}
+
+	// The new lead stops being a co-assignee.
+	if isTaskAssignee(t, req.EmployeeID) {
+		rest := make([]int, 0, len(t.Assignees))
+		for _, u := range t.Assignees {
+			if u.ID != req.EmployeeID {
+				rest = append(rest, u.ID)
+			}
+		}
+		if err := s.repo.Task().SetTaskAssignees(ctx, taskID, rest); err != nil {
+			return err
+		}
+	}
+
+	from := t.EmployeeID
+	to := req.EmployeeID
+	t.EmployeeID = to
+	if err := s.repo.Task().UpdateTask(ctx, t); err != nil {
+		return err
+	}
+
+	h := &models.TaskStatusHistory{
+		TaskID:     taskID,
+		Event:      models.HistoryReassign,
+		OldStatus:  t.Status,
+		NewStatus:  t.Status,
+		Note:       req.Note,
+		FromUserID: &from,
+		ToUserID:   &to,
+		ChangedBy:  userID,
+	}
+	if err := s.repo.Task().CreateHistory(ctx, h); err != nil {
+		s.logger.Error().Err(err).Msg("failed to record reassignment history")
+	}
+
+	// The handoff note is kept in the history, see GetTaskHistory.
+	s.notify(ctx, []int{to}, userID, taskID, models.NotificationReassigned,
+		fmt.Sprintf("Task %q was handed over to you", t.Title))
+	s.notify(ctx, []int{from}, userID, taskID, models.NotificationReassigned,
+		fmt.Sprintf("Task %q was reassigned to another employee", t.Title))
+	return nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTaskService_ReassignTask(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("manager hands over to co-assignee", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockNotificationRepo := new(MockNotificationRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Notification").Return(mockNotificationRepo)
+		mockNotificationRepo.On("GetMutedUsers", ctx, mock.Anything, mock.Anything).Return([]int{}, nil)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+		mockUserRepo.On("GetUserByID", ctx, 30).Return(&models.User{ID: 30}, nil)
+		mockTaskRepo.On("SetTaskAssignees", ctx, 1, []int{}).Return(nil)
+		mockTaskRepo.On("UpdateTask", ctx, mock.MatchedBy(func(t *models.Task) bool {
+			return t.EmployeeID == 30
+		})).Return(nil)
+		mockTaskRepo.On("CreateHistory", ctx, mock.MatchedBy(func(h *models.TaskStatusHistory) bool {
+			return h.Event == models.HistoryReassign && *h.FromUserID == 20 && *h.ToUserID == 30 &&
+				h.Note == "DB part is done" && h.ChangedBy == 99
+		})).Return(nil)
+		mockNotificationRepo.On("CreateNotifications", ctx, mock.MatchedBy(func(ns []models.Notification) bool {
+			return len(ns) == 1 && ns[0].UserID == 30
+		})).Return(nil).Once()
+		mockNotificationRepo.On("CreateNotifications", ctx, mock.MatchedBy(func(ns []models.Notification) bool {
+			return len(ns) == 1 && ns[0].UserID == 20
+		})).Return(nil).Once()
+
+		req := &dto.ReassignTaskRequest{EmployeeID: 30, Note: "DB part is done"}
+		err := s.Task().ReassignTask(ctx, 1, req, 99, "manager", true)
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("lead cannot reassign", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(sharedTask(), nil)
+
+		err := s.Task().ReassignTask(ctx, 1, &dto.ReassignTaskRequest{EmployeeID: 30}, 20, "employee", false)
+
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+
+	t.Run("required skills missing", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockSkillRepo := new(MockSkillRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		task := sharedTask()
+		task.RequiredSkills = []models.Skill{{ID: 1, Name: "Go"}, {ID: 2, Name: "SQL"}}
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Skill").Return(mockSkillRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+		mockUserRepo.On("GetUserByID", ctx, 50).Return(&models.User{ID: 50}, nil)
+		mockSkillRepo.On("GetUserSkills", ctx, 50).Return([]models.Skill{{ID: 1, Name: "Go"}}, nil)
+
+		req := &dto.ReassignTaskRequest{EmployeeID: 50, RequireSkills: true}
+		err := s.Task().ReassignTask(ctx, 1, req, 10, "manager", true)
+
+		assert.Error(t, err)
+		assert.Equal(t, "assignee lacks required skills", err.Error())
+		mockTaskRepo.AssertNotCalled(t, "UpdateTask", ctx, mock.Anything)
+	})
+}
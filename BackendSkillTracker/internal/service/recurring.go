@@ -0,0 +1,480 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"time"
+)
+
+// RECURRING TASKS
+
+const (
+	defaultTaskDurationHours  = 24
+	defaultOccurrencesPreview = 10
+	maxOccurrencesPreview     = 100
+)
+
+func (s *services) Recurring() RecurringTaskService { return s }
+
+func recurringTaskToDTO(r *models.RecurringTask) *dto.RecurringTaskResponse {
+	return &dto.RecurringTaskResponse{
+		ID:                 r.ID,
+		CreatorID:          r.CreatorID,
+		EmployeeID:         r.EmployeeID,
+		Title:              r.Title,
+		Description:        r.Description,
+		DurationHours:      r.DurationHours,
+		Frequency:          string(r.Frequency),
+		Interval:           r.Interval,
+		Trigger:            string(r.Trigger),
+		StartsAt:           r.StartsAt,
+		Until:              r.Until,
+		Count:              r.Count,
+		OccurrencesCreated: r.OccurrencesCreated,
+		NextRunAt:          r.NextRunAt,
+		RequiredSkills:     skillsToDTO(r.RequiredSkills),
+		CreatedAt:          r.CreatedAt,
+		UpdatedAt:          r.UpdatedAt,
+	}
+}
+
+// occurrenceTime returns the start of the n-th (1-based) occurrence of r.
+// Occurrences are always counted from StartsAt, so monthly rules don't drift
+// after passing through a short month.
+func occurrenceTime(r *models.RecurringTask, n int) time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	steps := (n - 1) * interval
+	switch r.Frequency {
+	case models.FrequencyWeekly:
+		return r.StartsAt.AddDate(0, 0, 7*steps)
+	case models.FrequencyMonthly:
+		return addMonths(r.StartsAt, steps)
+	default:
+		return r.StartsAt.AddDate(0, 0, steps)
+	}
+}
+
+// addMonths clamps the day to the last day of the target month instead of
+// overflowing into the next one (Jan 31 + 1 month is Feb 28, not Mar 3).
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+func occurrenceInRange(r *models.RecurringTask, n int, at time.Time) bool {
+	if r.Count > 0 && n > r.Count {
+		return false
+	}
+	if r.Until != nil && at.After(*r.Until) {
+		return false
+	}
+	return true
+}
+
+func exceptionsByOccurrence(r *models.RecurringTask) map[int]*models.RecurringTaskException {
+	out := make(map[int]*models.RecurringTaskException, len(r.Exceptions))
+	for i := range r.Exceptions {
+		out[r.Exceptions[i].Occurrence] = &r.Exceptions[i]
+	}
+	return out
+}
+
+// scheduleNext points NextRunAt at the first upcoming occurrence that is not
+// skipped, or clears it when the rule is exhausted.
+func scheduleNext(r *models.RecurringTask) {
+	exceptions := exceptionsByOccurrence(r)
+	for n := r.OccurrencesCreated + 1; ; n++ {
+		at := occurrenceTime(r, n)
+		if !occurrenceInRange(r, n, at) {
+			r.NextRunAt = nil
+			return
+		}
+		if ex, ok := exceptions[n]; ok && ex.Skip {
+			continue
+		}
+		r.NextRunAt = &at
+		return
+	}
+}
+
+// buildOccurrence produces the task for the n-th occurrence with the
+// single-occurrence overrides applied. The task is not persisted.
+func buildOccurrence(r *models.RecurringTask, n int, ex *models.RecurringTaskException) *models.Task {
+	at := occurrenceTime(r, n)
+	recurringID := r.ID
+	t := &models.Task{
+		EmployeeID:      r.EmployeeID,
+		CreatorID:       r.CreatorID,
+		Title:           r.Title,
+		Description:     r.Description,
+		Deadline:        at.Add(time.Duration(r.DurationHours) * time.Hour),
+		Status:          models.StatusPending,
+		RecurringTaskID: &recurringID,
+		Occurrence:      n,
+		RequiredSkills:  r.RequiredSkills,
+	}
+	if ex != nil {
+		if ex.EmployeeID != nil {
+			t.EmployeeID = *ex.EmployeeID
+		}
+		if ex.Title != "" {
+			t.Title = ex.Title
+		}
+		if ex.Description != "" {
+			t.Description = ex.Description
+		}
+		if ex.Deadline != nil {
+			t.Deadline = *ex.Deadline
+		}
+	}
+	return t
+}
+
+// materializeNext creates the task for the next non-skipped occurrence of r.
+// It returns nil when the rule has no occurrences left or another instance
+// created the occurrence first.
+func (s *services) materializeNext(ctx context.Context, r *models.RecurringTask) (*models.Task, error) {
+	exceptions := exceptionsByOccurrence(r)
+	from := r.OccurrencesCreated
+	n := from + 1
+	for ; ; n++ {
+		if !occurrenceInRange(r, n, occurrenceTime(r, n)) {
+			r.OccurrencesCreated = n - 1
+			r.NextRunAt = nil
+			_, err := s.repo.RecurringTask().AdvanceRecurringTask(ctx, r, from)
+			return nil, err
+		}
+		if ex := exceptions[n]; ex == nil || !ex.Skip {
+			break
+		}
+	}
+
+	t := buildOccurrence(r, n, exceptions[n])
+	s.applySLAPolicy(ctx, t)
+
+	// Claiming the occurrence and creating its task commit together, so
+	// schedulers running side by side never create it twice and a failed
+	// insert leaves the occurrence to the next run.
+	r.OccurrencesCreated = n
+	scheduleNext(r)
+	claimed, err := s.repo.RecurringTask().CreateOccurrence(ctx, r, from, t)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		s.logger.Debug().Int("recurring_task_id", r.ID).Int("occurrence", n).Msg("occurrence already created elsewhere")
+		return nil, nil
+	}
+	s.logger.Info().Int("recurring_task_id", r.ID).Int("occurrence", n).Int("task_id", t.ID).Msg("recurring task occurrence created")
+	return t, nil
+}
+
+// applyRecurringRequest copies the request into r and validates the rule.
+func (s *services) applyRecurringRequest(ctx context.Context, r *models.RecurringTask, req *dto.RecurringTaskRequest) error {
+	if req.EmployeeID == 0 || req.Title == "" {
+		return errors.New("invalid input")
+	}
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		return errors.New("invalid starts_at format")
+	}
+	var until *time.Time
+	if req.Until != "" {
+		u, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			return errors.New("invalid until format")
+		}
+		if u.Before(startsAt) {
+			return errors.New("until must be after starts_at")
+		}
+		until = &u
+	}
+
+	skills := make([]models.Skill, 0, len(req.SkillIDs))
+	for _, skillID := range req.SkillIDs {
+		sk, err := s.repo.Skill().GetSkillByID(ctx, skillID)
+		if err != nil {
+			return errors.New("skill not found")
+		}
+		skills = append(skills, *sk)
+	}
+
+	r.EmployeeID = req.EmployeeID
+	r.Title = req.Title
+	r.Description = req.Description
+	r.DurationHours = req.DurationHours
+	if r.DurationHours == 0 {
+		r.DurationHours = defaultTaskDurationHours
+	}
+	r.Frequency = models.RecurrenceFrequency(req.Frequency)
+	r.Interval = req.Interval
+	if r.Interval < 1 {
+		r.Interval = 1
+	}
+	r.Trigger = models.RecurrenceTrigger(req.Trigger)
+	if r.Trigger == "" {
+		r.Trigger = models.TriggerSchedule
+	}
+	r.StartsAt = startsAt
+	r.Until = until
+	r.Count = req.Count
+	r.RequiredSkills = skills
+	return nil
+}
+
+func skillIDs(skills []models.Skill) []int {
+	out := make([]int, 0, len(skills))
+	for _, sk := range skills {
+		out = append(out, sk.ID)
+	}
+	return out
+}
+
+func (s *services) CreateRecurringTask(ctx context.Context, req *dto.RecurringTaskRequest, creatorID int) (*dto.RecurringTaskResponse, error) {
+	r := &models.RecurringTask{CreatorID: creatorID}
+	if err := s.applyRecurringRequest(ctx, r, req); err != nil {
+		return nil, err
+	}
+	scheduleNext(r)
+	if err := s.repo.RecurringTask().CreateRecurringTask(ctx, r); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RecurringTask().SetRecurringTaskSkills(ctx, r.ID, skillIDs(r.RequiredSkills)); err != nil {
+		return nil, err
+	}
+
+	// Completion-driven rules have no previous occurrence to wait for,
+	// so the first one is created right away.
+	if r.Trigger == models.TriggerCompletion {
+		if _, err := s.materializeNext(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+	return recurringTaskToDTO(r), nil
+}
+
+func (s *services) GetRecurringTasks(ctx context.Context) ([]*dto.RecurringTaskResponse, error) {
+	rs, err := s.repo.RecurringTask().GetRecurringTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.RecurringTaskResponse, 0, len(rs))
+	for i := range rs {
+		out = append(out, recurringTaskToDTO(&rs[i]))
+	}
+	return out, nil
+}
+
+func (s *services) GetRecurringTaskByID(ctx context.Context, id int) (*dto.RecurringTaskResponse, error) {
+	r, err := s.repo.RecurringTask().GetRecurringTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return recurringTaskToDTO(r), nil
+}
+
+func (s *services) getOwnRecurringTask(ctx context.Context, id int, userID int) (*models.RecurringTask, error) {
+	r, err := s.repo.RecurringTask().GetRecurringTaskByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("recurring task not found")
+	}
+	if r.CreatorID != userID {
+		return nil, errors.New("forbidden")
+	}
+	return r, nil
+}
+
+func (s *services) UpdateRecurringTask(ctx context.Context, id int, req *dto.RecurringTaskRequest, userID int) error {
+	r, err := s.getOwnRecurringTask(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.applyRecurringRequest(ctx, r, req); err != nil {
+		return err
+	}
+	scheduleNext(r)
+	if err := s.repo.RecurringTask().UpdateRecurringTask(ctx, r); err != nil {
+		return err
+	}
+	return s.repo.RecurringTask().SetRecurringTaskSkills(ctx, r.ID, skillIDs(r.RequiredSkills))
+}
+
+func (s *services) DeleteRecurringTask(ctx context.Context, id int, userID int) error {
+	if _, err := s.getOwnRecurringTask(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.repo.RecurringTask().DeleteRecurringTask(ctx, id)
+}
+
+func (s *services) GetOccurrences(ctx context.Context, id int, limit int) ([]*dto.OccurrenceResponse, error) {
+	r, err := s.repo.RecurringTask().GetRecurringTaskByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("recurring task not found")
+	}
+	if limit <= 0 {
+		limit = defaultOccurrencesPreview
+	}
+	if limit > maxOccurrencesPreview {
+		limit = maxOccurrencesPreview
+	}
+
+	exceptions := exceptionsByOccurrence(r)
+	out := make([]*dto.OccurrenceResponse, 0, limit)
+	for n := r.OccurrencesCreated + 1; len(out) < limit; n++ {
+		at := occurrenceTime(r, n)
+		if !occurrenceInRange(r, n, at) {
+			break
+		}
+		ex := exceptions[n]
+		t := buildOccurrence(r, n, ex)
+		out = append(out, &dto.OccurrenceResponse{
+			Occurrence:  n,
+			ScheduledAt: at,
+			Deadline:    t.Deadline,
+			EmployeeID:  t.EmployeeID,
+			Title:       t.Title,
+			Skipped:     ex != nil && ex.Skip,
+			Overridden:  ex != nil && !ex.Skip,
+		})
+	}
+	return out, nil
+}
+
+// getUpcomingOccurrence loads the rule and checks that occurrence n can still
+// be changed, i.e. it is within the rule and has not been materialized yet.
+func (s *services) getUpcomingOccurrence(ctx context.Context, id int, n int, userID int) (*models.RecurringTask, *models.RecurringTaskException, error) {
+	r, err := s.getOwnRecurringTask(ctx, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n <= r.OccurrencesCreated {
+		return nil, nil, errors.New("occurrence already created")
+	}
+	if !occurrenceInRange(r, n, occurrenceTime(r, n)) {
+		return nil, nil, errors.New("occurrence out of range")
+	}
+	ex := exceptionsByOccurrence(r)[n]
+	if ex == nil {
+		r.Exceptions = append(r.Exceptions, models.RecurringTaskException{RecurringTaskID: r.ID, Occurrence: n})
+		ex = &r.Exceptions[len(r.Exceptions)-1]
+	}
+	return r, ex, nil
+}
+
+func (s *services) UpdateOccurrence(ctx context.Context, id int, occurrence int, req *dto.OccurrenceRequest, userID int) error {
+	r, ex, err := s.getUpcomingOccurrence(ctx, id, occurrence, userID)
+	if err != nil {
+		return err
+	}
+	if req.Deadline != "" {
+		dl, err := time.Parse(time.RFC3339, req.Deadline)
+		if err != nil {
+			return errors.New("invalid deadline format")
+		}
+		ex.Deadline = &dl
+	}
+	if req.EmployeeID != 0 {
+		employeeID := req.EmployeeID
+		ex.EmployeeID = &employeeID
+	}
+	if req.Title != "" {
+		ex.Title = req.Title
+	}
+	if req.Description != "" {
+		ex.Description = req.Description
+	}
+	ex.Skip = false
+
+	if err := s.repo.RecurringTask().SaveException(ctx, ex); err != nil {
+		return err
+	}
+	scheduleNext(r)
+	return s.repo.RecurringTask().UpdateRecurringTask(ctx, r)
+}
+
+func (s *services) SkipOccurrence(ctx context.Context, id int, occurrence int, userID int) error {
+	r, ex, err := s.getUpcomingOccurrence(ctx, id, occurrence, userID)
+	if err != nil {
+		return err
+	}
+	ex.Skip = true
+	if err := s.repo.RecurringTask().SaveException(ctx, ex); err != nil {
+		return err
+	}
+	scheduleNext(r)
+	return s.repo.RecurringTask().UpdateRecurringTask(ctx, r)
+}
+
+// onOccurrenceCompleted creates the follow-up of a completion-driven rule
+// when its latest occurrence is completed.
+func (s *services) onOccurrenceCompleted(ctx context.Context, t *models.Task) {
+	r, err := s.repo.RecurringTask().GetRecurringTaskByID(ctx, *t.RecurringTaskID)
+	if err != nil {
+		return
+	}
+	if r.Trigger != models.TriggerCompletion || r.OccurrencesCreated != t.Occurrence {
+		return
+	}
+	if _, err := s.materializeNext(ctx, r); err != nil {
+		s.logger.Error().Err(err).Int("recurring_task_id", r.ID).Msg("failed to create next occurrence")
+	}
+}
+
+// RunDue materializes every scheduled occurrence whose start time has come,
+// all missed ones included, and returns the number of created tasks.
+func (s *services) RunDue(ctx context.Context, now time.Time) (int, error) {
+	rs, err := s.repo.RecurringTask().GetDueRecurringTasks(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for i := range rs {
+		// The rule is due; it stays so while occurrences were missed.
+		for r := &rs[i]; ; {
+			t, err := s.materializeNext(ctx, r)
+			if err != nil {
+				s.logger.Error().Err(err).Int("recurring_task_id", r.ID).Msg("failed to create occurrence")
+				break
+			}
+			if t == nil {
+				break
+			}
+			created++
+			if r.NextRunAt == nil || r.NextRunAt.After(now) {
+				break
+			}
+		}
+	}
+	return created, nil
+}
+
+// RunScheduler calls RunDue for every organization each interval until ctx
+// is cancelled.
+func (s *services) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		err := s.forEachOrganization(ctx, func(ctx context.Context) error {
+			_, err := s.RunDue(ctx, now)
+			return err
+		})
+		if err != nil {
+			s.logger.Error().Err(err).Msg("recurring task scheduler failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOccurrenceTime(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	t.Run("weekly with interval", func(t *testing.T) {
+		r := &models.RecurringTask{StartsAt: start, Frequency: models.FrequencyWeekly, Interval: 2}
+		assert.Equal(t, start, occurrenceTime(r, 1))
+		assert.Equal(t, start.AddDate(0, 0, 28), occurrenceTime(r, 3))
+	})
+
+	t.Run("monthly clamps to end of month", func(t *testing.T) {
+		r := &models.RecurringTask{StartsAt: start, Frequency: models.FrequencyMonthly, Interval: 1}
+		assert.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), occurrenceTime(r, 2))
+		assert.Equal(t, time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC), occurrenceTime(r, 3))
+	})
+}
+
+func TestScheduleNext(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("skips skipped occurrences", func(t *testing.T) {
+		r := &models.RecurringTask{
+			StartsAt:           start,
+			Frequency:          models.FrequencyDaily,
+			OccurrencesCreated: 1,
+			Exceptions:         []models.RecurringTaskException{{Occurrence: 2, Skip: true}},
+		}
+		scheduleNext(r)
+		assert.Equal(t, start.AddDate(0, 0, 2), *r.NextRunAt)
+	})
+
+	t.Run("exhausted by count", func(t *testing.T) {
+		r := &models.RecurringTask{StartsAt: start, Frequency: models.FrequencyDaily, Count: 2, OccurrencesCreated: 2}
+		scheduleNext(r)
+		assert.Nil(t, r.NextRunAt)
+	})
+
+	t.Run("exhausted by until", func(t *testing.T) {
+		until := start.AddDate(0, 0, 10)
+		r := &models.RecurringTask{StartsAt: start, Frequency: models.FrequencyWeekly, Until: &until, OccurrencesCreated: 2}
+		scheduleNext(r)
+		assert.Nil(t, r.NextRunAt)
+	})
+}
+
+func TestRecurringService_RunDue(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockSLARepo := new(MockSLARepo)
+	mockRecurringRepo := new(MockRecurringTaskRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+	ctx := context.Background()
+
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	override := 7
+	rule := models.RecurringTask{
+		ID:                 5,
+		CreatorID:          1,
+		EmployeeID:         2,
+		Title:              "Weekly report",
+		DurationHours:      8,
+		Frequency:          models.FrequencyWeekly,
+		Interval:           1,
+		Trigger:            models.TriggerSchedule,
+		StartsAt:           start,
+		OccurrencesCreated: 1,
+		RequiredSkills:     []models.Skill{{ID: 3, Name: "Excel"}},
+		Exceptions: []models.RecurringTaskException{
+			{Occurrence: 2, Skip: true},
+			{Occurrence: 3, EmployeeID: &override},
+		},
+	}
+	now := start.AddDate(0, 0, 15)
+
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("SLA").Return(mockSLARepo)
+	mockSLARepo.On("GetSLAPolicy", ctx, models.PriorityMedium).Return(nil, errors.New("record not found"))
+	mockRepo.On("RecurringTask").Return(mockRecurringRepo)
+	mockRecurringRepo.On("GetDueRecurringTasks", ctx, now).Return([]models.RecurringTask{rule}, nil)
+	mockRecurringRepo.On("CreateOccurrence", ctx, mock.MatchedBy(func(r *models.RecurringTask) bool {
+		return r.OccurrencesCreated == 3 && r.NextRunAt.Equal(start.AddDate(0, 0, 21))
+	}), 1, mock.MatchedBy(func(tk *models.Task) bool {
+		return tk.Occurrence == 3 &&
+			tk.EmployeeID == override &&
+			tk.Deadline.Equal(start.AddDate(0, 0, 14).Add(8*time.Hour)) &&
+			*tk.RecurringTaskID == 5 &&
+			len(tk.RequiredSkills) == 1 && tk.RequiredSkills[0].ID == 3
+	})).Return(true, nil)
+
+	created, err := s.Recurring().RunDue(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created)
+	mockTaskRepo.AssertExpectations(t)
+	mockRecurringRepo.AssertExpectations(t)
+}
+
+func TestRecurringService_RunDue_CatchUp(t *testing.T) {
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	now := start.AddDate(0, 0, 2).Add(time.Hour)
+	ctx := context.Background()
+	newRule := func() models.RecurringTask {
+		next := start
+		return models.RecurringTask{ID: 5, EmployeeID: 2, Title: "Standup notes", Frequency: models.FrequencyDaily,
+			Interval: 1, Trigger: models.TriggerSchedule, StartsAt: start, NextRunAt: &next}
+	}
+	setup := func() (ServiceInterface, *MockTaskRepo, *MockRecurringTaskRepo) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockSLARepo := new(MockSLARepo)
+		mockRecurringRepo := new(MockRecurringTaskRepo)
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("SLA").Return(mockSLARepo)
+		mockRepo.On("RecurringTask").Return(mockRecurringRepo)
+		mockSLARepo.On("GetSLAPolicy", ctx, models.PriorityMedium).Return(nil, errors.New("record not found"))
+		mockRecurringRepo.On("GetDueRecurringTasks", ctx, now).Return([]models.RecurringTask{newRule()}, nil)
+		return New(mockRepo, zerolog.Nop(), testKeys, Options{}), mockTaskRepo, mockRecurringRepo
+	}
+
+	t.Run("every missed occurrence is created in one run", func(t *testing.T) {
+		s, _, mockRecurringRepo := setup()
+		for from := 0; from < 3; from++ {
+			mockRecurringRepo.On("CreateOccurrence", ctx, mock.Anything, from, mock.Anything).Return(true, nil).Once()
+		}
+
+		created, err := s.Recurring().RunDue(ctx, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, created)
+		mockRecurringRepo.AssertExpectations(t)
+	})
+
+	t.Run("an occurrence claimed elsewhere is not created again", func(t *testing.T) {
+		s, _, mockRecurringRepo := setup()
+		mockRecurringRepo.On("CreateOccurrence", ctx, mock.Anything, 0, mock.Anything).Return(false, nil).Once()
+
+		created, err := s.Recurring().RunDue(ctx, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, created)
+		mockRecurringRepo.AssertExpectations(t)
+	})
+
+	t.Run("a failed insert leaves the occurrence to the next run", func(t *testing.T) {
+		s, _, mockRecurringRepo := setup()
+		mockRecurringRepo.On("CreateOccurrence", ctx, mock.Anything, 0, mock.Anything).Return(false, errors.New("connection reset")).Once()
+
+		created, err := s.Recurring().RunDue(ctx, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, created)
+		mockRecurringRepo.AssertNumberOfCalls(t, "CreateOccurrence", 1)
+	})
+}
@@ -3,13 +3,15 @@ package service
 import (
     "context"
     "errors"
+    "fmt"
     "time"
     "skilltracker/internal/dto"
     "skilltracker/internal/models"
+    "skilltracker/internal/permission"
     "skilltracker/internal/repository"
+    "skilltracker/internal/tenant"
     jwtutil "skilltracker/internal/utils/jwt"
     "github.com/rs/zerolog"
-    "golang.org/x/crypto/bcrypt"
 )
 
 type ServiceInterface interface {
@@ -17,218 +19,527 @@ type ServiceInterface interface {
     Task() TaskService
     Comment() CommentService
     Skill() SkillService
-    SeedAdmin(ctx context.Context, adminPassword string) error
+    Recurring() RecurringTaskService
+    Template() TaskTemplateService
+    Checklist() ChecklistService
+    Time() TimeService
+    SLA() SLAService
+    Label() LabelService
+    Project() ProjectService
+    Sprint() SprintService
+    Notification() NotificationService
+    Access() AccessService
+    Team() TeamService
+    Organization() OrganizationService
+    Session() SessionService
+    TwoFactor() TwoFactorService
+    APIToken() APITokenService
+    Impersonation() ImpersonationService
+    Audit() AuditService
+    Profile() ProfileService
+    SeedOrganization(ctx context.Context, slug, name, adminPassword string) error
 }
 
 type UserService interface {
-	Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error)
-	RefreshToken(ctx context.Context, req *dto.RefreshRequest) (*dto.LoginResponse, error)
-	Logout(ctx context.Context, userID int) error
-	CreateUser(ctx context.Context, req *dto.UserRequest) (*dto.UserResponse, error)
-	GetUsers(ctx context.Context) ([]*dto.UserResponse, error)
-	UpdateUser(ctx context.Context, id int, req *dto.UserRequest) error
-	DeleteUser(ctx context.Context, id int) error
-	GetUserByID(ctx context.Context, id int) (*dto.UserResponse, error)
+	Login(ctx context.Context, req *dto.LoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	RefreshToken(ctx context.Context, req *dto.RefreshRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	Logout(ctx context.Context, userID int, sessionID int) error
+	LogoutAll(ctx context.Context, userID int) error
+	// VerifyLoginTwoFactor is the second login step when Login returned a
+	// challenge; EnrollLoginTwoFactor sets up 2FA required by the role.
+	VerifyLoginTwoFactor(ctx context.Context, req *dto.TwoFactorLoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	EnrollLoginTwoFactor(ctx context.Context, req *dto.TwoFactorChallengeRequest) (*dto.TwoFactorEnrollResponse, error)
+	// StartOIDCLogin and CompleteOIDCLogin log in through an OpenID
+	// Connect provider instead of a password.
+	StartOIDCLogin(ctx context.Context, provider string) (*dto.OIDCAuthorizeResponse, error)
+	CompleteOIDCLogin(ctx context.Context, provider string, req *dto.OIDCCallbackRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	// ChangeLoginPassword completes a login that has to replace a password
+	// chosen by someone else.
+	ChangeLoginPassword(ctx context.Context, req *dto.PasswordChangeLoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	RequestPasswordReset(ctx context.Context, req *dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error
+	GetPasswordPolicy() *dto.PasswordPolicyResponse
+	// JWKS publishes the keys access tokens can be verified with.
+	JWKS() jwtutil.JWKS
+	CreateUser(ctx context.Context, req *dto.UserRequest, callerRole string) (*dto.UserResponse, error)
+	GetUsers(ctx context.Context, viewerID int, role string, allTeams bool) ([]*dto.UserResponse, error)
+	UpdateUser(ctx context.Context, id int, req *dto.UserRequest, managerID int, role string, allTeams bool) error
+	DeleteUser(ctx context.Context, id int, managerID int, role string, allTeams bool) error
+	GetUserByID(ctx context.Context, id int, viewerID int, role string, allTeams bool) (*dto.UserResponse, error)
+	UnlockUser(ctx context.Context, id int, managerID int, role string, allTeams bool) error
 }
 
 type TaskService interface {
-    CreateTask(ctx context.Context, req *dto.TaskRequest, creatorID int) (*dto.TaskResponse, error)
-    GetTaskByID(ctx context.Context, id int) (*dto.TaskResponse, error)
+    CreateTask(ctx context.Context, req *dto.TaskRequest, creatorID int, role string, allTeams bool) (*dto.TaskResponse, error)
+    GetTaskByID(ctx context.Context, id int, userID int, role string, allTeams bool) (*dto.TaskResponse, error)
     GetTasksByEmployeeID(ctx context.Context, employeeID int) ([]*dto.TaskResponse, error)
-    UpdateTask(ctx context.Context, id int, req *dto.TaskRequest, userID int) error
-    DeleteTask(ctx context.Context, id int, userID int) error
+    UpdateTask(ctx context.Context, id int, req *dto.TaskRequest, userID int, role string, allTeams bool) error
+    DeleteTask(ctx context.Context, id int, userID int, role string, allTeams bool) error
     UploadAttachment(ctx context.Context, taskID int, userID int, fileName string, filePath string, fileSize int64) (*dto.AttachmentResponse, error)
-    GetTaskHistory(ctx context.Context, taskID int) ([]*dto.TaskHistoryResponse, error)
-    ListTasks(ctx context.Context, filter dto.TaskFilter) ([]*dto.TaskResponse, error)
+    GetTaskHistory(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.TaskHistoryResponse, error)
+    ListTasks(ctx context.Context, filter dto.TaskFilter, userID int, role string) ([]*dto.TaskResponse, error)
     AddSkillToTask(ctx context.Context, taskID int, skillID int, userID int) error
     RemoveSkillFromTask(ctx context.Context, taskID int, skillID int, userID int) error
-    GetTaskSkills(ctx context.Context, taskID int) ([]*dto.SkillResponse, error)
-    GetRecommendedEmployees(ctx context.Context, taskID int) ([]*dto.RecommendedEmployeeResponse, error)
+    GetTaskSkills(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.SkillResponse, error)
+    GetRecommendedEmployees(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.RecommendedEmployeeResponse, error)
+    SetTaskAssignees(ctx context.Context, taskID int, req *dto.TaskAssigneesRequest, userID int) error
+    AddTaskWatcher(ctx context.Context, taskID int, watcherID int, userID int, role string, allTeams bool) error
+    RemoveTaskWatcher(ctx context.Context, taskID int, watcherID int, userID int) error
+    GetWatchedTasks(ctx context.Context, userID int) ([]*dto.TaskResponse, error)
+    ReassignTask(ctx context.Context, taskID int, req *dto.ReassignTaskRequest, userID int, role string, allTeams bool) error
 }
 
 type CommentService interface {
-    CreateComment(ctx context.Context, taskID int, userID int, text string) (*dto.CommentResponse, error)
-    GetCommentsByTaskID(ctx context.Context, taskID int) ([]*dto.CommentResponse, error)
+    CreateComment(ctx context.Context, taskID int, userID int, role string, allTeams bool, text string) (*dto.CommentResponse, error)
+    GetCommentsByTaskID(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.CommentResponse, error)
     UpdateComment(ctx context.Context, id int, userID int, text string) error
-    DeleteComment(ctx context.Context, id int, userID int) error
+    DeleteComment(ctx context.Context, id int, userID int, role string) error
 }
 
 type SkillService interface {
     CreateSkill(ctx context.Context, req *dto.SkillRequest) (*dto.SkillResponse, error)
     GetSkills(ctx context.Context) ([]*dto.SkillResponse, error)
     DeleteSkill(ctx context.Context, id int) error
-    AssignSkillToUser(ctx context.Context, userID int, skillID int) error
-    RemoveSkillFromUser(ctx context.Context, userID int, skillID int) error
-    GetUserSkills(ctx context.Context, userID int) ([]*dto.SkillResponse, error)
+    AssignSkillToUser(ctx context.Context, userID int, skillID int, managerID int, role string, allTeams bool) error
+    RemoveSkillFromUser(ctx context.Context, userID int, skillID int, managerID int, role string, allTeams bool) error
+    GetUserSkills(ctx context.Context, userID int, viewerID int, role string, allTeams bool) ([]*dto.SkillResponse, error)
+}
+
+type RecurringTaskService interface {
+    CreateRecurringTask(ctx context.Context, req *dto.RecurringTaskRequest, creatorID int) (*dto.RecurringTaskResponse, error)
+    GetRecurringTasks(ctx context.Context) ([]*dto.RecurringTaskResponse, error)
+    GetRecurringTaskByID(ctx context.Context, id int) (*dto.RecurringTaskResponse, error)
+    UpdateRecurringTask(ctx context.Context, id int, req *dto.RecurringTaskRequest, userID int) error
+    DeleteRecurringTask(ctx context.Context, id int, userID int) error
+    GetOccurrences(ctx context.Context, id int, limit int) ([]*dto.OccurrenceResponse, error)
+    UpdateOccurrence(ctx context.Context, id int, occurrence int, req *dto.OccurrenceRequest, userID int) error
+    SkipOccurrence(ctx context.Context, id int, occurrence int, userID int) error
+    RunDue(ctx context.Context, now time.Time) (int, error)
+    RunScheduler(ctx context.Context, interval time.Duration)
+}
+
+type TaskTemplateService interface {
+    CreateTemplate(ctx context.Context, req *dto.TaskTemplateRequest, creatorID int) (*dto.TaskTemplateResponse, error)
+    GetTemplates(ctx context.Context, userID int) ([]*dto.TaskTemplateResponse, error)
+    GetTemplateByID(ctx context.Context, id int, userID int) (*dto.TaskTemplateResponse, error)
+    UpdateTemplate(ctx context.Context, id int, req *dto.TaskTemplateRequest, userID int) error
+    DeleteTemplate(ctx context.Context, id int, userID int) error
+    CreateTaskFromTemplate(ctx context.Context, templateID int, req *dto.TaskFromTemplateRequest, creatorID int, role string, allTeams bool) (*dto.TaskResponse, error)
+}
+
+type ChecklistService interface {
+    GetChecklist(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.ChecklistItemResponse, error)
+    AddChecklistItem(ctx context.Context, taskID int, userID int, text string) (*dto.ChecklistItemResponse, error)
+    UpdateChecklistItem(ctx context.Context, taskID int, itemID int, userID int, text string) error
+    SetChecklistItemDone(ctx context.Context, taskID int, itemID int, userID int, done bool) error
+    DeleteChecklistItem(ctx context.Context, taskID int, itemID int, userID int) error
+    ReorderChecklist(ctx context.Context, taskID int, userID int, itemIDs []int) error
+}
+
+type TimeService interface {
+    StartTimer(ctx context.Context, taskID int, userID int) (*dto.TimeEntryResponse, error)
+    StopTimer(ctx context.Context, userID int) (*dto.TimeEntryResponse, error)
+    LogTime(ctx context.Context, taskID int, userID int, req *dto.TimeEntryRequest) (*dto.TimeEntryResponse, error)
+    GetTaskTimeEntries(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.TimeEntryResponse, error)
+    DeleteTimeEntry(ctx context.Context, id int, userID int) error
+    GetTimesheet(ctx context.Context, userID int, week string, viewerID int, role string, allTeams bool) (*dto.TimesheetResponse, error)
+    SubmitTimesheet(ctx context.Context, userID int, week string) error
+    ReviewTimesheet(ctx context.Context, id int, reviewerID int, role string, allTeams bool, approve bool, comment string) error
+    GetTimeReport(ctx context.Context, from, to string) (*dto.TimeReportResponse, error)
+}
+
+type SLAService interface {
+    GetSLAPolicies(ctx context.Context) ([]*dto.SLAPolicyResponse, error)
+    UpdateSLAPolicy(ctx context.Context, priority string, req *dto.SLAPolicyRequest) (*dto.SLAPolicyResponse, error)
+    CheckSLA(ctx context.Context, now time.Time) (int, error)
+    RunSLAMonitor(ctx context.Context, interval time.Duration)
+}
+
+type LabelService interface {
+    CreateLabel(ctx context.Context, req *dto.LabelRequest) (*dto.LabelResponse, error)
+    GetLabels(ctx context.Context) ([]*dto.LabelResponse, error)
+    UpdateLabel(ctx context.Context, id int, req *dto.LabelRequest) error
+    DeleteLabel(ctx context.Context, id int) error
+    AddLabelToTask(ctx context.Context, taskID int, labelID int, userID int) error
+    RemoveLabelFromTask(ctx context.Context, taskID int, labelID int, userID int) error
+    GetLabelUsage(ctx context.Context) ([]*dto.LabelUsageResponse, error)
+}
+
+type ProjectService interface {
+    CreateProject(ctx context.Context, req *dto.ProjectRequest, ownerID int) (*dto.ProjectResponse, error)
+    GetProjects(ctx context.Context, userID int, role string) ([]*dto.ProjectResponse, error)
+    GetProjectByID(ctx context.Context, id int, userID int, role string) (*dto.ProjectResponse, error)
+    UpdateProject(ctx context.Context, id int, req *dto.ProjectRequest, userID int) error
+    DeleteProject(ctx context.Context, id int, userID int) error
+}
+
+type SprintService interface {
+    CreateSprint(ctx context.Context, req *dto.SprintRequest, creatorID int) (*dto.SprintResponse, error)
+    GetSprints(ctx context.Context, projectID int, userID int, role string) ([]*dto.SprintResponse, error)
+    GetSprintByID(ctx context.Context, id int, userID int, role string) (*dto.SprintResponse, error)
+    UpdateSprint(ctx context.Context, id int, req *dto.SprintRequest) error
+    DeleteSprint(ctx context.Context, id int) error
+    StartSprint(ctx context.Context, id int) error
+    CloseSprint(ctx context.Context, id int, req *dto.CloseSprintRequest, userID int) (*dto.CloseSprintResponse, error)
+    GetBurndown(ctx context.Context, id int, userID int, role string) (*dto.BurndownResponse, error)
+}
+
+// AccessService resolves roles to permissions and manages custom roles.
+type AccessService interface {
+    Can(ctx context.Context, role string, p permission.Permission) bool
+    GetPermissions() []string
+    GetRoles(ctx context.Context) ([]*dto.RoleResponse, error)
+    CreateRole(ctx context.Context, req *dto.RoleRequest, callerRole string) (*dto.RoleResponse, error)
+    UpdateRole(ctx context.Context, id int, req *dto.RoleRequest, callerRole string) error
+    DeleteRole(ctx context.Context, id int) error
+}
+
+type TeamService interface {
+    CreateTeam(ctx context.Context, req *dto.TeamRequest) (*dto.TeamResponse, error)
+    GetTeams(ctx context.Context) ([]*dto.TeamResponse, error)
+    GetTeamByID(ctx context.Context, id int) (*dto.TeamResponse, error)
+    UpdateTeam(ctx context.Context, id int, req *dto.TeamRequest) error
+    DeleteTeam(ctx context.Context, id int) error
+    SetUserTeam(ctx context.Context, userID int, req *dto.UserTeamRequest, managerID int, role string, allTeams bool) error
+}
+
+type SessionService interface {
+    GetMySessions(ctx context.Context, userID int, currentID int) ([]*dto.SessionResponse, error)
+    RevokeMySession(ctx context.Context, userID int, sessionID int) error
+    RevokeUserSessions(ctx context.Context, userID int, managerID int, role string, allTeams bool) error
+    // TokenVersionValid reports whether access tokens carrying version
+    // are still valid for the user.
+    TokenVersionValid(ctx context.Context, userID int, version int) bool
+}
+
+type TwoFactorService interface {
+    GetTwoFactorStatus(ctx context.Context, userID int, role string) (*dto.TwoFactorStatusResponse, error)
+    EnrollTwoFactor(ctx context.Context, userID int) (*dto.TwoFactorEnrollResponse, error)
+    ConfirmTwoFactor(ctx context.Context, userID int, code string) (*dto.RecoveryCodesResponse, error)
+    DisableTwoFactor(ctx context.Context, userID int, role string, code string) error
+    RegenerateRecoveryCodes(ctx context.Context, userID int, code string) (*dto.RecoveryCodesResponse, error)
+    ResetTwoFactor(ctx context.Context, userID int, managerID int, role string, allTeams bool) error
+}
+
+type APITokenService interface {
+    CreateAPIToken(ctx context.Context, userID int, req *dto.APITokenRequest) (*dto.APITokenCreatedResponse, error)
+    GetAPITokens(ctx context.Context, userID int) ([]*dto.APITokenResponse, error)
+    RevokeAPIToken(ctx context.Context, userID int, id int) error
+    RevokeUserAPITokens(ctx context.Context, userID int, managerID int, role string, allTeams bool) error
+    // AuthenticateAPIToken resolves a personal access token presented
+    // from ip.
+    AuthenticateAPIToken(ctx context.Context, token string, ip string) (*dto.APITokenIdentity, error)
+}
+
+type ImpersonationService interface {
+    Impersonate(ctx context.Context, actorID int, targetID int, allTeams bool, reason string) (*dto.ImpersonationResponse, error)
+    RecordImpersonatedRequest(ctx context.Context, actorID int, userID int, method string, path string, status int)
+}
+
+type AuditService interface {
+    GetAuditLog(ctx context.Context, filter dto.AuditFilter) (*dto.AuditLogPage, error)
+    ExportAuditLog(ctx context.Context, filter dto.AuditFilter) ([]*dto.AuditLogResponse, error)
+    PurgeAuditLog(ctx context.Context, now time.Time) (int64, error)
+    RunAuditRetention(ctx context.Context, interval time.Duration)
+}
+
+type OrganizationService interface {
+    GetOrganization(ctx context.Context, orgID int) (*dto.OrganizationResponse, error)
+}
+
+type NotificationService interface {
+    GetNotifications(ctx context.Context, userID int, unreadOnly bool) ([]*dto.NotificationResponse, error)
+    MarkNotificationRead(ctx context.Context, id int, userID int) error
+    MarkAllNotificationsRead(ctx context.Context, userID int) error
+    GetNotificationPreferences(ctx context.Context, userID int) ([]dto.NotificationPreference, error)
+    UpdateNotificationPreferences(ctx context.Context, userID int, req *dto.NotificationPreferencesRequest) ([]dto.NotificationPreference, error)
+}
+
+// ProfileService is the self-service of the current user.
+type ProfileService interface {
+    GetProfile(ctx context.Context, userID int) (*dto.ProfileResponse, error)
+    UpdateProfile(ctx context.Context, userID int, req *dto.UpdateProfileRequest) (*dto.ProfileResponse, error)
+    SetAvatar(ctx context.Context, userID int, fileName string) (string, error)
+    // ChangePassword ends every session of the user and starts a new one.
+    ChangePassword(ctx context.Context, userID int, req *dto.ChangePasswordRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+}
+
+// Options holds the policies of the services that come from configuration.
+type Options struct {
+    Lockout   LockoutPolicy
+    TwoFactor TwoFactorPolicy
+    // Providers check passwords at login, in order; only local users by
+    // default.
+    Providers []AuthProvider
+    OIDC      []OIDCProvider
+    Password      PasswordPolicy
+    PasswordReset PasswordReset
+    APITokens     APITokenPolicy
+    Impersonation ImpersonationPolicy
+    Audit         AuditPolicy
 }
 
 type services struct {
     repo      repository.Repository
     logger    zerolog.Logger
-    jwtSecret []byte
+    keys      *jwtutil.KeySet
+    versions  *versionCache
+    opts      Options
 }
 
-func New(repo repository.Repository, l zerolog.Logger, jwtSecret []byte) ServiceInterface {
-    return &services{repo: repo, logger: l, jwtSecret: jwtSecret}
-}
-
-// SeedAdmin creates the admin account if it doesn't exist
-func (s *services) SeedAdmin(ctx context.Context, adminPassword string) error {
-    _, err := s.repo.User().GetUserByUsername(ctx, "admin")
-    if err == nil {
-        return nil // already exists
-    }
-    hash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
-    if err != nil {
-        return err
+func New(repo repository.Repository, l zerolog.Logger, keys *jwtutil.KeySet, opts Options) ServiceInterface {
+    if len(opts.Providers) == 0 {
+        opts.Providers = []AuthProvider{NewPasswordProvider(repo)}
     }
-    u := &models.User{
-        Username:     "admin",
-        PasswordHash: string(hash),
-        Role:         models.RoleManager,
-        Name:         "Administrator",
-    }
-    if err := s.repo.User().CreateUser(ctx, u); err != nil {
-        return err
-    }
-    s.logger.Info().Str("username", "admin").Msg("Admin account created")
-    return nil
+    return &services{repo: repo, logger: l, keys: keys, versions: newVersionCache(tokenVersionTTL), opts: opts}
 }
 
 // USER
 
 func (s *services) User() UserService { return s }
 
-func (s *services) Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
-	u, err := s.repo.User().GetUserByUsername(ctx, req.Username)
-	if err != nil {
-		return nil, errors.New("invalid credentials")
+// Login checks the password with the auth providers. Failed attempts are
+// throttled per username and per client IP, see LockoutPolicy.
+func (s *services) Login(ctx context.Context, req *dto.LoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	slug := req.Organization
+	if slug == "" {
+		slug = DefaultOrganization
+	}
+	orgID := 0
+	if org, err := s.repo.Organization().GetOrganizationBySlug(ctx, slug); err == nil {
+		orgID = org.ID
+	}
+
+	username := normalizeUsername(req.Username)
+	now := time.Now()
+	throttled := s.opts.Lockout.enabled()
+	userKey, ipKey := loginKeys(orgID, username, client.IP)
+	if throttled {
+		if err := s.checkLoginThrottle(ctx, userKey, ipKey, now); err != nil {
+			s.securityEvent(zerolog.WarnLevel, "login_throttled").Str("username", username).Str("ip", client.IP).
+				Str("reason", err.Error()).Msg("login attempt refused")
+			return nil, err
+		}
 	}
-	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)) != nil {
+	fail := func() (*dto.LoginResponse, error) {
+		s.audit(ctx, auditEntry{Action: "login.failed", ActorName: username})
+		if throttled {
+			s.recordLoginFailure(ctx, userKey, ipKey, username, client.IP, now)
+		}
 		return nil, errors.New("invalid credentials")
 	}
+	if orgID == 0 {
+		return fail()
+	}
+	ctx = tenant.WithOrg(ctx, orgID)
 
-	accessToken, err := jwtutil.GenerateAccessToken(u.ID, u.Username, string(u.Role), s.jwtSecret)
+	u, err := s.authenticate(ctx, slug, username, req.Password)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken, err := jwtutil.GenerateRefreshToken(s.jwtSecret)
-	if err != nil {
-		return nil, err
+	if u == nil {
+		return fail()
 	}
-
-	u.RefreshToken = refreshToken
-	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
-		return nil, err
+	// With a second factor due, failures of the username are only cleared
+	// once it is passed, or a known password would reset the code guesses.
+	if res, err := s.secondFactor(ctx, u); err != nil || res != nil {
+		return res, err
 	}
-
-	return &dto.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User: dto.UserResponse{
-			ID:       u.ID,
-			Username: u.Username,
-			Role:     string(u.Role),
-			Name:     u.Name,
-		},
-	}, nil
+	s.loginSucceeded(ctx, u)
+	if res, err := s.passwordChange(u); err != nil || res != nil {
+		return res, err
+	}
+	return s.startSession(ctx, u, client)
 }
 
-func (s *services) RefreshToken(ctx context.Context, req *dto.RefreshRequest) (*dto.LoginResponse, error) {
-	_, err := jwtutil.ValidateRefreshToken(req.RefreshToken, s.jwtSecret)
+// RefreshToken rotates the refresh token of a session. A token that was
+// already used means it leaked, so the whole session is revoked.
+func (s *services) RefreshToken(ctx context.Context, req *dto.RefreshRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	// The refresh token identifies the session and with it the organization.
+	rt, err := s.repo.Session().GetRefreshToken(tenant.System(ctx), hashToken(req.RefreshToken))
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
-
-	u, err := s.repo.User().GetUserByRefreshToken(ctx, req.RefreshToken)
+	ctx = tenant.WithOrg(ctx, rt.OrgID)
+	sess := &rt.Session
+	now := time.Now()
+	if sess.RevokedAt != nil || !now.Before(sess.ExpiresAt) {
+		return nil, errors.New("invalid refresh token")
+	}
+	if rt.UsedAt != nil {
+		s.revokeReusedSession(ctx, sess, now)
+		return nil, errors.New("invalid refresh token")
+	}
+	u, err := s.repo.User().GetUserByID(ctx, sess.UserID)
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
-	newAccessToken, err := jwtutil.GenerateAccessToken(u.ID, u.Username, string(u.Role), s.jwtSecret)
+	refreshToken, err := newOpaqueToken()
 	if err != nil {
 		return nil, err
 	}
-	newRefreshToken, err := jwtutil.GenerateRefreshToken(s.jwtSecret)
+	sess.LastUsedAt = now
+	sess.ExpiresAt = now.Add(refreshTokenTTL)
+	sess.UserAgent = client.UserAgent
+	sess.IP = client.IP
+	next := &models.RefreshToken{SessionID: sess.ID, TokenHash: hashToken(refreshToken)}
+	rotated, err := s.repo.Session().RotateRefreshToken(ctx, rt.ID, next, sess)
 	if err != nil {
 		return nil, err
 	}
+	if !rotated {
+		// Another request used the token first.
+		s.revokeReusedSession(ctx, sess, now)
+		return nil, errors.New("invalid refresh token")
+	}
+	return s.issueTokens(u, sess.ID, refreshToken)
+}
 
-	u.RefreshToken = newRefreshToken
-	if err := s.repo.User().UpdateUser(ctx, u); err != nil {
-		return nil, err
+// Logout ends the session of the access token. Tokens issued before
+// sessions existed carry no session, so all sessions of the user end.
+func (s *services) Logout(ctx context.Context, userID int, sessionID int) error {
+	var err error
+	if sessionID == 0 {
+		err = s.endAllSessions(ctx, userID)
+	} else {
+		err = s.revokeSession(ctx, userID, sessionID)
 	}
+	if err != nil {
+		return err
+	}
+	s.audit(ctx, auditEntry{Action: "logout", TargetType: "user", TargetID: userID})
+	return nil
+}
 
-	return &dto.LoginResponse{
-		AccessToken:  newAccessToken,
-		RefreshToken: newRefreshToken,
-		User: dto.UserResponse{
-			ID:       u.ID,
-			Username: u.Username,
-			Role:     string(u.Role),
-			Name:     u.Name,
-		},
-	}, nil
+// LogoutAll ends every session of the user and revokes the access tokens
+// already issued to them.
+func (s *services) LogoutAll(ctx context.Context, userID int) error {
+	if err := s.endAllSessions(ctx, userID); err != nil {
+		return err
+	}
+	s.audit(ctx, auditEntry{Action: "logout.all", TargetType: "user", TargetID: userID})
+	return nil
 }
 
-func (s *services) Logout(ctx context.Context, userID int) error {
-	u, err := s.repo.User().GetUserByID(ctx, userID)
-	if err != nil {
+func (s *services) endAllSessions(ctx context.Context, userID int) error {
+	if err := s.repo.Session().RevokeUserSessions(ctx, userID, time.Now()); err != nil {
 		return err
 	}
-	u.RefreshToken = ""
-	return s.repo.User().UpdateUser(ctx, u)
+	return s.revokeAccessTokens(ctx, userID)
 }
 
-func (s *services) CreateUser(ctx context.Context, req *dto.UserRequest) (*dto.UserResponse, error) {
-    hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-    if err != nil {
-        return nil, errors.New("failed to hash password")
-    }
+// CreateUser registers a user. The caller can only hand out a role whose
+// permissions they hold themselves.
+func (s *services) CreateUser(ctx context.Context, req *dto.UserRequest, callerRole string) (*dto.UserResponse, error) {
+    if err := s.checkRoleGrantable(ctx, callerRole, req.Role); err != nil { return nil, err }
+    // The manager chose the password, so the user replaces it at first login.
     u := &models.User{
-        Username:     req.Username,
-        PasswordHash: string(hash),
-        Role:         models.Role(req.Role),
-        Name:         req.Name,
+        Username:           normalizeUsername(req.Username),
+        Role:               models.Role(req.Role),
+        Name:               req.Name,
+        Email:              req.Email,
+        Position:           req.Position,
+        Department:         req.Department,
+        MustChangePassword: true,
+    }
+    if err := s.setPassword(ctx, u, req.Password); err != nil {
+        return nil, err
     }
     if err := s.repo.User().CreateUser(ctx, u); err != nil {
         return nil, err
     }
-    return &dto.UserResponse{ID: u.ID, Username: u.Username, Role: string(u.Role), Name: u.Name}, nil
+    s.audit(ctx, auditEntry{Action: "user.created", TargetType: "user", TargetID: u.ID, After: userSnapshot(u)})
+    return &dto.UserResponse{ID: u.ID, Username: u.Username, Role: string(u.Role), Name: u.Name, Email: u.Email,
+        Position: u.Position, Department: u.Department}, nil
 }
 
-func (s *services) GetUsers(ctx context.Context) ([]*dto.UserResponse, error) {
+// GetUsers lists users. Team managers only see their subtree unless they ask
+// for all teams.
+func (s *services) GetUsers(ctx context.Context, viewerID int, role string, allTeams bool) ([]*dto.UserResponse, error) {
+    scope, err := s.teamScope(ctx, viewerID, role, allTeams)
+    if err != nil { return nil, err }
     users, err := s.repo.User().GetUsers(ctx)
     if err != nil { return nil, err }
     out := make([]*dto.UserResponse, 0, len(users))
     for _, u := range users {
-        out = append(out, &dto.UserResponse{ID: u.ID, Username: u.Username, Role: string(u.Role), Name: u.Name})
+        if scope != nil && !inTeams(u.TeamID, scope) { continue }
+        res := userToDTO(u)
+        out = append(out, &res)
     }
     return out, nil
 }
 
-func (s *services) UpdateUser(ctx context.Context, id int, req *dto.UserRequest) error {
-    u, err := s.repo.User().GetUserByID(ctx, id)
+// UpdateUser changes a user of the manager's team scope. Like CreateUser, it
+// is limited to users whose current and new role grant nothing beyond the
+// manager's own permissions.
+func (s *services) UpdateUser(ctx context.Context, id int, req *dto.UserRequest, managerID int, role string, allTeams bool) error {
+    u, err := s.getUserInScope(ctx, id, managerID, role, allTeams)
     if err != nil { return err }
-    if req.Username != "" { u.Username = req.Username }
+    if err := s.checkRoleGrantable(ctx, role, string(u.Role)); err != nil { return err }
+    before := userSnapshot(u)
+    if req.Username != "" { u.Username = normalizeUsername(req.Username) }
+    // A new password or role invalidates the access tokens issued so far.
+    revoke := false
     if req.Password != "" {
-        hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-        if err != nil { return errors.New("failed to hash password") }
-        u.PasswordHash = string(hash)
+        if !u.LocalAuth() { return errors.New("password is managed by the directory") }
+        if err := s.setPassword(ctx, u, req.Password); err != nil { return err }
+        u.MustChangePassword = true
+        revoke = true
+    }
+    if req.Role != "" && models.Role(req.Role) != u.Role {
+        if err := s.checkRoleGrantable(ctx, role, req.Role); err != nil { return err }
+        u.Role = models.Role(req.Role)
+        revoke = true
     }
-    if req.Role != "" { u.Role = models.Role(req.Role) }
     if req.Name != "" { u.Name = req.Name }
-    return s.repo.User().UpdateUser(ctx, u)
+    if req.Email != "" { u.Email = req.Email }
+    if req.Position != "" { u.Position = req.Position }
+    if req.Department != "" { u.Department = req.Department }
+    if err := s.repo.User().UpdateUser(ctx, u); err != nil { return err }
+    // The password itself is never recorded, only that it changed.
+    after := struct {
+        dto.UserResponse
+        PasswordChanged bool `json:"password_changed,omitempty"`
+    }{userSnapshot(u), req.Password != ""}
+    s.audit(ctx, auditEntry{Action: "user.updated", TargetType: "user", TargetID: u.ID, Before: before, After: after})
+    // A reset password also ends the sessions, or refresh tokens would
+    // keep a possibly compromised account signed in.
+    if req.Password != "" { return s.endAllSessions(ctx, u.ID) }
+    if revoke { return s.revokeAccessTokens(ctx, u.ID) }
+    return nil
 }
 
-func (s *services) DeleteUser(ctx context.Context, id int) error {
-    return s.repo.User().DeleteUser(ctx, id)
+func (s *services) DeleteUser(ctx context.Context, id int, managerID int, role string, allTeams bool) error {
+    u, err := s.getUserInScope(ctx, id, managerID, role, allTeams)
+    if err != nil { return err }
+    before := userSnapshot(u)
+    if err := s.revokeAccessTokens(ctx, id); err != nil { return err }
+    if err := s.repo.User().DeleteUser(ctx, id); err != nil { return err }
+    s.audit(ctx, auditEntry{Action: "user.deleted", TargetType: "user", TargetID: id, Before: before})
+    return nil
 }
 
-func (s *services) GetUserByID(ctx context.Context, id int) (*dto.UserResponse, error) {
-    u, err := s.repo.User().GetUserByID(ctx, id)
+func (s *services) GetUserByID(ctx context.Context, id int, viewerID int, role string, allTeams bool) (*dto.UserResponse, error) {
+    u, err := s.getUserInScope(ctx, id, viewerID, role, allTeams)
     if err != nil { return nil, err }
-    return &dto.UserResponse{ID: u.ID, Username: u.Username, Role: string(u.Role), Name: u.Name}, nil
+    res := userToDTO(u)
+    return &res, nil
+}
+
+func userToDTO(u *models.User) dto.UserResponse {
+    return dto.UserResponse{
+        ID:         u.ID,
+        Username:   u.Username,
+        Role:       string(u.Role),
+        Name:       u.Name,
+        TeamID:     u.TeamID,
+        Email:      u.Email,
+        AuthSource: u.AuthSource,
+        Position:   u.Position,
+        Department: u.Department,
+        AvatarURL:  avatarURL(u.Avatar),
+    }
 }
 
 // TASK
@@ -239,7 +550,7 @@ func skillsToDTO(skills []models.Skill) []dto.SkillResponse {
     out := make([]dto.SkillResponse, 0, len(skills))
     for _, sk := range skills {
         out = append(out, dto.SkillResponse{
-            ID: sk.ID, Name: sk.Name, Description: sk.Description, CreatedAt: sk.CreatedAt,
+            ID: sk.ID, Name: sk.Name, Description: sk.Description, Level: sk.Level, CreatedAt: sk.CreatedAt,
         })
     }
     return out
@@ -247,40 +558,69 @@ func skillsToDTO(skills []models.Skill) []dto.SkillResponse {
 
 func taskToDTO(t *models.Task) *dto.TaskResponse {
     return &dto.TaskResponse{
-        ID:             t.ID,
-        EmployeeID:     t.EmployeeID,
-        CreatorID:      t.CreatorID,
-        Title:          t.Title,
-        Description:    t.Description,
-        Deadline:       t.Deadline,
-        Status:         string(t.Status),
-        Progress:       t.Progress,
-        RequiredSkills: skillsToDTO(t.RequiredSkills),
-        CreatedAt:      t.CreatedAt,
-        UpdatedAt:      t.UpdatedAt,
-    }
-}
-
-func (s *services) CreateTask(ctx context.Context, req *dto.TaskRequest, creatorID int) (*dto.TaskResponse, error) {
+        ID:              t.ID,
+        EmployeeID:      t.EmployeeID,
+        CreatorID:       t.CreatorID,
+        Title:           t.Title,
+        Description:     t.Description,
+        Deadline:        t.Deadline,
+        Status:          string(t.Status),
+        Progress:        t.Progress,
+        Priority:        string(t.Priority),
+        SLA:             slaStatus(t, time.Now()),
+        EstimateMinutes: t.EstimateMinutes,
+        RequiredSkills:  skillsToDTO(t.RequiredSkills),
+        RecurringTaskID: t.RecurringTaskID,
+        Occurrence:      t.Occurrence,
+        TemplateID:      t.TemplateID,
+        ProjectID:       t.ProjectID,
+        SprintID:        t.SprintID,
+        Checklist:       checklistToDTO(t.Checklist),
+        Labels:          labelsToDTO(t.Labels),
+        Assignees:       usersToSummary(t.Assignees),
+        Watchers:        usersToSummary(t.Watchers),
+        CreatedAt:       t.CreatedAt,
+        UpdatedAt:       t.UpdatedAt,
+    }
+}
+
+// CreateTask creates a task led by an employee of the creator's team scope.
+func (s *services) CreateTask(ctx context.Context, req *dto.TaskRequest, creatorID int, role string, allTeams bool) (*dto.TaskResponse, error) {
     if req.EmployeeID == 0 || req.Title == "" { return nil, errors.New("invalid input") }
+    if _, err := s.loadUsersInScope(ctx, []int{req.EmployeeID}, creatorID, role, allTeams); err != nil { return nil, err }
     deadline, err := time.Parse(time.RFC3339, req.Deadline)
     if err != nil { return nil, errors.New("invalid deadline format") }
     t := &models.Task{
-        EmployeeID:  req.EmployeeID,
-        CreatorID:   creatorID,
-        Title:       req.Title,
-        Description: req.Description,
-        Deadline:    deadline,
-        Status:      models.TaskStatus(req.Status),
-        Progress:    req.Progress,
+        EmployeeID:      req.EmployeeID,
+        CreatorID:       creatorID,
+        Title:           req.Title,
+        Description:     req.Description,
+        Deadline:        deadline,
+        Status:          models.TaskStatus(req.Status),
+        Progress:        req.Progress,
+        EstimateMinutes: req.EstimateMinutes,
+        Priority:        models.TaskPriority(req.Priority),
     }
     if t.Status == "" { t.Status = models.StatusPending }
+    if req.ProjectID != nil {
+        if t.ProjectID, err = s.resolveProjectID(ctx, *req.ProjectID); err != nil { return nil, err }
+    }
+    if req.SprintID != nil {
+        if t.SprintID, err = s.resolveSprintID(ctx, *req.SprintID); err != nil { return nil, err }
+    }
+    assignees, err := s.loadUsersInScope(ctx, coAssignees(req.EmployeeID, req.AssigneeIDs), creatorID, role, allTeams)
+    if err != nil { return nil, err }
+    watchers, err := s.loadUsers(ctx, req.WatcherIDs)
+    if err != nil { return nil, err }
+    s.applySLAPolicy(ctx, t)
+    trackSLA(t, models.StatusPending, time.Now())
     if err := s.repo.Task().CreateTask(ctx, t); err != nil { return nil, err }
+    if err := s.addTaskPeople(ctx, t, assignees, watchers); err != nil { return nil, err }
     return taskToDTO(t), nil
 }
 
-func (s *services) GetTaskByID(ctx context.Context, id int) (*dto.TaskResponse, error) {
-    t, err := s.repo.Task().GetTaskByID(ctx, id)
+func (s *services) GetTaskByID(ctx context.Context, id int, userID int, role string, allTeams bool) (*dto.TaskResponse, error) {
+    t, err := s.getReadableTask(ctx, id, userID, role, allTeams)
     if err != nil { return nil, err }
     return taskToDTO(t), nil
 }
@@ -295,11 +635,12 @@ func (s *services) GetTasksByEmployeeID(ctx context.Context, employeeID int) ([]
     return out, nil
 }
 
-func (s *services) UpdateTask(ctx context.Context, id int, req *dto.TaskRequest, userID int) error {
+func (s *services) UpdateTask(ctx context.Context, id int, req *dto.TaskRequest, userID int, role string, allTeams bool) error {
     t, err := s.repo.Task().GetTaskByID(ctx, id)
     if err != nil { return err }
-    if t.CreatorID != userID && t.EmployeeID != userID {
-        return errors.New("forbidden")
+    if !canEditTask(t, userID) {
+        if !s.Can(ctx, role, permission.TaskUpdateAny) { return errors.New("forbidden") }
+        if err := s.checkTaskInScope(ctx, t, userID, role, allTeams); err != nil { return err }
     }
 
     oldStatus := t.Status
@@ -307,12 +648,25 @@ func (s *services) UpdateTask(ctx context.Context, id int, req *dto.TaskRequest,
     if req.Title != "" { t.Title = req.Title }
     if req.Description != "" { t.Description = req.Description }
     if req.Status != "" { t.Status = models.TaskStatus(req.Status) }
-    if req.Progress != 0 { t.Progress = req.Progress }
+    // With a checklist the progress is derived from completed items.
+    if req.Progress != 0 && len(t.Checklist) == 0 { t.Progress = req.Progress }
+    if req.EstimateMinutes != 0 { t.EstimateMinutes = req.EstimateMinutes }
+    if req.ProjectID != nil {
+        if t.ProjectID, err = s.resolveProjectID(ctx, *req.ProjectID); err != nil { return err }
+    }
+    if req.SprintID != nil {
+        if t.SprintID, err = s.resolveSprintID(ctx, *req.SprintID); err != nil { return err }
+    }
+    if req.Priority != "" && models.TaskPriority(req.Priority) != t.Priority {
+        t.Priority = models.TaskPriority(req.Priority)
+        s.applySLAPolicy(ctx, t)
+    }
     if req.Deadline != "" {
         dl, err := time.Parse(time.RFC3339, req.Deadline)
         if err != nil { return errors.New("invalid deadline format") }
         t.Deadline = dl
     }
+    trackSLA(t, oldStatus, time.Now())
 
     if err := s.repo.Task().UpdateTask(ctx, t); err != nil {
         return err
@@ -321,6 +675,7 @@ func (s *services) UpdateTask(ctx context.Context, id int, req *dto.TaskRequest,
     if oldStatus != t.Status {
         h := &models.TaskStatusHistory{
             TaskID:    id,
+            Event:     models.HistoryStatus,
             OldStatus: oldStatus,
             NewStatus: t.Status,
             ChangedBy: userID,
@@ -328,16 +683,23 @@ func (s *services) UpdateTask(ctx context.Context, id int, req *dto.TaskRequest,
         if err := s.repo.Task().CreateHistory(ctx, h); err != nil {
             s.logger.Error().Err(err).Msg("failed to record status history")
         }
+        if t.Status == models.StatusCompleted && t.RecurringTaskID != nil {
+            s.onOccurrenceCompleted(ctx, t)
+        }
+        s.notify(ctx, taskParticipants(t), userID, id, models.NotificationStatusChanged,
+            fmt.Sprintf("Task %q status changed from %s to %s", t.Title, oldStatus, t.Status))
     }
 
     return nil
 }
 
-func (s *services) DeleteTask(ctx context.Context, id int, userID int) error {
+func (s *services) DeleteTask(ctx context.Context, id int, userID int, role string, allTeams bool) error {
     t, err := s.repo.Task().GetTaskByID(ctx, id)
     if err != nil { return err }
+    // Co-assignees can work on the task but only the creator or the lead may delete it.
     if t.CreatorID != userID && t.EmployeeID != userID {
-        return errors.New("forbidden")
+        if !s.Can(ctx, role, permission.TaskDeleteAny) { return errors.New("forbidden") }
+        if err := s.checkTaskInScope(ctx, t, userID, role, allTeams); err != nil { return err }
     }
     return s.repo.Task().DeleteTask(ctx, id)
 }
@@ -347,7 +709,7 @@ func (s *services) UploadAttachment(ctx context.Context, taskID int, userID int,
 	if err != nil {
 		return nil, errors.New("task not found")
 	}
-	if t.CreatorID != userID && t.EmployeeID != userID {
+	if !canEditTask(t, userID) {
 		return nil, errors.New("forbidden")
 	}
 
@@ -371,7 +733,10 @@ func (s *services) UploadAttachment(ctx context.Context, taskID int, userID int,
 	}, nil
 }
 
-func (s *services) GetTaskHistory(ctx context.Context, taskID int) ([]*dto.TaskHistoryResponse, error) {
+func (s *services) GetTaskHistory(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.TaskHistoryResponse, error) {
+	if _, err := s.getReadableTask(ctx, taskID, userID, role, allTeams); err != nil {
+		return nil, err
+	}
 	history, err := s.repo.Task().GetHistoryByTaskID(ctx, taskID)
 	if err != nil {
 		return nil, err
@@ -380,18 +745,33 @@ func (s *services) GetTaskHistory(ctx context.Context, taskID int) ([]*dto.TaskH
 	out := make([]*dto.TaskHistoryResponse, 0, len(history))
 	for _, h := range history {
 		out = append(out, &dto.TaskHistoryResponse{
-			ID:        h.ID,
-			TaskID:    h.TaskID,
-			OldStatus: string(h.OldStatus),
-			NewStatus: string(h.NewStatus),
-			ChangedBy: h.ChangedBy,
-			CreatedAt: h.CreatedAt,
+			ID:         h.ID,
+			TaskID:     h.TaskID,
+			Event:      string(h.Event),
+			OldStatus:  string(h.OldStatus),
+			NewStatus:  string(h.NewStatus),
+			Note:       h.Note,
+			FromUserID: h.FromUserID,
+			ToUserID:   h.ToUserID,
+			ChangedBy:  h.ChangedBy,
+			CreatedAt:  h.CreatedAt,
 		})
 	}
 	return out, nil
 }
 
-func (s *services) ListTasks(ctx context.Context, filter dto.TaskFilter) ([]*dto.TaskResponse, error) {
+func (s *services) ListTasks(ctx context.Context, filter dto.TaskFilter, userID int, role string) ([]*dto.TaskResponse, error) {
+	if !s.Can(ctx, role, permission.TaskReadAny) {
+		filter.ParticipantID = userID
+	} else {
+		scope, err := s.teamScope(ctx, userID, role, filter.AllTeams)
+		if err != nil {
+			return nil, err
+		}
+		if scope != nil {
+			filter.TeamIDs, filter.TeamViewerID = scope, userID
+		}
+	}
 	tasks, err := s.repo.Task().ListTasks(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -413,7 +793,7 @@ func (s *services) AddSkillToTask(ctx context.Context, taskID int, skillID int,
     if _, err := s.repo.Skill().GetSkillByID(ctx, skillID); err != nil {
         return errors.New("skill not found")
     }
-    return s.repo.Task().AddSkillToTask(ctx, taskID, skillID)
+    return s.repo.Task().AddSkillToTask(ctx, taskID, skillID, 0)
 }
 
 func (s *services) RemoveSkillFromTask(ctx context.Context, taskID int, skillID int, userID int) error {
@@ -425,18 +805,24 @@ func (s *services) RemoveSkillFromTask(ctx context.Context, taskID int, skillID
     return s.repo.Task().RemoveSkillFromTask(ctx, taskID, skillID)
 }
 
-func (s *services) GetTaskSkills(ctx context.Context, taskID int) ([]*dto.SkillResponse, error) {
+func (s *services) GetTaskSkills(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.SkillResponse, error) {
+    if _, err := s.getReadableTask(ctx, taskID, userID, role, allTeams); err != nil { return nil, err }
     skills, err := s.repo.Task().GetTaskSkills(ctx, taskID)
     if err != nil { return nil, err }
     out := make([]*dto.SkillResponse, 0, len(skills))
     for _, sk := range skills {
         sk2 := sk
-        out = append(out, &dto.SkillResponse{ID: sk2.ID, Name: sk2.Name, Description: sk2.Description, CreatedAt: sk2.CreatedAt})
+        out = append(out, &dto.SkillResponse{ID: sk2.ID, Name: sk2.Name, Description: sk2.Description, Level: sk2.Level, CreatedAt: sk2.CreatedAt})
     }
     return out, nil
 }
 
-func (s *services) GetRecommendedEmployees(ctx context.Context, taskID int) ([]*dto.RecommendedEmployeeResponse, error) {
+// GetRecommendedEmployees ranks employees by skill match. Team managers get
+// candidates from their subtree unless they ask for all teams.
+func (s *services) GetRecommendedEmployees(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.RecommendedEmployeeResponse, error) {
+    scope, err := s.teamScope(ctx, userID, role, allTeams)
+    if err != nil { return nil, err }
+
     // Get task required skills
     taskSkills, err := s.repo.Task().GetTaskSkills(ctx, taskID)
     if err != nil { return nil, err }
@@ -453,6 +839,7 @@ func (s *services) GetRecommendedEmployees(ctx context.Context, taskID int) ([]*
 
     out := make([]*dto.RecommendedEmployeeResponse, 0, len(employees))
     for _, emp := range employees {
+        if scope != nil && !inTeams(emp.TeamID, scope) { continue }
         skillDTOs := make([]dto.SkillResponse, 0, len(emp.Skills))
         for _, sk := range emp.Skills {
             skillDTOs = append(skillDTOs, dto.SkillResponse{
@@ -508,13 +895,15 @@ func (s *services) GetRecommendedEmployees(ctx context.Context, taskID int) ([]*
 
 func (s *services) Comment() CommentService { return s }
 
-func (s *services) CreateComment(ctx context.Context, taskID int, userID int, text string) (*dto.CommentResponse, error) {
+func (s *services) CreateComment(ctx context.Context, taskID int, userID int, role string, allTeams bool, text string) (*dto.CommentResponse, error) {
+    if _, err := s.getReadableTask(ctx, taskID, userID, role, allTeams); err != nil { return nil, err }
     c := &models.Comment{ TaskID: taskID, UserID: userID, Text: text }
     if err := s.repo.Comment().CreateComment(ctx, c); err != nil { return nil, err }
     return &dto.CommentResponse{ ID: c.ID, TaskID: c.TaskID, UserID: c.UserID, Text: c.Text, CreatedAt: c.CreatedAt }, nil
 }
 
-func (s *services) GetCommentsByTaskID(ctx context.Context, taskID int) ([]*dto.CommentResponse, error) {
+func (s *services) GetCommentsByTaskID(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.CommentResponse, error) {
+    if _, err := s.getReadableTask(ctx, taskID, userID, role, allTeams); err != nil { return nil, err }
     cs, err := s.repo.Comment().GetCommentsByTaskID(ctx, taskID)
     if err != nil { return nil, err }
     out := make([]*dto.CommentResponse, 0, len(cs))
@@ -533,12 +922,12 @@ func (s *services) UpdateComment(ctx context.Context, id int, userID int, text s
     return s.repo.Comment().UpdateComment(ctx, c)
 }
 
-func (s *services) DeleteComment(ctx context.Context, id int, userID int) error {
+func (s *services) DeleteComment(ctx context.Context, id int, userID int, role string) error {
 	c, err := s.repo.Comment().GetCommentByID(ctx, id)
 	if err != nil {
 		return err
 	}
-	if c.UserID != userID {
+	if c.UserID != userID && !s.Can(ctx, role, permission.CommentDeleteAny) {
 		return errors.New("forbidden")
 	}
 	return s.repo.Comment().DeleteComment(ctx, id)
@@ -576,21 +965,37 @@ func (s *services) DeleteSkill(ctx context.Context, id int) error {
     return s.repo.Skill().DeleteSkill(ctx, id)
 }
 
-func (s *services) AssignSkillToUser(ctx context.Context, userID int, skillID int) error {
-    if _, err := s.repo.User().GetUserByID(ctx, userID); err != nil {
-        return errors.New("user not found")
-    }
-    if _, err := s.repo.Skill().GetSkillByID(ctx, skillID); err != nil {
+func (s *services) AssignSkillToUser(ctx context.Context, userID int, skillID int, managerID int, role string, allTeams bool) error {
+    if _, err := s.getUserInScope(ctx, userID, managerID, role, allTeams); err != nil { return err }
+    sk, err := s.repo.Skill().GetSkillByID(ctx, skillID)
+    if err != nil {
         return errors.New("skill not found")
     }
-    return s.repo.Skill().AssignSkillToUser(ctx, userID, skillID)
+    if err := s.repo.Skill().AssignSkillToUser(ctx, userID, skillID); err != nil {
+        return err
+    }
+    s.audit(ctx, auditEntry{Action: "user.skill_assigned", TargetType: "user", TargetID: userID,
+        After: dto.SkillResponse{ID: sk.ID, Name: sk.Name, Level: sk.Level}})
+    return nil
 }
 
-func (s *services) RemoveSkillFromUser(ctx context.Context, userID int, skillID int) error {
-    return s.repo.Skill().RemoveSkillFromUser(ctx, userID, skillID)
+func (s *services) RemoveSkillFromUser(ctx context.Context, userID int, skillID int, managerID int, role string, allTeams bool) error {
+    if _, err := s.getUserInScope(ctx, userID, managerID, role, allTeams); err != nil { return err }
+    if err := s.repo.Skill().RemoveSkillFromUser(ctx, userID, skillID); err != nil {
+        return err
+    }
+    s.audit(ctx, auditEntry{Action: "user.skill_removed", TargetType: "user", TargetID: userID,
+        Before: dto.SkillResponse{ID: skillID}})
+    return nil
 }
 
-func (s *services) GetUserSkills(ctx context.Context, userID int) ([]*dto.SkillResponse, error) {
+// GetUserSkills returns the skills of userID. Users can see their own
+// skills; other profiles require user.read and are limited to the team scope.
+func (s *services) GetUserSkills(ctx context.Context, userID int, viewerID int, role string, allTeams bool) ([]*dto.SkillResponse, error) {
+    if userID != viewerID {
+        if !s.Can(ctx, role, permission.UserRead) { return nil, errors.New("forbidden") }
+        if _, err := s.getUserInScope(ctx, userID, viewerID, role, allTeams); err != nil { return nil, err }
+    }
     skills, err := s.repo.Skill().GetUserSkills(ctx, userID)
     if err != nil { return nil, err }
     out := make([]*dto.SkillResponse, 0, len(skills))
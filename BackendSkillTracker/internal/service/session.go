@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	jwtutil "skilltracker/internal/utils/jwt"
+	"time"
+)
+
+// refreshTokenTTL is how long a session lasts without being refreshed.
+const refreshTokenTTL = 7 * 24 * time.Hour
+
+// newOpaqueToken returns a random URL-safe token.
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is the stored form of a token. Tokens are random, so a plain
+// SHA-256 is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// startSession opens a session for a successful login.
+func (s *services) startSession(ctx context.Context, u *models.User, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sess := &models.Session{
+		UserID:     u.ID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+		LastUsedAt: now,
+		Tokens:     []models.RefreshToken{{TokenHash: hashToken(refreshToken)}},
+	}
+	if err := s.repo.Session().CreateSession(ctx, sess); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, auditEntry{Action: "login.succeeded", TargetType: "user", TargetID: u.ID, Actor: u})
+	return s.issueTokens(u, sess.ID, refreshToken)
+}
+
+func (s *services) issueTokens(u *models.User, sessionID int, refreshToken string) (*dto.LoginResponse, error) {
+	accessToken, err := s.keys.GenerateAccessToken(u.ID, u.OrgID, sessionID, u.TokenVersion, u.Username, string(u.Role))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User: dto.UserResponse{
+			ID:       u.ID,
+			Username: u.Username,
+			Role:     string(u.Role),
+			Name:     u.Name,
+			TeamID:   u.TeamID,
+		},
+	}, nil
+}
+
+func (s *services) JWKS() jwtutil.JWKS { return s.keys.JWKS() }
+
+func (s *services) revokeReusedSession(ctx context.Context, sess *models.Session, now time.Time) {
+	s.logger.Warn().Int("session_id", sess.ID).Int("user_id", sess.UserID).
+		Msg("refresh token reuse detected, session revoked")
+	if err := s.repo.Session().RevokeSession(ctx, sess.ID, now); err != nil {
+		s.logger.Error().Err(err).Int("session_id", sess.ID).Msg("failed to revoke session")
+	}
+}
+
+// SESSIONS
+
+func (s *services) Session() SessionService { return s }
+
+func (s *services) GetMySessions(ctx context.Context, userID int, currentID int) ([]*dto.SessionResponse, error) {
+	sessions, err := s.repo.Session().GetActiveSessions(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.SessionResponse, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, &dto.SessionResponse{
+			ID:         sess.ID,
+			UserAgent:  sess.UserAgent,
+			IP:         sess.IP,
+			CreatedAt:  sess.CreatedAt,
+			LastUsedAt: sess.LastUsedAt,
+			ExpiresAt:  sess.ExpiresAt,
+			Current:    sess.ID == currentID,
+		})
+	}
+	return out, nil
+}
+
+func (s *services) RevokeMySession(ctx context.Context, userID int, sessionID int) error {
+	if err := s.revokeSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.audit(ctx, auditEntry{Action: "session.revoked", TargetType: "session", TargetID: sessionID})
+	return nil
+}
+
+func (s *services) revokeSession(ctx context.Context, userID int, sessionID int) error {
+	sess, err := s.repo.Session().GetSessionByID(ctx, sessionID)
+	if err != nil || sess.UserID != userID || sess.RevokedAt != nil {
+		return errors.New("session not found")
+	}
+	return s.repo.Session().RevokeSession(ctx, sess.ID, time.Now())
+}
+
+func (s *services) RevokeUserSessions(ctx context.Context, userID int, managerID int, role string, allTeams bool) error {
+	if _, err := s.getUserInScope(ctx, userID, managerID, role, allTeams); err != nil {
+		return err
+	}
+	if err := s.endAllSessions(ctx, userID); err != nil {
+		return err
+	}
+	s.audit(ctx, auditEntry{Action: "user.sessions_revoked", TargetType: "user", TargetID: userID})
+	return nil
+}
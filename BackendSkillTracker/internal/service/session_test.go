@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// liveToken returns an unused refresh token of session 9 of user 5 in
+// organization 3.
+func liveToken(raw string) *models.RefreshToken {
+	return &models.RefreshToken{
+		ID: 20, OrgID: 3, SessionID: 9, TokenHash: hashToken(raw),
+		Session: models.Session{ID: 9, OrgID: 3, UserID: 5, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+}
+
+func TestLogin_StoresHashedRefreshToken(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	mockRepo.On("Organization").Return(defaultOrgRepo())
+
+	mockRepo.On("Audit").Return(acceptingAuditRepo())
+	mockRepo.On("User").Return(mockUserRepo)
+	mockRepo.On("Session").Return(mockSessionRepo)
+	mockRepo.On("TwoFactor").Return(noTwoFactorRepo())
+	mockUserRepo.On("GetUserByUsername", mock.Anything, "alice").
+		Return(&models.User{ID: 5, OrgID: 1, Username: "alice", PasswordHash: string(hash), Role: models.RoleEmployee}, nil)
+	var stored *models.Session
+	mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.Session)
+		stored.ID = 9
+	}).Return(nil)
+
+	res, err := s.User().Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "password123"},
+		dto.ClientInfo{UserAgent: "Firefox", IP: "10.0.0.1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Firefox", stored.UserAgent)
+	assert.Equal(t, "10.0.0.1", stored.IP)
+	assert.Len(t, stored.Tokens, 1)
+	assert.NotEqual(t, res.RefreshToken, stored.Tokens[0].TokenHash)
+	assert.Equal(t, hashToken(res.RefreshToken), stored.Tokens[0].TokenHash)
+	claims, err := testKeys.ValidateToken(res.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, claims.SessionID)
+}
+
+func TestRefreshToken_Rotates(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+	mockRepo.On("User").Return(mockUserRepo)
+	mockRepo.On("Session").Return(mockSessionRepo)
+	mockSessionRepo.On("GetRefreshToken", mock.MatchedBy(tenant.IsSystem), hashToken("old")).Return(liveToken("old"), nil)
+	mockUserRepo.On("GetUserByID", inOrg(3), 5).Return(&models.User{ID: 5, OrgID: 3, Username: "alice", Role: models.RoleEmployee}, nil)
+	var next *models.RefreshToken
+	mockSessionRepo.On("RotateRefreshToken", inOrg(3), 20, mock.Anything, mock.MatchedBy(func(sess *models.Session) bool {
+		return sess.ID == 9 && sess.IP == "10.0.0.2" && sess.ExpiresAt.After(time.Now().Add(refreshTokenTTL-time.Minute))
+	})).Run(func(args mock.Arguments) {
+		next = args.Get(2).(*models.RefreshToken)
+	}).Return(true, nil)
+
+	res, err := s.User().RefreshToken(ctx, &dto.RefreshRequest{RefreshToken: "old"}, dto.ClientInfo{IP: "10.0.0.2"})
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "old", res.RefreshToken)
+	assert.Equal(t, 9, next.SessionID)
+	assert.Equal(t, hashToken(res.RefreshToken), next.TokenHash)
+	claims, err := testKeys.ValidateToken(res.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, claims.OrgID)
+	assert.Equal(t, 9, claims.SessionID)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestRefreshToken_ReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("used token", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockSessionRepo := new(MockSessionRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		used := liveToken("old")
+		usedAt := time.Now().Add(-time.Minute)
+		used.UsedAt = &usedAt
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockSessionRepo.On("GetRefreshToken", mock.Anything, hashToken("old")).Return(used, nil)
+		mockSessionRepo.On("RevokeSession", inOrg(3), 9, mock.Anything).Return(nil)
+
+		_, err := s.User().RefreshToken(ctx, &dto.RefreshRequest{RefreshToken: "old"}, dto.ClientInfo{})
+
+		assert.EqualError(t, err, "invalid refresh token")
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("token used concurrently", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockSessionRepo := new(MockSessionRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockSessionRepo.On("GetRefreshToken", mock.Anything, hashToken("old")).Return(liveToken("old"), nil)
+		mockUserRepo.On("GetUserByID", mock.Anything, 5).Return(&models.User{ID: 5, OrgID: 3}, nil)
+		mockSessionRepo.On("RotateRefreshToken", mock.Anything, 20, mock.Anything, mock.Anything).Return(false, nil)
+		mockSessionRepo.On("RevokeSession", inOrg(3), 9, mock.Anything).Return(nil)
+
+		_, err := s.User().RefreshToken(ctx, &dto.RefreshRequest{RefreshToken: "old"}, dto.ClientInfo{})
+
+		assert.EqualError(t, err, "invalid refresh token")
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockSessionRepo := new(MockSessionRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		rt := liveToken("old")
+		revokedAt := time.Now()
+		rt.Session.RevokedAt = &revokedAt
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockSessionRepo.On("GetRefreshToken", mock.Anything, hashToken("old")).Return(rt, nil)
+
+		_, err := s.User().RefreshToken(ctx, &dto.RefreshRequest{RefreshToken: "old"}, dto.ClientInfo{})
+
+		assert.EqualError(t, err, "invalid refresh token")
+		mockSessionRepo.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("list marks the current session", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockSessionRepo := new(MockSessionRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockSessionRepo.On("GetActiveSessions", ctx, 5, mock.Anything).
+			Return([]models.Session{{ID: 9, UserID: 5}, {ID: 10, UserID: 5}}, nil)
+
+		res, err := s.Session().GetMySessions(ctx, 5, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.False(t, res[0].Current)
+		assert.True(t, res[1].Current)
+	})
+
+	t.Run("can't revoke another user's session", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockSessionRepo := new(MockSessionRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockSessionRepo.On("GetSessionByID", ctx, 9).Return(&models.Session{ID: 9, UserID: 6}, nil)
+
+		err := s.Session().RevokeMySession(ctx, 5, 9)
+
+		assert.EqualError(t, err, "session not found")
+		mockSessionRepo.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("revoke all sessions of a user", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockSessionRepo := new(MockSessionRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5}, nil)
+		mockSessionRepo.On("RevokeUserSessions", ctx, 5, mock.Anything).Return(nil)
+		mockUserRepo.On("BumpTokenVersion", ctx, 5).Return(nil)
+
+		assert.NoError(t, s.Session().RevokeUserSessions(ctx, 5, 1, "manager", true))
+		mockSessionRepo.AssertExpectations(t)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(nil, errors.New("not found"))
+
+		assert.EqualError(t, s.Session().RevokeUserSessions(ctx, 5, 1, "manager", true), "user not found")
+	})
+}
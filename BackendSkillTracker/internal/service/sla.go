@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"time"
+)
+
+// SLA
+
+func (s *services) SLA() SLAService { return s }
+
+func validPriority(p models.TaskPriority) bool {
+	switch p {
+	case models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityCritical:
+		return true
+	}
+	return false
+}
+
+// applySLAPolicy sets the task due times from the policy of its priority,
+// counting from the task creation. Without a policy the task has no SLA.
+func (s *services) applySLAPolicy(ctx context.Context, t *models.Task) {
+	if t.Priority == "" {
+		t.Priority = models.PriorityMedium
+	}
+	t.ResponseDueAt, t.ResolutionDueAt = nil, nil
+	p, err := s.repo.SLA().GetSLAPolicy(ctx, t.Priority)
+	if err != nil {
+		return
+	}
+	base := t.CreatedAt
+	if base.IsZero() {
+		base = time.Now()
+	}
+	responseDue := base.Add(time.Duration(p.ResponseMinutes) * time.Minute)
+	resolutionDue := base.Add(time.Duration(p.ResolutionMinutes) * time.Minute)
+	t.ResponseDueAt, t.ResolutionDueAt = &responseDue, &resolutionDue
+}
+
+// trackSLA records response and completion times on a status change and
+// the breaches they cause.
+func trackSLA(t *models.Task, oldStatus models.TaskStatus, now time.Time) {
+	if oldStatus == models.StatusPending && t.Status != models.StatusPending && t.RespondedAt == nil {
+		t.RespondedAt = &now
+		if t.ResponseDueAt != nil && now.After(*t.ResponseDueAt) && t.ResponseBreachedAt == nil {
+			t.ResponseBreachedAt = t.ResponseDueAt
+		}
+	}
+	switch {
+	case t.Status == models.StatusCompleted && oldStatus != models.StatusCompleted:
+		t.CompletedAt = &now
+		if t.ResolutionDueAt != nil && now.After(*t.ResolutionDueAt) && t.ResolutionBreachedAt == nil {
+			t.ResolutionBreachedAt = t.ResolutionDueAt
+		}
+	case t.Status != models.StatusCompleted && oldStatus == models.StatusCompleted:
+		t.CompletedAt = nil
+	}
+}
+
+func slaStatus(t *models.Task, now time.Time) *dto.SLAStatusResponse {
+	if t.ResponseDueAt == nil && t.ResolutionDueAt == nil {
+		return nil
+	}
+	status := "on_track"
+	switch {
+	case t.ResponseBreachedAt != nil || t.ResolutionBreachedAt != nil,
+		t.RespondedAt == nil && t.ResponseDueAt != nil && now.After(*t.ResponseDueAt),
+		t.CompletedAt == nil && t.ResolutionDueAt != nil && now.After(*t.ResolutionDueAt):
+		status = "breached"
+	case t.CompletedAt != nil:
+		status = "met"
+	}
+	return &dto.SLAStatusResponse{
+		Status:               status,
+		ResponseDueAt:        t.ResponseDueAt,
+		ResolutionDueAt:      t.ResolutionDueAt,
+		RespondedAt:          t.RespondedAt,
+		CompletedAt:          t.CompletedAt,
+		ResponseBreachedAt:   t.ResponseBreachedAt,
+		ResolutionBreachedAt: t.ResolutionBreachedAt,
+	}
+}
+
+func slaPolicyToDTO(p *models.SLAPolicy) *dto.SLAPolicyResponse {
+	return &dto.SLAPolicyResponse{
+		Priority:          string(p.Priority),
+		ResponseMinutes:   p.ResponseMinutes,
+		ResolutionMinutes: p.ResolutionMinutes,
+		UpdatedAt:         p.UpdatedAt,
+	}
+}
+
+func (s *services) GetSLAPolicies(ctx context.Context) ([]*dto.SLAPolicyResponse, error) {
+	ps, err := s.repo.SLA().GetSLAPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.SLAPolicyResponse, 0, len(ps))
+	for i := range ps {
+		out = append(out, slaPolicyToDTO(&ps[i]))
+	}
+	return out, nil
+}
+
+// UpdateSLAPolicy creates or replaces the policy of a priority. Due times of
+// existing tasks are kept; new tasks and priority changes use the new policy.
+func (s *services) UpdateSLAPolicy(ctx context.Context, priority string, req *dto.SLAPolicyRequest) (*dto.SLAPolicyResponse, error) {
+	pr := models.TaskPriority(priority)
+	if !validPriority(pr) {
+		return nil, errors.New("invalid priority")
+	}
+	if req.ResolutionMinutes < req.ResponseMinutes {
+		return nil, errors.New("resolution time is shorter than response time")
+	}
+	p, err := s.repo.SLA().GetSLAPolicy(ctx, pr)
+	if err != nil {
+		p = &models.SLAPolicy{Priority: pr}
+	}
+	p.ResponseMinutes = req.ResponseMinutes
+	p.ResolutionMinutes = req.ResolutionMinutes
+	if err := s.repo.SLA().SaveSLAPolicy(ctx, p); err != nil {
+		return nil, err
+	}
+	return slaPolicyToDTO(p), nil
+}
+
+// CheckSLA records breaches of tasks whose due times passed without a
+// response or completion and returns the number of updated tasks.
+func (s *services) CheckSLA(ctx context.Context, now time.Time) (int, error) {
+	ts, err := s.repo.SLA().GetTasksBreachingSLA(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for i := range ts {
+		t := &ts[i]
+		if t.RespondedAt == nil && t.ResponseBreachedAt == nil && t.ResponseDueAt != nil && now.After(*t.ResponseDueAt) {
+			t.ResponseBreachedAt = t.ResponseDueAt
+		}
+		if t.CompletedAt == nil && t.ResolutionBreachedAt == nil && t.ResolutionDueAt != nil && now.After(*t.ResolutionDueAt) {
+			t.ResolutionBreachedAt = t.ResolutionDueAt
+		}
+		recorded, err := s.repo.SLA().RecordSLABreaches(ctx, t)
+		if err != nil {
+			s.logger.Error().Err(err).Int("task_id", t.ID).Msg("failed to record SLA breach")
+			continue
+		}
+		if !recorded {
+			continue
+		}
+		s.logger.Warn().Int("task_id", t.ID).Str("priority", string(t.Priority)).Msg("SLA breached")
+		updated++
+	}
+	return updated, nil
+}
+
+// RunSLAMonitor calls CheckSLA for every organization each interval until
+// ctx is cancelled.
+func (s *services) RunSLAMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		err := s.forEachOrganization(ctx, func(ctx context.Context) error {
+			_, err := s.CheckSLA(ctx, now)
+			return err
+		})
+		if err != nil {
+			s.logger.Error().Err(err).Msg("SLA monitor failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
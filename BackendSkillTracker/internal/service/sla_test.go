@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTrackSLA(t *testing.T) {
+	created := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	responseDue := created.Add(time.Hour)
+	resolutionDue := created.Add(8 * time.Hour)
+	task := &models.Task{
+		Status:          models.StatusInProgress,
+		ResponseDueAt:   &responseDue,
+		ResolutionDueAt: &resolutionDue,
+	}
+
+	// Responded late, resolved in time.
+	trackSLA(task, models.StatusPending, created.Add(2*time.Hour))
+	assert.Equal(t, &responseDue, task.ResponseBreachedAt)
+	assert.Equal(t, "breached", slaStatus(task, created.Add(2*time.Hour)).Status)
+
+	task.Status = models.StatusCompleted
+	trackSLA(task, models.StatusInProgress, created.Add(4*time.Hour))
+	assert.Nil(t, task.ResolutionBreachedAt)
+	assert.NotNil(t, task.CompletedAt)
+
+	// Reopening clears the completion time.
+	task.Status = models.StatusInProgress
+	trackSLA(task, models.StatusCompleted, created.Add(5*time.Hour))
+	assert.Nil(t, task.CompletedAt)
+}
+
+func TestSLAStatus(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	assert.Nil(t, slaStatus(&models.Task{}, now))
+	assert.Equal(t, "on_track", slaStatus(&models.Task{ResolutionDueAt: &later}, now).Status)
+	assert.Equal(t, "breached", slaStatus(&models.Task{ResolutionDueAt: &earlier}, now).Status)
+	assert.Equal(t, "met", slaStatus(&models.Task{ResolutionDueAt: &later, CompletedAt: &earlier}, now).Status)
+}
+
+func TestSLAService_UpdateTaskPriority(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockSLARepo := new(MockSLARepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	created := time.Now().Add(-30 * time.Minute)
+	task := &models.Task{ID: 1, CreatorID: 2, EmployeeID: 3, Status: models.StatusPending, Priority: models.PriorityLow, CreatedAt: created}
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("SLA").Return(mockSLARepo)
+	mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+	mockSLARepo.On("GetSLAPolicy", ctx, models.PriorityCritical).
+		Return(&models.SLAPolicy{Priority: models.PriorityCritical, ResponseMinutes: 15, ResolutionMinutes: 240}, nil)
+	mockTaskRepo.On("UpdateTask", ctx, mock.MatchedBy(func(tk *models.Task) bool {
+		return tk.Priority == models.PriorityCritical &&
+			tk.ResponseDueAt.Equal(created.Add(15*time.Minute)) &&
+			tk.ResolutionDueAt.Equal(created.Add(4*time.Hour))
+	})).Return(nil)
+
+	err := s.Task().UpdateTask(ctx, 1, &dto.TaskRequest{Priority: "critical"}, 2, "employee", false)
+
+	assert.NoError(t, err)
+	mockTaskRepo.AssertExpectations(t)
+}
+
+func TestSLAService_CheckSLA(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockSLARepo := new(MockSLARepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	responseDue := now.Add(-2 * time.Hour)
+	resolutionDue := now.Add(2 * time.Hour)
+	responded := now.Add(-3 * time.Hour)
+	tasks := []models.Task{
+		{ID: 1, ResponseDueAt: &responseDue, ResolutionDueAt: &resolutionDue},
+		{ID: 2, ResponseDueAt: &responseDue, ResolutionDueAt: &responseDue, RespondedAt: &responded},
+		{ID: 3, ResponseDueAt: &responseDue},
+	}
+	mockRepo.On("SLA").Return(mockSLARepo)
+	mockSLARepo.On("GetTasksBreachingSLA", ctx, now).Return(tasks, nil)
+	mockSLARepo.On("RecordSLABreaches", ctx, mock.MatchedBy(func(tk *models.Task) bool {
+		return tk.ID == 1 && tk.ResponseBreachedAt.Equal(responseDue) && tk.ResolutionBreachedAt == nil
+	})).Return(true, nil)
+	mockSLARepo.On("RecordSLABreaches", ctx, mock.MatchedBy(func(tk *models.Task) bool {
+		return tk.ID == 2 && tk.ResponseBreachedAt == nil && tk.ResolutionBreachedAt.Equal(responseDue)
+	})).Return(true, nil)
+	// Recorded meanwhile by another instance.
+	mockSLARepo.On("RecordSLABreaches", ctx, mock.MatchedBy(func(tk *models.Task) bool {
+		return tk.ID == 3
+	})).Return(false, nil)
+
+	updated, err := s.SLA().CheckSLA(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated)
+	mockSLARepo.AssertExpectations(t)
+	mockTaskRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+	"time"
+)
+
+// SPRINTS
+
+func (s *services) Sprint() SprintService { return s }
+
+func sprintToDTO(sp *models.Sprint) *dto.SprintResponse {
+	return &dto.SprintResponse{
+		ID:        sp.ID,
+		Name:      sp.Name,
+		Goal:      sp.Goal,
+		ProjectID: sp.ProjectID,
+		StartDate: sp.StartDate.Format(dateLayout),
+		EndDate:   sp.EndDate.Format(dateLayout),
+		Status:    string(sp.Status),
+		CreatorID: sp.CreatorID,
+		ClosedAt:  sp.ClosedAt,
+		CreatedAt: sp.CreatedAt,
+	}
+}
+
+func (s *services) applySprintRequest(ctx context.Context, sp *models.Sprint, req *dto.SprintRequest) error {
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		return errors.New("invalid start_date format")
+	}
+	end, err := time.Parse(dateLayout, req.EndDate)
+	if err != nil {
+		return errors.New("invalid end_date format")
+	}
+	if end.Before(start) {
+		return errors.New("end date before start date")
+	}
+	if req.ProjectID != nil {
+		if sp.ProjectID, err = s.resolveProjectID(ctx, *req.ProjectID); err != nil {
+			return err
+		}
+	}
+	sp.Name = req.Name
+	sp.Goal = req.Goal
+	sp.StartDate = start
+	sp.EndDate = end
+	return nil
+}
+
+func (s *services) CreateSprint(ctx context.Context, req *dto.SprintRequest, creatorID int) (*dto.SprintResponse, error) {
+	sp := &models.Sprint{CreatorID: creatorID, Status: models.SprintPlanned}
+	if err := s.applySprintRequest(ctx, sp, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Sprint().CreateSprint(ctx, sp); err != nil {
+		return nil, err
+	}
+	return sprintToDTO(sp), nil
+}
+
+// canSeeSprint reports whether the user may see the sprint: with
+// task.read.any, as a member of its project or by taking part in one of its
+// tasks.
+func (s *services) canSeeSprint(ctx context.Context, sp *models.Sprint, userID int, role string) bool {
+	if s.Can(ctx, role, permission.TaskReadAny) {
+		return true
+	}
+	if sp.ProjectID != nil {
+		if _, err := s.getVisibleProject(ctx, *sp.ProjectID, userID, role); err == nil {
+			return true
+		}
+	}
+	ts, err := s.repo.Task().ListTasks(ctx, dto.TaskFilter{SprintID: sp.ID, ParticipantID: userID})
+	return err == nil && len(ts) > 0
+}
+
+// getVisibleSprint hides sprints the user can't see as not found, like
+// projects.
+func (s *services) getVisibleSprint(ctx context.Context, id int, userID int, role string) (*models.Sprint, error) {
+	sp, err := s.repo.Sprint().GetSprintByID(ctx, id)
+	if err != nil || !s.canSeeSprint(ctx, sp, userID, role) {
+		return nil, errors.New("sprint not found")
+	}
+	return sp, nil
+}
+
+func (s *services) GetSprints(ctx context.Context, projectID int, userID int, role string) ([]*dto.SprintResponse, error) {
+	sps, err := s.repo.Sprint().GetSprints(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.SprintResponse, 0, len(sps))
+	for i := range sps {
+		if s.canSeeSprint(ctx, &sps[i], userID, role) {
+			out = append(out, sprintToDTO(&sps[i]))
+		}
+	}
+	return out, nil
+}
+
+func (s *services) GetSprintByID(ctx context.Context, id int, userID int, role string) (*dto.SprintResponse, error) {
+	sp, err := s.getVisibleSprint(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return sprintToDTO(sp), nil
+}
+
+// getOpenSprint returns the sprint if it exists and isn't closed.
+func (s *services) getOpenSprint(ctx context.Context, id int) (*models.Sprint, error) {
+	sp, err := s.repo.Sprint().GetSprintByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("sprint not found")
+	}
+	if sp.Status == models.SprintClosed {
+		return nil, errors.New("sprint is closed")
+	}
+	return sp, nil
+}
+
+func (s *services) UpdateSprint(ctx context.Context, id int, req *dto.SprintRequest) error {
+	sp, err := s.getOpenSprint(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.applySprintRequest(ctx, sp, req); err != nil {
+		return err
+	}
+	return s.repo.Sprint().UpdateSprint(ctx, sp)
+}
+
+func (s *services) DeleteSprint(ctx context.Context, id int) error {
+	if _, err := s.repo.Sprint().GetSprintByID(ctx, id); err != nil {
+		return errors.New("sprint not found")
+	}
+	return s.repo.Sprint().DeleteSprint(ctx, id)
+}
+
+func (s *services) StartSprint(ctx context.Context, id int) error {
+	sp, err := s.getOpenSprint(ctx, id)
+	if err != nil {
+		return err
+	}
+	if sp.Status != models.SprintPlanned {
+		return errors.New("sprint already started")
+	}
+	sp.Status = models.SprintActive
+	return s.repo.Sprint().UpdateSprint(ctx, sp)
+}
+
+// CloseSprint closes the sprint and carries its unfinished tasks over to the
+// next sprint. Without a next sprint they are taken out of the sprint.
+func (s *services) CloseSprint(ctx context.Context, id int, req *dto.CloseSprintRequest, userID int) (*dto.CloseSprintResponse, error) {
+	sp, err := s.getOpenSprint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var next *models.Sprint
+	if req.NextSprintID != 0 {
+		if req.NextSprintID == id {
+			return nil, errors.New("invalid next sprint")
+		}
+		if next, err = s.getOpenSprint(ctx, req.NextSprintID); err != nil {
+			return nil, errors.New("invalid next sprint")
+		}
+	} else if found, err := s.repo.Sprint().GetNextSprint(ctx, sp); err == nil {
+		next = found
+	}
+
+	tasks, err := s.repo.Sprint().GetSprintTasks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := &dto.CloseSprintResponse{}
+	note := fmt.Sprintf("removed from closed sprint %q", sp.Name)
+	if next != nil {
+		res.NextSprintID = &next.ID
+		note = fmt.Sprintf("carried over from sprint %q to %q", sp.Name, next.Name)
+	}
+	// Every task stays a member of the closed sprint for its burndown; the
+	// unfinished ones move on with a history entry.
+	ids := make([]int, 0, len(tasks))
+	moved := make([]models.TaskStatusHistory, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+		if t.Status == models.StatusCompleted {
+			continue
+		}
+		moved = append(moved, models.TaskStatusHistory{
+			TaskID:    t.ID,
+			Event:     models.HistorySprint,
+			OldStatus: t.Status,
+			NewStatus: t.Status,
+			Note:      note,
+			ChangedBy: userID,
+		})
+	}
+	res.CarriedOver = len(moved)
+
+	now := time.Now()
+	sp.Status = models.SprintClosed
+	sp.ClosedAt = &now
+	if err := s.repo.Sprint().CloseSprint(ctx, sp, ids, moved, res.NextSprintID); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetBurndown replays the status history of the sprint tasks and returns
+// one point per sprint day up to today.
+func (s *services) GetBurndown(ctx context.Context, id int, userID int, role string) (*dto.BurndownResponse, error) {
+	sp, err := s.getVisibleSprint(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.repo.Sprint().GetSprintTasks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	history, err := s.repo.Sprint().GetStatusHistoryForTasks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.BurndownResponse{SprintID: sp.ID, Points: burndown(sp, tasks, history, time.Now())}, nil
+}
+
+// statusAt returns the status of the task at time at. events are the status
+// changes of the task in chronological order.
+func statusAt(t *models.Task, events []models.TaskStatusHistory, at time.Time) models.TaskStatus {
+	if len(events) == 0 {
+		if t.CreatedAt.After(at) {
+			return models.StatusPending
+		}
+		return t.Status
+	}
+	status := events[0].OldStatus
+	for _, e := range events {
+		if e.CreatedAt.After(at) {
+			break
+		}
+		status = e.NewStatus
+	}
+	return status
+}
+
+func burndown(sp *models.Sprint, tasks []models.Task, history []models.TaskStatusHistory, now time.Time) []dto.BurndownPoint {
+	events := make(map[int][]models.TaskStatusHistory, len(tasks))
+	for _, h := range history {
+		events[h.TaskID] = append(events[h.TaskID], h)
+	}
+
+	start := time.Date(sp.StartDate.Year(), sp.StartDate.Month(), sp.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(sp.EndDate.Sub(sp.StartDate).Hours()/24) + 1
+	total := len(tasks)
+	points := []dto.BurndownPoint{}
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		if day.After(now) {
+			break
+		}
+		dayEnd := day.AddDate(0, 0, 1)
+		completed := 0
+		for j := range tasks {
+			if statusAt(&tasks[j], events[tasks[j].ID], dayEnd) == models.StatusCompleted {
+				completed++
+			}
+		}
+		ideal := 0.0
+		if days > 1 {
+			ideal = float64(total) * float64(days-1-i) / float64(days-1)
+		}
+		points = append(points, dto.BurndownPoint{
+			Date:      day.Format(dateLayout),
+			Total:     total,
+			Completed: completed,
+			Remaining: total - completed,
+			Ideal:     ideal,
+		})
+	}
+	return points
+}
+
+// resolveSprintID checks the sprint referenced by a task request; 0 means
+// no sprint. Tasks can't be placed into a closed sprint.
+func (s *services) resolveSprintID(ctx context.Context, id int) (*int, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	if _, err := s.getOpenSprint(ctx, id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
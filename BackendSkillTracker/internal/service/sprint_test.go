@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBurndown(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	sp := &models.Sprint{ID: 1, StartDate: start, EndDate: start.AddDate(0, 0, 4)}
+	tasks := []models.Task{
+		{ID: 1, Status: models.StatusCompleted, CreatedAt: start.Add(-time.Hour)},
+		{ID: 2, Status: models.StatusInProgress, CreatedAt: start.Add(-time.Hour)},
+		{ID: 3, Status: models.StatusPending, CreatedAt: start.Add(-time.Hour)},
+	}
+	history := []models.TaskStatusHistory{
+		{TaskID: 1, OldStatus: models.StatusPending, NewStatus: models.StatusCompleted, CreatedAt: start.Add(26 * time.Hour)},
+		{TaskID: 2, OldStatus: models.StatusPending, NewStatus: models.StatusCompleted, CreatedAt: start.Add(30 * time.Hour)},
+		{TaskID: 2, OldStatus: models.StatusCompleted, NewStatus: models.StatusInProgress, CreatedAt: start.Add(50 * time.Hour)},
+	}
+	now := start.AddDate(0, 0, 2).Add(12 * time.Hour)
+
+	points := burndown(sp, tasks, history, now)
+
+	assert.Len(t, points, 3)
+	assert.Equal(t, dto.BurndownPoint{Date: "2024-03-04", Total: 3, Completed: 0, Remaining: 3, Ideal: 3}, points[0])
+	assert.Equal(t, 2, points[1].Completed)
+	assert.Equal(t, 1, points[2].Completed)
+	assert.Equal(t, 1.5, points[2].Ideal)
+}
+
+func TestSprintService_CloseSprint(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("success - unfinished tasks carried over to the next sprint", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockSprintRepo := new(MockSprintRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		sprint := &models.Sprint{ID: 1, Name: "Sprint 1", Status: models.SprintActive}
+		next := &models.Sprint{ID: 2, Name: "Sprint 2", Status: models.SprintPlanned}
+		mockRepo.On("Sprint").Return(mockSprintRepo)
+		mockSprintRepo.On("GetSprintByID", ctx, 1).Return(sprint, nil)
+		mockSprintRepo.On("GetNextSprint", ctx, sprint).Return(next, nil)
+		mockSprintRepo.On("GetSprintTasks", ctx, 1).Return([]models.Task{
+			{ID: 10, Status: models.StatusCompleted},
+			{ID: 11, Status: models.StatusInProgress},
+		}, nil)
+		mockSprintRepo.On("CloseSprint", ctx, mock.MatchedBy(func(sp *models.Sprint) bool {
+			return sp.Status == models.SprintClosed && sp.ClosedAt != nil
+		}), []int{10, 11}, mock.MatchedBy(func(moved []models.TaskStatusHistory) bool {
+			return len(moved) == 1 && moved[0].TaskID == 11 && moved[0].Event == models.HistorySprint
+		}), mock.MatchedBy(func(id *int) bool { return *id == 2 })).Return(nil)
+
+		res, err := s.Sprint().CloseSprint(ctx, 1, &dto.CloseSprintRequest{}, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, res.CarriedOver)
+		assert.Equal(t, 2, *res.NextSprintID)
+		mockSprintRepo.AssertExpectations(t)
+	})
+
+	t.Run("no next sprint", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockSprintRepo := new(MockSprintRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		sprint := &models.Sprint{ID: 1, Status: models.SprintActive}
+		mockRepo.On("Sprint").Return(mockSprintRepo)
+		mockSprintRepo.On("GetSprintByID", ctx, 1).Return(sprint, nil)
+		mockSprintRepo.On("GetNextSprint", ctx, sprint).Return(nil, errors.New("record not found"))
+		mockSprintRepo.On("GetSprintTasks", ctx, 1).Return([]models.Task{}, nil)
+		mockSprintRepo.On("CloseSprint", ctx, sprint, []int{}, []models.TaskStatusHistory{}, (*int)(nil)).Return(nil)
+
+		res, err := s.Sprint().CloseSprint(ctx, 1, &dto.CloseSprintRequest{}, 2)
+
+		assert.NoError(t, err)
+		assert.Nil(t, res.NextSprintID)
+	})
+
+	t.Run("already closed", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockSprintRepo := new(MockSprintRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Sprint").Return(mockSprintRepo)
+		mockSprintRepo.On("GetSprintByID", ctx, 1).Return(&models.Sprint{ID: 1, Status: models.SprintClosed}, nil)
+
+		_, err := s.Sprint().CloseSprint(ctx, 1, &dto.CloseSprintRequest{}, 2)
+
+		assert.Error(t, err)
+		assert.Equal(t, "sprint is closed", err.Error())
+	})
+}
+
+func TestSprintService_Visibility(t *testing.T) {
+	ctx := context.Background()
+	projectID := 3
+	ownProject := &models.Sprint{ID: 1, Name: "Own project", ProjectID: &projectID}
+	withOwnTask := &models.Sprint{ID: 2, Name: "Own task"}
+	foreign := &models.Sprint{ID: 3, Name: "Foreign"}
+
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockSprintRepo := new(MockSprintRepo)
+	mockProjectRepo := new(MockProjectRepo)
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("Sprint").Return(mockSprintRepo)
+	mockRepo.On("Project").Return(mockProjectRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+	mockSprintRepo.On("GetSprints", ctx, 0).Return([]models.Sprint{*ownProject, *withOwnTask, *foreign}, nil)
+	mockSprintRepo.On("GetSprintByID", ctx, 3).Return(foreign, nil)
+	mockProjectRepo.On("GetProjectByID", ctx, 3).Return(&models.Project{ID: 3, OwnerID: 7}, nil)
+	mockTaskRepo.On("ListTasks", ctx, dto.TaskFilter{SprintID: 2, ParticipantID: 7}).Return([]models.Task{{ID: 10}}, nil)
+	mockTaskRepo.On("ListTasks", ctx, mock.Anything).Return([]models.Task{}, nil)
+
+	res, err := s.Sprint().GetSprints(ctx, 0, 7, "employee")
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, []int{1, 2}, []int{res[0].ID, res[1].ID})
+
+	_, err = s.Sprint().GetSprintByID(ctx, 3, 7, "employee")
+	assert.EqualError(t, err, "sprint not found")
+	_, err = s.Sprint().GetBurndown(ctx, 3, 7, "employee")
+	assert.EqualError(t, err, "sprint not found")
+	mockSprintRepo.AssertNotCalled(t, "GetSprintTasks", ctx, 3)
+
+	res, err = s.Sprint().GetSprints(ctx, 0, 8, "manager")
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"skilltracker/internal/dto"
 	"skilltracker/internal/models"
 	"testing"
@@ -15,8 +16,9 @@ import (
 func TestTaskService_CreateTask(t *testing.T) {
 	mockRepo := new(MockRepo)
 	mockTaskRepo := new(MockTaskRepo)
+	mockSLARepo := new(MockSLARepo)
 	logger := zerolog.Nop()
-	s := New(mockRepo, logger, []byte("secret"))
+	s := New(mockRepo, logger, testKeys, Options{})
 	ctx := context.Background()
 
 	t.Run("success", func(t *testing.T) {
@@ -27,12 +29,17 @@ func TestTaskService_CreateTask(t *testing.T) {
 			Status:     "pending",
 		}
 
+		mockUserRepo := new(MockUserRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockUserRepo.On("GetUserByID", ctx, 1).Return(&models.User{ID: 1}, nil)
+		mockRepo.On("SLA").Return(mockSLARepo)
+		mockSLARepo.On("GetSLAPolicy", ctx, models.PriorityMedium).Return(nil, errors.New("record not found"))
 		mockRepo.On("Task").Return(mockTaskRepo)
 		mockTaskRepo.On("CreateTask", ctx, mock.MatchedBy(func(tk *models.Task) bool {
 			return tk.Title == req.Title && tk.EmployeeID == req.EmployeeID
 		})).Return(nil)
 
-		res, err := s.Task().CreateTask(ctx, req, 2)
+		res, err := s.Task().CreateTask(ctx, req, 2, "manager", true)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, res)
@@ -45,7 +52,7 @@ func TestTaskService_UpdateTask(t *testing.T) {
 	mockRepo := new(MockRepo)
 	mockTaskRepo := new(MockTaskRepo)
 	logger := zerolog.Nop()
-	s := New(mockRepo, logger, []byte("secret"))
+	s := New(mockRepo, logger, testKeys, Options{})
 	ctx := context.Background()
 
 	t.Run("success - creator updates", func(t *testing.T) {
@@ -56,7 +63,7 @@ func TestTaskService_UpdateTask(t *testing.T) {
 		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
 		mockTaskRepo.On("UpdateTask", ctx, mock.Anything).Return(nil)
 
-		err := s.Task().UpdateTask(ctx, 1, req, 2)
+		err := s.Task().UpdateTask(ctx, 1, req, 2, "employee", false)
 		assert.NoError(t, err)
 	})
 
@@ -67,7 +74,7 @@ func TestTaskService_UpdateTask(t *testing.T) {
 		mockRepo.On("Task").Return(mockTaskRepo)
 		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
 
-		err := s.Task().UpdateTask(ctx, 1, req, 4) // Other user
+		err := s.Task().UpdateTask(ctx, 1, req, 4, "employee", false) // Other user
 		assert.Error(t, err)
 		assert.Equal(t, "forbidden", err.Error())
 	})
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+	"sort"
+)
+
+// TEAMS
+
+func (s *services) Team() TeamService { return s }
+
+// teamSubtree returns the given teams and all their descendants.
+func teamSubtree(teams []models.Team, roots []int) []int {
+	children := map[int][]int{}
+	for _, t := range teams {
+		if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t.ID)
+		}
+	}
+	seen := map[int]struct{}{}
+	queue := append([]int{}, roots...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		queue = append(queue, children[id]...)
+	}
+	out := make([]int, 0, len(seen))
+	for id := range seen {
+		out = append(out, id)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// teamScope returns the teams a manager is limited to: the subtree of every
+// team they manage, empty if they manage none. Nil means no limit and is
+// only returned for a cross-team search, which needs team.search.all.
+func (s *services) teamScope(ctx context.Context, userID int, role string, allTeams bool) ([]int, error) {
+	if allTeams {
+		if !s.Can(ctx, role, permission.TeamSearchAll) {
+			return nil, errors.New("forbidden")
+		}
+		return nil, nil
+	}
+	teams, err := s.repo.Team().GetTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roots := []int{}
+	for _, t := range teams {
+		if t.ManagerID != nil && *t.ManagerID == userID {
+			roots = append(roots, t.ID)
+		}
+	}
+	return teamSubtree(teams, roots), nil
+}
+
+// checkUserInScope fails unless the manager may act on u: u is the manager
+// themselves or in their team scope. Like the lists, users of other teams
+// are only reached when allTeams is asked for, which needs team.search.all.
+// Users outside the scope are reported as not found.
+func (s *services) checkUserInScope(ctx context.Context, managerID int, role string, allTeams bool, u *models.User) error {
+	if u.ID == managerID {
+		return nil
+	}
+	scope, err := s.teamScope(ctx, managerID, role, allTeams)
+	if err != nil {
+		return err
+	}
+	if scope != nil && !inTeams(u.TeamID, scope) {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// getUserInScope loads a user the manager may act on.
+func (s *services) getUserInScope(ctx context.Context, id int, managerID int, role string, allTeams bool) (*models.User, error) {
+	u, err := s.repo.User().GetUserByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := s.checkUserInScope(ctx, managerID, role, allTeams, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// loadUsersInScope is loadUsers limited to the team scope of the manager.
+func (s *services) loadUsersInScope(ctx context.Context, ids []int, managerID int, role string, allTeams bool) ([]models.User, error) {
+	users, err := s.loadUsers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		if err := s.checkUserInScope(ctx, managerID, role, allTeams, &users[i]); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+func inTeams(teamID *int, scope []int) bool {
+	if teamID == nil {
+		return false
+	}
+	for _, id := range scope {
+		if id == *teamID {
+			return true
+		}
+	}
+	return false
+}
+
+func teamToDTO(t *models.Team) *dto.TeamResponse {
+	return &dto.TeamResponse{ID: t.ID, Name: t.Name, ParentID: t.ParentID, ManagerID: t.ManagerID}
+}
+
+// applyTeamRequest validates the parent and manager and copies the request
+// into t. A team can't become its own ancestor.
+func (s *services) applyTeamRequest(ctx context.Context, t *models.Team, req *dto.TeamRequest) error {
+	if req.ParentID != nil && *req.ParentID != 0 {
+		if _, err := s.repo.Team().GetTeamByID(ctx, *req.ParentID); err != nil {
+			return errors.New("parent team not found")
+		}
+		if t.ID != 0 {
+			teams, err := s.repo.Team().GetTeams(ctx)
+			if err != nil {
+				return err
+			}
+			if inTeams(req.ParentID, teamSubtree(teams, []int{t.ID})) {
+				return errors.New("invalid parent team")
+			}
+		}
+		t.ParentID = req.ParentID
+	} else {
+		t.ParentID = nil
+	}
+	if req.ManagerID != nil && *req.ManagerID != 0 {
+		if _, err := s.repo.User().GetUserByID(ctx, *req.ManagerID); err != nil {
+			return errors.New("user not found")
+		}
+		t.ManagerID = req.ManagerID
+	} else {
+		t.ManagerID = nil
+	}
+	t.Name = req.Name
+	return nil
+}
+
+func (s *services) CreateTeam(ctx context.Context, req *dto.TeamRequest) (*dto.TeamResponse, error) {
+	t := &models.Team{}
+	if err := s.applyTeamRequest(ctx, t, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Team().CreateTeam(ctx, t); err != nil {
+		return nil, err
+	}
+	return teamToDTO(t), nil
+}
+
+func (s *services) GetTeams(ctx context.Context) ([]*dto.TeamResponse, error) {
+	teams, err := s.repo.Team().GetTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.TeamResponse, 0, len(teams))
+	for i := range teams {
+		out = append(out, teamToDTO(&teams[i]))
+	}
+	return out, nil
+}
+
+func (s *services) GetTeamByID(ctx context.Context, id int) (*dto.TeamResponse, error) {
+	t, err := s.repo.Team().GetTeamByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("team not found")
+	}
+	members, err := s.repo.Team().GetTeamMembers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := teamToDTO(t)
+	res.Members = usersToSummary(members)
+	return res, nil
+}
+
+func (s *services) UpdateTeam(ctx context.Context, id int, req *dto.TeamRequest) error {
+	t, err := s.repo.Team().GetTeamByID(ctx, id)
+	if err != nil {
+		return errors.New("team not found")
+	}
+	if err := s.applyTeamRequest(ctx, t, req); err != nil {
+		return err
+	}
+	return s.repo.Team().UpdateTeam(ctx, t)
+}
+
+// DeleteTeam removes a team without subteams; its members stay without a team.
+func (s *services) DeleteTeam(ctx context.Context, id int) error {
+	teams, err := s.repo.Team().GetTeams(ctx)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, t := range teams {
+		if t.ID == id {
+			found = true
+		}
+		if t.ParentID != nil && *t.ParentID == id {
+			return errors.New("team has subteams")
+		}
+	}
+	if !found {
+		return errors.New("team not found")
+	}
+	return s.repo.Team().DeleteTeam(ctx, id)
+}
+
+// SetUserTeam moves a user of the manager's scope into another team of it.
+func (s *services) SetUserTeam(ctx context.Context, userID int, req *dto.UserTeamRequest, managerID int, role string, allTeams bool) error {
+	u, err := s.getUserInScope(ctx, userID, managerID, role, allTeams)
+	if err != nil {
+		return err
+	}
+	teamID := req.TeamID
+	if teamID != nil && *teamID == 0 {
+		teamID = nil
+	}
+	if teamID != nil {
+		if _, err := s.repo.Team().GetTeamByID(ctx, *teamID); err != nil {
+			return errors.New("team not found")
+		}
+		scope, err := s.teamScope(ctx, managerID, role, allTeams)
+		if err != nil {
+			return err
+		}
+		if scope != nil && !inTeams(teamID, scope) {
+			return errors.New("team not found")
+		}
+	}
+	if err := s.repo.Team().SetUserTeam(ctx, userID, teamID); err != nil {
+		return err
+	}
+	before := userSnapshot(u)
+	u.TeamID = teamID
+	s.audit(ctx, auditEntry{Action: "user.team_changed", TargetType: "user", TargetID: userID, Before: before, After: userSnapshot(u)})
+	return nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/permission"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func intPtr(v int) *int { return &v }
+
+// orgTeams: 1 Engineering (manager 100) -> 2 Backend -> 4 Payments; 3 Sales (manager 200).
+func orgTeams() []models.Team {
+	return []models.Team{
+		{ID: 1, Name: "Engineering", ManagerID: intPtr(100)},
+		{ID: 2, Name: "Backend", ParentID: intPtr(1)},
+		{ID: 3, Name: "Sales", ManagerID: intPtr(200)},
+		{ID: 4, Name: "Payments", ParentID: intPtr(2)},
+	}
+}
+
+func TestTeamSubtree(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 4}, teamSubtree(orgTeams(), []int{1}))
+	assert.Equal(t, []int{2, 4}, teamSubtree(orgTeams(), []int{2}))
+	assert.Equal(t, []int{3}, teamSubtree(orgTeams(), []int{3}))
+}
+
+func TestUserService_GetUsers_TeamScope(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	users := []*models.User{
+		{ID: 10, Username: "alice", TeamID: intPtr(2)},
+		{ID: 11, Username: "bob", TeamID: intPtr(4)},
+		{ID: 12, Username: "carol", TeamID: intPtr(3)},
+		{ID: 13, Username: "dave"},
+	}
+
+	t.Run("manager sees the subtree", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockTeamRepo := new(MockTeamRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Team").Return(mockTeamRepo)
+		mockTeamRepo.On("GetTeams", ctx).Return(orgTeams(), nil)
+		mockUserRepo.On("GetUsers", ctx).Return(users, nil)
+
+		res, err := s.User().GetUsers(ctx, 100, "manager", false)
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, "alice", res[0].Username)
+		assert.Equal(t, "bob", res[1].Username)
+	})
+
+	t.Run("cross-team search", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockUserRepo.On("GetUsers", ctx).Return(users, nil)
+
+		res, err := s.User().GetUsers(ctx, 100, "manager", true)
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 4)
+	})
+
+	t.Run("cross-team search needs permission", func(t *testing.T) {
+		s := New(new(MockRepo), logger, testKeys, Options{})
+
+		_, err := s.User().GetUsers(ctx, 10, "employee", true)
+
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+}
+
+func TestUserService_TargetUsers_TeamScope(t *testing.T) {
+	ctx := context.Background()
+	// lead manages users and tasks but can't act across teams.
+	lead := &models.RoleDefinition{ID: 5, Name: "lead"}
+	for _, p := range []permission.Permission{permission.UserRead, permission.UserManage, permission.SkillAssign,
+		permission.TaskCreate, permission.TaskAssign, permission.TeamManage} {
+		lead.Permissions = append(lead.Permissions, models.RolePermission{RoleID: 5, Permission: string(p)})
+	}
+	alice := &models.User{ID: 10, Username: "alice", Role: models.RoleEmployee, TeamID: intPtr(2)}
+	carol := &models.User{ID: 12, Username: "carol", Role: models.RoleEmployee, TeamID: intPtr(3)}
+	setup := func() (*MockUserRepo, ServiceInterface) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockTeamRepo := new(MockTeamRepo)
+		mockRoleRepo := new(MockRoleRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Team").Return(mockTeamRepo)
+		mockRepo.On("Role").Return(mockRoleRepo)
+		mockTeamRepo.On("GetTeams", ctx).Return(orgTeams(), nil)
+		mockTeamRepo.On("GetTeamByID", ctx, mock.Anything).Return(&models.Team{}, nil)
+		mockRoleRepo.On("GetRoleByName", ctx, "lead").Return(lead, nil)
+		mockUserRepo.On("GetUserByID", ctx, 10).Return(alice, nil)
+		mockUserRepo.On("GetUserByID", ctx, 12).Return(carol, nil)
+		return mockUserRepo, New(mockRepo, zerolog.Nop(), testKeys, Options{})
+	}
+
+	t.Run("another team's member is out of reach", func(t *testing.T) {
+		users, s := setup()
+
+		_, err := s.User().GetUserByID(ctx, 10, 200, "lead", false)
+		assert.EqualError(t, err, "user not found")
+		assert.EqualError(t, s.User().UpdateUser(ctx, 10, &dto.UserRequest{Name: "Alice"}, 200, "lead", false), "user not found")
+		assert.EqualError(t, s.User().DeleteUser(ctx, 10, 200, "lead", false), "user not found")
+		assert.EqualError(t, s.User().UnlockUser(ctx, 10, 200, "lead", false), "user not found")
+		assert.EqualError(t, s.TwoFactor().ResetTwoFactor(ctx, 10, 200, "lead", false), "user not found")
+		assert.EqualError(t, s.Session().RevokeUserSessions(ctx, 10, 200, "lead", false), "user not found")
+		assert.EqualError(t, s.Skill().AssignSkillToUser(ctx, 10, 1, 200, "lead", false), "user not found")
+		assert.EqualError(t, s.Team().SetUserTeam(ctx, 10, &dto.UserTeamRequest{TeamID: intPtr(3)}, 200, "lead", false), "user not found")
+		_, err = s.Task().CreateTask(ctx, &dto.TaskRequest{EmployeeID: 10, Title: "Call", Deadline: "2026-01-01T00:00:00Z"}, 200, "lead", false)
+		assert.EqualError(t, err, "user not found")
+		users.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		users.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("own team member is managed", func(t *testing.T) {
+		_, s := setup()
+
+		res, err := s.User().GetUserByID(ctx, 12, 200, "lead", false)
+		assert.NoError(t, err)
+		assert.Equal(t, "carol", res.Username)
+		// Nor can members be moved out of the scope.
+		assert.EqualError(t, s.Team().SetUserTeam(ctx, 12, &dto.UserTeamRequest{TeamID: intPtr(2)}, 200, "lead", false), "team not found")
+	})
+
+	t.Run("a manager without teams gets an empty scope", func(t *testing.T) {
+		users, s := setup()
+		users.On("GetUsers", ctx).Return([]*models.User{alice, carol}, nil)
+
+		res, err := s.User().GetUsers(ctx, 300, "lead", false)
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+		_, err = s.User().GetUserByID(ctx, 12, 300, "lead", false)
+		assert.EqualError(t, err, "user not found")
+	})
+
+	t.Run("team.search.all acts across teams only when asked", func(t *testing.T) {
+		_, s := setup()
+
+		_, err := s.User().GetUserByID(ctx, 10, 200, "manager", false)
+		assert.EqualError(t, err, "user not found")
+		assert.EqualError(t, s.User().DeleteUser(ctx, 10, 200, "manager", false), "user not found")
+		res, err := s.User().GetUserByID(ctx, 10, 200, "manager", true)
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", res.Username)
+	})
+
+	t.Run("all_teams needs team.search.all", func(t *testing.T) {
+		_, s := setup()
+
+		_, err := s.User().GetUserByID(ctx, 10, 200, "lead", true)
+		assert.EqualError(t, err, "forbidden")
+	})
+}
+
+func TestTaskService_ListTasks_TeamScope(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("Team").Return(mockTeamRepo)
+	mockTeamRepo.On("GetTeams", ctx).Return(orgTeams(), nil)
+	mockTaskRepo.On("ListTasks", ctx, mock.MatchedBy(func(f dto.TaskFilter) bool {
+		return len(f.TeamIDs) == 1 && f.TeamIDs[0] == 3 && f.TeamViewerID == 200
+	})).Return([]models.Task{}, nil)
+
+	_, err := s.Task().ListTasks(ctx, dto.TaskFilter{}, 200, "manager")
+
+	assert.NoError(t, err)
+	mockTaskRepo.AssertExpectations(t)
+}
+
+func TestTaskService_GetRecommendedEmployees_TeamScope(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("User").Return(mockUserRepo)
+	mockRepo.On("Team").Return(mockTeamRepo)
+	mockTeamRepo.On("GetTeams", ctx).Return(orgTeams(), nil)
+	mockTaskRepo.On("GetTaskSkills", ctx, 1).Return([]models.Skill{}, nil)
+	mockUserRepo.On("GetEmployeesWithSkills", ctx).Return([]*models.User{
+		{ID: 10, TeamID: intPtr(4)},
+		{ID: 12, TeamID: intPtr(3)},
+	}, nil)
+
+	res, err := s.Task().GetRecommendedEmployees(ctx, 1, 200, "manager", false)
+
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, 12, res[0].ID)
+}
+
+func TestTeamService_UpdateTeam_Cycle(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	teams := orgTeams()
+	mockRepo.On("Team").Return(mockTeamRepo)
+	mockTeamRepo.On("GetTeamByID", ctx, 1).Return(&teams[0], nil)
+	mockTeamRepo.On("GetTeamByID", ctx, 4).Return(&teams[3], nil)
+	mockTeamRepo.On("GetTeams", ctx).Return(teams, nil)
+
+	err := s.Team().UpdateTeam(ctx, 1, &dto.TeamRequest{Name: "Engineering", ParentID: intPtr(4)})
+
+	assert.Error(t, err)
+	assert.Equal(t, "invalid parent team", err.Error())
+	mockTeamRepo.AssertNotCalled(t, "UpdateTeam", ctx, mock.Anything)
+}
+
+func TestTeamService_DeleteTeam_WithSubteams(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	mockRepo.On("Team").Return(mockTeamRepo)
+	mockTeamRepo.On("GetTeams", ctx).Return(orgTeams(), nil)
+
+	err := s.Team().DeleteTeam(ctx, 2)
+
+	assert.Error(t, err)
+	assert.Equal(t, "team has subteams", err.Error())
+}
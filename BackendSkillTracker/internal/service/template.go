@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"time"
+)
+
+// TASK TEMPLATES
+
+func (s *services) Template() TaskTemplateService { return s }
+
+func templateToDTO(t *models.TaskTemplate) *dto.TaskTemplateResponse {
+	skills := make([]dto.TemplateSkillResponse, 0, len(t.Skills))
+	for _, sk := range t.Skills {
+		skills = append(skills, dto.TemplateSkillResponse{SkillID: sk.SkillID, Name: sk.Skill.Name, Level: sk.Level})
+	}
+	checklist := make([]string, 0, len(t.Checklist))
+	for _, item := range t.Checklist {
+		checklist = append(checklist, item.Text)
+	}
+	return &dto.TaskTemplateResponse{
+		ID:            t.ID,
+		CreatorID:     t.CreatorID,
+		Title:         t.Title,
+		Description:   t.Description,
+		DurationHours: t.DurationHours,
+		Shared:        t.Shared,
+		Skills:        skills,
+		Checklist:     checklist,
+		CreatedAt:     t.CreatedAt,
+		UpdatedAt:     t.UpdatedAt,
+	}
+}
+
+func (s *services) applyTemplateRequest(ctx context.Context, t *models.TaskTemplate, req *dto.TaskTemplateRequest) error {
+	skills := make([]models.TaskTemplateSkill, 0, len(req.Skills))
+	seen := make(map[int]struct{}, len(req.Skills))
+	for _, rs := range req.Skills {
+		if _, dup := seen[rs.SkillID]; dup {
+			continue
+		}
+		seen[rs.SkillID] = struct{}{}
+		sk, err := s.repo.Skill().GetSkillByID(ctx, rs.SkillID)
+		if err != nil {
+			return errors.New("skill not found")
+		}
+		skills = append(skills, models.TaskTemplateSkill{TemplateID: t.ID, SkillID: sk.ID, Level: rs.Level, Skill: *sk})
+	}
+	checklist := make([]models.TaskTemplateChecklistItem, 0, len(req.Checklist))
+	for i, text := range req.Checklist {
+		checklist = append(checklist, models.TaskTemplateChecklistItem{TemplateID: t.ID, Position: i, Text: text})
+	}
+
+	t.Title = req.Title
+	t.Description = req.Description
+	t.DurationHours = req.DurationHours
+	if t.DurationHours == 0 {
+		t.DurationHours = defaultTaskDurationHours
+	}
+	t.Shared = req.Shared
+	t.Skills = skills
+	t.Checklist = checklist
+	return nil
+}
+
+func (s *services) CreateTemplate(ctx context.Context, req *dto.TaskTemplateRequest, creatorID int) (*dto.TaskTemplateResponse, error) {
+	t := &models.TaskTemplate{CreatorID: creatorID}
+	if err := s.applyTemplateRequest(ctx, t, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Template().CreateTemplate(ctx, t); err != nil {
+		return nil, err
+	}
+	return templateToDTO(t), nil
+}
+
+func (s *services) GetTemplates(ctx context.Context, userID int) ([]*dto.TaskTemplateResponse, error) {
+	ts, err := s.repo.Template().GetTemplatesVisibleTo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.TaskTemplateResponse, 0, len(ts))
+	for i := range ts {
+		out = append(out, templateToDTO(&ts[i]))
+	}
+	return out, nil
+}
+
+// getVisibleTemplate returns the template if it is shared or owned by userID.
+func (s *services) getVisibleTemplate(ctx context.Context, id int, userID int) (*models.TaskTemplate, error) {
+	t, err := s.repo.Template().GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("template not found")
+	}
+	if !t.Shared && t.CreatorID != userID {
+		return nil, errors.New("template not found")
+	}
+	return t, nil
+}
+
+func (s *services) GetTemplateByID(ctx context.Context, id int, userID int) (*dto.TaskTemplateResponse, error) {
+	t, err := s.getVisibleTemplate(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return templateToDTO(t), nil
+}
+
+func (s *services) UpdateTemplate(ctx context.Context, id int, req *dto.TaskTemplateRequest, userID int) error {
+	t, err := s.getVisibleTemplate(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if t.CreatorID != userID {
+		return errors.New("forbidden")
+	}
+	if err := s.applyTemplateRequest(ctx, t, req); err != nil {
+		return err
+	}
+	return s.repo.Template().UpdateTemplate(ctx, t)
+}
+
+func (s *services) DeleteTemplate(ctx context.Context, id int, userID int) error {
+	t, err := s.getVisibleTemplate(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if t.CreatorID != userID {
+		return errors.New("forbidden")
+	}
+	return s.repo.Template().DeleteTemplate(ctx, id)
+}
+
+func (s *services) CreateTaskFromTemplate(ctx context.Context, templateID int, req *dto.TaskFromTemplateRequest, creatorID int, role string, allTeams bool) (*dto.TaskResponse, error) {
+	tpl, err := s.getVisibleTemplate(ctx, templateID, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if req.EmployeeID == 0 {
+		return nil, errors.New("invalid input")
+	}
+	if _, err := s.getUserInScope(ctx, req.EmployeeID, creatorID, role, allTeams); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(time.Duration(tpl.DurationHours) * time.Hour)
+	if req.Deadline != "" {
+		deadline, err = time.Parse(time.RFC3339, req.Deadline)
+		if err != nil {
+			return nil, errors.New("invalid deadline format")
+		}
+	}
+	id := tpl.ID
+	t := &models.Task{
+		EmployeeID:  req.EmployeeID,
+		CreatorID:   creatorID,
+		Title:       tpl.Title,
+		Description: tpl.Description,
+		Deadline:    deadline,
+		Status:      models.StatusPending,
+		TemplateID:  &id,
+	}
+	if req.Title != "" {
+		t.Title = req.Title
+	}
+	if req.Description != "" {
+		t.Description = req.Description
+	}
+	s.applySLAPolicy(ctx, t)
+	if err := s.repo.Task().CreateTask(ctx, t); err != nil {
+		return nil, err
+	}
+
+	for _, sk := range tpl.Skills {
+		if err := s.repo.Task().AddSkillToTask(ctx, t.ID, sk.SkillID, sk.Level); err != nil {
+			return nil, err
+		}
+		skill := sk.Skill
+		skill.Level = sk.Level
+		t.RequiredSkills = append(t.RequiredSkills, skill)
+	}
+	for _, tplItem := range tpl.Checklist {
+		item := models.ChecklistItem{TaskID: t.ID, Position: tplItem.Position, Text: tplItem.Text}
+		if err := s.repo.Checklist().CreateChecklistItem(ctx, &item); err != nil {
+			return nil, err
+		}
+		t.Checklist = append(t.Checklist, item)
+	}
+	return taskToDTO(t), nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTemplateService_CreateTaskFromTemplate(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	tpl := &models.TaskTemplate{
+		ID:            4,
+		CreatorID:     1,
+		Title:         "Certificate renewal",
+		Description:   "Renew the TLS certificate",
+		DurationHours: 48,
+		Shared:        true,
+		Skills:        []models.TaskTemplateSkill{{TemplateID: 4, SkillID: 9, Level: 3, Skill: models.Skill{ID: 9, Name: "TLS"}}},
+	}
+
+	t.Run("success - shared template with overrides", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockSLARepo := new(MockSLARepo)
+		mockTemplateRepo := new(MockTemplateRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockUserRepo := new(MockUserRepo)
+		mockRepo.On("Template").Return(mockTemplateRepo)
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("SLA").Return(mockSLARepo)
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5}, nil)
+		mockSLARepo.On("GetSLAPolicy", ctx, models.PriorityMedium).Return(nil, errors.New("record not found"))
+		mockTemplateRepo.On("GetTemplateByID", ctx, 4).Return(tpl, nil)
+		mockTaskRepo.On("CreateTask", ctx, mock.MatchedBy(func(tk *models.Task) bool {
+			return tk.Title == "Renew api.example.com" &&
+				tk.Description == tpl.Description &&
+				tk.CreatorID == 2 &&
+				*tk.TemplateID == 4 &&
+				time.Until(tk.Deadline) > 47*time.Hour
+		})).Return(nil)
+		mockTaskRepo.On("AddSkillToTask", ctx, 0, 9, 3).Return(nil)
+
+		res, err := s.Template().CreateTaskFromTemplate(ctx, 4, &dto.TaskFromTemplateRequest{
+			EmployeeID: 5,
+			Title:      "Renew api.example.com",
+		}, 2, "manager", true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, res.EmployeeID)
+		assert.Equal(t, 3, res.RequiredSkills[0].Level)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("private template of another manager", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTemplateRepo := new(MockTemplateRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		private := *tpl
+		private.Shared = false
+		mockRepo.On("Template").Return(mockTemplateRepo)
+		mockTemplateRepo.On("GetTemplateByID", ctx, 4).Return(&private, nil)
+
+		_, err := s.Template().CreateTaskFromTemplate(ctx, 4, &dto.TaskFromTemplateRequest{EmployeeID: 5}, 2, "manager", true)
+
+		assert.Error(t, err)
+		assert.Equal(t, "template not found", err.Error())
+	})
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"skilltracker/internal/models"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// LockoutPolicy limits password guessing. Every failed login of a username
+// or from an IP delays the next attempt exponentially, and a username is
+// locked once it reaches MaxFailures. A zero policy disables throttling.
+type LockoutPolicy struct {
+	// MaxFailures locks a username for Duration.
+	MaxFailures int
+	// IPMaxFailures locks out an IP for Duration. An IP is only delayed
+	// after MaxFailures, so a shared office IP isn't slowed by a few typos.
+	IPMaxFailures int
+	// Duration is the lockout time and how long failures are remembered.
+	Duration time.Duration
+	// BaseDelay is the delay after the first counted failure; it doubles
+	// with every further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func (p LockoutPolicy) enabled() bool { return p.MaxFailures > 0 || p.IPMaxFailures > 0 }
+
+// backoff is the wait after failures, of which the first free ones cost
+// nothing.
+func (p LockoutPolicy) backoff(failures, free int) time.Duration {
+	n := failures - free
+	if n <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// normalizeUsername is the form usernames are stored, looked up and
+// throttled in, so "Alice " and "alice" are one account with one counter.
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func userThrottleKey(orgID int, username string) string {
+	return fmt.Sprintf("user:%d:%s", orgID, normalizeUsername(username))
+}
+
+func ipThrottleKey(ip string) string { return "ip:" + ip }
+
+// loginKeys returns the throttle keys of a login attempt. The username key
+// is empty when the organization is unknown, the IP key when there's no IP.
+func loginKeys(orgID int, username, ip string) (userKey, ipKey string) {
+	if orgID != 0 {
+		userKey = userThrottleKey(orgID, username)
+	}
+	if ip != "" {
+		ipKey = ipThrottleKey(ip)
+	}
+	return userKey, ipKey
+}
+
+// securityEvent starts a log entry for the audit trail of authentication.
+func (s *services) securityEvent(level zerolog.Level, event string) *zerolog.Event {
+	return s.logger.WithLevel(level).Str("security_event", event)
+}
+
+// checkLoginThrottle fails while the username or IP is locked or has to
+// wait before the next attempt.
+func (s *services) checkLoginThrottle(ctx context.Context, userKey, ipKey string, now time.Time) error {
+	p := s.opts.Lockout
+	var keys []string
+	for _, k := range []string{userKey, ipKey} {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	rows, err := s.repo.LoginThrottle().GetLoginThrottles(ctx, keys)
+	if err != nil {
+		return err
+	}
+	for _, t := range rows {
+		if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+			if t.Key == userKey {
+				return errors.New("account locked")
+			}
+			return errors.New("too many login attempts")
+		}
+		if now.Sub(t.LastFailureAt) >= p.Duration {
+			continue
+		}
+		free := 0
+		if t.Key == ipKey {
+			free = p.MaxFailures
+		}
+		if now.Before(t.LastFailureAt.Add(p.backoff(t.Failures, free))) {
+			return errors.New("too many login attempts")
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login and locks the username or IP
+// once it reaches its limit.
+func (s *services) recordLoginFailure(ctx context.Context, userKey, ipKey string, username, ip string, now time.Time) {
+	p := s.opts.Lockout
+	s.securityEvent(zerolog.WarnLevel, "login_failed").Str("username", username).Str("ip", ip).Msg("login failed")
+	for _, k := range []struct {
+		key   string
+		limit int
+	}{{userKey, p.MaxFailures}, {ipKey, p.IPMaxFailures}} {
+		if k.key == "" {
+			continue
+		}
+		t, err := s.repo.LoginThrottle().RecordLoginFailure(ctx, k.key, now, p.Duration)
+		if err != nil {
+			s.logger.Error().Err(err).Str("key", k.key).Msg("failed to record login failure")
+			continue
+		}
+		if k.limit > 0 && t.Failures >= k.limit && t.LockedUntil == nil {
+			until := now.Add(p.Duration)
+			if err := s.repo.LoginThrottle().LockLogin(ctx, k.key, until); err != nil {
+				s.logger.Error().Err(err).Str("key", k.key).Msg("failed to lock login")
+				continue
+			}
+			s.securityEvent(zerolog.WarnLevel, "login_locked").Str("key", k.key).Int("failures", t.Failures).
+				Time("locked_until", until).Msg("login locked after repeated failures")
+		}
+	}
+}
+
+// UnlockUser lifts a lockout of the user and clears their failed logins.
+func (s *services) UnlockUser(ctx context.Context, id int, managerID int, role string, allTeams bool) error {
+	u, err := s.getUserInScope(ctx, id, managerID, role, allTeams)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.LoginThrottle().ResetLoginThrottle(ctx, userThrottleKey(u.OrgID, u.Username)); err != nil {
+		return err
+	}
+	s.securityEvent(zerolog.InfoLevel, "login_unlocked").Int("user_id", u.ID).Int("org_id", u.OrgID).
+		Int("manager_id", managerID).Msg("user unlocked")
+	s.audit(ctx, auditEntry{Action: "user.unlocked", TargetType: "user", TargetID: u.ID})
+	return nil
+}
+
+// loginSucceeded clears the failures of the username. Failures of the IP
+// stay, so one valid account can't reset the count of a guessing client.
+func (s *services) loginSucceeded(ctx context.Context, u *models.User) {
+	if !s.opts.Lockout.enabled() {
+		return
+	}
+	if err := s.repo.LoginThrottle().ResetLoginThrottle(ctx, userThrottleKey(u.OrgID, u.Username)); err != nil {
+		s.logger.Error().Err(err).Int("user_id", u.ID).Msg("failed to reset login failures")
+	}
+}
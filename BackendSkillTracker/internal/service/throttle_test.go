@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/storage/memory"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// throttledService has user alice (password "password123") in the default
+// organization and keeps failed logins in memory.
+func throttledService(p LockoutPolicy) (ServiceInterface, *memory.LoginThrottle) {
+	mockRepo := new(MockRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	throttle := memory.NewLoginThrottle()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	mockRepo.On("Organization").Return(defaultOrgRepo())
+
+	mockRepo.On("Audit").Return(acceptingAuditRepo())
+	mockRepo.On("User").Return(mockUserRepo)
+	mockRepo.On("Session").Return(mockSessionRepo)
+	mockRepo.On("TwoFactor").Return(noTwoFactorRepo())
+	mockRepo.On("LoginThrottle").Return(throttle)
+	alice := &models.User{ID: 5, OrgID: 1, Username: "alice", PasswordHash: string(hash), Role: models.RoleEmployee}
+	mockUserRepo.On("GetUserByUsername", mock.Anything, "alice").Return(alice, nil)
+	mockUserRepo.On("GetUserByUsername", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+	mockUserRepo.On("GetUserByID", mock.Anything, 5).Return(alice, nil)
+	mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(nil)
+	return New(mockRepo, zerolog.Nop(), testKeys, Options{Lockout: p}), throttle
+}
+
+func login(s ServiceInterface, username, password, ip string) error {
+	_, err := s.User().Login(context.Background(), &dto.LoginRequest{Username: username, Password: password}, dto.ClientInfo{IP: ip})
+	return err
+}
+
+func TestLogin_Lockout(t *testing.T) {
+	t.Run("username is locked after max failures", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 3, Duration: 15 * time.Minute})
+
+		for i := 0; i < 3; i++ {
+			assert.EqualError(t, login(s, "alice", "wrong", "10.0.0.1"), "invalid credentials")
+		}
+		// Even the right password, from another IP, is refused now.
+		assert.EqualError(t, login(s, "alice", "password123", "10.0.0.2"), "account locked")
+	})
+
+	t.Run("case and spaces don't make another username", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 3, Duration: 15 * time.Minute})
+
+		for _, name := range []string{"alice", "Alice", " ALICE "} {
+			assert.EqualError(t, login(s, name, "wrong", "10.0.0.1"), "invalid credentials")
+		}
+		assert.EqualError(t, login(s, "alice", "password123", "10.0.0.2"), "account locked")
+	})
+
+	t.Run("usernames are looked up lowercased", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 3, Duration: 15 * time.Minute})
+
+		assert.NoError(t, login(s, " Alice", "password123", "10.0.0.1"))
+	})
+
+	t.Run("unknown usernames are tracked too", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 2, Duration: 15 * time.Minute})
+
+		assert.EqualError(t, login(s, "mallory", "x", ""), "invalid credentials")
+		assert.EqualError(t, login(s, "mallory", "x", ""), "invalid credentials")
+		assert.EqualError(t, login(s, "mallory", "x", ""), "account locked")
+	})
+
+	t.Run("failures back off exponentially", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 5, Duration: 15 * time.Minute, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+		assert.EqualError(t, login(s, "alice", "wrong", "10.0.0.1"), "invalid credentials")
+		assert.EqualError(t, login(s, "alice", "password123", "10.0.0.1"), "too many login attempts")
+	})
+
+	t.Run("success clears failures of the username only", func(t *testing.T) {
+		s, throttle := throttledService(LockoutPolicy{MaxFailures: 3, IPMaxFailures: 10, Duration: 15 * time.Minute})
+
+		assert.Error(t, login(s, "alice", "wrong", "10.0.0.1"))
+		assert.Error(t, login(s, "alice", "wrong", "10.0.0.1"))
+		assert.NoError(t, login(s, "alice", "password123", "10.0.0.1"))
+
+		rows, _ := throttle.GetLoginThrottles(context.Background(), []string{userThrottleKey(1, "alice"), ipThrottleKey("10.0.0.1")})
+		assert.Len(t, rows, 1)
+		assert.Equal(t, ipThrottleKey("10.0.0.1"), rows[0].Key)
+		assert.Equal(t, 2, rows[0].Failures)
+	})
+
+	t.Run("IP is locked across usernames", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 10, IPMaxFailures: 3, Duration: 15 * time.Minute})
+
+		for _, name := range []string{"bob", "carol", "dave"} {
+			assert.EqualError(t, login(s, name, "x", "10.0.0.9"), "invalid credentials")
+		}
+		assert.EqualError(t, login(s, "alice", "password123", "10.0.0.9"), "too many login attempts")
+		assert.NoError(t, login(s, "alice", "password123", "10.0.0.1"))
+	})
+
+	t.Run("manager unlock", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{MaxFailures: 1, Duration: 15 * time.Minute})
+
+		assert.Error(t, login(s, "alice", "wrong", ""))
+		assert.EqualError(t, login(s, "alice", "password123", ""), "account locked")
+		assert.NoError(t, s.User().UnlockUser(context.Background(), 5, 1, "manager", true))
+		assert.NoError(t, login(s, "alice", "password123", ""))
+	})
+
+	t.Run("zero policy doesn't throttle", func(t *testing.T) {
+		s, _ := throttledService(LockoutPolicy{})
+
+		for i := 0; i < 10; i++ {
+			assert.EqualError(t, login(s, "alice", "wrong", "10.0.0.1"), "invalid credentials")
+		}
+		assert.NoError(t, login(s, "alice", "password123", "10.0.0.1"))
+	})
+}
+
+func TestLockoutPolicy_Backoff(t *testing.T) {
+	p := LockoutPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	assert.Equal(t, time.Duration(0), p.backoff(0, 0))
+	assert.Equal(t, time.Second, p.backoff(1, 0))
+	assert.Equal(t, 2*time.Second, p.backoff(2, 0))
+	assert.Equal(t, 8*time.Second, p.backoff(4, 0))
+	assert.Equal(t, 10*time.Second, p.backoff(20, 0))
+	assert.Equal(t, time.Duration(0), p.backoff(5, 5), "free failures")
+	assert.Equal(t, time.Second, p.backoff(6, 5))
+}
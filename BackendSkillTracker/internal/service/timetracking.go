@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"sort"
+	"time"
+)
+
+// TIME TRACKING
+
+const dateLayout = "2006-01-02"
+
+func (s *services) Time() TimeService { return s }
+
+func timeEntryToDTO(e *models.TimeEntry) *dto.TimeEntryResponse {
+	return &dto.TimeEntryResponse{
+		ID:              e.ID,
+		TaskID:          e.TaskID,
+		UserID:          e.UserID,
+		StartedAt:       e.StartedAt,
+		EndedAt:         e.EndedAt,
+		DurationMinutes: e.DurationMinutes,
+		Running:         e.EndedAt == nil,
+		Note:            e.Note,
+	}
+}
+
+// weekStart returns midnight UTC of the Monday of the week containing t.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// parseWeek accepts any date of the week as YYYY-MM-DD, defaulting to the current week.
+func parseWeek(week string) (time.Time, error) {
+	if week == "" {
+		return weekStart(time.Now()), nil
+	}
+	d, err := time.Parse(dateLayout, week)
+	if err != nil {
+		return time.Time{}, errors.New("invalid week format")
+	}
+	return weekStart(d), nil
+}
+
+// ensureWeekOpen rejects changes to entries of a submitted or approved week.
+func (s *services) ensureWeekOpen(ctx context.Context, userID int, at time.Time) error {
+	ts, err := s.repo.Time().GetTimesheet(ctx, userID, weekStart(at))
+	if err != nil {
+		return nil
+	}
+	if ts.Status == models.TimesheetSubmitted || ts.Status == models.TimesheetApproved {
+		return errors.New("timesheet locked")
+	}
+	return nil
+}
+
+func (s *services) StartTimer(ctx context.Context, taskID int, userID int) (*dto.TimeEntryResponse, error) {
+	if _, err := s.getTaskAsParticipant(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.Time().GetRunningTimeEntry(ctx, userID); err == nil {
+		return nil, errors.New("timer already running")
+	}
+	now := time.Now()
+	if err := s.ensureWeekOpen(ctx, userID, now); err != nil {
+		return nil, err
+	}
+	e := &models.TimeEntry{TaskID: taskID, UserID: userID, StartedAt: now}
+	if err := s.repo.Time().CreateTimeEntry(ctx, e); err != nil {
+		// The unique index on running entries rejects a timer started
+		// concurrently, e.g. from another device.
+		if _, running := s.repo.Time().GetRunningTimeEntry(ctx, userID); running == nil {
+			return nil, errors.New("timer already running")
+		}
+		return nil, err
+	}
+	return timeEntryToDTO(e), nil
+}
+
+func (s *services) StopTimer(ctx context.Context, userID int) (*dto.TimeEntryResponse, error) {
+	e, err := s.repo.Time().GetRunningTimeEntry(ctx, userID)
+	if err != nil {
+		return nil, errors.New("no running timer")
+	}
+	if err := s.ensureWeekOpen(ctx, userID, e.StartedAt); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	e.EndedAt = &now
+	// Round up so that a started timer always accounts for at least a minute.
+	e.DurationMinutes = int((now.Sub(e.StartedAt) + time.Minute - 1) / time.Minute)
+	if e.DurationMinutes < 1 {
+		e.DurationMinutes = 1
+	}
+	if err := s.repo.Time().UpdateTimeEntry(ctx, e); err != nil {
+		return nil, err
+	}
+	return timeEntryToDTO(e), nil
+}
+
+func (s *services) LogTime(ctx context.Context, taskID int, userID int, req *dto.TimeEntryRequest) (*dto.TimeEntryResponse, error) {
+	if _, err := s.getTaskAsParticipant(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	startedAt, err := time.Parse(time.RFC3339, req.StartedAt)
+	if err != nil {
+		return nil, errors.New("invalid started_at format")
+	}
+	if err := s.ensureWeekOpen(ctx, userID, startedAt); err != nil {
+		return nil, err
+	}
+	endedAt := startedAt.Add(time.Duration(req.DurationMinutes) * time.Minute)
+	e := &models.TimeEntry{
+		TaskID:          taskID,
+		UserID:          userID,
+		StartedAt:       startedAt,
+		EndedAt:         &endedAt,
+		DurationMinutes: req.DurationMinutes,
+		Note:            req.Note,
+	}
+	if err := s.repo.Time().CreateTimeEntry(ctx, e); err != nil {
+		return nil, err
+	}
+	return timeEntryToDTO(e), nil
+}
+
+func (s *services) GetTaskTimeEntries(ctx context.Context, taskID int, userID int, role string, allTeams bool) ([]*dto.TimeEntryResponse, error) {
+	if _, err := s.getReadableTask(ctx, taskID, userID, role, allTeams); err != nil {
+		return nil, err
+	}
+	es, err := s.repo.Time().GetTimeEntriesByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*dto.TimeEntryResponse, 0, len(es))
+	for i := range es {
+		out = append(out, timeEntryToDTO(&es[i]))
+	}
+	return out, nil
+}
+
+func (s *services) DeleteTimeEntry(ctx context.Context, id int, userID int) error {
+	e, err := s.repo.Time().GetTimeEntryByID(ctx, id)
+	if err != nil {
+		return errors.New("time entry not found")
+	}
+	if e.UserID != userID {
+		return errors.New("forbidden")
+	}
+	if err := s.ensureWeekOpen(ctx, userID, e.StartedAt); err != nil {
+		return err
+	}
+	return s.repo.Time().DeleteTimeEntry(ctx, id)
+}
+
+// GetTimesheet returns the week of userID. Managers see the timesheets of
+// their team scope.
+func (s *services) GetTimesheet(ctx context.Context, userID int, week string, viewerID int, role string, allTeams bool) (*dto.TimesheetResponse, error) {
+	if userID != viewerID {
+		if _, err := s.getUserInScope(ctx, userID, viewerID, role, allTeams); err != nil {
+			return nil, err
+		}
+	}
+	ws, err := parseWeek(week)
+	if err != nil {
+		return nil, err
+	}
+	es, err := s.repo.Time().GetTimeEntriesByUser(ctx, userID, ws, ws.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.TimesheetResponse{
+		UserID:    userID,
+		WeekStart: ws.Format(dateLayout),
+		Status:    string(models.TimesheetOpen),
+		Days:      make([]dto.TimesheetDay, 7),
+		Entries:   make([]dto.TimeEntryResponse, 0, len(es)),
+	}
+	for i := range res.Days {
+		res.Days[i].Date = ws.AddDate(0, 0, i).Format(dateLayout)
+	}
+	for i := range es {
+		day := int(es[i].StartedAt.UTC().Sub(ws) / (24 * time.Hour))
+		res.Days[day].Minutes += es[i].DurationMinutes
+		res.TotalMinutes += es[i].DurationMinutes
+		res.Entries = append(res.Entries, *timeEntryToDTO(&es[i]))
+	}
+
+	if ts, err := s.repo.Time().GetTimesheet(ctx, userID, ws); err == nil {
+		res.ID = ts.ID
+		res.Status = string(ts.Status)
+		res.SubmittedAt = ts.SubmittedAt
+		res.ReviewedBy = ts.ReviewedBy
+		res.ReviewedAt = ts.ReviewedAt
+		res.Comment = ts.Comment
+	}
+	return res, nil
+}
+
+func (s *services) SubmitTimesheet(ctx context.Context, userID int, week string) error {
+	ws, err := parseWeek(week)
+	if err != nil {
+		return err
+	}
+	ts, err := s.repo.Time().GetTimesheet(ctx, userID, ws)
+	if err != nil {
+		ts = &models.Timesheet{UserID: userID, WeekStart: ws}
+	}
+	if ts.Status == models.TimesheetSubmitted || ts.Status == models.TimesheetApproved {
+		return errors.New("timesheet already submitted")
+	}
+	now := time.Now()
+	ts.Status = models.TimesheetSubmitted
+	ts.SubmittedAt = &now
+	ts.ReviewedBy = nil
+	ts.ReviewedAt = nil
+	return s.repo.Time().SaveTimesheet(ctx, ts)
+}
+
+func (s *services) ReviewTimesheet(ctx context.Context, id int, reviewerID int, role string, allTeams bool, approve bool, comment string) error {
+	ts, err := s.repo.Time().GetTimesheetByID(ctx, id)
+	if err != nil {
+		return errors.New("timesheet not found")
+	}
+	if ts.UserID == reviewerID {
+		return errors.New("forbidden")
+	}
+	if _, err := s.getUserInScope(ctx, ts.UserID, reviewerID, role, allTeams); err != nil {
+		return errors.New("timesheet not found")
+	}
+	if ts.Status != models.TimesheetSubmitted {
+		return errors.New("timesheet not submitted")
+	}
+	now := time.Now()
+	ts.Status = models.TimesheetRejected
+	if approve {
+		ts.Status = models.TimesheetApproved
+	}
+	ts.ReviewedBy = &reviewerID
+	ts.ReviewedAt = &now
+	ts.Comment = comment
+	return s.repo.Time().SaveTimesheet(ctx, ts)
+}
+
+// GetTimeReport compares estimates with logged time for tasks that have
+// time logged in the period (dates are inclusive, both optional).
+func (s *services) GetTimeReport(ctx context.Context, from, to string) (*dto.TimeReportResponse, error) {
+	var fromDate, toDate *time.Time
+	if from != "" {
+		d, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return nil, errors.New("invalid from date")
+		}
+		fromDate = &d
+	}
+	if to != "" {
+		d, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return nil, errors.New("invalid to date")
+		}
+		d = d.AddDate(0, 0, 1)
+		toDate = &d
+	}
+
+	logged, err := s.repo.Time().GetLoggedMinutesByTask(ctx, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(logged))
+	for id := range logged {
+		ids = append(ids, id)
+	}
+	tasks, err := s.repo.Task().GetTasksByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.TimeReportResponse{
+		Tasks:  make([]dto.TaskTimeReport, 0, len(tasks)),
+		Skills: []dto.SkillTimeReport{},
+	}
+	bySkill := map[int]*dto.SkillTimeReport{}
+	for _, t := range tasks {
+		actual := logged[t.ID]
+		res.Tasks = append(res.Tasks, dto.TaskTimeReport{
+			TaskID:          t.ID,
+			Title:           t.Title,
+			EstimateMinutes: t.EstimateMinutes,
+			ActualMinutes:   actual,
+			VarianceMinutes: actual - t.EstimateMinutes,
+		})
+		for _, sk := range t.RequiredSkills {
+			r, ok := bySkill[sk.ID]
+			if !ok {
+				r = &dto.SkillTimeReport{SkillID: sk.ID, Name: sk.Name}
+				bySkill[sk.ID] = r
+			}
+			r.Tasks++
+			r.EstimateMinutes += t.EstimateMinutes
+			r.ActualMinutes += actual
+			r.VarianceMinutes = r.ActualMinutes - r.EstimateMinutes
+		}
+	}
+	for _, r := range bySkill {
+		res.Skills = append(res.Skills, *r)
+	}
+	sort.Slice(res.Skills, func(i, j int) bool { return res.Skills[i].Name < res.Skills[j].Name })
+	return res, nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), weekStart(sunday))
+
+	monday := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, weekStart(monday))
+}
+
+func TestTimeService_StopTimer(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	t.Run("success - duration rounded up to minutes", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTimeRepo := new(MockTimeRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		running := &models.TimeEntry{ID: 7, TaskID: 1, UserID: 3, StartedAt: time.Now().Add(-90 * time.Second)}
+		mockRepo.On("Time").Return(mockTimeRepo)
+		mockTimeRepo.On("GetRunningTimeEntry", ctx, 3).Return(running, nil)
+		mockTimeRepo.On("GetTimesheet", ctx, 3, weekStart(running.StartedAt)).Return(nil, errors.New("record not found"))
+		mockTimeRepo.On("UpdateTimeEntry", ctx, mock.MatchedBy(func(e *models.TimeEntry) bool {
+			return e.EndedAt != nil && e.DurationMinutes == 2
+		})).Return(nil)
+
+		res, err := s.Time().StopTimer(ctx, 3)
+
+		assert.NoError(t, err)
+		assert.False(t, res.Running)
+		mockTimeRepo.AssertExpectations(t)
+	})
+
+	t.Run("no running timer", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTimeRepo := new(MockTimeRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Time").Return(mockTimeRepo)
+		mockTimeRepo.On("GetRunningTimeEntry", ctx, 3).Return(nil, errors.New("record not found"))
+
+		_, err := s.Time().StopTimer(ctx, 3)
+
+		assert.Error(t, err)
+		assert.Equal(t, "no running timer", err.Error())
+	})
+
+	t.Run("week submitted while the timer ran", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTimeRepo := new(MockTimeRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		running := &models.TimeEntry{ID: 7, TaskID: 1, UserID: 3, StartedAt: time.Date(2024, 3, 8, 16, 0, 0, 0, time.UTC)}
+		mockRepo.On("Time").Return(mockTimeRepo)
+		mockTimeRepo.On("GetRunningTimeEntry", ctx, 3).Return(running, nil)
+		mockTimeRepo.On("GetTimesheet", ctx, 3, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)).
+			Return(&models.Timesheet{ID: 5, UserID: 3, Status: models.TimesheetSubmitted}, nil)
+
+		_, err := s.Time().StopTimer(ctx, 3)
+
+		assert.EqualError(t, err, "timesheet locked")
+		mockTimeRepo.AssertNotCalled(t, "UpdateTimeEntry", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTimeService_StartTimer_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockTimeRepo := new(MockTimeRepo)
+	s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+	// The first lookup finds no timer; the insert then loses to a timer
+	// started meanwhile, which the second lookup finds.
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("Time").Return(mockTimeRepo)
+	mockTaskRepo.On("GetTaskByID", ctx, 1).Return(&models.Task{ID: 1, CreatorID: 2, EmployeeID: 3}, nil)
+	mockTimeRepo.On("GetRunningTimeEntry", ctx, 3).Return(nil, errors.New("record not found")).Once()
+	mockTimeRepo.On("GetTimesheet", ctx, 3, mock.Anything).Return(nil, errors.New("record not found"))
+	mockTimeRepo.On("CreateTimeEntry", ctx, mock.Anything).Return(errors.New("duplicate key value violates unique constraint"))
+	mockTimeRepo.On("GetRunningTimeEntry", ctx, 3).Return(&models.TimeEntry{ID: 8, UserID: 3}, nil).Once()
+
+	_, err := s.Time().StartTimer(ctx, 1, 3)
+
+	assert.EqualError(t, err, "timer already running")
+}
+
+func TestTimeService_LogTime(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	task := &models.Task{ID: 1, CreatorID: 2, EmployeeID: 3}
+
+	t.Run("week already submitted", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		mockTimeRepo := new(MockTimeRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockRepo.On("Time").Return(mockTimeRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+		mockTimeRepo.On("GetTimesheet", ctx, 3, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)).
+			Return(&models.Timesheet{ID: 5, UserID: 3, Status: models.TimesheetSubmitted}, nil)
+
+		_, err := s.Time().LogTime(ctx, 1, 3, &dto.TimeEntryRequest{StartedAt: "2024-03-06T09:00:00Z", DurationMinutes: 30})
+
+		assert.Error(t, err)
+		assert.Equal(t, "timesheet locked", err.Error())
+		mockTimeRepo.AssertNotCalled(t, "CreateTimeEntry", mock.Anything, mock.Anything)
+	})
+
+	t.Run("not a participant", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockTaskRepo := new(MockTaskRepo)
+		s := New(mockRepo, logger, testKeys, Options{})
+
+		mockRepo.On("Task").Return(mockTaskRepo)
+		mockTaskRepo.On("GetTaskByID", ctx, 1).Return(task, nil)
+
+		_, err := s.Time().LogTime(ctx, 1, 9, &dto.TimeEntryRequest{StartedAt: "2024-03-06T09:00:00Z", DurationMinutes: 30})
+
+		assert.Error(t, err)
+		assert.Equal(t, "forbidden", err.Error())
+	})
+}
+
+func TestTimeService_GetTimeReport(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+
+	mockRepo := new(MockRepo)
+	mockTaskRepo := new(MockTaskRepo)
+	mockTimeRepo := new(MockTimeRepo)
+	s := New(mockRepo, logger, testKeys, Options{})
+
+	goSkill := models.Skill{ID: 4, Name: "Go"}
+	tasks := []models.Task{
+		{ID: 1, Title: "API", EstimateMinutes: 120, RequiredSkills: []models.Skill{goSkill}},
+		{ID: 2, Title: "Worker", EstimateMinutes: 60, RequiredSkills: []models.Skill{goSkill}},
+	}
+	mockRepo.On("Task").Return(mockTaskRepo)
+	mockRepo.On("Time").Return(mockTimeRepo)
+	mockTimeRepo.On("GetLoggedMinutesByTask", ctx, mock.Anything, mock.Anything).Return(map[int]int{1: 150, 2: 45}, nil)
+	mockTaskRepo.On("GetTasksByIDs", ctx, mock.Anything).Return(tasks, nil)
+
+	res, err := s.Time().GetTimeReport(ctx, "2024-03-01", "2024-03-31")
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Tasks, 2)
+	assert.Equal(t, 30, res.Tasks[0].VarianceMinutes)
+	assert.Equal(t, -15, res.Tasks[1].VarianceMinutes)
+	assert.Equal(t, []dto.SkillTimeReport{{SkillID: 4, Name: "Go", Tasks: 2, EstimateMinutes: 180, ActualMinutes: 195, VarianceMinutes: 15}}, res.Skills)
+}
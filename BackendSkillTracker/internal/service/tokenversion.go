@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"skilltracker/internal/tenant"
+	"sync"
+	"time"
+)
+
+// tokenVersionTTL bounds how long a cached token version is trusted. Bumps
+// made by this instance take effect at once; bumps made by another instance
+// are seen within this time.
+const tokenVersionTTL = 30 * time.Second
+
+type versionKey struct{ orgID, userID int }
+
+type versionEntry struct {
+	version   int
+	fetchedAt time.Time
+}
+
+// versionCache keeps the current token version of recently seen users so
+// authenticating a request doesn't need a query each time.
+type versionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[versionKey]versionEntry
+}
+
+func newVersionCache(ttl time.Duration) *versionCache {
+	return &versionCache{ttl: ttl, entries: map[versionKey]versionEntry{}}
+}
+
+func (c *versionCache) get(k versionKey, now time.Time) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[k]
+	if !ok || now.Sub(e.fetchedAt) >= c.ttl {
+		return 0, false
+	}
+	return e.version, true
+}
+
+func (c *versionCache) put(k versionKey, version int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[k] = versionEntry{version: version, fetchedAt: now}
+}
+
+func (c *versionCache) forget(k versionKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, k)
+}
+
+// TokenVersionValid reports whether an access token of the user carrying
+// version is still valid. Deleted users and lookup failures reject it.
+func (s *services) TokenVersionValid(ctx context.Context, userID int, version int) bool {
+	orgID, _ := tenant.OrgID(ctx)
+	k := versionKey{orgID, userID}
+	now := time.Now()
+	current, ok := s.versions.get(k, now)
+	if !ok {
+		v, err := s.repo.User().GetTokenVersion(ctx, userID)
+		if err != nil {
+			return false
+		}
+		current = v
+		s.versions.put(k, current, now)
+	}
+	return version == current
+}
+
+// revokeAccessTokens bumps the token version of the user, so access tokens
+// issued so far stop working before they expire.
+func (s *services) revokeAccessTokens(ctx context.Context, userID int) error {
+	orgID, _ := tenant.OrgID(ctx)
+	defer s.versions.forget(versionKey{orgID, userID})
+	return s.repo.User().BumpTokenVersion(ctx, userID)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTokenVersionValid(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 3)
+
+	t.Run("version is cached", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockUserRepo.On("GetTokenVersion", inOrg(3), 5).Return(2, nil).Once()
+
+		assert.True(t, s.Session().TokenVersionValid(ctx, 5, 2))
+		assert.False(t, s.Session().TokenVersionValid(ctx, 5, 1))
+		mockUserRepo.AssertNumberOfCalls(t, "GetTokenVersion", 1)
+	})
+
+	t.Run("cache is per organization", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockUserRepo.On("GetTokenVersion", inOrg(3), 5).Return(2, nil)
+		mockUserRepo.On("GetTokenVersion", inOrg(4), 5).Return(0, nil)
+
+		assert.True(t, s.Session().TokenVersionValid(ctx, 5, 2))
+		assert.True(t, s.Session().TokenVersionValid(tenant.WithOrg(context.Background(), 4), 5, 0))
+	})
+
+	t.Run("expired entries are reloaded", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := &services{repo: mockRepo, logger: zerolog.Nop(), versions: newVersionCache(time.Nanosecond)}
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockUserRepo.On("GetTokenVersion", inOrg(3), 5).Return(2, nil).Once()
+		mockUserRepo.On("GetTokenVersion", inOrg(3), 5).Return(3, nil).Once()
+
+		assert.True(t, s.TokenVersionValid(ctx, 5, 2))
+		time.Sleep(time.Millisecond)
+		assert.False(t, s.TokenVersionValid(ctx, 5, 2))
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockUserRepo.On("GetTokenVersion", inOrg(3), 5).Return(0, errors.New("record not found"))
+
+		assert.False(t, s.Session().TokenVersionValid(ctx, 5, 0))
+	})
+}
+
+func TestRevokeAccessTokens(t *testing.T) {
+	ctx := tenant.WithOrg(context.Background(), 3)
+
+	t.Run("logout everywhere drops the cached version", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockSessionRepo := new(MockSessionRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockUserRepo.On("GetTokenVersion", inOrg(3), 5).Return(0, nil).Once()
+		mockUserRepo.On("GetTokenVersion", inOrg(3), 5).Return(1, nil).Once()
+		mockSessionRepo.On("RevokeUserSessions", ctx, 5, mock.Anything).Return(nil)
+		mockUserRepo.On("BumpTokenVersion", ctx, 5).Return(nil)
+
+		assert.True(t, s.Session().TokenVersionValid(ctx, 5, 0))
+		assert.NoError(t, s.User().LogoutAll(ctx, 5))
+		assert.False(t, s.Session().TokenVersionValid(ctx, 5, 0))
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("role change", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, Role: models.RoleManager}, nil)
+		mockUserRepo.On("UpdateUser", ctx, mock.Anything).Return(nil)
+		mockUserRepo.On("BumpTokenVersion", ctx, 5).Return(nil)
+
+		assert.NoError(t, s.User().UpdateUser(ctx, 5, &dto.UserRequest{Role: string(models.RoleEmployee)}, 1, "manager", true))
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("password change", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockSessionRepo := new(MockSessionRepo)
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, Role: models.RoleEmployee}, nil)
+		mockUserRepo.On("UpdateUser", ctx, mock.Anything).Return(nil)
+		mockUserRepo.On("BumpTokenVersion", ctx, 5).Return(nil)
+		mockSessionRepo.On("RevokeUserSessions", ctx, 5, mock.Anything).Return(nil)
+
+		assert.NoError(t, s.User().UpdateUser(ctx, 5, &dto.UserRequest{Password: "n3w-password"}, 1, "manager", true))
+		mockUserRepo.AssertExpectations(t)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("other changes keep tokens", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, Role: models.RoleEmployee}, nil)
+		mockUserRepo.On("UpdateUser", ctx, mock.Anything).Return(nil)
+
+		req := &dto.UserRequest{Name: "Alice", Role: string(models.RoleEmployee)}
+		assert.NoError(t, s.User().UpdateUser(ctx, 5, req, 1, "manager", true))
+		mockUserRepo.AssertNotCalled(t, "BumpTokenVersion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5}, nil)
+		mockUserRepo.On("BumpTokenVersion", ctx, 5).Return(nil)
+		mockUserRepo.On("DeleteUser", ctx, 5).Return(nil)
+
+		assert.NoError(t, s.User().DeleteUser(ctx, 5, 1, "manager", true))
+		mockUserRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+	"skilltracker/internal/utils/totp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// challengeTTL is how long the second login step may take.
+	challengeTTL = 5 * time.Minute
+	// totpSkew is how many 30s steps a code may be off, for clock drift.
+	totpSkew          = 1
+	recoveryCodeCount = 10
+
+	purposeVerify = "2fa_verify"
+	purposeEnroll = "2fa_enroll"
+)
+
+// TwoFactorPolicy configures TOTP. Users with one of RequiredRoles can't
+// log in without 2FA: their first login sets it up.
+type TwoFactorPolicy struct {
+	// Issuer names the account in authenticator apps.
+	Issuer        string
+	RequiredRoles []string
+}
+
+func (p TwoFactorPolicy) required(role string) bool {
+	for _, r := range p.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p TwoFactorPolicy) issuer() string {
+	if p.Issuer == "" {
+		return "SkillTracker"
+	}
+	return p.Issuer
+}
+
+// newRecoveryCodes returns codes to show the user and their stored form.
+func newRecoveryCodes(userID int) ([]string, []models.RecoveryCode, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: hashToken(raw)})
+	}
+	return codes, rows, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+// twoFactorEnabled returns the confirmed credential of the user, if any.
+func (s *services) twoFactorEnabled(ctx context.Context, userID int) (*models.TOTPCredential, bool) {
+	c, err := s.repo.TwoFactor().GetTOTPCredential(ctx, userID)
+	if err != nil || c.ConfirmedAt == nil {
+		return nil, false
+	}
+	return c, true
+}
+
+// secondFactor returns the challenge a login has to pass after the
+// password, or nil when the password is enough.
+func (s *services) secondFactor(ctx context.Context, u *models.User) (*dto.LoginResponse, error) {
+	purpose, step := purposeVerify, "verify"
+	if _, ok := s.twoFactorEnabled(ctx, u.ID); !ok {
+		if !s.opts.TwoFactor.required(string(u.Role)) {
+			return nil, nil
+		}
+		purpose, step = purposeEnroll, "enroll"
+	}
+	token, err := s.keys.GenerateChallengeToken(u.ID, u.OrgID, purpose, challengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResponse{TwoFactor: step, ChallengeToken: token}, nil
+}
+
+// challengeUser resolves a challenge token of one of the purposes.
+func (s *services) challengeUser(ctx context.Context, token string, purposes ...string) (context.Context, *models.User, string, error) {
+	for _, purpose := range purposes {
+		claims, err := s.keys.ValidateChallengeToken(token, purpose)
+		if err != nil {
+			continue
+		}
+		ctx = tenant.WithOrg(ctx, claims.OrgID)
+		u, err := s.repo.User().GetUserByID(ctx, claims.UserID)
+		if err != nil {
+			break
+		}
+		return ctx, u, purpose, nil
+	}
+	return ctx, nil, "", errors.New("invalid challenge token")
+}
+
+// verifySecondFactor accepts a TOTP code, each at most once, or an unused
+// recovery code.
+func (s *services) verifySecondFactor(ctx context.Context, userID int, code string, now time.Time) (bool, error) {
+	c, ok := s.twoFactorEnabled(ctx, userID)
+	if !ok {
+		return false, nil
+	}
+	if step, ok := totp.Verify(c.Secret, code, now, totpSkew); ok {
+		return s.repo.TwoFactor().UseTOTPStep(ctx, userID, step)
+	}
+	used, err := s.repo.TwoFactor().UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)), now)
+	if used {
+		s.securityEvent(zerolog.WarnLevel, "recovery_code_used").Int("user_id", userID).Msg("recovery code used")
+	}
+	return used, err
+}
+
+func (s *services) enrollTOTP(ctx context.Context, u *models.User) (*dto.TwoFactorEnrollResponse, error) {
+	c, err := s.repo.TwoFactor().GetTOTPCredential(ctx, u.ID)
+	if err == nil && c.ConfirmedAt != nil {
+		return nil, errors.New("2fa already enabled")
+	}
+	if err != nil {
+		c = &models.TOTPCredential{UserID: u.ID}
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	c.Secret = secret
+	c.LastStep = 0
+	if err := s.repo.TwoFactor().SaveTOTPCredential(ctx, c); err != nil {
+		return nil, err
+	}
+	return &dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(s.opts.TwoFactor.issuer(), u.Username, secret),
+	}, nil
+}
+
+// confirmTOTP enables a pending credential with its first code and returns
+// fresh recovery codes.
+func (s *services) confirmTOTP(ctx context.Context, userID int, code string, now time.Time) ([]string, error) {
+	c, err := s.repo.TwoFactor().GetTOTPCredential(ctx, userID)
+	if err != nil {
+		return nil, errors.New("2fa enrollment not started")
+	}
+	if c.ConfirmedAt != nil {
+		return nil, errors.New("2fa already enabled")
+	}
+	step, ok := totp.Verify(c.Secret, code, now, totpSkew)
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+	codes, rows, err := newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	c.ConfirmedAt = &now
+	c.LastStep = step
+	if err := s.repo.TwoFactor().EnableTwoFactor(ctx, c, rows); err != nil {
+		return nil, err
+	}
+	s.securityEvent(zerolog.InfoLevel, "2fa_enabled").Int("user_id", userID).Msg("2fa enabled")
+	s.audit(ctx, auditEntry{Action: "2fa.enabled", TargetType: "user", TargetID: userID})
+	return codes, nil
+}
+
+// EnrollLoginTwoFactor starts 2FA setup for a user whose role requires it,
+// during login.
+func (s *services) EnrollLoginTwoFactor(ctx context.Context, req *dto.TwoFactorChallengeRequest) (*dto.TwoFactorEnrollResponse, error) {
+	ctx, u, _, err := s.challengeUser(ctx, req.ChallengeToken, purposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.enrollTOTP(ctx, u)
+}
+
+// VerifyLoginTwoFactor completes a login with a code. Wrong codes count as
+// failed logins, see LockoutPolicy.
+func (s *services) VerifyLoginTwoFactor(ctx context.Context, req *dto.TwoFactorLoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	ctx, u, purpose, err := s.challengeUser(ctx, req.ChallengeToken, purposeVerify, purposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	throttled := s.opts.Lockout.enabled()
+	userKey, ipKey := loginKeys(u.OrgID, u.Username, client.IP)
+	if throttled {
+		if err := s.checkLoginThrottle(ctx, userKey, ipKey, now); err != nil {
+			return nil, err
+		}
+	}
+
+	var codes []string
+	ok := false
+	if purpose == purposeEnroll {
+		codes, err = s.confirmTOTP(ctx, u.ID, req.Code, now)
+		ok = err == nil
+		if err != nil && err.Error() != "invalid code" {
+			return nil, err
+		}
+	} else if ok, err = s.verifySecondFactor(ctx, u.ID, req.Code, now); err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.audit(ctx, auditEntry{Action: "login.failed", TargetType: "user", TargetID: u.ID, Actor: u})
+		if throttled {
+			s.recordLoginFailure(ctx, userKey, ipKey, u.Username, client.IP, now)
+		}
+		return nil, errors.New("invalid code")
+	}
+
+	s.loginSucceeded(ctx, u)
+	res, err := s.passwordChange(u)
+	if err == nil && res == nil {
+		res, err = s.startSession(ctx, u, client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.RecoveryCodes = codes
+	return res, nil
+}
+
+// TWO-FACTOR SETTINGS
+
+func (s *services) TwoFactor() TwoFactorService { return s }
+
+func (s *services) GetTwoFactorStatus(ctx context.Context, userID int, role string) (*dto.TwoFactorStatusResponse, error) {
+	res := &dto.TwoFactorStatusResponse{Required: s.opts.TwoFactor.required(role)}
+	if _, ok := s.twoFactorEnabled(ctx, userID); ok {
+		res.Enabled = true
+		n, err := s.repo.TwoFactor().CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		res.RecoveryCodesLeft = n
+	}
+	return res, nil
+}
+
+func (s *services) EnrollTwoFactor(ctx context.Context, userID int) (*dto.TwoFactorEnrollResponse, error) {
+	u, err := s.repo.User().GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.enrollTOTP(ctx, u)
+}
+
+func (s *services) ConfirmTwoFactor(ctx context.Context, userID int, code string) (*dto.RecoveryCodesResponse, error) {
+	codes, err := s.confirmTOTP(ctx, userID, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor removes the authenticator and recovery codes. It needs a
+// current code and isn't possible while the role requires 2FA.
+func (s *services) DisableTwoFactor(ctx context.Context, userID int, role string, code string) error {
+	if s.opts.TwoFactor.required(role) {
+		return errors.New("2fa is required for your role")
+	}
+	ok, err := s.verifySecondFactor(ctx, userID, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid code")
+	}
+	if err := s.repo.TwoFactor().DeleteTwoFactor(ctx, userID); err != nil {
+		return err
+	}
+	s.securityEvent(zerolog.InfoLevel, "2fa_disabled").Int("user_id", userID).Msg("2fa disabled")
+	s.audit(ctx, auditEntry{Action: "2fa.disabled", TargetType: "user", TargetID: userID})
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user.
+func (s *services) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) (*dto.RecoveryCodesResponse, error) {
+	ok, err := s.verifySecondFactor(ctx, userID, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+	codes, rows, err := newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.TwoFactor().ReplaceRecoveryCodes(ctx, userID, rows); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, auditEntry{Action: "2fa.recovery_codes_regenerated", TargetType: "user", TargetID: userID})
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// ResetTwoFactor removes 2FA of a user who lost their authenticator and
+// recovery codes. If their role requires 2FA, they set it up again at the
+// next login.
+func (s *services) ResetTwoFactor(ctx context.Context, userID int, managerID int, role string, allTeams bool) error {
+	if _, err := s.getUserInScope(ctx, userID, managerID, role, allTeams); err != nil {
+		return err
+	}
+	if err := s.repo.TwoFactor().DeleteTwoFactor(ctx, userID); err != nil {
+		return err
+	}
+	s.securityEvent(zerolog.WarnLevel, "2fa_reset").Int("user_id", userID).Int("manager_id", managerID).
+		Msg("2fa reset by manager")
+	s.audit(ctx, auditEntry{Action: "2fa.reset", TargetType: "user", TargetID: userID})
+	return nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/storage/memory"
+	"skilltracker/internal/utils/totp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+func currentCode(t *testing.T) string {
+	code, err := totp.Code(testTOTPSecret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+// twoFactorService has manager alice (password "password123") in the
+// default organization.
+func twoFactorService(opts Options, tf *MockTwoFactorRepo) ServiceInterface {
+	mockRepo := new(MockRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	mockRepo.On("Organization").Return(defaultOrgRepo())
+
+	mockRepo.On("Audit").Return(acceptingAuditRepo())
+	mockRepo.On("User").Return(mockUserRepo)
+	mockRepo.On("Session").Return(mockSessionRepo)
+	mockRepo.On("TwoFactor").Return(tf)
+	mockRepo.On("LoginThrottle").Return(memory.NewLoginThrottle())
+	alice := &models.User{ID: 5, OrgID: 1, Username: "alice", PasswordHash: string(hash), Role: models.RoleManager}
+	mockUserRepo.On("GetUserByUsername", mock.Anything, "alice").Return(alice, nil)
+	mockUserRepo.On("GetUserByID", inOrg(1), 5).Return(alice, nil)
+	mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(nil)
+	return New(mockRepo, zerolog.Nop(), testKeys, opts)
+}
+
+func enabledTOTP() *models.TOTPCredential {
+	confirmed := time.Now().Add(-time.Hour)
+	return &models.TOTPCredential{ID: 1, OrgID: 1, UserID: 5, Secret: testTOTPSecret, ConfirmedAt: &confirmed}
+}
+
+func passwordStep(t *testing.T, s ServiceInterface) *dto.LoginResponse {
+	t.Helper()
+	res, err := s.User().Login(context.Background(), &dto.LoginRequest{Username: "alice", Password: "password123"}, dto.ClientInfo{})
+	require.NoError(t, err)
+	return res
+}
+
+func TestLogin_TwoFactor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("password only yields a challenge", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", inOrg(1), 5).Return(enabledTOTP(), nil)
+		s := twoFactorService(Options{}, tf)
+
+		res := passwordStep(t, s)
+
+		assert.Equal(t, "verify", res.TwoFactor)
+		assert.Empty(t, res.AccessToken)
+		assert.Empty(t, res.RefreshToken)
+		_, err := testKeys.ValidateToken(res.ChallengeToken)
+		assert.Error(t, err, "a challenge is no access token")
+	})
+
+	t.Run("TOTP code completes the login", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", inOrg(1), 5).Return(enabledTOTP(), nil)
+		tf.On("UseTOTPStep", inOrg(1), 5, mock.Anything).Return(true, nil)
+		s := twoFactorService(Options{}, tf)
+		challenge := passwordStep(t, s).ChallengeToken
+
+		res, err := s.User().VerifyLoginTwoFactor(ctx, &dto.TwoFactorLoginRequest{ChallengeToken: challenge, Code: currentCode(t)}, dto.ClientInfo{})
+
+		require.NoError(t, err)
+		claims, err := testKeys.ValidateToken(res.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, 5, claims.UserID)
+		assert.NotEmpty(t, res.RefreshToken)
+	})
+
+	t.Run("a used code is refused", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", inOrg(1), 5).Return(enabledTOTP(), nil)
+		tf.On("UseTOTPStep", inOrg(1), 5, mock.Anything).Return(false, nil)
+		s := twoFactorService(Options{}, tf)
+		challenge := passwordStep(t, s).ChallengeToken
+
+		_, err := s.User().VerifyLoginTwoFactor(ctx, &dto.TwoFactorLoginRequest{ChallengeToken: challenge, Code: currentCode(t)}, dto.ClientInfo{})
+
+		assert.EqualError(t, err, "invalid code")
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", inOrg(1), 5).Return(enabledTOTP(), nil)
+		tf.On("UseRecoveryCode", inOrg(1), 5, hashToken("abcde12345"), mock.Anything).Return(true, nil)
+		s := twoFactorService(Options{}, tf)
+		challenge := passwordStep(t, s).ChallengeToken
+
+		res, err := s.User().VerifyLoginTwoFactor(ctx, &dto.TwoFactorLoginRequest{ChallengeToken: challenge, Code: "ABCDE-12345"}, dto.ClientInfo{})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, res.AccessToken)
+	})
+
+	t.Run("wrong codes count as failed logins", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", inOrg(1), 5).Return(enabledTOTP(), nil)
+		tf.On("UseRecoveryCode", inOrg(1), 5, mock.Anything, mock.Anything).Return(false, nil)
+		s := twoFactorService(Options{Lockout: LockoutPolicy{MaxFailures: 2, Duration: time.Minute}}, tf)
+		challenge := passwordStep(t, s).ChallengeToken
+		req := &dto.TwoFactorLoginRequest{ChallengeToken: challenge, Code: "000000"}
+
+		_, err := s.User().VerifyLoginTwoFactor(ctx, req, dto.ClientInfo{})
+		assert.EqualError(t, err, "invalid code")
+		_, err = s.User().VerifyLoginTwoFactor(ctx, req, dto.ClientInfo{})
+		assert.EqualError(t, err, "invalid code")
+		req.Code = currentCode(t)
+		_, err = s.User().VerifyLoginTwoFactor(ctx, req, dto.ClientInfo{})
+		assert.EqualError(t, err, "account locked")
+	})
+
+	t.Run("invalid challenge", func(t *testing.T) {
+		s := twoFactorService(Options{}, new(MockTwoFactorRepo))
+		access, _ := testKeys.GenerateAccessToken(5, 1, 9, 0, "alice", "manager")
+
+		_, err := s.User().VerifyLoginTwoFactor(ctx, &dto.TwoFactorLoginRequest{ChallengeToken: access, Code: "123456"}, dto.ClientInfo{})
+
+		assert.EqualError(t, err, "invalid challenge token")
+	})
+}
+
+func TestLogin_TwoFactorRequiredByRole(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{TwoFactor: TwoFactorPolicy{Issuer: "Acme", RequiredRoles: []string{"manager"}}}
+
+	tf := new(MockTwoFactorRepo)
+	// Nothing is set up at the password step and at enrollment; the code
+	// confirms what enrollment saved.
+	pending := &models.TOTPCredential{}
+	tf.On("GetTOTPCredential", inOrg(1), 5).Return(nil, errors.New("not found")).Twice()
+	tf.On("GetTOTPCredential", inOrg(1), 5).Return(pending, nil)
+	tf.On("SaveTOTPCredential", inOrg(1), mock.Anything).Run(func(args mock.Arguments) {
+		*pending = *args.Get(1).(*models.TOTPCredential)
+	}).Return(nil)
+	var stored []models.RecoveryCode
+	tf.On("EnableTwoFactor", inOrg(1), mock.MatchedBy(func(c *models.TOTPCredential) bool {
+		return c.ConfirmedAt != nil && c.LastStep == totp.Step(time.Now())
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(2).([]models.RecoveryCode)
+	}).Return(nil)
+	s := twoFactorService(opts, tf)
+
+	res := passwordStep(t, s)
+	require.Equal(t, "enroll", res.TwoFactor)
+	assert.Empty(t, res.AccessToken)
+
+	enroll, err := s.User().EnrollLoginTwoFactor(ctx, &dto.TwoFactorChallengeRequest{ChallengeToken: res.ChallengeToken})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enroll.OtpauthURI, "otpauth://totp/Acme:alice?"))
+	assert.Equal(t, enroll.Secret, pending.Secret)
+	assert.Nil(t, pending.ConfirmedAt)
+
+	code, _ := totp.Code(enroll.Secret, totp.Step(time.Now()))
+	done, err := s.User().VerifyLoginTwoFactor(ctx, &dto.TwoFactorLoginRequest{ChallengeToken: res.ChallengeToken, Code: code}, dto.ClientInfo{})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, done.AccessToken)
+	require.Len(t, done.RecoveryCodes, recoveryCodeCount)
+	require.Len(t, stored, recoveryCodeCount)
+	assert.Equal(t, hashToken(normalizeRecoveryCode(done.RecoveryCodes[0])), stored[0].CodeHash)
+}
+
+func TestTwoFactorSettings(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("can't enroll twice", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", ctx, 5).Return(enabledTOTP(), nil)
+		mockRepo := new(MockRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockRepo.On("User").Return(mockUserRepo)
+		mockRepo.On("TwoFactor").Return(tf)
+		mockUserRepo.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, Username: "alice"}, nil)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		_, err := s.TwoFactor().EnrollTwoFactor(ctx, 5)
+
+		assert.EqualError(t, err, "2fa already enabled")
+		tf.AssertNotCalled(t, "SaveTOTPCredential", mock.Anything, mock.Anything)
+	})
+
+	t.Run("required role can't disable", func(t *testing.T) {
+		s := New(new(MockRepo), zerolog.Nop(), testKeys, Options{TwoFactor: TwoFactorPolicy{RequiredRoles: []string{"manager"}}})
+
+		err := s.TwoFactor().DisableTwoFactor(ctx, 5, "manager", "123456")
+
+		assert.EqualError(t, err, "2fa is required for your role")
+	})
+
+	t.Run("disable with a code", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", ctx, 5).Return(enabledTOTP(), nil)
+		tf.On("UseTOTPStep", ctx, 5, mock.Anything).Return(true, nil)
+		tf.On("DeleteTwoFactor", ctx, 5).Return(nil)
+		mockRepo := new(MockRepo)
+		mockRepo.On("TwoFactor").Return(tf)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{})
+
+		assert.NoError(t, s.TwoFactor().DisableTwoFactor(ctx, 5, "employee", currentCode(t)))
+		tf.AssertExpectations(t)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		tf := new(MockTwoFactorRepo)
+		tf.On("GetTOTPCredential", ctx, 5).Return(enabledTOTP(), nil)
+		tf.On("CountRecoveryCodes", ctx, 5).Return(int64(7), nil)
+		mockRepo := new(MockRepo)
+		mockRepo.On("TwoFactor").Return(tf)
+		s := New(mockRepo, zerolog.Nop(), testKeys, Options{TwoFactor: TwoFactorPolicy{RequiredRoles: []string{"manager"}}})
+
+		res, err := s.TwoFactor().GetTwoFactorStatus(ctx, 5, "manager")
+
+		require.NoError(t, err)
+		assert.Equal(t, &dto.TwoFactorStatusResponse{Enabled: true, Required: true, RecoveryCodesLeft: 7}, res)
+	})
+}
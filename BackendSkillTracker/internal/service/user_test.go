@@ -13,7 +13,7 @@ import (
 )
 
 func TestUserService_Login(t *testing.T) {
-	jwtSecret := []byte("secret")
+	jwtSecret := testKeys
 	logger := zerolog.Nop()
 
 	ctx := context.Background()
@@ -24,42 +24,56 @@ func TestUserService_Login(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockRepo := new(MockRepo)
 		mockUserRepo := new(MockUserRepo)
-		s := New(mockRepo, logger, jwtSecret)
+		s := New(mockRepo, logger, jwtSecret, Options{})
 
 		user := &models.User{
 			ID:           1,
+			OrgID:        1,
 			Username:     username,
 			PasswordHash: string(hashedPassword),
 			Role:         models.RoleEmployee,
 		}
 
 		mockRepo.On("User").Return(mockUserRepo)
-		mockUserRepo.On("GetUserByUsername", ctx, username).Return(user, nil)
-		mockUserRepo.On("UpdateUser", ctx, mock.Anything).Return(nil)
+		mockRepo.On("Organization").Return(defaultOrgRepo())
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockUserRepo.On("GetUserByUsername", inOrg(1), username).Return(user, nil)
+		mockSessionRepo := new(MockSessionRepo)
+		mockRepo.On("Session").Return(mockSessionRepo)
+		mockRepo.On("TwoFactor").Return(noTwoFactorRepo())
+		mockSessionRepo.On("CreateSession", inOrg(1), mock.MatchedBy(func(sess *models.Session) bool {
+			return sess.UserID == 1 && len(sess.Tokens) == 1
+		})).Return(nil)
 
 		res, err := s.User().Login(ctx, &dto.LoginRequest{
 			Username: username,
 			Password: password,
-		})
+		}, dto.ClientInfo{})
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, res.AccessToken)
 		assert.NotEmpty(t, res.RefreshToken)
+		claims, err := testKeys.ValidateToken(res.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, claims.OrgID)
 		mockUserRepo.AssertExpectations(t)
+		mockSessionRepo.AssertExpectations(t)
 	})
 
 	t.Run("invalid credentials - user not found", func(t *testing.T) {
 		mockRepo := new(MockRepo)
 		mockUserRepo := new(MockUserRepo)
-		s := New(mockRepo, logger, jwtSecret)
+		s := New(mockRepo, logger, jwtSecret, Options{})
 
 		mockRepo.On("User").Return(mockUserRepo)
-		mockUserRepo.On("GetUserByUsername", ctx, username).Return(nil, assert.AnError)
+		mockRepo.On("Organization").Return(defaultOrgRepo())
+		mockRepo.On("Audit").Return(acceptingAuditRepo())
+		mockUserRepo.On("GetUserByUsername", inOrg(1), username).Return(nil, assert.AnError)
 
 		res, err := s.User().Login(ctx, &dto.LoginRequest{
 			Username: username,
 			Password: password,
-		})
+		}, dto.ClientInfo{})
 
 		assert.Error(t, err)
 		assert.Nil(t, res)
@@ -68,24 +82,22 @@ func TestUserService_Login(t *testing.T) {
 }
 
 func TestUserService_Logout(t *testing.T) {
-	jwtSecret := []byte("secret")
+	jwtSecret := testKeys
 	logger := zerolog.Nop()
 	ctx := context.Background()
 	userID := 1
 
 	mockRepo := new(MockRepo)
-	mockUserRepo := new(MockUserRepo)
-	s := New(mockRepo, logger, jwtSecret)
+	mockSessionRepo := new(MockSessionRepo)
+	s := New(mockRepo, logger, jwtSecret, Options{})
 
-	mockRepo.On("User").Return(mockUserRepo)
-	mockUserRepo.On("GetUserByID", ctx, userID).Return(&models.User{ID: userID}, nil)
-	mockUserRepo.On("UpdateUser", ctx, mock.MatchedBy(func(u *models.User) bool {
-		return u.RefreshToken == ""
-	})).Return(nil)
+	mockRepo.On("Session").Return(mockSessionRepo)
+	mockSessionRepo.On("GetSessionByID", ctx, 7).Return(&models.Session{ID: 7, UserID: userID}, nil)
+	mockSessionRepo.On("RevokeSession", ctx, 7, mock.Anything).Return(nil)
 
-	err := s.User().Logout(ctx, userID)
+	err := s.User().Logout(ctx, userID, 7)
 	assert.NoError(t, err)
-	mockUserRepo.AssertExpectations(t)
+	mockSessionRepo.AssertExpectations(t)
 }
 
 func TestUserService_CreateUser(t *testing.T) {
@@ -93,7 +105,7 @@ func TestUserService_CreateUser(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	logger := zerolog.Nop()
 
-	s := New(mockRepo, logger, []byte("secret"))
+	s := New(mockRepo, logger, testKeys, Options{})
 
 	ctx := context.Background()
 
@@ -110,7 +122,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			return u.Username == req.Username && u.Name == req.Name
 		})).Return(nil)
 
-		res, err := s.User().CreateUser(ctx, req)
+		res, err := s.User().CreateUser(ctx, req, "manager")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, res)
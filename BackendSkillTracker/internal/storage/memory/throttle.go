@@ -0,0 +1,76 @@
+// Package memory holds in-process repository implementations for
+// single-node setups.
+package memory
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"skilltracker/internal/repository"
+	"sync"
+	"time"
+)
+
+// LoginThrottle keeps failed login counts in memory. They are lost on
+// restart and not shared between replicas.
+type LoginThrottle struct {
+	mu   sync.Mutex
+	rows map[string]models.LoginThrottle
+}
+
+func NewLoginThrottle() *LoginThrottle {
+	return &LoginThrottle{rows: map[string]models.LoginThrottle{}}
+}
+
+func (m *LoginThrottle) GetLoginThrottles(_ context.Context, keys []string) ([]models.LoginThrottle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.LoginThrottle
+	for _, k := range keys {
+		if t, ok := m.rows[k]; ok {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m *LoginThrottle) RecordLoginFailure(_ context.Context, key string, at time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.rows[key]
+	if !ok || t.LastFailureAt.Before(at.Add(-window)) {
+		t = models.LoginThrottle{Key: key}
+	}
+	t.Failures++
+	t.LastFailureAt = at
+	m.rows[key] = t
+	return &t, nil
+}
+
+func (m *LoginThrottle) LockLogin(_ context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.rows[key]; ok {
+		t.LockedUntil = &until
+		m.rows[key] = t
+	}
+	return nil
+}
+
+func (m *LoginThrottle) ResetLoginThrottle(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rows, key)
+	return nil
+}
+
+type withLoginThrottle struct {
+	repository.Repository
+	throttle repository.LoginThrottleRepository
+}
+
+func (r withLoginThrottle) LoginThrottle() repository.LoginThrottleRepository { return r.throttle }
+
+// WithLoginThrottle returns repo with failed logins tracked in memory.
+func WithLoginThrottle(repo repository.Repository) repository.Repository {
+	return withLoginThrottle{Repository: repo, throttle: NewLoginThrottle()}
+}
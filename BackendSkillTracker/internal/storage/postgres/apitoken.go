@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+)
+
+// API TOKENS
+
+func (s *Storage) CreateAPIToken(ctx context.Context, t *models.APIToken) error {
+	return s.db.WithContext(ctx).Create(t).Error
+}
+
+func (s *Storage) GetAPITokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	var ts []models.APIToken
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&ts).Error
+	return ts, err
+}
+
+func (s *Storage) GetAPITokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	var t models.APIToken
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Storage) RevokeAPIToken(ctx context.Context, userID int, id int, at time.Time) (bool, error) {
+	res := s.db.WithContext(ctx).Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (s *Storage) RevokeUserAPITokens(ctx context.Context, userID int, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+func (s *Storage) TouchAPIToken(ctx context.Context, id int, at time.Time, ip string) error {
+	return s.db.WithContext(ctx).Model(&models.APIToken{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
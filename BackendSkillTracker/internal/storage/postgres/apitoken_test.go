@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeAPIToken(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.RevokeAPIToken(tenant.WithOrg(context.Background(), 3), 7, 5, time.Now())
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, `UPDATE "api_tokens" SET "revoked_at"`)
+	assert.Contains(t, stmt, "user_id = 7")
+	assert.Contains(t, stmt, "revoked_at IS NULL")
+	assert.Contains(t, stmt, `"api_tokens"."org_id" = 3`)
+}
+
+func TestGetAPITokenByHash_System(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	// The hash is looked up before the organization is known.
+	_, _ = s.GetAPITokenByHash(tenant.System(context.Background()), "abc")
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, "token_hash = 'abc'")
+	assert.NotContains(t, stmt, "org_id")
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditLogAppendOnly makes the database refuse changes to audit entries,
+// whatever the application does. Deleting stays possible for the
+// retention purge.
+const auditLogAppendOnly = `
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit log entries can not be changed';
+END
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only BEFORE UPDATE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();`
+
+// AUDIT LOG
+
+func (s *Storage) CreateAuditLog(ctx context.Context, l *models.AuditLog) error {
+	return s.db.WithContext(ctx).Create(l).Error
+}
+
+func (s *Storage) GetAuditLogs(ctx context.Context, filter dto.AuditFilter) ([]models.AuditLog, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action IN ?", strings.Split(filter.Action, ","))
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ? OR impersonator_id = ?", filter.ActorID, filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != "" {
+		if t, err := time.Parse("2006-01-02", filter.From); err == nil {
+			query = query.Where("created_at >= ?", t)
+		}
+	}
+	if filter.To != "" {
+		if t, err := time.Parse("2006-01-02", filter.To); err == nil {
+			query = query.Where("created_at < ?", t.AddDate(0, 0, 1))
+		}
+	}
+	// The count and the page both start from the filtered query.
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var ls []models.AuditLog
+	err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&ls).Error
+	return ls, total, err
+}
+
+func (s *Storage) DeleteAuditLogsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.AuditLog{})
+	return res.RowsAffected, res.Error
+}
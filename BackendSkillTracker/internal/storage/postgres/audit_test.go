@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"skilltracker/internal/dto"
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAuditLogs_Filters(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, _, err := s.GetAuditLogs(tenant.WithOrg(context.Background(), 3), dto.AuditFilter{
+		Action:  "login.failed,user.updated",
+		ActorID: 5,
+		From:    "2026-03-01",
+		To:      "2026-03-31",
+		Limit:   50,
+		Offset:  100,
+	})
+	require.NoError(t, err)
+	require.Len(t, rec.stmts, 2)
+
+	count, page := rec.stmts[0], rec.stmts[1]
+	assert.Contains(t, count, "count(*)")
+	for _, stmt := range rec.stmts {
+		assert.Contains(t, stmt, "action IN ('login.failed','user.updated')")
+		// Entries made while impersonating count for the manager too.
+		assert.Contains(t, stmt, "(actor_id = 5 OR impersonator_id = 5)")
+		assert.Contains(t, stmt, "created_at >= '2026-03-01")
+		// The end date is inclusive.
+		assert.Contains(t, stmt, "created_at < '2026-04-01")
+		assert.Contains(t, stmt, `"audit_logs"."org_id" = 3`)
+	}
+	assert.Contains(t, page, "ORDER BY id DESC")
+	assert.Contains(t, page, "LIMIT 50 OFFSET 100")
+}
+
+func TestDeleteAuditLogsBefore_AllOrganizations(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	before := time.Date(2025, 10, 18, 0, 0, 0, 0, time.UTC)
+	_, err := s.DeleteAuditLogsBefore(tenant.System(context.Background()), before)
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, `DELETE FROM "audit_logs"`)
+	assert.Contains(t, stmt, "created_at < '2025-10-18")
+	assert.NotContains(t, stmt, "org_id")
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CHECKLISTS
+
+func (s *Storage) CreateChecklistItem(ctx context.Context, item *models.ChecklistItem) error {
+	return s.db.WithContext(ctx).Create(item).Error
+}
+
+func (s *Storage) GetChecklistItemByID(ctx context.Context, id int) (*models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	if err := s.db.WithContext(ctx).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Storage) GetChecklistByTaskID(ctx context.Context, taskID int) ([]models.ChecklistItem, error) {
+	var out []models.ChecklistItem
+	err := s.db.WithContext(ctx).Where("task_id = ?", taskID).Order("position, id").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) UpdateChecklistItem(ctx context.Context, item *models.ChecklistItem) error {
+	return s.db.WithContext(ctx).Save(item).Error
+}
+
+func (s *Storage) DeleteChecklistItem(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Delete(&models.ChecklistItem{}, id).Error
+}
+
+// ReorderChecklist sets item positions to their index in itemIDs.
+func (s *Storage) ReorderChecklist(ctx context.Context, taskID int, itemIDs []int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range itemIDs {
+			err := tx.Model(&models.ChecklistItem{}).
+				Where("id = ? AND task_id = ?", id, taskID).
+				Update("position", i).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
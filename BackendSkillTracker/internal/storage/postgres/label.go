@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LABELS
+
+func (s *Storage) CreateLabel(ctx context.Context, l *models.Label) error {
+	return s.db.WithContext(ctx).Create(l).Error
+}
+
+func (s *Storage) GetLabelByID(ctx context.Context, id int) (*models.Label, error) {
+	var l models.Label
+	if err := s.db.WithContext(ctx).First(&l, id).Error; err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (s *Storage) GetLabels(ctx context.Context) ([]models.Label, error) {
+	var out []models.Label
+	err := s.db.WithContext(ctx).Order("name").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) UpdateLabel(ctx context.Context, l *models.Label) error {
+	return s.db.WithContext(ctx).Save(l).Error
+}
+
+func (s *Storage) DeleteLabel(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("label_id = ?", id).Delete(&models.TaskLabel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Label{}, id).Error
+	})
+}
+
+func (s *Storage) AddLabelToTask(ctx context.Context, taskID int, labelID int) error {
+	return s.db.WithContext(ctx).
+		Where(models.TaskLabel{TaskID: taskID, LabelID: labelID}).
+		FirstOrCreate(&models.TaskLabel{TaskID: taskID, LabelID: labelID}).Error
+}
+
+func (s *Storage) RemoveLabelFromTask(ctx context.Context, taskID int, labelID int) error {
+	return s.db.WithContext(ctx).
+		Where("task_id = ? AND label_id = ?", taskID, labelID).
+		Delete(&models.TaskLabel{}).Error
+}
+
+// GetLabelUsage counts labelled tasks per label and status, ignoring deleted tasks.
+func (s *Storage) GetLabelUsage(ctx context.Context) ([]models.LabelUsage, error) {
+	var out []models.LabelUsage
+	err := s.db.WithContext(ctx).Model(&models.Task{}).
+		Select("task_labels.label_id, tasks.status, COUNT(*) AS count").
+		Joins("JOIN task_labels ON task_labels.task_id = tasks.id").
+		Group("task_labels.label_id, tasks.status").
+		Scan(&out).Error
+	return out, err
+}
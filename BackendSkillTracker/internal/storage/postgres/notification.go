@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NOTIFICATIONS
+
+func (s *Storage) CreateNotifications(ctx context.Context, ns []models.Notification) error {
+	if len(ns) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Create(&ns).Error
+}
+
+func (s *Storage) GetNotifications(ctx context.Context, userID int, unreadOnly bool) ([]models.Notification, error) {
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	var out []models.Notification
+	err := query.Order("created_at DESC").Limit(200).Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) MarkNotificationRead(ctx context.Context, id int, userID int) error {
+	res := s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *Storage) MarkAllNotificationsRead(ctx context.Context, userID int) error {
+	return s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+func (s *Storage) GetNotificationPreferences(ctx context.Context, userID int) ([]models.NotificationPreference, error) {
+	var out []models.NotificationPreference
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) SaveNotificationPreferences(ctx context.Context, prefs []models.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&prefs).Error
+}
+
+func (s *Storage) GetMutedUsers(ctx context.Context, userIDs []int, typ models.NotificationType) ([]int, error) {
+	var ids []int
+	err := s.db.WithContext(ctx).Model(&models.NotificationPreference{}).
+		Where("user_id IN ? AND type = ? AND NOT enabled", userIDs, typ).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveNotificationPreferences_Upsert(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	err := s.SaveNotificationPreferences(tenant.WithOrg(context.Background(), 3), []models.NotificationPreference{
+		{UserID: 5, Type: models.NotificationAssigned, Enabled: false},
+	})
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, `INSERT INTO "notification_preferences"`)
+	assert.Contains(t, stmt, `ON CONFLICT ("user_id","type") DO UPDATE SET "enabled"="excluded"."enabled"`)
+	// A preference of another organization is never overwritten.
+	assert.Contains(t, stmt, `"notification_preferences"."org_id" = 3`)
+}
+
+func TestGetMutedUsers(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.GetMutedUsers(tenant.WithOrg(context.Background(), 3), []int{5, 6}, models.NotificationReassigned)
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, "user_id IN (5,6) AND type = 'reassigned' AND NOT enabled")
+	assert.Contains(t, stmt, `"notification_preferences"."org_id" = 3`)
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OPENID CONNECT
+
+// CreateOIDCState also drops expired states of abandoned logins.
+func (s *Storage) CreateOIDCState(ctx context.Context, st *models.OIDCState) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCState{}).Error; err != nil {
+		return err
+	}
+	return db.Create(st).Error
+}
+
+func (s *Storage) TakeOIDCState(ctx context.Context, stateHash string, now time.Time) (*models.OIDCState, error) {
+	var states []models.OIDCState
+	err := s.db.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > ?", stateHash, now).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
+
+func (s *Storage) GetExternalIdentity(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error) {
+	var id models.ExternalIdentity
+	err := s.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (s *Storage) CreateExternalIdentity(ctx context.Context, id *models.ExternalIdentity) error {
+	return s.db.WithContext(ctx).Create(id).Error
+}
+
+func (s *Storage) CountExternalIdentities(ctx context.Context, userID int) (int64, error) {
+	var n int64
+	err := s.db.WithContext(ctx).Model(&models.ExternalIdentity{}).Where("user_id = ?", userID).Count(&n).Error
+	return n, err
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeOIDCState(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	// The callback has only the state, so there's no tenant yet.
+	_, _ = s.TakeOIDCState(context.Background(), "hash", time.Now())
+	stmt := rec.last()
+	assert.Contains(t, stmt, `DELETE FROM "oidc_states"`)
+	assert.Contains(t, stmt, "expires_at >")
+	assert.Contains(t, stmt, "RETURNING")
+	assert.NotContains(t, stmt, "org_id")
+}
+
+func TestGetExternalIdentity(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, _ = s.GetExternalIdentity(tenant.WithOrg(context.Background(), 3), "corp", "sub-1")
+	assert.Contains(t, rec.last(), `"external_identities"."org_id" = 3`)
+}
@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+)
+
+// ORGANIZATIONS
+
+func (s *Storage) CreateOrganization(ctx context.Context, o *models.Organization) error {
+	return s.db.WithContext(ctx).Create(o).Error
+}
+
+func (s *Storage) GetOrganizations(ctx context.Context) ([]models.Organization, error) {
+	var out []models.Organization
+	err := s.db.WithContext(ctx).Order("id").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetOrganizationByID(ctx context.Context, id int) (*models.Organization, error) {
+	var o models.Organization
+	if err := s.db.WithContext(ctx).First(&o, id).Error; err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (s *Storage) GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	var o models.Organization
+	if err := s.db.WithContext(ctx).Where("slug = ?", slug).First(&o).Error; err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PASSWORDS
+
+func (s *Storage) GetPasswordHistory(ctx context.Context, userID int, limit int) ([]models.PasswordHistory, error) {
+	var hs []models.PasswordHistory
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&hs).Error
+	return hs, err
+}
+
+func (s *Storage) AddPasswordHistory(ctx context.Context, h *models.PasswordHistory, keep int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(h).Error; err != nil {
+			return err
+		}
+		newest := tx.Session(&gorm.Session{NewDB: true}).Model(&models.PasswordHistory{}).
+			Select("id").Where("user_id = ?", h.UserID).Order("id DESC").Limit(keep)
+		return tx.Where("user_id = ? AND id NOT IN (?)", h.UserID, newest).Delete(&models.PasswordHistory{}).Error
+	})
+}
+
+// CreatePasswordResetToken also drops expired tokens of the organization.
+func (s *Storage) CreatePasswordResetToken(ctx context.Context, t *models.PasswordResetToken) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("(user_id = ? AND used_at IS NULL) OR expires_at < ?", t.UserID, time.Now()).
+			Delete(&models.PasswordResetToken{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(t).Error
+	})
+}
+
+func (s *Storage) GetPasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var t models.PasswordResetToken
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Storage) UsePasswordResetToken(ctx context.Context, id int, at time.Time) (bool, error) {
+	res := s.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return res.RowsAffected > 0, res.Error
+}
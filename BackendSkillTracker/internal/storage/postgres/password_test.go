@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPasswordHistory(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.GetPasswordHistory(tenant.WithOrg(context.Background(), 3), 7, 4)
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, `"password_histories"."org_id" = 3`)
+	assert.Contains(t, stmt, "ORDER BY id DESC LIMIT 4")
+}
+
+func TestUsePasswordResetToken(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.UsePasswordResetToken(tenant.WithOrg(context.Background(), 3), 5, time.Now())
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, `UPDATE "password_reset_tokens" SET "used_at"`)
+	assert.Contains(t, stmt, "used_at IS NULL")
+}
@@ -5,10 +5,12 @@ import (
 	"skilltracker/internal/models"
 	"skilltracker/internal/repository"
 	"skilltracker/internal/dto"
+	"strings"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -42,9 +44,51 @@ func New(dsn string) (*Storage, error) {
 		&models.Comment{},
 		&models.FileAttachment{},
 		&models.TaskStatusHistory{},
+		&models.RecurringTask{},
+		&models.RecurringTaskSkill{},
+		&models.RecurringTaskException{},
+		&models.TaskTemplate{},
+		&models.TaskTemplateSkill{},
+		&models.TaskTemplateChecklistItem{},
+		&models.ChecklistItem{},
+		&models.TimeEntry{},
+		&models.Timesheet{},
+		&models.SLAPolicy{},
+		&models.Label{},
+		&models.TaskLabel{},
+		&models.Project{},
+		&models.ProjectMember{},
+		&models.Sprint{},
+		&models.SprintTask{},
+		&models.TaskAssignee{},
+		&models.TaskWatcher{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.RoleDefinition{},
+		&models.RolePermission{},
+		&models.Team{},
+		&models.Organization{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.LoginThrottle{},
+		&models.TOTPCredential{},
+		&models.RecoveryCode{},
+		&models.OIDCState{},
+		&models.ExternalIdentity{},
+		&models.PasswordHistory{},
+		&models.PasswordResetToken{},
+		&models.APIToken{},
+		&models.AuditLog{},
 	); err != nil {
 		return nil, err
 	}
+	if err := db.Exec(auditLogAppendOnly).Error; err != nil {
+		return nil, err
+	}
+
+	if err := registerTenantScope(db); err != nil {
+		return nil, err
+	}
 
 	return &Storage{db: db}, nil
 }
@@ -54,6 +98,25 @@ func (s *Storage) Task() repository.TaskRepository       { return s }
 func (s *Storage) Comment() repository.CommentRepository { return s }
 func (s *Storage) File() repository.FileRepository       { return s }
 func (s *Storage) Skill() repository.SkillRepository     { return s }
+func (s *Storage) RecurringTask() repository.RecurringTaskRepository { return s }
+func (s *Storage) Template() repository.TaskTemplateRepository       { return s }
+func (s *Storage) Checklist() repository.ChecklistRepository          { return s }
+func (s *Storage) Time() repository.TimeRepository                    { return s }
+func (s *Storage) SLA() repository.SLARepository                      { return s }
+func (s *Storage) Label() repository.LabelRepository                  { return s }
+func (s *Storage) Project() repository.ProjectRepository              { return s }
+func (s *Storage) Sprint() repository.SprintRepository                { return s }
+func (s *Storage) Notification() repository.NotificationRepository    { return s }
+func (s *Storage) Role() repository.RoleRepository                    { return s }
+func (s *Storage) Team() repository.TeamRepository                    { return s }
+func (s *Storage) Organization() repository.OrganizationRepository    { return s }
+func (s *Storage) Session() repository.SessionRepository              { return s }
+func (s *Storage) LoginThrottle() repository.LoginThrottleRepository { return s }
+func (s *Storage) TwoFactor() repository.TwoFactorRepository         { return s }
+func (s *Storage) OIDC() repository.OIDCRepository                   { return s }
+func (s *Storage) Password() repository.PasswordRepository           { return s }
+func (s *Storage) APIToken() repository.APITokenRepository           { return s }
+func (s *Storage) Audit() repository.AuditRepository                 { return s }
 
 // USERS
 
@@ -69,24 +132,29 @@ func (s *Storage) GetUserByID(ctx context.Context, id int) (*models.User, error)
 	return &u, nil
 }
 
+// GetUserByUsername matches the username case-insensitively, so accounts
+// created before usernames were lowercased are still found.
 func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	var u models.User
-	if err := s.db.WithContext(ctx).Where("username = ?", username).First(&u).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("LOWER(username) = LOWER(?)", username).First(&u).Error; err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-func (s *Storage) GetUserByRefreshToken(ctx context.Context, token string) (*models.User, error) {
+// GetUserByEmail matches the address case-insensitively.
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var u models.User
-	if err := s.db.WithContext(ctx).Where("refresh_token = ?", token).First(&u).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&u).Error; err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
+// UpdateUser saves the user except its token version, which only ever
+// moves forward through BumpTokenVersion.
 func (s *Storage) UpdateUser(ctx context.Context, u *models.User) error {
-	return s.db.WithContext(ctx).Save(u).Error
+	return s.db.WithContext(ctx).Omit("TokenVersion").Save(u).Error
 }
 
 func (s *Storage) DeleteUser(ctx context.Context, id int) error {
@@ -109,6 +177,19 @@ func (s *Storage) GetEmployeesWithSkills(ctx context.Context) ([]*models.User, e
 	return out, err
 }
 
+func (s *Storage) GetTokenVersion(ctx context.Context, id int) (int, error) {
+	var u models.User
+	if err := s.db.WithContext(ctx).Select("token_version").First(&u, id).Error; err != nil {
+		return 0, err
+	}
+	return u.TokenVersion, nil
+}
+
+func (s *Storage) BumpTokenVersion(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
+
 // TASKS
 
 func (s *Storage) CreateTask(ctx context.Context, t *models.Task) error {
@@ -117,7 +198,14 @@ func (s *Storage) CreateTask(ctx context.Context, t *models.Task) error {
 
 func (s *Storage) GetTaskByID(ctx context.Context, id int) (*models.Task, error) {
 	var t models.Task
-	if err := s.db.WithContext(ctx).Preload("RequiredSkills").First(&t, id).Error; err != nil {
+	err := s.db.WithContext(ctx).
+		Preload("RequiredSkills").
+		Preload("Labels").
+		Preload("Assignees").
+		Preload("Watchers").
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		First(&t, id).Error
+	if err != nil {
 		return nil, err
 	}
 	return &t, nil
@@ -126,23 +214,32 @@ func (s *Storage) GetTaskByID(ctx context.Context, id int) (*models.Task, error)
 func (s *Storage) GetTasksByEmployeeID(ctx context.Context, employeeID int) ([]models.Task, error) {
 	var ts []models.Task
 	err := s.db.WithContext(ctx).
-		Where("employee_id = ?", employeeID).
+		Where("(employee_id = ? OR id IN (?))", employeeID,
+			s.db.Table("task_assignees").Select("task_id").Where("user_id = ?", employeeID)).
 		Preload("RequiredSkills").
+		Preload("Labels").
+		Preload("Assignees").
+		Preload("Watchers").
 		Order("created_at DESC").
 		Find(&ts).Error
 	return ts, err
 }
 
+// UpdateTask saves the task columns only; associations have their own methods.
 func (s *Storage) UpdateTask(ctx context.Context, t *models.Task) error {
-	return s.db.WithContext(ctx).Save(t).Error
+	return s.db.WithContext(ctx).Omit(clause.Associations).Save(t).Error
+}
+
+func (s *Storage) SetTaskProgress(ctx context.Context, taskID int, progress int) error {
+	return s.db.WithContext(ctx).Model(&models.Task{}).Where("id = ?", taskID).Update("progress", progress).Error
 }
 
 func (s *Storage) DeleteTask(ctx context.Context, id int) error {
 	return s.db.WithContext(ctx).Delete(&models.Task{}, id).Error
 }
 
-func (s *Storage) AddSkillToTask(ctx context.Context, taskID int, skillID int) error {
-	return s.db.WithContext(ctx).Create(&models.TaskSkill{TaskID: taskID, SkillID: skillID}).Error
+func (s *Storage) AddSkillToTask(ctx context.Context, taskID int, skillID int, level int) error {
+	return s.db.WithContext(ctx).Create(&models.TaskSkill{TaskID: taskID, SkillID: skillID, Level: level}).Error
 }
 
 func (s *Storage) RemoveSkillFromTask(ctx context.Context, taskID int, skillID int) error {
@@ -154,12 +251,62 @@ func (s *Storage) RemoveSkillFromTask(ctx context.Context, taskID int, skillID i
 func (s *Storage) GetTaskSkills(ctx context.Context, taskID int) ([]models.Skill, error) {
 	var skills []models.Skill
 	err := s.db.WithContext(ctx).
+		Select("skills.*, task_skills.level").
 		Joins("JOIN task_skills ON task_skills.skill_id = skills.id").
 		Where("task_skills.task_id = ?", taskID).
 		Find(&skills).Error
 	return skills, err
 }
 
+func (s *Storage) GetTasksByIDs(ctx context.Context, ids []int) ([]models.Task, error) {
+	var ts []models.Task
+	if len(ids) == 0 {
+		return ts, nil
+	}
+	err := s.db.WithContext(ctx).Preload("RequiredSkills").Where("id IN ?", ids).Order("id").Find(&ts).Error
+	return ts, err
+}
+
+// SetTaskAssignees replaces the assignees of the task.
+func (s *Storage) SetTaskAssignees(ctx context.Context, taskID int, userIDs []int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskAssignee{}).Error; err != nil {
+			return err
+		}
+		for _, id := range userIDs {
+			if err := tx.Create(&models.TaskAssignee{TaskID: taskID, UserID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) AddTaskWatcher(ctx context.Context, taskID int, userID int) error {
+	return s.db.WithContext(ctx).
+		Where(models.TaskWatcher{TaskID: taskID, UserID: userID}).
+		FirstOrCreate(&models.TaskWatcher{TaskID: taskID, UserID: userID}).Error
+}
+
+func (s *Storage) RemoveTaskWatcher(ctx context.Context, taskID int, userID int) error {
+	return s.db.WithContext(ctx).
+		Where("task_id = ? AND user_id = ?", taskID, userID).
+		Delete(&models.TaskWatcher{}).Error
+}
+
+func (s *Storage) GetWatchedTasks(ctx context.Context, userID int) ([]models.Task, error) {
+	var ts []models.Task
+	err := s.db.WithContext(ctx).
+		Where("id IN (?)", s.db.Table("task_watchers").Select("task_id").Where("user_id = ?", userID)).
+		Preload("RequiredSkills").
+		Preload("Labels").
+		Preload("Assignees").
+		Preload("Watchers").
+		Order("created_at DESC").
+		Find(&ts).Error
+	return ts, err
+}
+
 // COMMENTS
 
 func (s *Storage) CreateComment(ctx context.Context, cmt *models.Comment) error {
@@ -204,18 +351,48 @@ func (s *Storage) GetHistoryByTaskID(ctx context.Context, taskID int) ([]models.
 	return out, err
 }
 
+// labelNames splits a comma-separated label filter into distinct names,
+// normalized the way labels are stored.
+func labelNames(list string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, n := range strings.Split(list, ",") {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n != "" && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// priorityOrder ranks priorities so that more urgent ones sort higher.
+const priorityOrder = "CASE priority WHEN 'critical' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END"
+
 func (s *Storage) ListTasks(ctx context.Context, filter dto.TaskFilter) ([]models.Task, error) {
-	query := s.db.WithContext(ctx).Preload("RequiredSkills")
+	query := s.db.WithContext(ctx).Preload("RequiredSkills").Preload("Labels").Preload("Assignees").Preload("Watchers")
 
 	if filter.Status != "" {
 		query = query.Where("status = ?", filter.Status)
 	}
 	if filter.EmployeeID != 0 {
-		query = query.Where("employee_id = ?", filter.EmployeeID)
+		query = query.Where("(employee_id = ? OR id IN (?))", filter.EmployeeID,
+			s.db.Table("task_assignees").Select("task_id").Where("user_id = ?", filter.EmployeeID))
 	}
 	if filter.CreatorID != 0 {
 		query = query.Where("creator_id = ?", filter.CreatorID)
 	}
+	if filter.TeamIDs != nil {
+		query = query.Where("(employee_id IN (?) OR creator_id = ?)",
+			s.db.WithContext(ctx).Model(&models.User{}).Select("id").Where("team_id IN ?", filter.TeamIDs),
+			filter.TeamViewerID)
+	}
+	if filter.ParticipantID != 0 {
+		query = query.Where("(creator_id = ? OR employee_id = ? OR id IN (?) OR id IN (?))",
+			filter.ParticipantID, filter.ParticipantID,
+			s.db.Table("task_assignees").Select("task_id").Where("user_id = ?", filter.ParticipantID),
+			s.db.Table("task_watchers").Select("task_id").Where("user_id = ?", filter.ParticipantID))
+	}
 	if filter.Search != "" {
 		searchTerm := "%" + filter.Search + "%"
 		query = query.Where("title LIKE ? OR description LIKE ?", searchTerm, searchTerm)
@@ -230,9 +407,41 @@ func (s *Storage) ListTasks(ctx context.Context, filter dto.TaskFilter) ([]model
 			query = query.Where("deadline <= ?", t)
 		}
 	}
+	if filter.ProjectID != 0 {
+		query = query.Where("project_id = ?", filter.ProjectID)
+	}
+	if filter.SprintID != 0 {
+		query = query.Where("sprint_id = ?", filter.SprintID)
+	}
+	if filter.Priority != "" {
+		query = query.Where("priority IN ?", strings.Split(filter.Priority, ","))
+	}
+	if names := labelNames(filter.Tags); len(names) > 0 {
+		query = query.Where("id IN (?)", s.db.Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.name IN ?", names))
+	}
+	if names := labelNames(filter.TagsAll); len(names) > 0 {
+		query = query.Where("id IN (?)", s.db.Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.name IN ?", names).
+			Group("task_labels.task_id").
+			Having("COUNT(DISTINCT labels.id) = ?", len(names)))
+	}
+
+	switch filter.Sort {
+	case "priority":
+		query = query.Order(priorityOrder + " DESC").Order("deadline")
+	case "deadline":
+		query = query.Order("deadline")
+	default:
+		query = query.Order("created_at DESC")
+	}
 
 	var out []models.Task
-	err := query.Order("created_at DESC").Find(&out).Error
+	err := query.Find(&out).Error
 	return out, err
 }
 
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PROJECTS
+
+func (s *Storage) CreateProject(ctx context.Context, p *models.Project) error {
+	return s.db.WithContext(ctx).Omit("Owner", "Members").Create(p).Error
+}
+
+func (s *Storage) GetProjectByID(ctx context.Context, id int) (*models.Project, error) {
+	var p models.Project
+	if err := s.db.WithContext(ctx).Preload("Members").First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Storage) GetProjects(ctx context.Context) ([]models.Project, error) {
+	var out []models.Project
+	err := s.db.WithContext(ctx).Preload("Members").Order("created_at DESC").Find(&out).Error
+	return out, err
+}
+
+// GetProjectsByMember returns projects the user is a member or the owner of.
+func (s *Storage) GetProjectsByMember(ctx context.Context, userID int) ([]models.Project, error) {
+	var out []models.Project
+	err := s.db.WithContext(ctx).Preload("Members").
+		Where("owner_id = ? OR id IN (?)", userID,
+			s.db.Table("project_members").Select("project_id").Where("user_id = ?", userID)).
+		Order("created_at DESC").
+		Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) UpdateProject(ctx context.Context, p *models.Project) error {
+	return s.db.WithContext(ctx).Omit("Owner", "Members").Save(p).Error
+}
+
+// DeleteProject deletes the project and detaches its tasks.
+func (s *Storage) DeleteProject(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("project_id = ?", id).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Project{}, id).Error
+	})
+}
+
+// SetProjectMembers replaces the member list of the project.
+func (s *Storage) SetProjectMembers(ctx context.Context, projectID int, userIDs []int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		for _, id := range userIDs {
+			if err := tx.Create(&models.ProjectMember{ProjectID: projectID, UserID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) GetProjectProgress(ctx context.Context, projectIDs []int) ([]models.ProjectProgress, error) {
+	var out []models.ProjectProgress
+	if len(projectIDs) == 0 {
+		return out, nil
+	}
+	err := s.db.WithContext(ctx).Model(&models.Task{}).
+		Select("project_id, COUNT(*) AS tasks, "+
+			"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed, "+
+			"SUM(CASE WHEN status = ? THEN 100 ELSE progress END) AS progress_sum",
+			models.StatusCompleted, models.StatusCompleted).
+		Where("project_id IN ?", projectIDs).
+		Group("project_id").
+		Scan(&out).Error
+	return out, err
+}
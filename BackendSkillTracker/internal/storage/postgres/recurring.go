@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RECURRING TASKS
+
+func (s *Storage) CreateRecurringTask(ctx context.Context, r *models.RecurringTask) error {
+	return s.db.WithContext(ctx).Omit("RequiredSkills", "Exceptions").Create(r).Error
+}
+
+func (s *Storage) GetRecurringTaskByID(ctx context.Context, id int) (*models.RecurringTask, error) {
+	var r models.RecurringTask
+	err := s.db.WithContext(ctx).
+		Preload("RequiredSkills").
+		Preload("Exceptions").
+		First(&r, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Storage) GetRecurringTasks(ctx context.Context) ([]models.RecurringTask, error) {
+	var out []models.RecurringTask
+	err := s.db.WithContext(ctx).Preload("RequiredSkills").Order("id").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetDueRecurringTasks(ctx context.Context, now time.Time) ([]models.RecurringTask, error) {
+	var out []models.RecurringTask
+	err := s.db.WithContext(ctx).
+		Where("trigger = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", models.TriggerSchedule, now).
+		Preload("RequiredSkills").
+		Preload("Exceptions").
+		Order("next_run_at").
+		Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) UpdateRecurringTask(ctx context.Context, r *models.RecurringTask) error {
+	return s.db.WithContext(ctx).Omit("RequiredSkills", "Exceptions").Save(r).Error
+}
+
+func (s *Storage) AdvanceRecurringTask(ctx context.Context, r *models.RecurringTask, from int) (bool, error) {
+	res := s.db.WithContext(ctx).Model(&models.RecurringTask{}).
+		Where("id = ? AND occurrences_created = ?", r.ID, from).
+		Updates(map[string]interface{}{"occurrences_created": r.OccurrencesCreated, "next_run_at": r.NextRunAt})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (s *Storage) CreateOccurrence(ctx context.Context, r *models.RecurringTask, from int, t *models.Task) (bool, error) {
+	claimed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RecurringTask{}).
+			Where("id = ? AND occurrences_created = ?", r.ID, from).
+			Updates(map[string]interface{}{"occurrences_created": r.OccurrencesCreated, "next_run_at": r.NextRunAt})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if err := tx.Omit("RequiredSkills").Create(t).Error; err != nil {
+			return err
+		}
+		for _, sk := range t.RequiredSkills {
+			if err := tx.Create(&models.TaskSkill{TaskID: t.ID, SkillID: sk.ID, Level: sk.Level}).Error; err != nil {
+				return err
+			}
+		}
+		claimed = true
+		return nil
+	})
+	return claimed && err == nil, err
+}
+
+func (s *Storage) DeleteRecurringTask(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Delete(&models.RecurringTask{}, id).Error
+}
+
+func (s *Storage) SetRecurringTaskSkills(ctx context.Context, id int, skillIDs []int) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("recurring_task_id = ?", id).Delete(&models.RecurringTaskSkill{}).Error; err != nil {
+		return err
+	}
+	for _, skillID := range skillIDs {
+		if err := db.Create(&models.RecurringTaskSkill{RecurringTaskID: id, SkillID: skillID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) SaveException(ctx context.Context, ex *models.RecurringTaskException) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "recurring_task_id"}, {Name: "occurrence"}},
+		DoUpdates: clause.AssignmentColumns([]string{"skip", "employee_id", "title", "description", "deadline"}),
+	}).Create(ex).Error
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvanceRecurringTask_OnlyFromTheReadState(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	next := time.Date(2024, time.March, 5, 9, 0, 0, 0, time.UTC)
+
+	_, err := s.AdvanceRecurringTask(tenant.WithOrg(context.Background(), 3),
+		&models.RecurringTask{ID: 5, OccurrencesCreated: 4, NextRunAt: &next}, 3)
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, `UPDATE "recurring_tasks" SET "next_run_at"='2024-03-05 09:00:00',"occurrences_created"=4`)
+	assert.Contains(t, stmt, "id = 5 AND occurrences_created = 3")
+	assert.Contains(t, stmt, `"recurring_tasks"."org_id" = 3`)
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ROLES
+
+func (s *Storage) CreateRole(ctx context.Context, r *models.RoleDefinition) error {
+	return s.db.WithContext(ctx).Create(r).Error
+}
+
+func (s *Storage) GetRoles(ctx context.Context) ([]models.RoleDefinition, error) {
+	var out []models.RoleDefinition
+	err := s.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetRoleByID(ctx context.Context, id int) (*models.RoleDefinition, error) {
+	var r models.RoleDefinition
+	if err := s.db.WithContext(ctx).Preload("Permissions").First(&r, id).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Storage) GetRoleByName(ctx context.Context, name string) (*models.RoleDefinition, error) {
+	var r models.RoleDefinition
+	if err := s.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&r).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UpdateRole saves the role and replaces its permissions.
+func (s *Storage) UpdateRole(ctx context.Context, r *models.RoleDefinition) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(r).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", r.ID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		for i := range r.Permissions {
+			r.Permissions[i].RoleID = r.ID
+		}
+		if len(r.Permissions) == 0 {
+			return nil
+		}
+		return tx.Create(&r.Permissions).Error
+	})
+}
+
+func (s *Storage) DeleteRole(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Delete(&models.RoleDefinition{}, id).Error
+}
+
+func (s *Storage) CountUsersWithRole(ctx context.Context, name string) (int64, error) {
+	var n int64
+	err := s.db.WithContext(ctx).Model(&models.User{}).Where("role = ?", name).Count(&n).Error
+	return n, err
+}
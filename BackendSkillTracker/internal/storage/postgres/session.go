@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SESSIONS
+
+// CreateSession stores the session together with its first refresh token.
+func (s *Storage) CreateSession(ctx context.Context, sess *models.Session) error {
+	return s.db.WithContext(ctx).Create(sess).Error
+}
+
+func (s *Storage) GetSessionByID(ctx context.Context, id int) (*models.Session, error) {
+	var sess models.Session
+	if err := s.db.WithContext(ctx).First(&sess, id).Error; err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (s *Storage) GetActiveSessions(ctx context.Context, userID int, now time.Time) ([]models.Session, error) {
+	var out []models.Session
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at DESC").
+		Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	if err := s.db.WithContext(ctx).Preload("Session").Where("token_hash = ?", tokenHash).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Storage) RotateRefreshToken(ctx context.Context, usedID int, next *models.RefreshToken, sess *models.Session) (bool, error) {
+	rotated := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", usedID).
+			Update("used_at", sess.LastUsedAt)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(sess).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+func (s *Storage) RevokeSession(ctx context.Context, id int, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+func (s *Storage) RevokeUserSessions(ctx context.Context, userID int, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
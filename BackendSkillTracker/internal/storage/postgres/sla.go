@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+)
+
+// SLA
+
+func (s *Storage) GetSLAPolicies(ctx context.Context) ([]models.SLAPolicy, error) {
+	var out []models.SLAPolicy
+	err := s.db.WithContext(ctx).Order(priorityOrder + " DESC").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetSLAPolicy(ctx context.Context, priority models.TaskPriority) (*models.SLAPolicy, error) {
+	var p models.SLAPolicy
+	if err := s.db.WithContext(ctx).Where("priority = ?", priority).First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Storage) SaveSLAPolicy(ctx context.Context, p *models.SLAPolicy) error {
+	return s.db.WithContext(ctx).Save(p).Error
+}
+
+// GetTasksBreachingSLA returns tasks with a missed response or resolution
+// due time that hasn't been recorded as a breach yet.
+func (s *Storage) GetTasksBreachingSLA(ctx context.Context, now time.Time) ([]models.Task, error) {
+	var out []models.Task
+	err := s.db.WithContext(ctx).
+		Where("(responded_at IS NULL AND response_breached_at IS NULL AND response_due_at < ?) OR "+
+			"(completed_at IS NULL AND resolution_breached_at IS NULL AND resolution_due_at < ?)", now, now).
+		Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) RecordSLABreaches(ctx context.Context, t *models.Task) (bool, error) {
+	breaches := []struct {
+		column string
+		at     *time.Time
+	}{
+		{"response_breached_at", t.ResponseBreachedAt},
+		{"resolution_breached_at", t.ResolutionBreachedAt},
+	}
+	recorded := false
+	for _, b := range breaches {
+		if b.at == nil {
+			continue
+		}
+		res := s.db.WithContext(ctx).Model(&models.Task{}).
+			Where("id = ? AND "+b.column+" IS NULL", t.ID).
+			Update(b.column, *b.at)
+		if res.Error != nil {
+			return recorded, res.Error
+		}
+		recorded = recorded || res.RowsAffected > 0
+	}
+	return recorded, nil
+}
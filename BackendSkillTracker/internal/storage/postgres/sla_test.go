@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordSLABreaches_OnlyUnrecordedColumns(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	due := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.UTC)
+
+	_, err := s.RecordSLABreaches(tenant.WithOrg(context.Background(), 3), &models.Task{ID: 7, ResponseBreachedAt: &due})
+	require.NoError(t, err)
+
+	require.Len(t, rec.stmts, 1)
+	stmt := rec.last()
+	assert.Contains(t, stmt, `UPDATE "tasks" SET "response_breached_at"='2024-03-04 10:00:00'`)
+	assert.Contains(t, stmt, "id = 7 AND response_breached_at IS NULL")
+	assert.Contains(t, stmt, `"tasks"."org_id" = 3`)
+	assert.NotContains(t, stmt, "resolution_breached_at")
+	assert.NotContains(t, stmt, `"title"`)
+}
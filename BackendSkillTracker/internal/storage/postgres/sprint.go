@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SPRINTS
+
+func (s *Storage) CreateSprint(ctx context.Context, sp *models.Sprint) error {
+	return s.db.WithContext(ctx).Create(sp).Error
+}
+
+func (s *Storage) GetSprintByID(ctx context.Context, id int) (*models.Sprint, error) {
+	var sp models.Sprint
+	if err := s.db.WithContext(ctx).First(&sp, id).Error; err != nil {
+		return nil, err
+	}
+	return &sp, nil
+}
+
+// GetSprints lists sprints by start date; projectID 0 lists all sprints.
+func (s *Storage) GetSprints(ctx context.Context, projectID int) ([]models.Sprint, error) {
+	query := s.db.WithContext(ctx)
+	if projectID != 0 {
+		query = query.Where("project_id = ?", projectID)
+	}
+	var out []models.Sprint
+	err := query.Order("start_date").Find(&out).Error
+	return out, err
+}
+
+// GetNextSprint returns the earliest not closed sprint of the same project
+// starting after sp.
+func (s *Storage) GetNextSprint(ctx context.Context, sp *models.Sprint) (*models.Sprint, error) {
+	query := s.db.WithContext(ctx).
+		Where("id <> ? AND status <> ? AND start_date >= ?", sp.ID, models.SprintClosed, sp.StartDate)
+	if sp.ProjectID != nil {
+		query = query.Where("project_id = ?", *sp.ProjectID)
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+	var next models.Sprint
+	if err := query.Order("start_date, id").First(&next).Error; err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+func (s *Storage) UpdateSprint(ctx context.Context, sp *models.Sprint) error {
+	return s.db.WithContext(ctx).Save(sp).Error
+}
+
+// DeleteSprint deletes the sprint and takes its tasks out of it.
+func (s *Storage) DeleteSprint(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("sprint_id = ?", id).Update("sprint_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("sprint_id = ?", id).Delete(&models.SprintTask{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Sprint{}, id).Error
+	})
+}
+
+func (s *Storage) GetSprintTasks(ctx context.Context, sprintID int) ([]models.Task, error) {
+	var out []models.Task
+	db := s.db.WithContext(ctx)
+	members := db.Session(&gorm.Session{NewDB: true}).Model(&models.SprintTask{}).
+		Select("task_id").Where("sprint_id = ?", sprintID)
+	err := db.Where("sprint_id = ? OR id IN (?)", sprintID, members).Order("id").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetStatusHistoryForTasks(ctx context.Context, taskIDs []int) ([]models.TaskStatusHistory, error) {
+	var out []models.TaskStatusHistory
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	err := s.db.WithContext(ctx).
+		Where("task_id IN ? AND event = ?", taskIDs, models.HistoryStatus).
+		Order("created_at, id").
+		Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) CloseSprint(ctx context.Context, sp *models.Sprint, taskIDs []int, moved []models.TaskStatusHistory, nextSprintID *int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range taskIDs {
+			if err := tx.Create(&models.SprintTask{SprintID: sp.ID, TaskID: id}).Error; err != nil {
+				return err
+			}
+		}
+		if len(moved) > 0 {
+			ids := make([]int, 0, len(moved))
+			for _, h := range moved {
+				ids = append(ids, h.TaskID)
+			}
+			if err := tx.Model(&models.Task{}).Where("id IN ?", ids).Update("sprint_id", nextSprintID).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&moved).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(sp).Error
+	})
+}
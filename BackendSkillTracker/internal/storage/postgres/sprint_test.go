@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSprintTasks_IncludesMembersAtClose(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.GetSprintTasks(tenant.WithOrg(context.Background(), 3), 5)
+	require.NoError(t, err)
+
+	stmt := rec.last()
+	assert.Contains(t, stmt, `sprint_id = 5 OR id IN (SELECT "task_id" FROM "sprint_tasks" WHERE sprint_id = 5)`)
+	assert.Contains(t, stmt, `"tasks"."org_id" = 3`)
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"skilltracker/internal/dto"
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelNames(t *testing.T) {
+	assert.Equal(t, []string{"urgent", "client-x"}, labelNames(" Urgent,urgent, CLIENT-X ,,"))
+	assert.Empty(t, labelNames(" , "))
+}
+
+func TestListTasks_TagFiltersAreNormalized(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.ListTasks(tenant.WithOrg(context.Background(), 3), dto.TaskFilter{Tags: "Bug, bug", TagsAll: " Urgent,urgent,client-x"})
+	require.NoError(t, err)
+
+	var stmt string
+	for _, st := range rec.stmts {
+		if strings.HasPrefix(st, `SELECT * FROM "tasks"`) {
+			stmt = st
+		}
+	}
+	assert.Contains(t, stmt, "labels.name IN ('bug')")
+	assert.Contains(t, stmt, "labels.name IN ('urgent','client-x')")
+	assert.Contains(t, stmt, "COUNT(DISTINCT labels.id) = 2")
+
+	_, err = s.ListTasks(tenant.WithOrg(context.Background(), 3), dto.TaskFilter{TagsAll: " , "})
+	require.NoError(t, err)
+	assert.NotContains(t, strings.Join(rec.stmts, "\n"), "HAVING COUNT(DISTINCT labels.id) = 0")
+}
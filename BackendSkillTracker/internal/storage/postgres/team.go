@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TEAMS
+
+func (s *Storage) CreateTeam(ctx context.Context, t *models.Team) error {
+	return s.db.WithContext(ctx).Create(t).Error
+}
+
+func (s *Storage) GetTeams(ctx context.Context) ([]models.Team, error) {
+	var out []models.Team
+	err := s.db.WithContext(ctx).Order("name").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetTeamByID(ctx context.Context, id int) (*models.Team, error) {
+	var t models.Team
+	if err := s.db.WithContext(ctx).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Storage) UpdateTeam(ctx context.Context, t *models.Team) error {
+	return s.db.WithContext(ctx).Save(t).Error
+}
+
+// DeleteTeam removes the team; its members are left without a team.
+func (s *Storage) DeleteTeam(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("team_id = ?", id).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Team{}, id).Error
+	})
+}
+
+func (s *Storage) GetTeamMembers(ctx context.Context, teamID int) ([]models.User, error) {
+	var out []models.User
+	err := s.db.WithContext(ctx).Where("team_id = ?", teamID).Order("id").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) SetUserTeam(ctx context.Context, userID int, teamID *int) error {
+	return s.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("team_id", teamID).Error
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"skilltracker/internal/dto"
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTasks_EmptyTeamScope(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.ListTasks(tenant.WithOrg(context.Background(), 3), dto.TaskFilter{TeamIDs: []int{}, TeamViewerID: 200})
+	require.NoError(t, err)
+
+	var stmt string
+	for _, st := range rec.stmts {
+		if strings.Contains(st, `FROM "tasks"`) {
+			stmt = st
+			break
+		}
+	}
+	// A manager without teams keeps only the tasks they created. The team
+	// subquery is scoped to the organization like the outer query.
+	assert.Contains(t, stmt, "team_id IN (NULL)")
+	assert.Contains(t, stmt, `"users"."org_id" = 3`)
+	assert.Contains(t, stmt, "creator_id = 200")
+}
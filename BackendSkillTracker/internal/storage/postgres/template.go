@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TASK TEMPLATES
+
+func preloadTemplate(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Skills.Skill").
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB { return db.Order("position") })
+}
+
+func (s *Storage) CreateTemplate(ctx context.Context, t *models.TaskTemplate) error {
+	return s.db.WithContext(ctx).Omit("Skills.Skill").Create(t).Error
+}
+
+func (s *Storage) GetTemplateByID(ctx context.Context, id int) (*models.TaskTemplate, error) {
+	var t models.TaskTemplate
+	if err := preloadTemplate(s.db.WithContext(ctx)).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Storage) GetTemplatesVisibleTo(ctx context.Context, userID int) ([]models.TaskTemplate, error) {
+	var out []models.TaskTemplate
+	err := preloadTemplate(s.db.WithContext(ctx)).
+		Where("creator_id = ? OR shared = ?", userID, true).
+		Order("title").
+		Find(&out).Error
+	return out, err
+}
+
+// UpdateTemplate saves the template and replaces its skills and checklist.
+func (s *Storage) UpdateTemplate(ctx context.Context, t *models.TaskTemplate) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Skills", "Checklist").Save(t).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", t.ID).Delete(&models.TaskTemplateSkill{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", t.ID).Delete(&models.TaskTemplateChecklistItem{}).Error; err != nil {
+			return err
+		}
+		for i := range t.Skills {
+			t.Skills[i].TemplateID = t.ID
+			if err := tx.Omit("Skill").Create(&t.Skills[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range t.Checklist {
+			t.Checklist[i].ID = 0
+			t.Checklist[i].TemplateID = t.ID
+			if err := tx.Create(&t.Checklist[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) DeleteTemplate(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Delete(&models.TaskTemplate{}, id).Error
+}
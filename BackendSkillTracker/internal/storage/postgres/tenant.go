@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"errors"
+	"reflect"
+
+	"skilltracker/internal/tenant"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// orgField is the field every tenant model has. Join tables don't carry
+// it; they are only reached through IDs of rows that were loaded scoped.
+const orgField = "OrgID"
+
+var errTenantMismatch = errors.New("tenant: record belongs to another organization")
+
+// registerTenantScope makes every statement on a tenant model filter by,
+// or write, the organization of its context. Statements without an
+// organization fail unless the context is a tenant.System one.
+func registerTenantScope(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:row", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", scopeTenantWrite); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:delete", scopeTenant); err != nil {
+		return err
+	}
+	return cb.Create().Before("gorm:create").Register("tenant:create", assignTenant)
+}
+
+// tenantOf returns the organization a statement is limited to. ok is false
+// when the statement isn't on a tenant model or runs in a system context.
+func tenantOf(db *gorm.DB) (field *schema.Field, orgID int, ok bool) {
+	stmt := db.Statement
+	if stmt.Schema == nil {
+		return nil, 0, false
+	}
+	field = stmt.Schema.LookUpField(orgField)
+	if field == nil {
+		return nil, 0, false
+	}
+	if orgID, ok = tenant.OrgID(stmt.Context); ok {
+		return field, orgID, true
+	}
+	if !tenant.IsSystem(stmt.Context) {
+		db.AddError(tenant.ErrMissing)
+	}
+	return nil, 0, false
+}
+
+func orgCondition(field *schema.Field, orgID int) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: orgID}
+}
+
+func scopeTenant(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if field, orgID, ok := tenantOf(db); ok {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{orgCondition(field, orgID)}})
+	}
+}
+
+// scopeTenantWrite scopes an update and pins the organization of the
+// written record, so Save can't move it to another tenant.
+func scopeTenantWrite(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if field, orgID, ok := tenantOf(db); ok {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{orgCondition(field, orgID)}})
+		setOrg(db, field, orgID)
+	}
+}
+
+// assignTenant sets the organization of new records. An upsert only
+// updates rows of the same organization.
+func assignTenant(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	field, orgID, ok := tenantOf(db)
+	if !ok {
+		return
+	}
+	setOrg(db, field, orgID)
+	if c, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, orgCondition(field, orgID))
+			db.Statement.AddClause(onConflict)
+		}
+	}
+}
+
+func setOrg(db *gorm.DB, field *schema.Field, orgID int) {
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setRecordOrg(db, field, reflect.Indirect(rv.Index(i)), orgID)
+		}
+	case reflect.Struct:
+		setRecordOrg(db, field, rv, orgID)
+	}
+}
+
+func setRecordOrg(db *gorm.DB, field *schema.Field, rv reflect.Value, orgID int) {
+	if rv.Kind() != reflect.Struct || rv.Type() != db.Statement.Schema.ModelType {
+		return
+	}
+	ctx := db.Statement.Context
+	if v, zero := field.ValueOf(ctx, rv); !zero {
+		if id, _ := v.(int); id != orgID {
+			db.AddError(errTenantMismatch)
+		}
+		return
+	}
+	db.AddError(field.Set(ctx, rv, orgID))
+}
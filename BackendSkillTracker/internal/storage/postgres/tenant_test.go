@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"skilltracker/internal/dto"
+	"skilltracker/internal/models"
+	"skilltracker/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder collects the statements gorm builds.
+type sqlRecorder struct {
+	logger.Interface
+	stmts []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.stmts = append(r.stmts, sql)
+}
+
+func (r *sqlRecorder) last() string {
+	if len(r.stmts) == 0 {
+		return ""
+	}
+	return r.stmts[len(r.stmts)-1]
+}
+
+// newDryRunStorage builds SQL without a database so the tenant scoping can
+// be checked on the generated statements.
+func newDryRunStorage(t *testing.T) (*Storage, *sqlRecorder) {
+	t.Helper()
+	rec := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 rec,
+	})
+	require.NoError(t, err)
+	require.NoError(t, registerTenantScope(db))
+	return &Storage{db: db}, rec
+}
+
+func TestTenantScope_QueriesAreFiltered(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	ctx := tenant.WithOrg(context.Background(), 7)
+
+	_, err := s.GetUsers(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, rec.last(), `"users"."org_id" = 7`)
+
+	_, err = s.GetUserByUsername(ctx, "admin")
+	require.NoError(t, err)
+	assert.Contains(t, rec.last(), `"users"."org_id" = 7`)
+
+	_, err = s.ListTasks(ctx, dto.TaskFilter{Search: "x"})
+	require.NoError(t, err)
+	for _, stmt := range rec.stmts {
+		if strings.Contains(stmt, `FROM "tasks"`) {
+			assert.Contains(t, stmt, `"tasks"."org_id" = 7`)
+		}
+	}
+
+	_, err = s.GetSkills(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, rec.last(), `"skills"."org_id" = 7`)
+
+	_, err = s.GetCommentsByTaskID(ctx, 1)
+	require.NoError(t, err)
+	for _, stmt := range rec.stmts {
+		if strings.Contains(stmt, `FROM "comments"`) {
+			assert.Contains(t, stmt, `"comments"."org_id" = 7`)
+		}
+	}
+}
+
+func TestTenantScope_PreloadsAreFiltered(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	ctx := tenant.WithOrg(context.Background(), 7)
+
+	_, err := s.GetEmployeesWithSkills(ctx)
+	require.NoError(t, err)
+	for _, stmt := range rec.stmts {
+		for _, table := range []string{"users", "skills"} {
+			if strings.Contains(stmt, `FROM "`+table+`"`) {
+				assert.Contains(t, stmt, `"`+table+`"."org_id" = 7`, stmt)
+			}
+		}
+	}
+}
+
+func TestTenantScope_WritesAreFiltered(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	ctx := tenant.WithOrg(context.Background(), 7)
+
+	require.NoError(t, s.UpdateUser(ctx, &models.User{ID: 5, Username: "bob"}))
+	assert.Contains(t, rec.last(), `"users"."org_id" = 7`)
+	assert.Contains(t, rec.last(), `"org_id"=7`)
+
+	require.NoError(t, s.DeleteTask(ctx, 5))
+	assert.Contains(t, rec.last(), `"tasks"."org_id" = 7`)
+
+	require.NoError(t, s.DeleteComment(ctx, 5))
+	assert.Contains(t, rec.last(), `"comments"."org_id" = 7`)
+}
+
+func TestTenantScope_CreateSetsOrganization(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	ctx := tenant.WithOrg(context.Background(), 7)
+
+	task := &models.Task{Title: "t", EmployeeID: 1, CreatorID: 1}
+	require.NoError(t, s.CreateTask(ctx, task))
+	assert.Equal(t, 7, task.OrgID)
+	assert.Contains(t, rec.last(), `INSERT INTO "tasks"`)
+
+	f := &models.FileAttachment{TaskID: 1, FileName: "a.txt"}
+	require.NoError(t, s.CreateAttachment(ctx, f))
+	assert.Equal(t, 7, f.OrgID)
+}
+
+func TestTenantScope_RejectsOtherOrganization(t *testing.T) {
+	s, _ := newDryRunStorage(t)
+	ctx := tenant.WithOrg(context.Background(), 7)
+
+	err := s.CreateTask(ctx, &models.Task{OrgID: 8, Title: "t"})
+	assert.True(t, errors.Is(err, errTenantMismatch))
+
+	err = s.UpdateUser(ctx, &models.User{ID: 5, OrgID: 8})
+	assert.True(t, errors.Is(err, errTenantMismatch))
+}
+
+func TestTenantScope_MissingTenant(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	ctx := context.Background()
+
+	_, err := s.GetUsers(ctx)
+	assert.True(t, errors.Is(err, tenant.ErrMissing))
+
+	err = s.CreateSkill(ctx, &models.Skill{Name: "go"})
+	assert.True(t, errors.Is(err, tenant.ErrMissing))
+
+	err = s.DeleteUser(ctx, 1)
+	assert.True(t, errors.Is(err, tenant.ErrMissing))
+
+	assert.Empty(t, rec.stmts, "no statement may be built without a tenant")
+}
+
+func TestTenantScope_SystemAndOrganizations(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	_, err := s.GetRefreshToken(tenant.System(context.Background()), "hash")
+	require.NoError(t, err)
+	for _, stmt := range rec.stmts {
+		assert.NotContains(t, stmt, "org_id")
+	}
+
+	// Organizations are the tenants themselves and aren't scoped.
+	_, err = s.GetOrganizationBySlug(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Contains(t, rec.last(), `FROM "organizations"`)
+
+	// An organization in the context wins over the system flag.
+	ctx := tenant.WithOrg(tenant.System(context.Background()), 7)
+	_, err = s.GetUsers(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, rec.last(), `"users"."org_id" = 7`)
+}
+
+func TestTokenVersion(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+	ctx := tenant.WithOrg(context.Background(), 7)
+
+	require.NoError(t, s.BumpTokenVersion(ctx, 5))
+	assert.Contains(t, rec.last(), `"token_version"=token_version + 1`)
+	assert.Contains(t, rec.last(), `"users"."org_id" = 7`)
+
+	// Saving a user loaded earlier must not roll a bump back.
+	require.NoError(t, s.UpdateUser(ctx, &models.User{ID: 5, Username: "bob", TokenVersion: 1}))
+	for _, stmt := range rec.stmts[1:] {
+		assert.NotContains(t, stmt, "token_version")
+	}
+}
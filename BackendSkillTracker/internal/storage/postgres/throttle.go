@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LOGIN THROTTLING
+
+func (s *Storage) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error) {
+	var out []models.LoginThrottle
+	err := s.db.WithContext(ctx).Where("key IN ?", keys).Find(&out).Error
+	return out, err
+}
+
+// RecordLoginFailure counts the failure in one upsert, so concurrent
+// attempts on several replicas are all counted.
+func (s *Storage) RecordLoginFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	stale := at.Add(-window)
+	t := models.LoginThrottle{Key: key, Failures: 1, LastFailureAt: at}
+	err := s.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END", stale),
+				"locked_until":    gorm.Expr("CASE WHEN login_throttles.last_failure_at < ? THEN NULL ELSE login_throttles.locked_until END", stale),
+				"last_failure_at": at,
+			}),
+		},
+		clause.Returning{},
+	).Create(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.LoginThrottle{}).Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (s *Storage) ResetLoginThrottle(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	s, rec := newDryRunStorage(t)
+
+	// Throttling runs before anyone is authenticated, so there's no tenant.
+	_, err := s.RecordLoginFailure(context.Background(), "ip:10.0.0.1", time.Now(), 15*time.Minute)
+	require.NoError(t, err)
+	stmt := rec.last()
+	assert.Contains(t, stmt, `INSERT INTO "login_throttles"`)
+	assert.Contains(t, stmt, `ON CONFLICT ("key") DO UPDATE`)
+	assert.Contains(t, stmt, "login_throttles.failures + 1")
+	assert.Contains(t, stmt, "RETURNING")
+	assert.NotContains(t, stmt, "org_id")
+}
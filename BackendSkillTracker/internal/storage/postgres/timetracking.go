@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+)
+
+// TIME TRACKING
+
+func (s *Storage) CreateTimeEntry(ctx context.Context, e *models.TimeEntry) error {
+	return s.db.WithContext(ctx).Omit("Task").Create(e).Error
+}
+
+func (s *Storage) GetTimeEntryByID(ctx context.Context, id int) (*models.TimeEntry, error) {
+	var e models.TimeEntry
+	if err := s.db.WithContext(ctx).First(&e, id).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *Storage) GetRunningTimeEntry(ctx context.Context, userID int) (*models.TimeEntry, error) {
+	var e models.TimeEntry
+	err := s.db.WithContext(ctx).Where("user_id = ? AND ended_at IS NULL", userID).First(&e).Error
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *Storage) UpdateTimeEntry(ctx context.Context, e *models.TimeEntry) error {
+	return s.db.WithContext(ctx).Omit("Task").Save(e).Error
+}
+
+func (s *Storage) DeleteTimeEntry(ctx context.Context, id int) error {
+	return s.db.WithContext(ctx).Delete(&models.TimeEntry{}, id).Error
+}
+
+func (s *Storage) GetTimeEntriesByTaskID(ctx context.Context, taskID int) ([]models.TimeEntry, error) {
+	var out []models.TimeEntry
+	err := s.db.WithContext(ctx).Where("task_id = ?", taskID).Order("started_at").Find(&out).Error
+	return out, err
+}
+
+func (s *Storage) GetTimeEntriesByUser(ctx context.Context, userID int, from, to time.Time) ([]models.TimeEntry, error) {
+	var out []models.TimeEntry
+	err := s.db.WithContext(ctx).
+		Preload("Task").
+		Where("user_id = ? AND started_at >= ? AND started_at < ?", userID, from, to).
+		Order("started_at").
+		Find(&out).Error
+	return out, err
+}
+
+// GetLoggedMinutesByTask sums finished entries per task, optionally limited
+// to entries started within [from, to).
+func (s *Storage) GetLoggedMinutesByTask(ctx context.Context, from, to *time.Time) (map[int]int, error) {
+	query := s.db.WithContext(ctx).
+		Model(&models.TimeEntry{}).
+		Select("task_id, SUM(duration_minutes) AS minutes").
+		Where("ended_at IS NOT NULL")
+	if from != nil {
+		query = query.Where("started_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("started_at < ?", *to)
+	}
+
+	var rows []struct {
+		TaskID  int
+		Minutes int
+	}
+	if err := query.Group("task_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]int, len(rows))
+	for _, r := range rows {
+		out[r.TaskID] = r.Minutes
+	}
+	return out, nil
+}
+
+func (s *Storage) GetTimesheet(ctx context.Context, userID int, weekStart time.Time) (*models.Timesheet, error) {
+	var ts models.Timesheet
+	err := s.db.WithContext(ctx).Where("user_id = ? AND week_start = ?", userID, weekStart).First(&ts).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ts, nil
+}
+
+func (s *Storage) GetTimesheetByID(ctx context.Context, id int) (*models.Timesheet, error) {
+	var ts models.Timesheet
+	if err := s.db.WithContext(ctx).First(&ts, id).Error; err != nil {
+		return nil, err
+	}
+	return &ts, nil
+}
+
+func (s *Storage) SaveTimesheet(ctx context.Context, ts *models.Timesheet) error {
+	return s.db.WithContext(ctx).Save(ts).Error
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"skilltracker/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TWO-FACTOR AUTHENTICATION
+
+func (s *Storage) GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	var c models.TOTPCredential
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *Storage) SaveTOTPCredential(ctx context.Context, c *models.TOTPCredential) error {
+	return s.db.WithContext(ctx).Save(c).Error
+}
+
+func (s *Storage) EnableTwoFactor(ctx context.Context, c *models.TOTPCredential, codes []models.RecoveryCode) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(c).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, c.UserID, codes)
+	})
+}
+
+func (s *Storage) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	res := s.db.WithContext(ctx).Model(&models.TOTPCredential{}).
+		Where("user_id = ? AND last_step < ?", userID, step).
+		Update("last_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (s *Storage) DeleteTwoFactor(ctx context.Context, userID int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TOTPCredential{}).Error
+	})
+}
+
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, userID int, codes []models.RecoveryCode) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID int, codes []models.RecoveryCode) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int, codeHash string, at time.Time) (bool, error) {
+	res := s.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (s *Storage) CountRecoveryCodes(ctx context.Context, userID int) (int64, error) {
+	var n int64
+	err := s.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
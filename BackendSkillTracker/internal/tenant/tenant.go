@@ -0,0 +1,47 @@
+// Package tenant carries the organization of a request through the context.
+// The storage layer scopes every query on a tenant model to this
+// organization and refuses queries without one.
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+type ctxKey int
+
+const (
+	orgKey ctxKey = iota
+	systemKey
+)
+
+// ErrMissing is returned by the storage layer for a query on tenant data
+// without an organization in the context.
+var ErrMissing = errors.New("tenant: missing organization")
+
+// WithOrg returns a context scoped to the organization.
+func WithOrg(ctx context.Context, orgID int) context.Context {
+	return context.WithValue(ctx, orgKey, orgID)
+}
+
+// OrgID returns the organization of the context.
+func OrgID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(orgKey).(int)
+	return id, ok && id > 0
+}
+
+// System returns a context that bypasses tenant scoping. It is only meant
+// for lookups that establish the tenant, such as resolving a refresh token.
+func System(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey, true)
+}
+
+// IsSystem reports whether the context bypasses tenant scoping. A context
+// scoped to an organization is never a system one.
+func IsSystem(ctx context.Context) bool {
+	if _, ok := OrgID(ctx); ok {
+		return false
+	}
+	sys, _ := ctx.Value(systemKey).(bool)
+	return sys
+}
@@ -1,10 +1,13 @@
 package transport
 
 import (
+	"net"
 	"net/http"
 	"skilltracker/internal/config"
 	"skilltracker/internal/handler"
 	m "skilltracker/internal/middleware"
+	"skilltracker/internal/permission"
+	jwtutil "skilltracker/internal/utils/jwt"
 
 	"context"
 	"os"
@@ -19,13 +22,16 @@ import (
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
-func NewServer(jwtSecret []byte, h *handler.Handler, cfg *config.Config) *http.Server {
+func NewServer(keys *jwtutil.KeySet, h *handler.Handler, cfg *config.Config) *http.Server {
 	e := echo.New()
+	e.IPExtractor = ipExtractor(cfg.HTTPServer.TrustedProxies)
 	e.Use(middleware.Recover())
+	e.Use(middleware.RequestID())
 	e.Use(middleware.Logger())
 	e.Use(echoprometheus.NewMiddleware("skilltracker"))
 
 	e.GET("/metrics", echoprometheus.NewHandler())
+	e.GET("/.well-known/jwks.json", h.JWKS)
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{
 			"http://localhost:3000",
@@ -44,46 +50,116 @@ func NewServer(jwtSecret []byte, h *handler.Handler, cfg *config.Config) *http.S
 		},
 		ExposeHeaders: []string{
 			"Content-Type",
+			"Content-Disposition",
+			echo.HeaderXRequestID,
 		},
 		AllowCredentials: true,
 	}))
 
 	api := e.Group("/api")
 	v1 := api.Group("/v1")
+	v1.Use(m.RequestInfo())
 
 	// Public
 	v1.POST("/login", h.Login)
 	v1.POST("/refresh", h.RefreshToken)
+	v1.POST("/login/2fa", h.VerifyLoginTwoFactor)
+	v1.POST("/login/2fa/enroll", h.EnrollLoginTwoFactor)
+	v1.POST("/login/password", h.ChangeLoginPassword)
+	v1.GET("/password/policy", h.GetPasswordPolicy)
+	v1.POST("/password/forgot", h.ForgotPassword)
+	v1.POST("/password/reset", h.ResetPassword)
+	v1.GET("/oidc/:provider/authorize", h.StartOIDCLogin)
+	v1.POST("/oidc/:provider/callback", h.CompleteOIDCLogin)
 	v1.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
 	// Protected
 	auth := v1.Group("")
-	auth.Use(m.AuthRequired([]byte(cfg.Auth.JWTSecret)))
+	auth.Use(m.AuthRequired(keys, h.Session(), h.APIToken()), m.ImpersonationAudit(h.Impersonation()))
 
-	auth.POST("/logout", h.Logout)
+	// interactive keeps personal access tokens and impersonation away from
+	// the credentials and sessions of the user.
+	interactive := m.InteractiveOnly()
 
-	// Users (only manager)
-	managerOnly := m.RoleRequired("manager")
-	auth.POST("/users", h.CreateUser, managerOnly)
-	auth.GET("/users", h.GetUsers, managerOnly)
-	auth.GET("/users/:id", h.GetUserByID, managerOnly)
-	auth.PUT("/users/:id", h.UpdateUser, managerOnly)
-	auth.DELETE("/users/:id", h.DeleteUser, managerOnly)
+	auth.POST("/logout", h.Logout, interactive)
+	auth.POST("/logout/all", h.LogoutAll, interactive)
+	auth.GET("/organization", h.GetOrganization)
 
-	// User skills (manager assigns, anyone can view)
-	auth.POST("/users/:id/skills/:skill_id", h.AssignSkillToUser, managerOnly)
-	auth.DELETE("/users/:id/skills/:skill_id", h.RemoveSkillFromUser, managerOnly)
+	// Two-factor authentication
+	auth.GET("/2fa", h.GetTwoFactorStatus, interactive)
+	auth.POST("/2fa/enroll", h.EnrollTwoFactor, interactive)
+	auth.POST("/2fa/confirm", h.ConfirmTwoFactor, interactive)
+	auth.POST("/2fa/recovery-codes", h.RegenerateRecoveryCodes, interactive)
+	auth.DELETE("/2fa", h.DisableTwoFactor, interactive)
+
+	// can guards a route with a named permission, see internal/permission.
+	can := func(p permission.Permission) echo.MiddlewareFunc {
+		return m.PermissionRequired(h.Access(), p)
+	}
+
+	// Roles and permissions
+	auth.GET("/permissions", h.GetPermissions, can(permission.RoleManage))
+	auth.GET("/roles", h.GetRoles, can(permission.RoleManage))
+	auth.POST("/roles", h.CreateRole, interactive, can(permission.RoleManage))
+	auth.PUT("/roles/:id", h.UpdateRole, interactive, can(permission.RoleManage))
+	auth.DELETE("/roles/:id", h.DeleteRole, interactive, can(permission.RoleManage))
+
+	// Users. Changing accounts and their credentials needs a regular login.
+	auth.POST("/users", h.CreateUser, interactive, can(permission.UserManage))
+	auth.GET("/users", h.GetUsers, can(permission.UserRead))
+	auth.GET("/users/:id", h.GetUserByID, can(permission.UserRead))
+	auth.PUT("/users/:id", h.UpdateUser, interactive, can(permission.UserManage))
+	auth.DELETE("/users/:id", h.DeleteUser, interactive, can(permission.UserManage))
+	auth.DELETE("/users/:id/sessions", h.RevokeUserSessions, interactive, can(permission.UserManage))
+	auth.POST("/users/:id/unlock", h.UnlockUser, interactive, can(permission.UserManage))
+	auth.DELETE("/users/:id/2fa", h.ResetTwoFactor, interactive, can(permission.UserManage))
+	auth.DELETE("/users/:id/tokens", h.RevokeUserAPITokens, interactive, can(permission.UserManage))
+	auth.POST("/users/:id/impersonate", h.ImpersonateUser, interactive, can(permission.UserImpersonate))
+
+	// Sessions
+	auth.GET("/sessions", h.GetMySessions, interactive)
+	auth.DELETE("/sessions/:id", h.RevokeMySession, interactive)
+
+	// Own profile. Changes need a regular login.
+	auth.GET("/me", h.GetProfile)
+	auth.PUT("/me", h.UpdateProfile, interactive)
+	auth.POST("/me/password", h.ChangePassword, interactive)
+	auth.POST("/me/avatar", h.UploadAvatar, interactive)
+	auth.DELETE("/me/avatar", h.DeleteAvatar, interactive)
+	auth.GET("/me/notification-preferences", h.GetNotificationPreferences)
+	auth.PUT("/me/notification-preferences", h.UpdateNotificationPreferences, interactive)
+
+	// Personal API tokens
+	auth.GET("/tokens", h.GetAPITokens, interactive)
+	auth.POST("/tokens", h.CreateAPIToken, interactive)
+	auth.DELETE("/tokens/:id", h.RevokeAPIToken, interactive)
+
+	// Audit log
+	auth.GET("/audit-log", h.GetAuditLog, can(permission.AuditRead))
+	auth.GET("/audit-log/export", h.ExportAuditLog, can(permission.AuditRead))
+
+	// Teams
+	auth.GET("/teams", h.GetTeams, can(permission.UserRead))
+	auth.GET("/teams/:id", h.GetTeamByID, can(permission.UserRead))
+	auth.POST("/teams", h.CreateTeam, can(permission.TeamManage))
+	auth.PUT("/teams/:id", h.UpdateTeam, can(permission.TeamManage))
+	auth.DELETE("/teams/:id", h.DeleteTeam, can(permission.TeamManage))
+	auth.PUT("/users/:id/team", h.SetUserTeam, can(permission.TeamManage))
+
+	// User skills (users see their own, user.read allows any)
+	auth.POST("/users/:id/skills/:skill_id", h.AssignSkillToUser, can(permission.SkillAssign))
+	auth.DELETE("/users/:id/skills/:skill_id", h.RemoveSkillFromUser, can(permission.SkillAssign))
 	auth.GET("/users/:id/skills", h.GetUserSkills)
 
 	// Skills
-	auth.POST("/skills", h.CreateSkill, managerOnly)
+	auth.POST("/skills", h.CreateSkill, can(permission.SkillManage))
 	auth.GET("/skills", h.GetSkills)
-	auth.DELETE("/skills/:id", h.DeleteSkill, managerOnly)
+	auth.DELETE("/skills/:id", h.DeleteSkill, can(permission.SkillManage))
 
 	// Tasks
-	auth.POST("/tasks", h.CreateTask, managerOnly)
+	auth.POST("/tasks", h.CreateTask, can(permission.TaskCreate))
 	auth.GET("/tasks", h.ListTasks)
 	auth.GET("/tasks/my", h.GetMyTasks)
 	auth.GET("/tasks/:id", h.GetTaskByID)
@@ -91,13 +167,94 @@ func NewServer(jwtSecret []byte, h *handler.Handler, cfg *config.Config) *http.S
 	auth.DELETE("/tasks/:id", h.DeleteTask)
 	auth.POST("/tasks/:id/attachments", h.UploadAttachment)
 	auth.GET("/tasks/:id/history", h.GetTaskHistory)
-	auth.GET("/tasks/:id/recommended-employees", h.GetRecommendedEmployees, managerOnly)
+	auth.GET("/tasks/:id/recommended-employees", h.GetRecommendedEmployees, can(permission.TaskAssign))
+
+	// Task assignees and watchers
+	auth.PUT("/tasks/:id/assignees", h.SetTaskAssignees)
+	auth.POST("/tasks/:id/reassign", h.ReassignTask)
+	auth.POST("/tasks/:id/watchers/:user_id", h.AddTaskWatcher)
+	auth.DELETE("/tasks/:id/watchers/:user_id", h.RemoveTaskWatcher)
 
 	// Task skills (only task creator manages)
-	auth.POST("/tasks/:id/skills/:skill_id", h.AddSkillToTask, managerOnly)
-	auth.DELETE("/tasks/:id/skills/:skill_id", h.RemoveSkillFromTask, managerOnly)
+	auth.POST("/tasks/:id/skills/:skill_id", h.AddSkillToTask, can(permission.TaskCreate))
+	auth.DELETE("/tasks/:id/skills/:skill_id", h.RemoveSkillFromTask, can(permission.TaskCreate))
 	auth.GET("/tasks/:id/skills", h.GetTaskSkills)
 
+	// Task checklists (task creator or assignee)
+	auth.GET("/tasks/:id/checklist", h.GetChecklist)
+	auth.POST("/tasks/:id/checklist", h.AddChecklistItem)
+	auth.PUT("/tasks/:id/checklist/order", h.ReorderChecklist)
+	auth.PUT("/tasks/:id/checklist/:item_id", h.UpdateChecklistItem)
+	auth.DELETE("/tasks/:id/checklist/:item_id", h.DeleteChecklistItem)
+	auth.POST("/tasks/:id/checklist/:item_id/check", h.CheckChecklistItem)
+	auth.POST("/tasks/:id/checklist/:item_id/uncheck", h.UncheckChecklistItem)
+
+	// Task templates
+	auth.POST("/task-templates", h.CreateTemplate, can(permission.TemplateManage))
+	auth.GET("/task-templates", h.GetTemplates, can(permission.TemplateManage))
+	auth.GET("/task-templates/:id", h.GetTemplateByID, can(permission.TemplateManage))
+	auth.PUT("/task-templates/:id", h.UpdateTemplate, can(permission.TemplateManage))
+	auth.DELETE("/task-templates/:id", h.DeleteTemplate, can(permission.TemplateManage))
+	auth.POST("/task-templates/:id/tasks", h.CreateTaskFromTemplate, can(permission.TaskCreate))
+
+	// Recurring tasks
+	auth.POST("/recurring-tasks", h.CreateRecurringTask, can(permission.RecurringManage))
+	auth.GET("/recurring-tasks", h.GetRecurringTasks, can(permission.RecurringManage))
+	auth.GET("/recurring-tasks/:id", h.GetRecurringTaskByID, can(permission.RecurringManage))
+	auth.PUT("/recurring-tasks/:id", h.UpdateRecurringTask, can(permission.RecurringManage))
+	auth.DELETE("/recurring-tasks/:id", h.DeleteRecurringTask, can(permission.RecurringManage))
+	auth.GET("/recurring-tasks/:id/occurrences", h.GetOccurrences, can(permission.RecurringManage))
+	auth.PUT("/recurring-tasks/:id/occurrences/:n", h.UpdateOccurrence, can(permission.RecurringManage))
+	auth.POST("/recurring-tasks/:id/occurrences/:n/skip", h.SkipOccurrence, can(permission.RecurringManage))
+
+	// Time tracking
+	auth.POST("/tasks/:id/time/start", h.StartTimer)
+	auth.POST("/time-entries/stop", h.StopTimer)
+	auth.POST("/tasks/:id/time", h.LogTime)
+	auth.GET("/tasks/:id/time", h.GetTaskTimeEntries)
+	auth.DELETE("/time-entries/:id", h.DeleteTimeEntry)
+	auth.GET("/timesheets/my", h.GetMyTimesheet)
+	auth.POST("/timesheets/submit", h.SubmitTimesheet)
+	auth.GET("/users/:id/timesheets", h.GetUserTimesheet, can(permission.TimesheetReview))
+	auth.POST("/timesheets/:id/approve", h.ApproveTimesheet, can(permission.TimesheetReview))
+	auth.POST("/timesheets/:id/reject", h.RejectTimesheet, can(permission.TimesheetReview))
+	auth.GET("/reports/time", h.GetTimeReport, can(permission.ReportRead))
+
+	// Projects
+	auth.POST("/projects", h.CreateProject, can(permission.ProjectManage))
+	auth.GET("/projects", h.GetProjects)
+	auth.GET("/projects/:id", h.GetProjectByID)
+	auth.PUT("/projects/:id", h.UpdateProject, can(permission.ProjectManage))
+	auth.DELETE("/projects/:id", h.DeleteProject, can(permission.ProjectManage))
+
+	// Sprints
+	auth.POST("/sprints", h.CreateSprint, can(permission.SprintManage))
+	auth.GET("/sprints", h.GetSprints)
+	auth.GET("/sprints/:id", h.GetSprintByID)
+	auth.PUT("/sprints/:id", h.UpdateSprint, can(permission.SprintManage))
+	auth.DELETE("/sprints/:id", h.DeleteSprint, can(permission.SprintManage))
+	auth.POST("/sprints/:id/start", h.StartSprint, can(permission.SprintManage))
+	auth.POST("/sprints/:id/close", h.CloseSprint, can(permission.SprintManage))
+	auth.GET("/sprints/:id/burndown", h.GetBurndown)
+
+	// Labels
+	auth.GET("/labels", h.GetLabels)
+	auth.GET("/labels/usage", h.GetLabelUsage, can(permission.ReportRead))
+	auth.POST("/labels", h.CreateLabel, can(permission.LabelManage))
+	auth.PUT("/labels/:id", h.UpdateLabel, can(permission.LabelManage))
+	auth.DELETE("/labels/:id", h.DeleteLabel, can(permission.LabelManage))
+	auth.POST("/tasks/:id/labels/:label_id", h.AddLabelToTask)
+	auth.DELETE("/tasks/:id/labels/:label_id", h.RemoveLabelFromTask)
+
+	// SLA policies
+	auth.GET("/sla-policies", h.GetSLAPolicies)
+	auth.PUT("/sla-policies/:priority", h.UpdateSLAPolicy, can(permission.SLAManage))
+
+	// Notifications
+	auth.GET("/notifications", h.GetNotifications)
+	auth.POST("/notifications/read-all", h.MarkAllNotificationsRead)
+	auth.POST("/notifications/:id/read", h.MarkNotificationRead)
+
 	// Comments
 	auth.POST("/comments", h.CreateComment)
 	auth.GET("/tasks/:task_id/comments", h.GetCommentsByTaskID)
@@ -131,3 +288,24 @@ func Run(s *http.Server) error {
 	defer cancel()
 	return s.Shutdown(ctx)
 }
+
+// ipExtractor decides the client IP that login throttling, sessions and API
+// tokens see. X-Forwarded-For is only read from the configured proxies;
+// otherwise any client could pick its own IP with the header.
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, p := range trustedProxies {
+		// The ranges are checked by config.Validate.
+		if _, ipNet, err := net.ParseCIDR(p); err == nil {
+			opts = append(opts, echo.TrustIPRange(ipNet))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPExtractor(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/login", nil)
+	req.RemoteAddr = "10.0.0.5:41000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	t.Run("the header is ignored without trusted proxies", func(t *testing.T) {
+		assert.Equal(t, "10.0.0.5", ipExtractor(nil)(req))
+	})
+
+	t.Run("trusted proxies forward the client IP", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.7", ipExtractor([]string{"10.0.0.0/8"})(req))
+	})
+
+	t.Run("other proxies are not believed", func(t *testing.T) {
+		assert.Equal(t, "10.0.0.5", ipExtractor([]string{"192.168.0.0/16"})(req))
+	})
+}
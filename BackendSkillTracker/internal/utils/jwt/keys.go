@@ -0,0 +1,184 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a signing key identified by its kid. Keys without a private part
+// only verify tokens, e.g. a retired key whose tokens haven't expired yet.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// NewKey wraps an RSA (RS256) or Ed25519 (EdDSA) private key.
+func NewKey(id string, priv crypto.Signer) (*Key, error) {
+	k, err := NewPublicKey(id, priv.Public())
+	if err != nil {
+		return nil, err
+	}
+	k.private = priv
+	return k, nil
+}
+
+// NewPublicKey wraps an RSA or Ed25519 public key for verification only.
+func NewPublicKey(id string, pub crypto.PublicKey) (*Key, error) {
+	var method jwt.SigningMethod
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		if p.N.BitLen() < 2048 {
+			return nil, errors.New("jwt: RSA keys must have at least 2048 bits")
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %T", pub)
+	}
+	if id == "" {
+		id = thumbprint(pub)
+	}
+	return &Key{ID: id, Method: method, public: pub}, nil
+}
+
+// ParsePrivateKeyPEM reads a PKCS#8 or PKCS#1 private key. An empty id is
+// replaced by a thumbprint of the key.
+func ParsePrivateKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM data")
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewKey(id, k)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := k.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("jwt: unsupported key type %T", k)
+	}
+	return NewKey(id, signer)
+}
+
+// ParsePublicKeyPEM reads a PKIX public key.
+func ParsePublicKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM data")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKey(id, pub)
+}
+
+// DeriveKey derives an Ed25519 key from a secret, for setups without key
+// files. Anyone knowing the secret can sign tokens with it.
+func DeriveKey(secret []byte) *Key {
+	seed := sha256.Sum256(secret)
+	k, _ := NewKey("", ed25519.NewKeyFromSeed(seed[:]))
+	return k
+}
+
+func thumbprint(pub crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8])
+}
+
+// KeySet signs tokens with its active key and verifies them with any of
+// its keys, so a new key can be activated while tokens signed by the
+// previous one are still in use.
+type KeySet struct {
+	Issuer   string
+	Audience string
+	active   *Key
+	keys     map[string]*Key
+	order    []*Key
+}
+
+func NewKeySet(issuer, audience string, active *Key, others ...*Key) (*KeySet, error) {
+	if active == nil || active.private == nil {
+		return nil, errors.New("jwt: the active key needs a private key")
+	}
+	ks := &KeySet{Issuer: issuer, Audience: audience, active: active, keys: map[string]*Key{}}
+	for _, k := range append([]*Key{active}, others...) {
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+		ks.order = append(ks.order, k)
+	}
+	return ks, nil
+}
+
+// JWK is the public part of a key as published in a JWKS document.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, active key first.
+func (ks *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	b64 := base64.RawURLEncoding
+	for _, k := range ks.order {
+		jwk := JWK{Use: "sig", Alg: k.Method.Alg(), Kid: k.ID}
+		switch p := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64.EncodeToString(p.N.Bytes())
+			jwk.E = b64.EncodeToString(big.NewInt(int64(p.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64.EncodeToString(p)
+		}
+		out.Keys = append(out.Keys, jwk)
+	}
+	return out
+}
+
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, errors.New("jwt: unknown key id")
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return k.public, nil
+}
@@ -5,42 +5,81 @@ import (
     "time"
 )
 
-var secretFallback = []byte("devsecret")
+// accessTokenTTL is how long an access token is valid.
+const accessTokenTTL = 15 * time.Minute
 
 type Claims struct {
+    UserID    int    `json:"user_id"`
+    OrgID     int    `json:"org_id"`
+    // SessionID is the login session the token was issued for.
+    SessionID int    `json:"sid,omitempty"`
+    // Version is the token version of the user at issue time; the token is
+    // revoked once the user's version moves past it.
+    Version   int    `json:"ver"`
+    Username  string `json:"username"`
+    Role      string `json:"role"`
+    // Act names the manager acting as the user in an impersonation token
+    // (RFC 8693); it is nil in tokens the user got by logging in.
+    Act       *Actor `json:"act,omitempty"`
+    jwt.RegisteredClaims
+}
+
+// Actor is the user behind an impersonation token. Version is the actor's
+// own token version, so the actor logging out everywhere ends it too.
+type Actor struct {
     UserID   int    `json:"user_id"`
     Username string `json:"username"`
-    Role     string `json:"role"`
-    jwt.RegisteredClaims
+    Version  int    `json:"ver"`
+}
+
+// GenerateAccessToken signs the claims with the active key of the set.
+func (ks *KeySet) GenerateAccessToken(userID, orgID, sessionID, version int, username, role string) (string, error) {
+	return ks.signAccessToken(&Claims{
+		UserID:    userID,
+		OrgID:     orgID,
+		SessionID: sessionID,
+		Version:   version,
+		Username:  username,
+		Role:      role,
+	}, accessTokenTTL)
 }
 
-func GenerateAccessToken(userID int, username, role string, secret []byte) (string, error) {
-	claims := &Claims{
+// GenerateImpersonationToken signs an access token for the user carrying
+// the actor in the act claim. It belongs to no session and can't be
+// refreshed.
+func (ks *KeySet) GenerateImpersonationToken(userID, orgID, version int, username, role string, act Actor, ttl time.Duration) (string, error) {
+	return ks.signAccessToken(&Claims{
 		UserID:   userID,
+		OrgID:    orgID,
+		Version:  version,
 		Username: username,
 		Role:     role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+		Act:      &act,
+	}, ttl)
 }
 
-func GenerateRefreshToken(secret []byte) (string, error) {
-	claims := &jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+func (ks *KeySet) signAccessToken(claims *Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    ks.Issuer,
+		Audience:  jwt.ClaimStrings{ks.Audience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.private)
 }
 
-func ValidateToken(tokenStr string, secret []byte) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+// ValidateToken checks the signature against the key named by the kid
+// header, and the issuer, audience and expiry.
+func (ks *KeySet) ValidateToken(tokenStr string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, ks.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(ks.Issuer),
+		jwt.WithAudience(ks.Audience),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -50,15 +89,51 @@ func ValidateToken(tokenStr string, secret []byte) (*Claims, error) {
 	return nil, jwt.ErrSignatureInvalid
 }
 
-func ValidateRefreshToken(tokenStr string, secret []byte) (*jwt.RegisteredClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+// ChallengeClaims identify a user who passed the password step of a login
+// but still has to complete a second one, such as a TOTP code. They are
+// issued for a separate audience, so they never pass as access tokens.
+type ChallengeClaims struct {
+	UserID  int    `json:"user_id"`
+	OrgID   int    `json:"org_id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+func (ks *KeySet) challengeAudience() string { return ks.Audience + "/challenge" }
+
+// GenerateChallengeToken signs a short-lived token for the given purpose.
+func (ks *KeySet) GenerateChallengeToken(userID, orgID int, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ChallengeClaims{
+		UserID:  userID,
+		OrgID:   orgID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    ks.Issuer,
+			Audience:  jwt.ClaimStrings{ks.challengeAudience()},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.private)
+}
+
+// ValidateChallengeToken checks a challenge token and its purpose.
+func (ks *KeySet) ValidateChallengeToken(tokenStr, purpose string) (*ChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &ChallengeClaims{}, ks.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(ks.Issuer),
+		jwt.WithAudience(ks.challengeAudience()),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok || !token.Valid || claims.Purpose != purpose {
+		return nil, jwt.ErrTokenInvalidClaims
 	}
-	return nil, jwt.ErrSignatureInvalid
+	return claims, nil
 }
@@ -0,0 +1,219 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaKey(t *testing.T, id string) *Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k, err := NewKey(id, priv)
+	require.NoError(t, err)
+	return k
+}
+
+func edKey(t *testing.T, id string) *Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k, err := NewKey(id, priv)
+	require.NoError(t, err)
+	return k
+}
+
+func TestKeySet_RoundTrip(t *testing.T) {
+	for _, k := range []*Key{rsaKey(t, "rsa-1"), edKey(t, "ed-1")} {
+		ks, err := NewKeySet("skilltracker", "api", k)
+		require.NoError(t, err)
+
+		tok, err := ks.GenerateAccessToken(5, 2, 9, 4, "alice", "employee")
+		require.NoError(t, err)
+		claims, err := ks.ValidateToken(tok)
+		require.NoError(t, err, k.Method.Alg())
+
+		assert.Equal(t, 5, claims.UserID)
+		assert.Equal(t, 2, claims.OrgID)
+		assert.Equal(t, 9, claims.SessionID)
+		assert.Equal(t, 4, claims.Version)
+		assert.Equal(t, "skilltracker", claims.Issuer)
+		assert.Equal(t, jwt.ClaimStrings{"api"}, claims.Audience)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(tok, &Claims{})
+		require.NoError(t, err)
+		assert.Equal(t, k.ID, parsed.Header["kid"])
+		assert.Equal(t, k.Method.Alg(), parsed.Header["alg"])
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, newKey := edKey(t, "2024"), edKey(t, "2025")
+	before, err := NewKeySet("skilltracker", "api", oldKey)
+	require.NoError(t, err)
+	tok, err := before.GenerateAccessToken(5, 1, 0, 0, "alice", "employee")
+	require.NoError(t, err)
+
+	// The new key signs; the old one still verifies tokens it signed.
+	during, err := NewKeySet("skilltracker", "api", newKey, oldKey)
+	require.NoError(t, err)
+	_, err = during.ValidateToken(tok)
+	assert.NoError(t, err)
+	fresh, err := during.GenerateAccessToken(5, 1, 0, 0, "alice", "employee")
+	require.NoError(t, err)
+	_, err = before.ValidateToken(fresh)
+	assert.Error(t, err, "unknown kid")
+
+	// Once the old key is dropped its tokens are rejected.
+	after, err := NewKeySet("skilltracker", "api", newKey)
+	require.NoError(t, err)
+	_, err = after.ValidateToken(tok)
+	assert.Error(t, err)
+}
+
+func TestKeySet_RejectsForeignTokens(t *testing.T) {
+	k := edKey(t, "ed-1")
+	ks, err := NewKeySet("skilltracker", "api", k)
+	require.NoError(t, err)
+
+	sign := func(claims jwt.Claims) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		tok.Header["kid"] = k.ID
+		s, err := tok.SignedString(k.private)
+		require.NoError(t, err)
+		return s
+	}
+	valid := func() *Claims {
+		return &Claims{UserID: 1, OrgID: 1, RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "skilltracker", Audience: jwt.ClaimStrings{"api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}}
+	}
+
+	_, err = ks.ValidateToken(sign(valid()))
+	assert.NoError(t, err)
+
+	c := valid()
+	c.Issuer = "someone-else"
+	_, err = ks.ValidateToken(sign(c))
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+
+	c = valid()
+	c.Audience = jwt.ClaimStrings{"other-api"}
+	_, err = ks.ValidateToken(sign(c))
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+
+	c = valid()
+	c.ExpiresAt = nil
+	_, err = ks.ValidateToken(sign(c))
+	assert.Error(t, err, "expiry is required")
+
+	// A token signed with an HMAC secret is never accepted.
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, valid())
+	hs.Header["kid"] = k.ID
+	hsTok, err := hs.SignedString([]byte("devsecret"))
+	require.NoError(t, err)
+	_, err = ks.ValidateToken(hsTok)
+	assert.Error(t, err)
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	rk, ek := rsaKey(t, "rsa-1"), edKey(t, "ed-1")
+	verifyOnly, err := NewPublicKey("old", ek.public)
+	require.NoError(t, err)
+	ks, err := NewKeySet("skilltracker", "api", rk, verifyOnly)
+	require.NoError(t, err)
+
+	set := ks.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, JWK{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: "rsa-1", N: set.Keys[0].N, E: "AQAB"}, set.Keys[0])
+	assert.NotEmpty(t, set.Keys[0].N)
+	assert.Equal(t, "OKP", set.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", set.Keys[1].Crv)
+	assert.Equal(t, "EdDSA", set.Keys[1].Alg)
+	assert.Equal(t, "old", set.Keys[1].Kid)
+}
+
+func TestKeys(t *testing.T) {
+	t.Run("PEM files", func(t *testing.T) {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		require.NoError(t, err)
+		k, err := ParsePrivateKeyPEM("", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		require.NoError(t, err)
+		assert.NotEmpty(t, k.ID, "kid defaults to a thumbprint")
+
+		pubDER, err := x509.MarshalPKIXPublicKey(priv.Public())
+		require.NoError(t, err)
+		pub, err := ParsePublicKeyPEM("", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+		require.NoError(t, err)
+		assert.Equal(t, k.ID, pub.ID)
+
+		_, err = NewKeySet("skilltracker", "api", pub)
+		assert.Error(t, err, "a public key can't sign")
+	})
+
+	t.Run("derived key is stable", func(t *testing.T) {
+		assert.Equal(t, DeriveKey([]byte("s3cret")).ID, DeriveKey([]byte("s3cret")).ID)
+		assert.NotEqual(t, DeriveKey([]byte("s3cret")).ID, DeriveKey([]byte("other")).ID)
+	})
+
+	t.Run("short RSA keys are refused", func(t *testing.T) {
+		priv, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		_, err = NewKey("weak", priv)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeySet_ChallengeToken(t *testing.T) {
+	ks, err := NewKeySet("skilltracker", "api", edKey(t, "ed-1"))
+	require.NoError(t, err)
+
+	tok, err := ks.GenerateChallengeToken(5, 2, "2fa", time.Minute)
+	require.NoError(t, err)
+	claims, err := ks.ValidateChallengeToken(tok, "2fa")
+	require.NoError(t, err)
+	assert.Equal(t, 5, claims.UserID)
+	assert.Equal(t, 2, claims.OrgID)
+
+	_, err = ks.ValidateChallengeToken(tok, "2fa_enroll")
+	assert.Error(t, err, "purpose must match")
+	_, err = ks.ValidateToken(tok)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience, "not an access token")
+
+	access, err := ks.GenerateAccessToken(5, 2, 9, 0, "alice", "employee")
+	require.NoError(t, err)
+	_, err = ks.ValidateChallengeToken(access, "2fa")
+	assert.Error(t, err, "an access token is no challenge")
+}
+
+func TestKeySet_ImpersonationToken(t *testing.T) {
+	ks, err := NewKeySet("skilltracker", "api", edKey(t, "ed-1"))
+	require.NoError(t, err)
+
+	tok, err := ks.GenerateImpersonationToken(5, 2, 3, "alice", "employee", Actor{UserID: 1, Username: "boss", Version: 4}, time.Minute)
+	require.NoError(t, err)
+	claims, err := ks.ValidateToken(tok)
+	require.NoError(t, err)
+	assert.Equal(t, 5, claims.UserID)
+	assert.Equal(t, 0, claims.SessionID)
+	assert.Equal(t, &Actor{UserID: 1, Username: "boss", Version: 4}, claims.Act)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+
+	access, err := ks.GenerateAccessToken(5, 2, 9, 0, "alice", "employee")
+	require.NoError(t, err)
+	claims, err = ks.ValidateToken(access)
+	require.NoError(t, err)
+	assert.Nil(t, claims.Act)
+}
@@ -0,0 +1,146 @@
+// Package mail sends plain-text email over SMTP.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// SMTPConfig is a submission server. Mail is sent with STARTTLS when the
+// server offers it and authentication needs it.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+	// TLSConfig is used for STARTTLS; by default the host name is verified.
+	TLSConfig *tls.Config
+}
+
+type smtpSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTP returns a sender through the server.
+func NewSMTP(cfg SMTPConfig) Sender {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &smtpSender{cfg: cfg}
+}
+
+func (s *smtpSender) Send(ctx context.Context, m Message) error {
+	from, err := mail.ParseAddress(s.cfg.From)
+	if err != nil {
+		return fmt.Errorf("mail: sender: %w", err)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return fmt.Errorf("mail: recipient: %w", err)
+	}
+	msg, err := compose(from, to, m, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		tlsConfig := s.cfg.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: s.cfg.Host}
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth refuses to send the password without TLS except to
+		// localhost.
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose renders the message. Line breaks in the subject are refused so
+// it can't add headers.
+func compose(from, to *mail.Address, m Message, now time.Time) ([]byte, error) {
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return nil, errors.New("mail: line break in subject")
+	}
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n")
+	b.WriteString(body)
+	if !strings.HasSuffix(body, "\r\n") {
+		b.WriteString("\r\n")
+	}
+	return b.Bytes(), nil
+}
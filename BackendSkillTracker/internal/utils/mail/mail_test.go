@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer accepts one message and records the session.
+type fakeServer struct {
+	ln       net.Listener
+	auth     string
+	from, to string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{ln: ln, done: make(chan struct{})}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) port() int { return s.ln.Addr().(*net.TCPAddr).Port }
+
+func (s *fakeServer) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO":
+			_ = tp.PrintfLine("250-localhost")
+			_ = tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = strings.TrimPrefix(line, "AUTH PLAIN ")
+			_ = tp.PrintfLine("235 ok")
+		case "MAIL":
+			s.from = line
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			s.to = line
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			lines, _ := tp.ReadDotLines()
+			s.data = strings.Join(lines, "\n")
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 unknown")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	srv := newFakeServer(t)
+	sender := NewSMTP(SMTPConfig{
+		Host: "localhost", Port: srv.port(), Username: "app", Password: "pw",
+		From: "SkillTracker <noreply@example.com>",
+	})
+
+	err := sender.Send(context.Background(), Message{
+		To: "alice@example.com", Subject: "Сброс пароля", Body: "line one\nline two",
+	})
+	require.NoError(t, err)
+	<-srv.done
+
+	creds, _ := base64.StdEncoding.DecodeString(srv.auth)
+	assert.Equal(t, "\x00app\x00pw", string(creds))
+	assert.Equal(t, "MAIL FROM:<noreply@example.com>", srv.from)
+	assert.Equal(t, "RCPT TO:<alice@example.com>", srv.to)
+	assert.Contains(t, srv.data, `From: "SkillTracker" <noreply@example.com>`)
+	assert.Contains(t, srv.data, "Subject: =?utf-8?q?")
+	assert.Contains(t, srv.data, "\n\nline one\nline two")
+}
+
+func TestCompose_RefusesHeaderInjection(t *testing.T) {
+	from := &netmail.Address{Address: "a@example.com"}
+	_, err := compose(from, from, Message{Subject: "hi\r\nBcc: x@example.com"}, time.Now())
+
+	assert.Error(t, err)
+}
+
+func TestSMTPSend_BadRecipient(t *testing.T) {
+	sender := NewSMTP(SMTPConfig{Host: "localhost", Port: 1, From: "a@example.com"})
+
+	err := sender.Send(context.Background(), Message{To: "not an address\r\nBcc: x@example.com"})
+
+	assert.ErrorContains(t, err, "recipient")
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// parse returns the signing keys by key ID. Keys of unknown types are
+// skipped.
+func (s jwks) parse() map[string]interface{} {
+	out := make(map[string]interface{}, len(s.Keys))
+	for _, k := range s.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub := k.publicKey(); pub != nil {
+			out[k.Kid] = pub
+		}
+	}
+	return out
+}
+
+func (k jwk) publicKey() interface{} {
+	switch k.Kty {
+	case "RSA":
+		n, e := decodeInt(k.N), decodeInt(k.E)
+		if n == nil || e == nil || !e.IsInt64() {
+			return nil
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil
+		}
+		x, y := decodeInt(k.X), decodeInt(k.Y)
+		if x == nil || y == nil || !curve.IsOnCurve(x, y) {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil
+		}
+		return ed25519.PublicKey(x)
+	}
+	return nil
+}
+
+func decodeInt(s string) *big.Int {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
@@ -0,0 +1,314 @@
+// Package oidc is a relying party for the OpenID Connect authorization code
+// flow with PKCE: discovery, the authorization URL, the code exchange and
+// ID token verification against the provider's JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrUnavailable means the provider couldn't be reached or answered
+	// with a server error.
+	ErrUnavailable = errors.New("oidc: provider unavailable")
+	// ErrInvalidGrant means the provider refused the code, e.g. because it
+	// was used or expired.
+	ErrInvalidGrant = errors.New("oidc: invalid grant")
+	// ErrInvalidToken means the ID token failed verification.
+	ErrInvalidToken = errors.New("oidc: invalid id token")
+)
+
+const (
+	// keyRefreshInterval limits JWKS refetches on unknown key IDs.
+	keyRefreshInterval = time.Minute
+	// clockSkew is tolerated on exp, iat and nbf.
+	clockSkew = time.Minute
+)
+
+type Config struct {
+	// Issuer is the provider URL; discovery is at
+	// Issuer/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes are requested besides "openid".
+	Scopes     []string
+	HTTPClient *http.Client
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client talks to one provider. Its metadata and keys are fetched on first
+// use and cached.
+type Client struct {
+	cfg Config
+
+	mu          sync.Mutex
+	meta        *metadata
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+func New(cfg Config) *Client {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Client{cfg: cfg}
+}
+
+// RandomString returns a URL safe random string for state, nonce and PKCE
+// verifiers.
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge is the S256 PKCE challenge of a verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL is where the user logs in at the provider.
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, c.cfg.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the raw ID token.
+func (c *Client) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if c.cfg.ClientSecret == "" {
+		form.Set("client_id", c.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	var tok struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &tok)
+	switch {
+	case resp.StatusCode >= 500:
+		return "", fmt.Errorf("%w: token endpoint: %s", ErrUnavailable, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("%w: %s %s", ErrInvalidGrant, resp.Status, tok.Error)
+	case tok.IDToken == "":
+		return "", fmt.Errorf("%w: no id_token in response", ErrInvalidToken)
+	}
+	return tok.IDToken, nil
+}
+
+// Claims of an ID token.
+type Claims map[string]interface{}
+
+// String returns a string claim, or "".
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Bool returns a boolean claim; some providers send "true" as a string.
+func (c Claims) Bool(name string) bool {
+	switch v := c[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// Strings returns a claim holding a string or a list of strings.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token and returns its claims.
+func (c *Client) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	meta, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.key(ctx, meta, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	out := Claims(claims)
+	if out.String("nonce") != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	if out.String("sub") == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	// With several audiences the token must have been issued to us.
+	if aud := out.Strings("aud"); len(aud) > 1 && out.String("azp") != c.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp mismatch", ErrInvalidToken)
+	}
+	return out, nil
+}
+
+func (c *Client) discover(ctx context.Context) (*metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.meta != nil {
+		return c.meta, nil
+	}
+	var meta metadata
+	if err := c.getJSON(ctx, c.cfg.Issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, err
+	}
+	if meta.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("%w: discovery issuer %q doesn't match %q", ErrUnavailable, meta.Issuer, c.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrUnavailable)
+	}
+	c.meta = &meta
+	return c.meta, nil
+}
+
+// key returns the verification key with a key ID, refetching the JWKS
+// when the ID is new, as after a key rotation at the provider.
+func (c *Client) key(ctx context.Context, meta *metadata, kid string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if k, ok := c.lookup(kid); ok {
+		return k, nil
+	}
+	if time.Since(c.keysFetched) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	var set jwks
+	if err := c.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	c.keys = set.parse()
+	c.keysFetched = time.Now()
+	if k, ok := c.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (c *Client) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, k := range c.keys {
+			return k, true
+		}
+	}
+	k, ok := c.keys[kid]
+	return k, ok
+}
+
+func (c *Client) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: GET %s: %s", ErrUnavailable, u, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%w: GET %s: %v", ErrUnavailable, u, err)
+	}
+	return nil
+}
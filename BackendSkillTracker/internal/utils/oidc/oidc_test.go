@@ -0,0 +1,116 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"skilltracker/internal/utils/oidc"
+	"skilltracker/internal/utils/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(idp *oidctest.IdP) *oidc.Client {
+	return oidc.New(oidc.Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "https://app.example.com/callback",
+		Scopes:       []string{"profile", "email"},
+	})
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.New("skilltracker", "s3cret")
+	defer idp.Close()
+	c := newClient(idp)
+	verifier, _ := oidc.RandomString()
+
+	authURL, err := c.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, "openid profile email", u.Query().Get("scope"))
+	assert.Equal(t, oidc.Challenge(verifier), u.Query().Get("code_challenge"))
+
+	code, state, err := idp.Authorize(authURL, map[string]interface{}{"sub": "u-1", "email": "alice@example.com", "groups": []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	raw, err := c.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+	claims, err := c.VerifyIDToken(ctx, raw, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "u-1", claims.String("sub"))
+	assert.Equal(t, []string{"a", "b"}, claims.Strings("groups"))
+
+	_, err = c.Exchange(ctx, code, verifier)
+	assert.ErrorIs(t, err, oidc.ErrInvalidGrant, "codes are single use")
+}
+
+func TestExchange_PKCE(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.New("skilltracker", "")
+	defer idp.Close()
+	c := newClient(idp)
+	verifier, _ := oidc.RandomString()
+	authURL, _ := c.AuthCodeURL(ctx, "s", "n", verifier)
+	code, _, _ := idp.Authorize(authURL, map[string]interface{}{"sub": "u-1"})
+
+	_, err := c.Exchange(ctx, code, "another-verifier")
+
+	assert.ErrorIs(t, err, oidc.ErrInvalidGrant)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.New("skilltracker", "")
+	defer idp.Close()
+	c := newClient(idp)
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": idp.Issuer(), "aud": "skilltracker", "sub": "u-1", "nonce": "n",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+	}
+
+	_, err := c.VerifyIDToken(ctx, idp.Sign(valid()), "n")
+	require.NoError(t, err)
+
+	for name, change := range map[string]func(map[string]interface{}){
+		"wrong nonce":    func(c map[string]interface{}) { c["nonce"] = "other" },
+		"wrong audience": func(c map[string]interface{}) { c["aud"] = "someone-else" },
+		"wrong issuer":   func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"expired":        func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(c map[string]interface{}) { delete(c, "exp") },
+		"no subject":     func(c map[string]interface{}) { delete(c, "sub") },
+		"foreign azp": func(c map[string]interface{}) {
+			c["aud"] = []string{"skilltracker", "other"}
+			c["azp"] = "other"
+		},
+	} {
+		claims := valid()
+		change(claims)
+		_, err := c.VerifyIDToken(ctx, idp.Sign(claims), "n")
+		assert.ErrorIs(t, err, oidc.ErrInvalidToken, name)
+	}
+
+	other := oidctest.New("skilltracker", "")
+	defer other.Close()
+	claims := valid()
+	_, err = c.VerifyIDToken(ctx, other.Sign(claims), "n")
+	assert.ErrorIs(t, err, oidc.ErrInvalidToken, "signed by another key")
+}
+
+func TestDiscoveryUnavailable(t *testing.T) {
+	idp := oidctest.New("skilltracker", "")
+	c := newClient(idp)
+	idp.Close()
+
+	_, err := c.AuthCodeURL(context.Background(), "s", "n", "v")
+
+	assert.ErrorIs(t, err, oidc.ErrUnavailable)
+}
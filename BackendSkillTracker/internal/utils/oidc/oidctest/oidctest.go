@@ -0,0 +1,157 @@
+// Package oidctest runs an in-process OpenID Connect provider for tests.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"skilltracker/internal/utils/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "test-key"
+
+type grant struct {
+	claims      map[string]interface{}
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// IdP is a provider with one client. Users "log in" with Authorize.
+type IdP struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+// New starts a provider; Close it when done.
+func New(clientID, clientSecret string) *IdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	p := &IdP{ClientID: clientID, ClientSecret: clientSecret, key: key, grants: make(map[string]grant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+// Issuer is the provider URL to configure.
+func (p *IdP) Issuer() string { return p.URL }
+
+// Authorize plays the browser: it logs a user with claims in at an
+// authorization URL and returns the code and state of the redirect back.
+func (p *IdP) Authorize(authURL string, claims map[string]interface{}) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" {
+		return "", "", errors.New("oidctest: bad authorization request")
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		return "", "", errors.New("oidctest: PKCE S256 required")
+	}
+	code, err = oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	p.mu.Lock()
+	p.grants[code] = grant{claims: claims, redirectURI: q.Get("redirect_uri"), challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	p.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+// Sign issues a token with the provider key, for tests of forged or
+// tampered tokens.
+func (p *IdP) Sign(claims map[string]interface{}) string {
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	t.Header["kid"] = keyID
+	s, err := t.SignedString(p.key)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (p *IdP) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+func (p *IdP) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": keyID,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (p *IdP) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if p.ClientSecret != "" && (!ok || id != p.ClientID || secret != p.ClientSecret) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	code := r.PostFormValue("code")
+	p.mu.Lock()
+	g, found := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+	if r.PostFormValue("grant_type") != "authorization_code" || !found ||
+		g.redirectURI != r.PostFormValue("redirect_uri") ||
+		oidc.Challenge(r.PostFormValue("code_verifier")) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   p.URL,
+		"aud":   p.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": g.nonce,
+	}
+	for k, v := range g.claims {
+		claims[k] = v
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "opaque",
+		"token_type":   "Bearer",
+		"id_token":     p.Sign(claims),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
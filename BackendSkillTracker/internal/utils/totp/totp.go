@@ -0,0 +1,86 @@
+// Package totp implements time-based one-time passwords (RFC 6238) as used
+// by authenticator apps: HMAC-SHA1, 6 digits, 30 second steps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	Digits = 6
+	Period = 30
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random 160-bit secret in base32, the form
+// authenticator apps expect.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// Step is the time step t falls in.
+func Step(t time.Time) int64 { return t.Unix() / Period }
+
+// Code is the password of the step.
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("totp: invalid secret: %w", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	v := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", v%1000000), nil
+}
+
+// Verify checks code against the step of t and skew steps around it, to
+// allow for clock drift. It returns the matching step so callers can refuse
+// a code that was already used.
+func Verify(secret, code string, t time.Time, skew int) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+	now := Step(t)
+	for i := -skew; i <= skew; i++ {
+		want, err := Code(secret, now+int64(i))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return now + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// URI is the otpauth:// URI authenticator apps import, usually shown as a
+// QR code.
+func URI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(Period))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
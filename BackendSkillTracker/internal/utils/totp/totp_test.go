@@ -0,0 +1,60 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; the 6-digit ones are their last digits.
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		got, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, unix)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	code, _ := Code(secret, Step(now))
+	step, ok := Verify(secret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	prev, _ := Code(secret, Step(now)-1)
+	step, ok = Verify(secret, prev, now, 1)
+	assert.True(t, ok, "one step of drift is allowed")
+	assert.Equal(t, Step(now)-1, step)
+
+	old, _ := Code(secret, Step(now)-3)
+	_, ok = Verify(secret, old, now, 1)
+	assert.False(t, ok)
+
+	_, ok = Verify(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("SkillTracker", "alice", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/SkillTracker:alice?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=SkillTracker")
+	assert.Contains(t, uri, "digits=6")
+}